@@ -0,0 +1,14 @@
+// Command summon-conjur is a Summon provider which fetches secrets from
+// Conjur, configured like the Conjur CLI by ~/.conjurrc, /etc/conjur.conf and
+// the CONJUR_* environment variables.
+package main
+
+import (
+	"os"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/summon"
+)
+
+func main() {
+	os.Exit(summon.Main(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,213 @@
+package conjurapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// accessReportPageSize is the number of resources fetched per request by
+// AccessReport when AccessReportOptions.PageSize isn't set.
+const accessReportPageSize = 1000
+
+// roleKinds are the kinds of resources which are also roles.
+var roleKinds = []string{"user", "host", "group", "layer", "policy"}
+
+// AccessReportOptions configures AccessReport.
+type AccessReportOptions struct {
+	// Kinds limits the report to resources of these kinds, such as "variable"
+	// or "host". Every kind is included when empty.
+	Kinds []string
+	// Memberships adds the direct members of every role in the report, with
+	// one request per role.
+	Memberships bool
+	// PageSize is the number of resources fetched per request.
+	PageSize int
+}
+
+// AccessReport is a snapshot of the resources visible to the authenticated
+// role, the permissions granted on them and optionally the memberships of the
+// roles among them, for compliance reporting.
+type AccessReport struct {
+	Account     string                   `json:"account"`
+	GeneratedAt time.Time                `json:"generated_at"`
+	Resources   []AccessReportResource   `json:"resources"`
+	Permissions []AccessReportPermission `json:"permissions"`
+	Memberships []RoleGrant              `json:"memberships,omitempty"`
+}
+
+// AccessReportResource is a resource in an AccessReport.
+type AccessReportResource struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	Owner       string            `json:"owner"`
+	Policy      string            `json:"policy,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AccessReportPermission is a privilege granted to a role on a resource.
+type AccessReportPermission struct {
+	Resource  string `json:"resource"`
+	Privilege string `json:"privilege"`
+	Role      string `json:"role"`
+	Policy    string `json:"policy,omitempty"`
+}
+
+// AccessReport walks the resources visible to the authenticated role, paging
+// through them, and collects their owners, annotations and permissions.
+// Resources, permissions and memberships are sorted.
+func (c *Client) AccessReport(options AccessReportOptions) (*AccessReport, error) {
+	return c.AccessReportWithContext(context.Background(), options)
+}
+
+// AccessReportWithContext is like AccessReport but uses the provided context
+// for the underlying requests.
+func (c *Client) AccessReportWithContext(ctx context.Context, options AccessReportOptions) (*AccessReport, error) {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = accessReportPageSize
+	}
+	kinds := options.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{""}
+	}
+
+	report := &AccessReport{
+		Account:     c.config.Account,
+		GeneratedAt: time.Now().UTC(),
+		Resources:   []AccessReportResource{},
+		Permissions: []AccessReportPermission{},
+	}
+	for _, kind := range kinds {
+		pager := c.ResourcesPagerWithContext(ctx, &ResourceFilter{Kind: kind}, PagerOptions{PageSize: pageSize})
+		for pager.Next() {
+			resource := pager.Value()
+			_, resourceKind, _ := c.unopinionatedParseID(resource.ID)
+			report.addResource(resourceKind, resource)
+		}
+		if err := pager.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Memberships {
+		for _, resource := range report.Resources {
+			if !contains(roleKinds, resource.Kind) {
+				continue
+			}
+			grants, err := c.roleGrants(ctx, resource.ID, RoleGraphMembers)
+			if err != nil {
+				return nil, err
+			}
+			report.Memberships = append(report.Memberships, grants...)
+		}
+	}
+
+	report.sort()
+	return report, nil
+}
+
+func (r *AccessReport) addResource(kind string, resource Resource) {
+	entry := AccessReportResource{
+		ID:        resource.ID,
+		Kind:      kind,
+		Owner:     resource.Owner,
+		Policy:    resource.Policy,
+		CreatedAt: resource.CreatedAt,
+	}
+	if len(resource.Annotations) > 0 {
+		entry.Annotations = make(map[string]string, len(resource.Annotations))
+		for _, annotation := range resource.Annotations {
+			entry.Annotations[annotation.Name] = annotation.Value
+		}
+	}
+	r.Resources = append(r.Resources, entry)
+
+	for _, permission := range resource.Permissions {
+		r.Permissions = append(r.Permissions, AccessReportPermission{
+			Resource:  resource.ID,
+			Privilege: permission.Privilege,
+			Role:      permission.Role,
+			Policy:    permission.Policy,
+		})
+	}
+}
+
+func (r *AccessReport) sort() {
+	sort.Slice(r.Resources, func(i, j int) bool {
+		return r.Resources[i].ID < r.Resources[j].ID
+	})
+	sort.Slice(r.Permissions, func(i, j int) bool {
+		a, b := r.Permissions[i], r.Permissions[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Privilege != b.Privilege {
+			return a.Privilege < b.Privilege
+		}
+		return a.Role < b.Role
+	})
+	sort.Slice(r.Memberships, func(i, j int) bool {
+		a, b := r.Memberships[i], r.Memberships[j]
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		return a.Member < b.Member
+	})
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func (r *AccessReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WritePermissionsCSV writes a CSV row per permission, with the owner and
+// policy of its resource. Resources without permissions get a row with empty
+// privilege and role columns, so that every resource appears.
+func (r *AccessReport) WritePermissionsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"resource", "kind", "owner", "policy", "privilege", "role", "granted_by"})
+
+	permissions := r.Permissions
+	for _, resource := range r.Resources {
+		row := []string{resource.ID, resource.Kind, resource.Owner, resource.Policy}
+		granted := false
+		for len(permissions) > 0 && permissions[0].Resource == resource.ID {
+			permission := permissions[0]
+			writer.Write(append(row[:4:4], permission.Privilege, permission.Role, permission.Policy))
+			permissions = permissions[1:]
+			granted = true
+		}
+		if !granted {
+			writer.Write(append(row, "", "", ""))
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteMembershipsCSV writes a CSV row per membership in the report.
+func (r *AccessReport) WriteMembershipsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"role", "member", "admin_option", "ownership", "policy"})
+	for _, grant := range r.Memberships {
+		writer.Write([]string{
+			grant.Role,
+			grant.Member,
+			strconv.FormatBool(grant.AdminOption),
+			strconv.FormatBool(grant.Ownership),
+			grant.Policy,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,106 @@
+package conjurapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AccessReport(t *testing.T) {
+	resources := []map[string]interface{}{
+		{
+			"id":          "cucumber:variable:db/password",
+			"owner":       "cucumber:policy:db",
+			"policy":      "cucumber:policy:root",
+			"permissions": []map[string]string{{"privilege": "read", "role": "cucumber:host:app"}, {"privilege": "execute", "role": "cucumber:host:app"}},
+			"annotations": []map[string]string{{"name": "description", "value": "Database password"}},
+		},
+		{"id": "cucumber:group:admins", "owner": "cucumber:user:admin", "permissions": []map[string]string{}},
+		{"id": "cucumber:host:app", "owner": "cucumber:policy:root", "permissions": []map[string]string{}},
+	}
+	var pages int
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimSuffix(r.URL.Path, "/") {
+		case "/resources/cucumber":
+			pages++
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			page := []map[string]interface{}{}
+			for i, resource := range resources {
+				if i < offset || i >= offset+limit {
+					continue
+				}
+				if kind := r.URL.Query().Get("kind"); kind == "" || kind == "variable" && resource["id"] == "cucumber:variable:db/password" {
+					page = append(page, resource)
+				}
+			}
+			json.NewEncoder(w).Encode(page)
+		case "/roles/cucumber/group/admins":
+			w.Write([]byte(`[{"role": "cucumber:group:admins", "member": "cucumber:user:alice", "admin_option": true}]`))
+		case "/roles/cucumber/host/app":
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	report, err := conjur.AccessReport(AccessReportOptions{PageSize: 2, Memberships: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, "cucumber", report.Account)
+
+	require.Len(t, report.Resources, 3)
+	assert.Equal(t, "cucumber:group:admins", report.Resources[0].ID)
+	assert.Equal(t, "group", report.Resources[0].Kind)
+	assert.Equal(t, map[string]string{"description": "Database password"}, report.Resources[2].Annotations)
+	assert.Equal(t, []AccessReportPermission{
+		{Resource: "cucumber:variable:db/password", Privilege: "execute", Role: "cucumber:host:app"},
+		{Resource: "cucumber:variable:db/password", Privilege: "read", Role: "cucumber:host:app"},
+	}, report.Permissions)
+	assert.Equal(t, []RoleGrant{{Role: "cucumber:group:admins", Member: "cucumber:user:alice", AdminOption: true}}, report.Memberships)
+
+	t.Run("Writes permissions as CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.WritePermissionsCSV(&out))
+		assert.Equal(t, `resource,kind,owner,policy,privilege,role,granted_by
+cucumber:group:admins,group,cucumber:user:admin,,,,
+cucumber:host:app,host,cucumber:policy:root,,,,
+cucumber:variable:db/password,variable,cucumber:policy:db,cucumber:policy:root,execute,cucumber:host:app,
+cucumber:variable:db/password,variable,cucumber:policy:db,cucumber:policy:root,read,cucumber:host:app,
+`, out.String())
+	})
+
+	t.Run("Writes memberships as CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.WriteMembershipsCSV(&out))
+		assert.Equal(t, "role,member,admin_option,ownership,policy\ncucumber:group:admins,cucumber:user:alice,true,false,\n", out.String())
+	})
+
+	t.Run("Writes JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.WriteJSON(&out))
+		decoded := AccessReport{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, report.Permissions, decoded.Permissions)
+	})
+
+	t.Run("Limits the report to kinds", func(t *testing.T) {
+		report, err := conjur.AccessReport(AccessReportOptions{Kinds: []string{"variable"}})
+		require.NoError(t, err)
+		require.Len(t, report.Resources, 1)
+		assert.Equal(t, "cucumber:variable:db/password", report.Resources[0].ID)
+		assert.Nil(t, report.Memberships)
+	})
+}
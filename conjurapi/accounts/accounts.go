@@ -0,0 +1,127 @@
+// Package accounts provides access to the Conjur accounts API, which
+// bootstrap automation uses to create and delete accounts without running
+// conjurctl on the server.
+package accounts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// Account is a newly created account along with the API key of its admin
+// user.
+type Account struct {
+	ID     string `json:"id"`
+	APIKey string `json:"api_key"`
+}
+
+// Client manages the accounts of a Conjur server using a Conjur client.
+type Client struct {
+	conjur *conjurapi.Client
+}
+
+// NewClient creates an accounts client. The accounts API requires the Conjur
+// client to be authenticated as a role with privileges on the
+// "!:webservice:accounts" webservice, such as the admin user of the "!"
+// system account: read to list accounts, execute to create them and update to
+// delete them. Servers where the API isn't enabled respond with
+// conjurapi.ErrNotFound.
+func NewClient(conjur *conjurapi.Client) *Client {
+	return &Client{conjur: conjur}
+}
+
+// ListAccounts returns the names of the server's accounts.
+func (c *Client) ListAccounts() ([]string, error) {
+	return c.ListAccountsWithContext(context.Background())
+}
+
+// ListAccountsWithContext is like ListAccounts but uses the provided context
+// for the underlying request.
+func (c *Client) ListAccountsWithContext(ctx context.Context) ([]string, error) {
+	req, err := c.conjur.AccountsRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.conjur.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []string{}
+	if err := response.JSONResponse(resp, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// CreateAccount creates an account, and returns it along with the API key of
+// its admin user. The API key isn't retrievable later, so store it before
+// discarding the result.
+func (c *Client) CreateAccount(account string) (*Account, error) {
+	return c.CreateAccountWithContext(context.Background(), account)
+}
+
+// CreateAccountWithContext is like CreateAccount but uses the provided context
+// for the underlying request.
+func (c *Client) CreateAccountWithContext(ctx context.Context, account string) (*Account, error) {
+	if err := validateAccount(account); err != nil {
+		return nil, err
+	}
+
+	req, err := c.conjur.CreateAccountRequest(account)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.conjur.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	created := Account{}
+	if err := response.JSONResponse(resp, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteAccount deletes an account along with all of its records and secrets.
+func (c *Client) DeleteAccount(account string) error {
+	return c.DeleteAccountWithContext(context.Background(), account)
+}
+
+// DeleteAccountWithContext is like DeleteAccount but uses the provided context
+// for the underlying request.
+func (c *Client) DeleteAccountWithContext(ctx context.Context, account string) error {
+	if err := validateAccount(account); err != nil {
+		return err
+	}
+
+	req, err := c.conjur.DeleteAccountRequest(account)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.conjur.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	return response.EmptyResponse(resp)
+}
+
+// validateAccount rejects account names which Conjur can't use in IDs.
+func validateAccount(account string) error {
+	if account == "" {
+		return fmt.Errorf("account name must not be empty")
+	}
+	for _, r := range account {
+		if r == ':' || r == '/' {
+			return fmt.Errorf("account name '%s' must not contain ':' or '/'", account)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package accounts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sampleToken = `{"protected":"eyJhbGciOiJjb25qdXIub3JnL3Nsb3NpbG8vdjIiLCJraWQiOiI5M2VjNTEwODRmZTM3Zjc3M2I1ODhlNTYyYWVjZGMxMSJ9","payload":"eyJzdWIiOiJhZG1pbiIsImlhdCI6MTUxMDc1MzI1OSwiZXhwIjo0MTAzMzc5MTY0fQo=","signature":"raCufKOf7sKzciZInQTphu1mBbLhAdIJM72ChLB4m5wKWxFnNz_7LawQ9iYEI_we1-tdZtTXoopn_T1qoTplR9_Bo3KkpI5Hj3DB7SmBpR3CSRTnnEwkJ0_aJ8bql5Cbst4i4rSftyEmUqX-FDOqJdAztdi9BUJyLfbeKTW9OGg-QJQzPX1ucB7IpvTFCEjMoO8KUxZpbHj-KpwqAMZRooG4ULBkxp5nSfs-LN27JupU58oRgIfaWASaDmA98O2x6o88MFpxK_M0FeFGuDKewNGrRc8lCOtTQ9cULA080M5CSnruCqu1Qd52r72KIOAfyzNIiBCLTkblz2fZyEkdSKQmZ8J3AakxQE2jyHmMT-eXjfsEIzEt-IRPJIirI3Qm"}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	conjur, err := conjurapi.NewClientFromToken(conjurapi.Config{
+		Account:           "!",
+		ApplianceURL:      ts.URL,
+		CredentialStorage: "none",
+	}, sampleToken)
+	require.NoError(t, err)
+	return NewClient(conjur)
+}
+
+func TestClient_ListAccounts(t *testing.T) {
+	t.Run("Returns the account names", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/accounts", r.URL.Path)
+			assert.Contains(t, r.Header.Get("Authorization"), "Token token=")
+			w.Write([]byte(`["default", "cucumber"]`))
+		})
+
+		accounts, err := client.ListAccounts()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"default", "cucumber"}, accounts)
+	})
+
+	t.Run("Returns ErrNotFound where the API isn't enabled", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := client.ListAccounts()
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+	})
+}
+
+func TestClient_CreateAccount(t *testing.T) {
+	t.Run("Returns the admin API key", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/accounts", r.URL.Path)
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "cucumber", r.PostForm.Get("id"))
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "cucumber", "api_key": "3ahcddy39rcxzh3ggac4cwk3j2r8pqwdg33059y835ys2rh2kzs2a"}`))
+		})
+
+		account, err := client.CreateAccount("cucumber")
+		require.NoError(t, err)
+		assert.Equal(t, &Account{ID: "cucumber", APIKey: "3ahcddy39rcxzh3ggac4cwk3j2r8pqwdg33059y835ys2rh2kzs2a"}, account)
+	})
+
+	t.Run("Returns ErrConflict for existing accounts", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": {"code": "conflict", "message": "account \"cucumber\" already exists"}}`))
+		})
+
+		_, err := client.CreateAccount("cucumber")
+		assert.ErrorIs(t, err, conjurapi.ErrConflict)
+	})
+
+	t.Run("Rejects invalid names", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("Unexpected request")
+		})
+
+		_, err := client.CreateAccount("")
+		assert.EqualError(t, err, "account name must not be empty")
+		_, err = client.CreateAccount("a:b")
+		assert.EqualError(t, err, "account name 'a:b' must not contain ':' or '/'")
+	})
+}
+
+func TestClient_DeleteAccount(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/accounts/cucumber", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.NoError(t, client.DeleteAccount("cucumber"))
+	assert.Error(t, client.DeleteAccount("a/b"))
+}
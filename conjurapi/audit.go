@@ -0,0 +1,121 @@
+package conjurapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// AuditFilter selects and pages through audit events. Zero values aren't
+// sent, leaving the server's defaults.
+type AuditFilter struct {
+	// Since and Until bound the time range of the events.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of events to return, starting from Offset.
+	Limit  int
+	Offset int
+}
+
+// AuditEvent is an event recorded in Conjur's audit database, such as a
+// permission check or a secret being fetched.
+type AuditEvent struct {
+	ID        string    `json:"event_id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Kind is the kind of event, such as "resource", "role" or "authn", and
+	// Action what happened, such as "check", "create" or "grant".
+	Kind   string `json:"kind"`
+	Action string `json:"action"`
+	// User is the role which performed the action and ActingAs the role it
+	// acted as, if different.
+	User     string `json:"user"`
+	ActingAs string `json:"acting_as"`
+	// Roles and Resources are the fully-qualified IDs of the records the event
+	// involves.
+	Roles     []string `json:"roles"`
+	Resources []string `json:"resources"`
+	Privilege string   `json:"privilege,omitempty"`
+	// Allowed is the outcome of a permission check.
+	Allowed *bool `json:"allowed,omitempty"`
+	// Raw is the full event, including any details not described above.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (e *AuditEvent) UnmarshalJSON(data []byte) error {
+	type auditEvent AuditEvent
+	event := auditEvent{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+
+	*e = AuditEvent(event)
+	e.Raw = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// AuditEvents fetches the audit events of the whole account, newest first.
+//
+// The audit feeds are only available on Conjur Enterprise appliances which
+// keep an audit database; Conjur Open Source responds with ErrNotFound.
+func (c *Client) AuditEvents(filter *AuditFilter) ([]AuditEvent, error) {
+	return c.AuditEventsWithContext(context.Background(), filter)
+}
+
+// AuditEventsWithContext is like AuditEvents but uses the provided context
+// for the underlying request.
+func (c *Client) AuditEventsWithContext(ctx context.Context, filter *AuditFilter) ([]AuditEvent, error) {
+	req, err := c.AuditEventsRequest(filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.auditEvents(ctx, req)
+}
+
+// ResourceAuditEvents fetches the audit events involving a resource, such as
+// the fetches of a variable's secret. Availability is as in AuditEvents.
+func (c *Client) ResourceAuditEvents(resourceID string, filter *AuditFilter) ([]AuditEvent, error) {
+	return c.ResourceAuditEventsWithContext(context.Background(), resourceID, filter)
+}
+
+// ResourceAuditEventsWithContext is like ResourceAuditEvents but uses the
+// provided context for the underlying request.
+func (c *Client) ResourceAuditEventsWithContext(ctx context.Context, resourceID string, filter *AuditFilter) ([]AuditEvent, error) {
+	req, err := c.ResourceAuditEventsRequest(resourceID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.auditEvents(ctx, req)
+}
+
+// RoleAuditEvents fetches the audit events involving a role, such as its
+// authentications and the actions it performed. Availability is as in
+// AuditEvents.
+func (c *Client) RoleAuditEvents(roleID string, filter *AuditFilter) ([]AuditEvent, error) {
+	return c.RoleAuditEventsWithContext(context.Background(), roleID, filter)
+}
+
+// RoleAuditEventsWithContext is like RoleAuditEvents but uses the provided
+// context for the underlying request.
+func (c *Client) RoleAuditEventsWithContext(ctx context.Context, roleID string, filter *AuditFilter) ([]AuditEvent, error) {
+	req, err := c.RoleAuditEventsRequest(roleID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.auditEvents(ctx, req)
+}
+
+func (c *Client) auditEvents(ctx context.Context, req *http.Request) ([]AuditEvent, error) {
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []AuditEvent{}
+	if err := response.JSONResponse(resp, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
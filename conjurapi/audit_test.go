@@ -0,0 +1,135 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const auditEvents = `[
+	{
+		"event_id": "3c6b1e0a",
+		"timestamp": "2024-03-01T12:00:00.000Z",
+		"kind": "resource",
+		"action": "check",
+		"user": "cucumber:host:app",
+		"acting_as": "cucumber:host:app",
+		"roles": ["cucumber:host:app"],
+		"resources": ["cucumber:variable:db/password"],
+		"privilege": "execute",
+		"allowed": true,
+		"request": {"ip": "10.0.0.5"}
+	},
+	{
+		"event_id": "9f2d4c11",
+		"timestamp": "2024-03-01T11:59:00.000Z",
+		"kind": "authn",
+		"action": "authenticate",
+		"user": "cucumber:host:app",
+		"roles": ["cucumber:host:app"],
+		"resources": []
+	}
+]`
+
+func newAuditTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+	return conjur
+}
+
+func TestClient_AuditEvents(t *testing.T) {
+	t.Run("Parses the account's events", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/audit", r.URL.Path)
+			assert.Empty(t, r.URL.RawQuery)
+			assert.NotEmpty(t, r.Header.Get("Authorization"))
+			w.Write([]byte(auditEvents))
+		})
+
+		events, err := conjur.AuditEvents(nil)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+
+		check := events[0]
+		assert.Equal(t, "3c6b1e0a", check.ID)
+		assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), check.Timestamp)
+		assert.Equal(t, "resource", check.Kind)
+		assert.Equal(t, "check", check.Action)
+		assert.Equal(t, "cucumber:host:app", check.User)
+		assert.Equal(t, "cucumber:host:app", check.ActingAs)
+		assert.Equal(t, []string{"cucumber:host:app"}, check.Roles)
+		assert.Equal(t, []string{"cucumber:variable:db/password"}, check.Resources)
+		assert.Equal(t, "execute", check.Privilege)
+		require.NotNil(t, check.Allowed)
+		assert.True(t, *check.Allowed)
+		assert.Contains(t, string(check.Raw), `"request": {"ip": "10.0.0.5"}`)
+
+		assert.Equal(t, "authenticate", events[1].Action)
+		assert.Nil(t, events[1].Allowed)
+	})
+
+	t.Run("Sends the time range and page", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			assert.Equal(t, "2024-03-01T00:00:00Z", query.Get("since"))
+			assert.Equal(t, "2024-03-02T00:00:00Z", query.Get("till"))
+			assert.Equal(t, "50", query.Get("limit"))
+			assert.Equal(t, "100", query.Get("offset"))
+			w.Write([]byte("[]"))
+		})
+
+		since := time.Date(2024, 3, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600))
+		events, err := conjur.AuditEvents(&AuditFilter{
+			Since:  since,
+			Until:  time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			Limit:  50,
+			Offset: 100,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("Returns ErrNotFound where audit isn't available", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		events, err := conjur.AuditEvents(nil)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, events)
+	})
+}
+
+func TestClient_ResourceAuditEvents(t *testing.T) {
+	conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audit/resources/cucumber%3Avariable%3Adb%2Fpassword", r.URL.EscapedPath())
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		w.Write([]byte(auditEvents))
+	})
+
+	events, err := conjur.ResourceAuditEvents("variable:db/password", &AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	_, err = conjur.ResourceAuditEvents("db/password", nil)
+	assert.ErrorContains(t, err, "malformed ID")
+}
+
+func TestClient_RoleAuditEvents(t *testing.T) {
+	conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audit/roles/other%3Ahost%3Aapp", r.URL.EscapedPath())
+		w.Write([]byte(auditEvents))
+	})
+
+	events, err := conjur.RoleAuditEvents("other:host:app", nil)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
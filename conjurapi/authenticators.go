@@ -0,0 +1,146 @@
+package conjurapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// AuthenticatorStatus is the result of an authenticator's status check, as
+// returned by its /status endpoint.
+type AuthenticatorStatus struct {
+	// Status is "ok" when the authenticator is configured correctly, and
+	// "error" otherwise.
+	Status string `json:"status"`
+	// Error describes what's wrong with the authenticator's configuration.
+	Error string `json:"error,omitempty"`
+}
+
+// OK reports whether the authenticator is configured correctly.
+func (s *AuthenticatorStatus) OK() bool {
+	return s.Status == "ok"
+}
+
+// authenticatorName returns the name of the authenticator of a type, such as
+// "jwt" or "authn-jwt", in Conjur's URLs: "authn-jwt".
+func authenticatorName(authnType string) string {
+	if authnType != "authn" && !strings.HasPrefix(authnType, "authn-") {
+		return "authn-" + authnType
+	}
+	return authnType
+}
+
+// AuthenticatorStatus checks the configuration of an authenticator, such as
+// "jwt" or "k8s", for a service ID, which is empty for authenticators without
+// one such as "gcp". A misconfigured authenticator is reported by a status
+// whose Error says what's wrong, rather than by an error, which is returned
+// when the status can't be checked.
+//
+// The authenticated user must have read privilege on the authenticator's
+// status webservice.
+func (c *Client) AuthenticatorStatus(authnType, serviceID string) (*AuthenticatorStatus, error) {
+	return c.AuthenticatorStatusWithContext(context.Background(), authnType, serviceID)
+}
+
+// AuthenticatorStatusWithContext is like AuthenticatorStatus but uses the
+// provided context for the underlying request.
+func (c *Client) AuthenticatorStatusWithContext(ctx context.Context, authnType, serviceID string) (*AuthenticatorStatus, error) {
+	req, err := c.AuthenticatorStatusRequest(authnType, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 300 {
+		status := AuthenticatorStatus{}
+		if err := response.JSONResponse(resp, &status); err != nil {
+			return nil, err
+		}
+		return &status, nil
+	}
+
+	// Failed checks respond with an error status and the status payload
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	status := AuthenticatorStatus{}
+	if json.Unmarshal(body, &status) == nil && status.Status != "" {
+		return &status, nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil, response.NewConjurError(resp)
+}
+
+// AuthenticatorStatusRequest crafts an HTTP request to check the
+// configuration of an authenticator.
+func (c *Client) AuthenticatorStatusRequest(authnType, serviceID string) (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, authenticatorName(authnType), serviceID, c.config.Account, "status").String(), nil)
+}
+
+// EnableAuthenticator enables an authenticator, such as "k8s", for a service
+// ID, which is empty for authenticators without one such as "gcp". Conjur
+// ignores authenticators enabled this way when its CONJUR_AUTHENTICATORS
+// setting lists the enabled authenticators.
+//
+// The authenticated user must have update privilege on the authenticator's
+// webservice.
+func (c *Client) EnableAuthenticator(authnType, serviceID string) error {
+	return c.EnableAuthenticatorWithContext(context.Background(), authnType, serviceID)
+}
+
+// EnableAuthenticatorWithContext is like EnableAuthenticator but uses the
+// provided context for the underlying request.
+func (c *Client) EnableAuthenticatorWithContext(ctx context.Context, authnType, serviceID string) error {
+	return c.setAuthenticatorEnabled(ctx, authnType, serviceID, true)
+}
+
+// DisableAuthenticator disables an authenticator for a service ID, as
+// EnableAuthenticator enables it.
+func (c *Client) DisableAuthenticator(authnType, serviceID string) error {
+	return c.DisableAuthenticatorWithContext(context.Background(), authnType, serviceID)
+}
+
+// DisableAuthenticatorWithContext is like DisableAuthenticator but uses the
+// provided context for the underlying request.
+func (c *Client) DisableAuthenticatorWithContext(ctx context.Context, authnType, serviceID string) error {
+	return c.setAuthenticatorEnabled(ctx, authnType, serviceID, false)
+}
+
+func (c *Client) setAuthenticatorEnabled(ctx context.Context, authnType, serviceID string, enabled bool) error {
+	req, err := c.AuthenticatorEnabledRequest(authnType, serviceID, enabled)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return response.EmptyResponse(resp)
+}
+
+// AuthenticatorEnabledRequest crafts an HTTP request to enable or disable an
+// authenticator.
+func (c *Client) AuthenticatorEnabledRequest(authnType, serviceID string, enabled bool) (*http.Request, error) {
+	body := url.Values{"enabled": {strconv.FormatBool(enabled)}}.Encode()
+	req, err := http.NewRequest("PATCH", makeRouterURL(c.config.ApplianceURL, authenticatorName(authnType), serviceID, c.config.Account).String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
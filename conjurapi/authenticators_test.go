@@ -0,0 +1,92 @@
+package conjurapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+func TestClient_AuthenticatorStatus(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authn-jwt/github/cucumber/status", "/authn-gcp/cucumber/status":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/authn-k8s/cluster/cucumber/status":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"status":"error","error":"CONJ00011E Failed to discover Kubernetes API"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Reports a configured authenticator", func(t *testing.T) {
+		for _, authnType := range []string{"jwt", "authn-jwt"} {
+			status, err := conjur.AuthenticatorStatus(authnType, "github")
+			require.NoError(t, err)
+			assert.True(t, status.OK())
+			assert.Equal(t, &AuthenticatorStatus{Status: "ok"}, status)
+		}
+	})
+
+	t.Run("Checks authenticators without a service ID", func(t *testing.T) {
+		status, err := conjur.AuthenticatorStatus("gcp", "")
+		require.NoError(t, err)
+		assert.True(t, status.OK())
+	})
+
+	t.Run("Reports a misconfigured authenticator", func(t *testing.T) {
+		status, err := conjur.AuthenticatorStatus("k8s", "cluster")
+		require.NoError(t, err)
+		assert.False(t, status.OK())
+		assert.Equal(t, "CONJ00011E Failed to discover Kubernetes API", status.Error)
+	})
+
+	t.Run("Returns an error when the status can't be checked", func(t *testing.T) {
+		_, err := conjur.AuthenticatorStatus("oidc", "okta")
+		assert.True(t, errors.Is(err, response.ErrUnauthorized))
+	})
+}
+
+func TestClient_EnableAuthenticator(t *testing.T) {
+	var requests []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.Method+" "+r.URL.Path+" enabled="+r.PostForm.Get("enabled"))
+		if r.URL.Path == "/authn-oidc/okta/cucumber" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Enables and disables authenticators", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, conjur.EnableAuthenticator("k8s", "cluster"))
+		require.NoError(t, conjur.DisableAuthenticator("authn-gcp", ""))
+		assert.Equal(t, []string{
+			"PATCH /authn-k8s/cluster/cucumber enabled=true",
+			"PATCH /authn-gcp/cucumber enabled=false",
+		}, requests)
+	})
+
+	t.Run("Returns errors", func(t *testing.T) {
+		err := conjur.EnableAuthenticator("oidc", "okta")
+		assert.True(t, errors.Is(err, response.ErrForbidden))
+	})
+}
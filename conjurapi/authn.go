@@ -1,15 +1,18 @@
 package conjurapi
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 )
 
 // OidcProvider contains information about an OIDC provider.
@@ -22,29 +25,126 @@ type OidcProvider struct {
 	RedirectURI  string `json:"redirect_uri"`
 }
 
+// WhoAmIResponse contains information on the role the client is
+// authenticated as, as returned by Conjur's /whoami endpoint.
+type WhoAmIResponse struct {
+	Account       string    `json:"account"`
+	Username      string    `json:"username"`
+	ClientIP      string    `json:"client_ip"`
+	UserAgent     string    `json:"user_agent"`
+	TokenIssuedAt time.Time `json:"token_issued_at"`
+}
+
 func (c *Client) RefreshToken() (err error) {
+	return c.RefreshTokenWithContext(context.Background())
+}
+
+// RefreshTokenWithContext is like RefreshToken but uses the provided context
+// for the underlying request(s).
+func (c *Client) RefreshTokenWithContext(ctx context.Context) error {
 	// Fetch cached conjur access token if using OIDC
 	if c.GetConfig().AuthnType == "oidc" {
 		token := c.readCachedAccessToken()
 		if token != nil {
-			c.authToken = token
+			c.setAuthToken(token)
+		}
+	}
+
+	// Reuse a token cached on disk by an earlier process
+	if c.tokenCache != nil && c.getAuthToken() == nil {
+		if token := c.readTokenCache(); token != nil {
+			c.setAuthToken(token)
 		}
 	}
 
 	if c.NeedsTokenRefresh() {
-		return c.refreshToken()
+		if c.asyncRefresh != nil && c.tokenStillValid() {
+			c.refreshTokenInBackground()
+			return nil
+		}
+		return c.refreshTokenOnce(ctx, false)
 	}
 
 	return nil
 }
 
+// ForceRefreshToken authenticates to obtain a new access token, even if the
+// current one is still valid. It joins a refresh already in progress instead
+// of starting another.
 func (c *Client) ForceRefreshToken() error {
-	return c.refreshToken()
+	return c.ForceRefreshTokenWithContext(context.Background())
+}
+
+// ForceRefreshTokenWithContext is like ForceRefreshToken but uses the provided
+// context for the underlying request(s).
+func (c *Client) ForceRefreshTokenWithContext(ctx context.Context) error {
+	return c.refreshTokenOnce(ctx, true)
 }
 
-func (c *Client) refreshToken() error {
+// tokenRefresh is a token refresh shared by the goroutines which need the new
+// token at the same time.
+type tokenRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshTokenOnce refreshes the access token, unless another goroutine is
+// already doing so, in which case it waits for that refresh and returns its
+// result. Unless forced, the token is only refreshed if it still needs to be
+// once this goroutine gets to refresh it.
+//
+// The refresh uses the context of the goroutine doing it. Waiting goroutines
+// stop waiting when their own context is done, and refresh the token
+// themselves if the refresh failed only because its context was done.
+func (c *Client) refreshTokenOnce(ctx context.Context, force bool) error {
+	c.refreshMutex.Lock()
+	for c.refreshing != nil {
+		refresh := c.refreshing
+		c.refreshMutex.Unlock()
+		select {
+		case <-refresh.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !isContextError(refresh.err) {
+			return refresh.err
+		}
+		c.refreshMutex.Lock()
+	}
+	refresh := &tokenRefresh{done: make(chan struct{})}
+	c.refreshing = refresh
+	c.refreshMutex.Unlock()
+
+	defer func() {
+		c.refreshMutex.Lock()
+		c.refreshing = nil
+		c.refreshMutex.Unlock()
+		close(refresh.done)
+	}()
+
+	if force || c.NeedsTokenRefresh() {
+		refresh.err = c.refreshToken(ctx)
+	}
+	return refresh.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *Client) refreshToken(ctx context.Context) (err error) {
+	if c.telemetry != nil {
+		start := time.Now()
+		defer func() { c.telemetry.TokenRefreshed(time.Since(start), err) }()
+	}
+
 	var tokenBytes []byte
-	tokenBytes, err := c.authenticator.RefreshToken()
+	authenticator := c.GetAuthenticator()
+	if contextual, ok := authenticator.(authn.ContextAuthenticator); ok {
+		tokenBytes, err = contextual.RefreshTokenWithContext(ctx)
+	} else {
+		tokenBytes, err = authenticator.RefreshToken()
+	}
 	if err != nil {
 		return err
 	}
@@ -54,15 +154,82 @@ func (c *Client) refreshToken() error {
 		return err
 	}
 
-	token.FromJSON(tokenBytes)
-	c.authToken = token
+	c.setAuthToken(token)
+	if c.tokenCache != nil {
+		c.storeTokenCache(token)
+	}
 	return nil
 }
 
+// TokenExpiresAt returns when the client's current access token expires,
+// authenticating first if the client has no token yet. For clients created
+// from a pre-fetched token, such as with CONJUR_AUTHN_TOKEN, this is when the
+// client stops working.
+func (c *Client) TokenExpiresAt() (time.Time, error) {
+	if err := c.RefreshToken(); err != nil {
+		return time.Time{}, err
+	}
+	return c.getAuthToken().ExpiresAt(), nil
+}
+
+// TokenRefreshAt returns when the client will refresh its current access
+// token, given the Config's TokenRefreshThreshold, authenticating first if the
+// client has no token yet. Callers caching data derived from the token can
+// expire it at the same time.
+func (c *Client) TokenRefreshAt() (time.Time, error) {
+	if err := c.RefreshToken(); err != nil {
+		return time.Time{}, err
+	}
+	return c.getAuthToken().RefreshAt(c.config.TokenRefreshThreshold), nil
+}
+
 func (c *Client) NeedsTokenRefresh() bool {
-	return c.authToken == nil ||
-		c.authToken.ShouldRefresh() ||
-		c.authenticator.NeedsTokenRefresh()
+	token := c.getAuthToken()
+	return token == nil ||
+		c.tokenDueForRefresh(token) ||
+		c.GetAuthenticator().NeedsTokenRefresh()
+}
+
+// tokenDueForRefresh reports whether a token has passed the Config's
+// TokenRefreshThreshold.
+func (c *Client) tokenDueForRefresh(token *authn.AuthnToken) bool {
+	return time.Now().After(token.RefreshAt(c.config.TokenRefreshThreshold))
+}
+
+// tokenStillValid reports whether requests can keep using the current access
+// token while it's renewed: it hasn't expired, and the authenticator doesn't
+// demand a new one.
+func (c *Client) tokenStillValid() bool {
+	token := c.getAuthToken()
+	return token != nil &&
+		!token.Expired() &&
+		!c.GetAuthenticator().NeedsTokenRefresh()
+}
+
+// getAuthToken returns the current access token. It is safe to call while a
+// TokenRefresher renews the token in the background.
+func (c *Client) getAuthToken() *authn.AuthnToken {
+	c.authTokenMutex.RLock()
+	defer c.authTokenMutex.RUnlock()
+	return c.authToken
+}
+
+func (c *Client) setAuthToken(token *authn.AuthnToken) {
+	c.authTokenMutex.Lock()
+	defer c.authTokenMutex.Unlock()
+	if c.wipeTokens && c.authToken != nil && c.authToken != token {
+		c.authToken.Wipe()
+	}
+	c.authToken = token
+}
+
+// authorizationHeader returns the Authorization header for the current access
+// token. It holds the lock while encoding the token, which may be wiped as
+// soon as it's replaced.
+func (c *Client) authorizationHeader() string {
+	c.authTokenMutex.RLock()
+	defer c.authTokenMutex.RUnlock()
+	return fmt.Sprintf("Token token=\"%s\"", base64.StdEncoding.EncodeToString(c.authToken.Raw()))
 }
 
 func (c *Client) readCachedAccessToken() *authn.AuthnToken {
@@ -81,25 +248,30 @@ func (c *Client) readCachedAccessToken() *authn.AuthnToken {
 }
 
 func (c *Client) createAuthRequest(req *http.Request) error {
-	if err := c.RefreshToken(); err != nil {
+	if err := c.RefreshTokenWithContext(req.Context()); err != nil {
 		return err
 	}
 
-	req.Header.Set(
-		"Authorization",
-		fmt.Sprintf("Token token=\"%s\"", base64.StdEncoding.EncodeToString(c.authToken.Raw())),
-	)
+	req.Header.Set("Authorization", c.authorizationHeader())
 
 	return nil
 }
 
+// ChangeUserPassword sets a new password for a user, authenticating with the
+// user's current password or API key.
 func (c *Client) ChangeUserPassword(username string, password string, newPassword string) ([]byte, error) {
+	return c.ChangeUserPasswordWithContext(context.Background(), username, password, newPassword)
+}
+
+// ChangeUserPasswordWithContext is like ChangeUserPassword but uses the
+// provided context for the underlying request.
+func (c *Client) ChangeUserPasswordWithContext(ctx context.Context, username string, password string, newPassword string) ([]byte, error) {
 	req, err := c.ChangeUserPasswordRequest(username, password, newPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.send(operationDefault, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -107,41 +279,80 @@ func (c *Client) ChangeUserPassword(username string, password string, newPasswor
 	return response.DataResponse(res)
 }
 
+// ChangeCurrentUserPassword sets a new password for the user whose
+// credentials are held in the client's credential storage, authenticating
+// with those credentials. The stored API key is unaffected.
 func (c *Client) ChangeCurrentUserPassword(newPassword string) ([]byte, error) {
+	return c.ChangeCurrentUserPasswordWithContext(context.Background(), newPassword)
+}
+
+// ChangeCurrentUserPasswordWithContext is like ChangeCurrentUserPassword but
+// uses the provided context for the underlying request.
+func (c *Client) ChangeCurrentUserPasswordWithContext(ctx context.Context, newPassword string) ([]byte, error) {
+	if c.storage == nil {
+		return nil, fmt.Errorf("changing the current user's password requires credential storage")
+	}
+
 	username, password, err := c.storage.ReadCredentials()
 	if err != nil {
 		return nil, err
 	}
 
-	return c.ChangeUserPassword(username, password, newPassword)
+	return c.ChangeUserPasswordWithContext(ctx, username, password, newPassword)
 }
 
-// Login exchanges a user's password for an API key.
-func (c *Client) Login(login string, password string) ([]byte, error) {
+// Login exchanges a user's password for an API key, which is stored in the
+// client's credential storage. A client without an authenticator, such as
+// one created by NewClient for an interactive login, then authenticates with
+// the API key, so it can be used straight away.
+func (c *Client) Login(login string, password string) (string, error) {
+	return c.LoginWithContext(context.Background(), login, password)
+}
+
+// LoginWithContext is like Login but uses the provided context for the
+// underlying request.
+func (c *Client) LoginWithContext(ctx context.Context, login string, password string) (string, error) {
 	req, err := c.LoginRequest(login, password)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	apiKey, err := response.DataResponse(res)
+	data, err := response.DataResponse(res)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	apiKey := string(data)
+
+	c.authenticatorMutex.Lock()
+	if c.authenticator == nil {
+		c.authenticator = &authn.APIKeyAuthenticator{
+			Authenticate:            c.Authenticate,
+			AuthenticateWithContext: c.AuthenticateWithContext,
+			LoginPair:               authn.LoginPair{Login: login, APIKey: apiKey},
+		}
 	}
+	c.authenticatorMutex.Unlock()
 
 	// Store the API key in the credentials store
 	if c.storage != nil {
-		err = c.storage.StoreCredentials(login, string(apiKey))
+		err = c.storage.StoreCredentials(login, apiKey)
 	}
 	return apiKey, err
 }
 
-// PurgeCredentials purges credentials from the client's credential storage.
+// PurgeCredentials purges credentials from the client's credential storage,
+// along with its access token in the token cache.
 func (c *Client) PurgeCredentials() error {
+	if key, ok := c.tokenCacheKey(); ok && c.tokenCache != nil {
+		if err := c.tokenCache.Purge(key); err != nil {
+			return err
+		}
+	}
 	if c.storage == nil {
 		return nil
 	}
@@ -167,34 +378,41 @@ func PurgeCredentials(config Config) error {
 
 // Authenticate obtains a new access token using the internal authenticator.
 func (c *Client) InternalAuthenticate() ([]byte, error) {
-	if c.authenticator == nil {
+	authenticator := c.GetAuthenticator()
+	if authenticator == nil {
 		return nil, errors.New("unable to authenticate using client without authenticator")
 	}
 
 	// If using OIDC, check if we have a cached access token
 	if c.GetConfig().AuthnType == "oidc" {
 		token := c.readCachedAccessToken()
-		if token != nil && !token.ShouldRefresh() {
+		if token != nil && !c.tokenDueForRefresh(token) {
 			return token.Raw(), nil
 		} else {
 			// We can't simply refresh the token because it'll require user input. Instead,
 			// we return an error and inform the client/user to login again.
-			return nil, errors.New("No valid OIDC token found. Please login again.")
+			return nil, errors.New("no valid OIDC token found, please login again")
 		}
 	}
 
 	// Otherwise refresh the token
-	return c.authenticator.RefreshToken()
+	return authenticator.RefreshToken()
 }
 
 // WhoAmI obtains information on the current user.
 func (c *Client) WhoAmI() ([]byte, error) {
+	return c.WhoAmIWithContext(context.Background())
+}
+
+// WhoAmIWithContext is like WhoAmI but uses the provided context for the
+// underlying request.
+func (c *Client) WhoAmIWithContext(ctx context.Context) ([]byte, error) {
 	req, err := c.WhoAmIRequest()
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.SubmitRequest(req)
+	res, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -202,9 +420,41 @@ func (c *Client) WhoAmI() ([]byte, error) {
 	return response.DataResponse(res)
 }
 
+// WhoAmIDetails obtains information on the current user, parsed into a
+// WhoAmIResponse.
+func (c *Client) WhoAmIDetails() (*WhoAmIResponse, error) {
+	return c.WhoAmIDetailsWithContext(context.Background())
+}
+
+// WhoAmIDetailsWithContext is like WhoAmIDetails but uses the provided context
+// for the underlying request.
+func (c *Client) WhoAmIDetailsWithContext(ctx context.Context) (*WhoAmIResponse, error) {
+	req, err := c.WhoAmIRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	whoami := WhoAmIResponse{}
+	if err := response.JSONResponse(res, &whoami); err != nil {
+		return nil, err
+	}
+	return &whoami, nil
+}
+
 // Authenticate obtains a new access token.
 func (c *Client) Authenticate(loginPair authn.LoginPair) ([]byte, error) {
-	resp, err := c.authenticate(loginPair)
+	return c.AuthenticateWithContext(context.Background(), loginPair)
+}
+
+// AuthenticateWithContext is like Authenticate but uses the provided context
+// for the underlying request.
+func (c *Client) AuthenticateWithContext(ctx context.Context, loginPair authn.LoginPair) ([]byte, error) {
+	resp, err := c.authenticate(ctx, loginPair)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +464,13 @@ func (c *Client) Authenticate(loginPair authn.LoginPair) ([]byte, error) {
 
 // AuthenticateReader obtains a new access token and returns it as a data stream.
 func (c *Client) AuthenticateReader(loginPair authn.LoginPair) (io.ReadCloser, error) {
-	resp, err := c.authenticate(loginPair)
+	return c.AuthenticateReaderWithContext(context.Background(), loginPair)
+}
+
+// AuthenticateReaderWithContext is like AuthenticateReader but uses the
+// provided context for the underlying request.
+func (c *Client) AuthenticateReaderWithContext(ctx context.Context, loginPair authn.LoginPair) (io.ReadCloser, error) {
+	resp, err := c.authenticate(ctx, loginPair)
 	if err != nil {
 		return nil, err
 	}
@@ -222,22 +478,56 @@ func (c *Client) AuthenticateReader(loginPair authn.LoginPair) (io.ReadCloser, e
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) authenticate(loginPair authn.LoginPair) (*http.Response, error) {
+func (c *Client) authenticate(ctx context.Context, loginPair authn.LoginPair) (*http.Response, error) {
 	req, err := c.AuthenticateRequest(loginPair)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.httpClient.Do(req)
+	return c.send(operationAuthn, req.WithContext(ctx))
 }
 
 func (c *Client) OidcAuthenticate(code, nonce, code_verifier string) ([]byte, error) {
+	return c.OidcAuthenticateWithContext(context.Background(), code, nonce, code_verifier)
+}
+
+// OidcAuthenticateWithContext is like OidcAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) OidcAuthenticateWithContext(ctx context.Context, code, nonce, code_verifier string) ([]byte, error) {
 	req, err := c.OidcAuthenticateRequest(code, nonce, code_verifier)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := response.DataResponse(res)
+
+	if err == nil && c.storage != nil {
+		c.storage.StoreAuthnToken(resp)
+	}
+
+	return resp, err
+}
+
+// OidcIDTokenAuthenticate exchanges an OIDC ID token for a new access token
+// using authn-oidc.
+func (c *Client) OidcIDTokenAuthenticate(idToken string) ([]byte, error) {
+	return c.OidcIDTokenAuthenticateWithContext(context.Background(), idToken)
+}
+
+// OidcIDTokenAuthenticateWithContext is like OidcIDTokenAuthenticate but uses
+// the provided context for the underlying request.
+func (c *Client) OidcIDTokenAuthenticateWithContext(ctx context.Context, idToken string) ([]byte, error) {
+	req, err := c.OidcIDTokenAuthenticateRequest(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -251,13 +541,152 @@ func (c *Client) OidcAuthenticate(code, nonce, code_verifier string) ([]byte, er
 	return resp, err
 }
 
+// JWTAuthenticate exchanges a JWT for a new access token using authn-jwt.
+func (c *Client) JWTAuthenticate(jwt, hostID string) ([]byte, error) {
+	return c.JWTAuthenticateWithContext(context.Background(), jwt, hostID)
+}
+
+// JWTAuthenticateWithContext is like JWTAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) JWTAuthenticateWithContext(ctx context.Context, jwt, hostID string) ([]byte, error) {
+	req, err := c.JWTAuthenticateRequest(jwt, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(res)
+}
+
+// K8sInjectClientCert asks authn-k8s to sign the CSR and inject the resulting
+// client certificate into the requesting pod.
+func (c *Client) K8sInjectClientCert(csr []byte, hostIDPrefix string) error {
+	return c.K8sInjectClientCertWithContext(context.Background(), csr, hostIDPrefix)
+}
+
+// K8sInjectClientCertWithContext is like K8sInjectClientCert but uses the
+// provided context for the underlying request.
+func (c *Client) K8sInjectClientCertWithContext(ctx context.Context, csr []byte, hostIDPrefix string) error {
+	req, err := c.K8sInjectClientCertRequest(csr, hostIDPrefix)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return response.EmptyResponse(res)
+}
+
+// K8sAuthenticate obtains a new access token from authn-k8s, presenting the
+// provided client certificate.
+func (c *Client) K8sAuthenticate(cert tls.Certificate, login string) ([]byte, error) {
+	return c.K8sAuthenticateWithContext(context.Background(), cert, login)
+}
+
+// K8sAuthenticateWithContext is like K8sAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) K8sAuthenticateWithContext(ctx context.Context, cert tls.Certificate, login string) ([]byte, error) {
+	req, err := c.K8sAuthenticateRequest(login)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClientWithClientCert(cert).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(res)
+}
+
+// IAMAuthenticate obtains a new access token from authn-iam using the signed
+// headers of an STS GetCallerIdentity request.
+func (c *Client) IAMAuthenticate(login string, signedHeaders []byte) ([]byte, error) {
+	return c.IAMAuthenticateWithContext(context.Background(), login, signedHeaders)
+}
+
+// IAMAuthenticateWithContext is like IAMAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) IAMAuthenticateWithContext(ctx context.Context, login string, signedHeaders []byte) ([]byte, error) {
+	req, err := c.IAMAuthenticateRequest(login, signedHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(res)
+}
+
+// AzureAuthenticate exchanges an Azure managed identity token for a new access
+// token using authn-azure.
+func (c *Client) AzureAuthenticate(login, azureToken string) ([]byte, error) {
+	return c.AzureAuthenticateWithContext(context.Background(), login, azureToken)
+}
+
+// AzureAuthenticateWithContext is like AzureAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) AzureAuthenticateWithContext(ctx context.Context, login, azureToken string) ([]byte, error) {
+	req, err := c.AzureAuthenticateRequest(login, azureToken)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(res)
+}
+
+// GCPAuthenticate exchanges a GCP identity token for a new access token using
+// authn-gcp.
+func (c *Client) GCPAuthenticate(identityToken string) ([]byte, error) {
+	return c.GCPAuthenticateWithContext(context.Background(), identityToken)
+}
+
+// GCPAuthenticateWithContext is like GCPAuthenticate but uses the provided
+// context for the underlying request.
+func (c *Client) GCPAuthenticateWithContext(ctx context.Context, identityToken string) ([]byte, error) {
+	req, err := c.GCPAuthenticateRequest(identityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(res)
+}
+
+// ListOidcProviders returns the OIDC providers configured for the account,
+// including the authorization URL and PKCE parameters for each one.
 func (c *Client) ListOidcProviders() ([]OidcProvider, error) {
+	return c.ListOidcProvidersWithContext(context.Background())
+}
+
+// ListOidcProvidersWithContext is like ListOidcProviders but uses the provided
+// context for the underlying request.
+func (c *Client) ListOidcProvidersWithContext(ctx context.Context) ([]OidcProvider, error) {
 	req, err := c.ListOidcProvidersRequest()
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(operationDefault, req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +703,13 @@ func (c *Client) ListOidcProviders() ([]OidcProvider, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateAPIKey(roleID string) ([]byte, error) {
-	resp, err := c.rotateAPIKey(roleID)
+	return c.RotateAPIKeyWithContext(context.Background(), roleID)
+}
+
+// RotateAPIKeyWithContext is like RotateAPIKey but uses the provided context
+// for the underlying request.
+func (c *Client) RotateAPIKeyWithContext(ctx context.Context, roleID string) ([]byte, error) {
+	resp, err := c.rotateAPIKey(ctx, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -282,18 +717,41 @@ func (c *Client) RotateAPIKey(roleID string) ([]byte, error) {
 	return response.DataResponse(resp)
 }
 
+// RotateCurrentUserAPIKey replaces the API key of the user whose credentials
+// are held in the client's credential storage, authenticating with those
+// credentials. The new API key is written back to the credential storage and
+// used for subsequent authentication, since the previous key stops working.
 func (c *Client) RotateCurrentUserAPIKey() ([]byte, error) {
+	return c.RotateCurrentUserAPIKeyWithContext(context.Background())
+}
+
+// RotateCurrentUserAPIKeyWithContext is like RotateCurrentUserAPIKey but uses
+// the provided context for the underlying request.
+func (c *Client) RotateCurrentUserAPIKeyWithContext(ctx context.Context) ([]byte, error) {
+	if c.storage == nil {
+		return nil, fmt.Errorf("rotating the current user's API key requires credential storage")
+	}
+
 	username, password, err := c.storage.ReadCredentials()
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.rotateCurrentUserAPIKey(username, password)
+	resp, err := c.rotateCurrentUserAPIKey(ctx, username, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return response.DataResponse(resp)
+	apiKey, err := response.DataResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous API key is no longer valid, so keep using the new one
+	if a, ok := c.GetAuthenticator().(*authn.APIKeyAuthenticator); ok && a.Login == username {
+		a.APIKey = string(apiKey)
+	}
+	return apiKey, c.storage.StoreCredentials(username, string(apiKey))
 }
 
 // RotateUserAPIKey constructs a role ID from a given user ID then replaces the
@@ -304,7 +762,13 @@ func (c *Client) RotateCurrentUserAPIKey() ([]byte, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateUserAPIKey(userID string) ([]byte, error) {
-	return c.rotateApiKeyAndEnforceKind(userID, "user")
+	return c.RotateUserAPIKeyWithContext(context.Background(), userID)
+}
+
+// RotateUserAPIKeyWithContext is like RotateUserAPIKey but uses the provided
+// context for the underlying request.
+func (c *Client) RotateUserAPIKeyWithContext(ctx context.Context, userID string) ([]byte, error) {
+	return c.rotateApiKeyAndEnforceKind(ctx, userID, "user")
 }
 
 // RotateHostAPIKey constructs a role ID from a given host ID then replaces the
@@ -315,17 +779,23 @@ func (c *Client) RotateUserAPIKey(userID string) ([]byte, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateHostAPIKey(hostID string) ([]byte, error) {
-	return c.rotateApiKeyAndEnforceKind(hostID, "host")
+	return c.RotateHostAPIKeyWithContext(context.Background(), hostID)
 }
 
-func (c *Client) rotateApiKeyAndEnforceKind(roleID, kind string) ([]byte, error) {
+// RotateHostAPIKeyWithContext is like RotateHostAPIKey but uses the provided
+// context for the underlying request.
+func (c *Client) RotateHostAPIKeyWithContext(ctx context.Context, hostID string) ([]byte, error) {
+	return c.rotateApiKeyAndEnforceKind(ctx, hostID, "host")
+}
+
+func (c *Client) rotateApiKeyAndEnforceKind(ctx context.Context, roleID, kind string) ([]byte, error) {
 	account, kind, identifier, err := c.parseIDandEnforceKind(roleID, kind)
 	if err != nil {
 		return nil, err
 	}
 
 	roleID = fmt.Sprintf("%s:%s:%s", account, kind, identifier)
-	return c.RotateAPIKey(roleID)
+	return c.RotateAPIKeyWithContext(ctx, roleID)
 }
 
 // RotateAPIKeyReader replaces the API key of a role on the server with a new
@@ -333,7 +803,13 @@ func (c *Client) rotateApiKeyAndEnforceKind(roleID, kind string) ([]byte, error)
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateAPIKeyReader(roleID string) (io.ReadCloser, error) {
-	resp, err := c.rotateAPIKey(roleID)
+	return c.RotateAPIKeyReaderWithContext(context.Background(), roleID)
+}
+
+// RotateAPIKeyReaderWithContext is like RotateAPIKeyReader but uses the
+// provided context for the underlying request.
+func (c *Client) RotateAPIKeyReaderWithContext(ctx context.Context, roleID string) (io.ReadCloser, error) {
+	resp, err := c.rotateAPIKey(ctx, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -341,31 +817,37 @@ func (c *Client) RotateAPIKeyReader(roleID string) (io.ReadCloser, error) {
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) rotateAPIKey(roleID string) (*http.Response, error) {
+func (c *Client) rotateAPIKey(ctx context.Context, roleID string) (*http.Response, error) {
 	req, err := c.RotateAPIKeyRequest(roleID)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.SubmitRequest(req)
+	return c.SubmitRequest(req.WithContext(ctx))
 }
 
-func (c *Client) rotateCurrentUserAPIKey(username string, password string) (*http.Response, error) {
+func (c *Client) rotateCurrentUserAPIKey(ctx context.Context, username string, password string) (*http.Response, error) {
 	req, err := c.RotateCurrentUserAPIKeyRequest(username, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.httpClient.Do(req)
+	return c.send(operationDefault, req.WithContext(ctx))
 }
 
 func (c *Client) PublicKeys(kind string, identifier string) ([]byte, error) {
+	return c.PublicKeysWithContext(context.Background(), kind, identifier)
+}
+
+// PublicKeysWithContext is like PublicKeys but uses the provided context for
+// the underlying request.
+func (c *Client) PublicKeysWithContext(ctx context.Context, kind string, identifier string) ([]byte, error) {
 	req, err := c.PublicKeysRequest(kind, identifier)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.SubmitRequest(req)
+	res, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,12 @@
 package authn
 
+import "context"
+
 type APIKeyAuthenticator struct {
 	Authenticate func(loginPair LoginPair) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, loginPair LoginPair) ([]byte, error)
 	LoginPair
 }
 
@@ -11,6 +16,13 @@ type LoginPair struct {
 }
 
 func (a *APIKeyAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *APIKeyAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, a.LoginPair)
+	}
 	return a.Authenticate(a.LoginPair)
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"runtime"
 	"time"
 )
 
@@ -21,6 +22,7 @@ type AuthnToken struct {
 	Signature string `json:"signature"`
 	iat       time.Time
 	exp       *time.Time
+	claims    map[string]interface{}
 }
 
 func hasField(fields map[string]string, name string) (hasField bool) {
@@ -31,15 +33,18 @@ func hasField(fields map[string]string, name string) (hasField bool) {
 func NewToken(data []byte) (token *AuthnToken, err error) {
 	fields := make(map[string]string)
 	if err = json.Unmarshal(data, &fields); err != nil {
-		err = fmt.Errorf("Unable to unmarshal token: %s", err)
+		err = fmt.Errorf("unable to unmarshal token: %w", err)
 		return
 	}
 
 	if hasField(fields, "protected") && hasField(fields, "payload") && hasField(fields, "signature") {
 		t := &AuthnToken{}
 		token = t
+	} else if hasField(fields, "data") && hasField(fields, "timestamp") && hasField(fields, "signature") && hasField(fields, "key") {
+		token, err = newToken4(data, fields)
+		return
 	} else {
-		err = fmt.Errorf("Unrecognized token format")
+		err = fmt.Errorf("unrecognized token format")
 		return
 	}
 
@@ -53,7 +58,7 @@ func (t *AuthnToken) FromJSON(data []byte) (err error) {
 
 	err = json.Unmarshal(data, &t)
 	if err != nil {
-		err = fmt.Errorf("Unable to unmarshal access token: %s", err)
+		err = fmt.Errorf("unable to unmarshal access token: %w", err)
 		return
 	}
 
@@ -61,15 +66,20 @@ func (t *AuthnToken) FromJSON(data []byte) (err error) {
 	payloadFields := make(map[string]interface{})
 	var payloadJSON []byte
 	payloadJSON, err = base64.StdEncoding.DecodeString(t.Payload)
+	if err != nil {
+		// Slosilo encodes tokens with the URL-safe alphabet
+		payloadJSON, err = base64.URLEncoding.DecodeString(t.Payload)
+	}
 	if err != nil {
 		err = fmt.Errorf("access token field 'payload' is not valid base64")
 		return
 	}
 	err = json.Unmarshal(payloadJSON, &payloadFields)
 	if err != nil {
-		err = fmt.Errorf("Unable to unmarshal access token field 'payload': %s", err)
+		err = fmt.Errorf("unable to unmarshal access token field 'payload': %w", err)
 		return
 	}
+	t.claims = payloadFields
 
 	iat_v, ok := payloadFields["iat"]
 	if !ok {
@@ -94,18 +104,97 @@ func (t *AuthnToken) FromJSON(data []byte) (err error) {
 	return
 }
 
+// newToken4 decodes a Conjur v4 token, such as
+// {"data":"admin","timestamp":"2017-10-24 20:31:50 UTC","signature":"...","key":"..."}.
+// Its data is the subject, and it expires 8 minutes after its timestamp.
+func newToken4(data []byte, fields map[string]string) (*AuthnToken, error) {
+	iat, err := time.Parse(TimeFormatToken4, fields["timestamp"])
+	if err != nil {
+		return nil, fmt.Errorf("access token field 'timestamp' is not valid: %w", err)
+	}
+	return &AuthnToken{
+		bytes:     data,
+		Signature: fields["signature"],
+		iat:       iat,
+		claims:    map[string]interface{}{"sub": fields["data"]},
+	}, nil
+}
+
 func (t *AuthnToken) Raw() []byte {
 	return t.bytes
 }
 
-func (t *AuthnToken) ShouldRefresh() bool {
+// Wipe overwrites the token's raw bytes with zeroes once the token is no
+// longer needed. Its decoded fields can't be wiped, so they're cleared.
+func (t *AuthnToken) Wipe() {
+	for i := range t.bytes {
+		t.bytes[i] = 0
+	}
+	runtime.KeepAlive(t.bytes)
+	t.Protected, t.Payload, t.Signature = "", "", ""
+	t.claims = nil
+}
+
+// Claims returns the claims of the token's payload, such as "sub", "iat" and
+// "exp". Numbers are decoded as float64.
+func (t *AuthnToken) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(t.claims))
+	for name, value := range t.claims {
+		claims[name] = value
+	}
+	return claims
+}
+
+// Subject returns the identity the token was issued to, such as "admin" or
+// "host/myapp".
+func (t *AuthnToken) Subject() string {
+	subject, _ := t.claims["sub"].(string)
+	return subject
+}
+
+// IssuedAt returns when the token was issued.
+func (t *AuthnToken) IssuedAt() time.Time {
+	return t.iat
+}
+
+// ExpiresAt returns when the token expires, which is 8 minutes after it was
+// issued if the token doesn't specify it.
+func (t *AuthnToken) ExpiresAt() time.Time {
 	if t.exp != nil {
-		// Expire when the token is 85% expired
-		lifespan := t.exp.Sub(t.iat)
-		duration := float32(lifespan) * 0.85
-		return time.Now().After(t.iat.Add(time.Duration(duration)))
-	} else {
-		// Token expires 8 minutes after issue, by default
-		return time.Now().After(t.iat.Add(5 * time.Minute))
+		return *t.exp
+	}
+	return t.iat.Add(8 * time.Minute)
+}
+
+// Expired reports whether the token has expired, so that it must be replaced
+// before it can be used again. Unlike ShouldRefresh, which reports that the
+// token is due to be renewed while still valid.
+func (t *AuthnToken) Expired() bool {
+	return !time.Now().Before(t.ExpiresAt())
+}
+
+// DefaultTokenRefreshThreshold is the fraction of a token's lifetime after
+// which ShouldRefresh reports that it should be refreshed.
+const DefaultTokenRefreshThreshold = 0.85
+
+// ShouldRefresh reports whether the token should be refreshed, once 85% of its
+// lifetime has passed, or 5 minutes after it was issued if the token doesn't
+// specify its expiry.
+func (t *AuthnToken) ShouldRefresh() bool {
+	return time.Now().After(t.RefreshAt(0))
+}
+
+// RefreshAt returns when the token should be refreshed, once the given
+// fraction of its lifetime has passed. A threshold of 0 uses the defaults of
+// ShouldRefresh.
+func (t *AuthnToken) RefreshAt(threshold float64) time.Time {
+	if threshold <= 0 {
+		if t.exp == nil {
+			// Token expires 8 minutes after issue, by default
+			return t.iat.Add(5 * time.Minute)
+		}
+		threshold = DefaultTokenRefreshThreshold
 	}
+	lifespan := t.ExpiresAt().Sub(t.iat)
+	return t.iat.Add(time.Duration(float64(lifespan) * threshold))
 }
@@ -1,6 +1,8 @@
 package authn
 
 import (
+	"encoding/base64"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -35,6 +37,16 @@ func TestToken_Parse(t *testing.T) {
 		assert.True(t, token.ShouldRefresh())
 	})
 
+	t.Run("Token expiry defaults to 8 minutes after issue", func(t *testing.T) {
+		token, err := NewToken([]byte(token_s))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(1510753259, 0).Add(8*time.Minute), token.ExpiresAt())
+
+		token, err = NewToken([]byte(token_with_exp_s))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(1510753359, 0), token.ExpiresAt())
+	})
+
 	t.Run("Token exp is supported", func(t *testing.T) {
 		token, err := NewToken([]byte(token_with_exp_s))
 		assert.NoError(t, err)
@@ -45,6 +57,66 @@ func TestToken_Parse(t *testing.T) {
 		assert.True(t, token.ShouldRefresh())
 	})
 
+	t.Run("Token expiry is distinct from refresh", func(t *testing.T) {
+		token, err := NewToken([]byte(token_with_exp_s))
+		assert.NoError(t, err)
+		assert.True(t, token.ShouldRefresh())
+		assert.True(t, token.Expired())
+
+		// A token due for refresh is still valid until it expires
+		now := time.Now()
+		payload := fmt.Sprintf(`{"sub":"admin","iat":%d,"exp":%d}`, now.Add(-time.Hour).Unix(), now.Add(time.Minute).Unix())
+		token, err = NewToken([]byte(fmt.Sprintf(`{"protected":"e30=","payload":"%s","signature":"c2ln"}`, base64.StdEncoding.EncodeToString([]byte(payload)))))
+		assert.NoError(t, err)
+		assert.True(t, token.ShouldRefresh())
+		assert.False(t, token.Expired())
+	})
+
+	t.Run("Token refresh time uses the threshold", func(t *testing.T) {
+		token, err := NewToken([]byte(token_with_exp_s))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(1510753259+85, 0), token.RefreshAt(0))
+		assert.Equal(t, time.Unix(1510753259+50, 0), token.RefreshAt(0.5))
+
+		// Without an expiry, tokens are refreshed after 5 of their 8 minutes
+		token, err = NewToken([]byte(token_s))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(1510753259, 0).Add(5*time.Minute), token.RefreshAt(0))
+		assert.Equal(t, time.Unix(1510753259, 0).Add(4*time.Minute), token.RefreshAt(0.5))
+	})
+
+	t.Run("Token claims are exposed", func(t *testing.T) {
+		token, err := NewToken([]byte(token_with_exp_s))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "admin", token.Subject())
+		assert.Equal(t, time.Unix(1510753259, 0), token.IssuedAt())
+		assert.Equal(t, map[string]interface{}{"sub": "admin", "iat": float64(1510753259), "exp": float64(1510753359)}, token.Claims())
+
+		// Changes to the returned claims don't affect the token
+		token.Claims()["sub"] = "alice"
+		assert.Equal(t, "admin", token.Subject())
+	})
+
+	t.Run("URL-safe base64 payloads are supported", func(t *testing.T) {
+		// {"sub":"host/app?>","iat":1510753259}
+		token_url_safe_s := `{"protected":"eyJhbGciOiJjb25qdXIub3JnL3Nsb3NpbG8vdjIiLCJraWQiOiI5M2VjNTEwODRmZTM3Zjc3M2I1ODhlNTYyYWVjZGMxMSJ9","payload":"eyJzdWIiOiJob3N0L2FwcD8-IiwiaWF0IjoxNTEwNzUzMjU5fQ==","signature":"raCufKOf"}`
+		token, err := NewToken([]byte(token_url_safe_s))
+		assert.NoError(t, err)
+		assert.Equal(t, "host/app?>", token.Subject())
+	})
+
+	t.Run("Token can be wiped", func(t *testing.T) {
+		data := []byte(token_s)
+		token, err := NewToken(data)
+		assert.NoError(t, err)
+
+		token.Wipe()
+		assert.Equal(t, make([]byte, len(token_s)), data)
+		assert.Empty(t, token.Payload)
+		assert.Empty(t, token.Subject())
+	})
+
 	t.Run("Malformed base64 in token is reported", func(t *testing.T) {
 		_, err := NewToken([]byte(token_mangled_s))
 		assert.Equal(t, "access token field 'payload' is not valid base64", err.Error())
@@ -52,18 +124,31 @@ func TestToken_Parse(t *testing.T) {
 
 	t.Run("Malformed JSON in token is reported", func(t *testing.T) {
 		_, err := NewToken([]byte(token_mangled_2_s))
-		assert.Equal(t, "Unable to unmarshal access token field 'payload': invalid character 'o' in literal false (expecting 'a')", err.Error())
+		assert.Equal(t, "unable to unmarshal access token field 'payload': invalid character 'o' in literal false (expecting 'a')", err.Error())
 	})
 
 	t.Run("Invalid JSON in token is reported", func(t *testing.T) {
 		token, err := NewToken([]byte("invalid json"))
-		assert.EqualError(t, err, "Unable to unmarshal token: invalid character 'i' looking for beginning of value")
+		assert.EqualError(t, err, "unable to unmarshal token: invalid character 'i' looking for beginning of value")
 		assert.Nil(t, token)
 	})
 
+	t.Run("Conjur v4 tokens are supported", func(t *testing.T) {
+		token, err := NewToken([]byte(`{"data":"alice","timestamp":"2017-10-24 20:31:50 UTC","signature":"c2ln","key":"a2V5"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", token.Subject())
+		assert.Equal(t, time.Date(2017, 10, 24, 20, 31, 50, 0, time.UTC), token.IssuedAt().UTC())
+		assert.Equal(t, token.IssuedAt().Add(8*time.Minute), token.ExpiresAt())
+	})
+
+	t.Run("Conjur v4 token with invalid timestamp", func(t *testing.T) {
+		_, err := NewToken([]byte(`{"data":"alice","timestamp":"yesterday","signature":"c2ln","key":"a2V5"}`))
+		assert.ErrorContains(t, err, "access token field 'timestamp' is not valid")
+	})
+
 	t.Run("Token without correct fields", func(t *testing.T) {
 		token, err := NewToken([]byte(`{"foo":"bar"}`))
-		assert.EqualError(t, err, "Unrecognized token format")
+		assert.EqualError(t, err, "unrecognized token format")
 		assert.Nil(t, token)
 	})
 
@@ -82,6 +167,6 @@ func TestToken_Parse(t *testing.T) {
 	t.Run("FromJSON returns error when provided invalid JSON", func(t *testing.T) {
 		token := &AuthnToken{}
 		err := token.FromJSON([]byte("invalid json"))
-		assert.EqualError(t, err, "Unable to unmarshal access token: invalid character 'i' looking for beginning of value")
+		assert.EqualError(t, err, "unable to unmarshal access token: invalid character 'i' looking for beginning of value")
 	})
 }
@@ -0,0 +1,120 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	AzureDefaultResource = "https://management.azure.com/"
+
+	azureDefaultMetadataURL = "http://169.254.169.254"
+	azureIMDSAPIVersion     = "2018-02-01"
+)
+
+// AzureAuthenticator authenticates an Azure workload with authn-azure. It
+// obtains an access token for the workload's managed identity from the Azure
+// Instance Metadata Service and exchanges it for a Conjur access token.
+type AzureAuthenticator struct {
+	// Login is the Conjur host ID of the workload.
+	Login string
+	// ClientID selects a user-assigned managed identity. The system-assigned
+	// identity is used when it is empty.
+	ClientID string
+	// Resource is the audience of the requested Azure token. Defaults to
+	// AzureDefaultResource.
+	Resource     string
+	Authenticate func(login, azureToken string) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, login, azureToken string) ([]byte, error)
+
+	metadataURL string
+	httpClient  *http.Client
+}
+
+func (a *AzureAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *AzureAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	azureToken, err := a.azureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, a.Login, azureToken)
+	}
+	return a.Authenticate(a.Login, azureToken)
+}
+
+func (a *AzureAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+// azureToken requests an access token for the managed identity from IMDS.
+func (a *AzureAuthenticator) azureToken(ctx context.Context) (string, error) {
+	resource := a.Resource
+	if resource == "" {
+		resource = AzureDefaultResource
+	}
+
+	query := url.Values{}
+	query.Set("api-version", azureIMDSAPIVersion)
+	query.Set("resource", resource)
+	if a.ClientID != "" {
+		query.Set("client_id", a.ClientID)
+	}
+
+	metadataURL := a.metadataURL
+	if metadataURL == "" {
+		metadataURL = azureDefaultMetadataURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	httpClient := a.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch Azure managed identity token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	result := struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	json.Unmarshal(body, &result)
+
+	if resp.StatusCode >= 300 {
+		if result.ErrorDescription != "" {
+			return "", fmt.Errorf("unable to fetch Azure managed identity token: %s", result.ErrorDescription)
+		}
+		return "", fmt.Errorf("unable to fetch Azure managed identity token: HTTP status %d", resp.StatusCode)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("no access token in the Azure managed identity token response")
+	}
+
+	return result.AccessToken, nil
+}
@@ -0,0 +1,79 @@
+package authn
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureAuthenticator_RefreshToken(t *testing.T) {
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Path != "/metadata/identity/oauth2/token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_request","error_description":"Required metadata header not specified"}`)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("client_id") == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_request","error_description":"Identity not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"azure-token:%s:%s","token_type":"Bearer"}`, query.Get("resource"), query.Get("client_id"))
+	}))
+	defer imds.Close()
+
+	authenticate := func(login, azureToken string) ([]byte, error) {
+		return []byte(login + "|" + azureToken), nil
+	}
+
+	t.Run("Uses the system-assigned identity by default", func(t *testing.T) {
+		authenticator := AzureAuthenticator{
+			Login:        "host/azure-apps/my-app",
+			Authenticate: authenticate,
+			metadataURL:  imds.URL,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "host/azure-apps/my-app|azure-token:https://management.azure.com/:", string(token))
+	})
+
+	t.Run("Uses a user-assigned identity and custom resource", func(t *testing.T) {
+		authenticator := AzureAuthenticator{
+			Login:        "host/azure-apps/my-app",
+			ClientID:     "client-id",
+			Resource:     "https://vault.azure.net",
+			Authenticate: authenticate,
+			metadataURL:  imds.URL,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "host/azure-apps/my-app|azure-token:https://vault.azure.net:client-id", string(token))
+	})
+
+	t.Run("Returns IMDS error description", func(t *testing.T) {
+		authenticator := AzureAuthenticator{
+			Login:        "host/azure-apps/my-app",
+			ClientID:     "unknown",
+			Authenticate: authenticate,
+			metadataURL:  imds.URL,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.Nil(t, token)
+		assert.EqualError(t, err, "unable to fetch Azure managed identity token: Identity not found")
+	})
+}
+
+func TestAzureAuthenticator_NeedsTokenRefresh(t *testing.T) {
+	t.Run("Returns false", func(t *testing.T) {
+		authenticator := AzureAuthenticator{}
+
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
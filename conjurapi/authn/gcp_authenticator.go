@@ -0,0 +1,105 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const gcpDefaultMetadataURL = "http://metadata.google.internal"
+
+// GCPAuthenticator authenticates a Google Cloud workload (GCE, GKE or Cloud
+// Run) with authn-gcp. It fetches an identity token for the workload's
+// service account from the metadata server and exchanges it for a Conjur
+// access token.
+type GCPAuthenticator struct {
+	// Account is the Conjur account, used to build the token audience.
+	Account string
+	// Login is the Conjur host ID of the workload.
+	Login string
+	// MetadataURL overrides the metadata server base URL. When empty, the
+	// GCE_METADATA_HOST environment variable is used if set, otherwise
+	// http://metadata.google.internal.
+	MetadataURL  string
+	Authenticate func(identityToken string) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, identityToken string) ([]byte, error)
+
+	httpClient *http.Client
+}
+
+func (a *GCPAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *GCPAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	identityToken, err := a.identityToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, identityToken)
+	}
+	return a.Authenticate(identityToken)
+}
+
+func (a *GCPAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+// Audience returns the audience Conjur expects in the identity token, in the
+// form conjur/<account>/<host-id>.
+func (a *GCPAuthenticator) Audience() string {
+	return fmt.Sprintf("conjur/%s/%s", a.Account, a.Login)
+}
+
+func (a *GCPAuthenticator) identityToken(ctx context.Context) (string, error) {
+	query := url.Values{}
+	query.Set("audience", a.Audience())
+	query.Set("format", "full")
+
+	identityURL := a.metadataURL() + "/computeMetadata/v1/instance/service-accounts/default/identity?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", identityURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	httpClient := a.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch GCP identity token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unable to fetch GCP identity token: HTTP status %d", resp.StatusCode)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (a *GCPAuthenticator) metadataURL() string {
+	if a.MetadataURL != "" {
+		return strings.TrimSuffix(a.MetadataURL, "/")
+	}
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		return "http://" + host
+	}
+	return gcpDefaultMetadataURL
+}
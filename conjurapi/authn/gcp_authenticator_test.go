@@ -0,0 +1,73 @@
+package authn
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPAuthenticator_RefreshToken(t *testing.T) {
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/identity" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "identity-token:%s:%s", r.URL.Query().Get("audience"), r.URL.Query().Get("format"))
+	}))
+	defer metadataServer.Close()
+
+	authenticate := func(identityToken string) ([]byte, error) {
+		return []byte(identityToken), nil
+	}
+
+	t.Run("Fetches an identity token with the Conjur audience", func(t *testing.T) {
+		authenticator := GCPAuthenticator{
+			Account:      "myaccount",
+			Login:        "host/gcp-apps/my-app",
+			MetadataURL:  metadataServer.URL,
+			Authenticate: authenticate,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "identity-token:conjur/myaccount/host/gcp-apps/my-app:full", string(token))
+	})
+
+	t.Run("Uses GCE_METADATA_HOST when MetadataURL is empty", func(t *testing.T) {
+		t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadataServer.URL, "http://"))
+
+		authenticator := GCPAuthenticator{
+			Account:      "myaccount",
+			Login:        "host/gcp-apps/my-app",
+			Authenticate: authenticate,
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Returns error when the metadata server rejects the request", func(t *testing.T) {
+		authenticator := GCPAuthenticator{
+			Account:      "myaccount",
+			Login:        "host/gcp-apps/my-app",
+			MetadataURL:  metadataServer.URL + "/invalid",
+			Authenticate: authenticate,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.Nil(t, token)
+		assert.EqualError(t, err, "unable to fetch GCP identity token: HTTP status 403")
+	})
+}
+
+func TestGCPAuthenticator_NeedsTokenRefresh(t *testing.T) {
+	t.Run("Returns false", func(t *testing.T) {
+		authenticator := GCPAuthenticator{}
+
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
@@ -0,0 +1,100 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const iamDefaultRegion = "us-east-1"
+
+// IAMAuthenticator authenticates an AWS workload with authn-iam. It signs an
+// STS GetCallerIdentity request with the ambient AWS credentials and sends the
+// signed headers to Conjur, which replays the request to STS to verify the
+// caller's identity.
+type IAMAuthenticator struct {
+	// Login is the Conjur host ID of the workload, e.g.
+	// host/myapp/123456789012/MyInstanceRole.
+	Login string
+	// Region selects a regional STS endpoint to sign for. The global endpoint
+	// (us-east-1) is used by default.
+	Region       string
+	Authenticate func(login string, signedHeaders []byte) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, login string, signedHeaders []byte) ([]byte, error)
+
+	credentials awsCredentialsProvider
+	now         func() time.Time
+}
+
+func (a *IAMAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *IAMAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	creds, err := a.credentials.retrieve()
+	if err != nil {
+		return nil, err
+	}
+
+	signedHeaders, err := a.signedHeaders(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, a.Login, signedHeaders)
+	}
+	return a.Authenticate(a.Login, signedHeaders)
+}
+
+func (a *IAMAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+// signedHeaders returns the JSON-encoded headers of a signed STS
+// GetCallerIdentity request, in the format expected by authn-iam.
+func (a *IAMAuthenticator) signedHeaders(creds *awsCredentials) ([]byte, error) {
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are incomplete")
+	}
+
+	region := a.Region
+	host := "sts.amazonaws.com"
+	if region == "" {
+		region = iamDefaultRegion
+	} else if region != iamDefaultRegion {
+		host = fmt.Sprintf("sts.%s.amazonaws.com", region)
+	}
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	timestamp := now()
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-date":           timestamp.UTC().Format(awsTimeFormat),
+		"x-amz-content-sha256": awsEmptyBodySHA256,
+	}
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
+	headers["authorization"] = signAWSRequest(
+		creds,
+		http.MethodGet,
+		"/",
+		"Action=GetCallerIdentity&Version=2011-06-15",
+		headers,
+		awsEmptyBodySHA256,
+		region,
+		"sts",
+		timestamp,
+	)
+
+	return json.Marshal(headers)
+}
@@ -0,0 +1,191 @@
+package authn
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	creds := &awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded; charset=utf-8",
+		"Host":         "iam.amazonaws.com",
+		"X-Amz-Date":   "20150830T123600Z",
+	}
+
+	authorization := signAWSRequest(creds, "GET", "/", "Action=ListUsers&Version=2010-05-08", headers, awsEmptyBodySHA256, "us-east-1", "iam", now)
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", authorization)
+}
+
+func TestIAMAuthenticator_RefreshToken(t *testing.T) {
+	now := func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	var gotLogin string
+	var gotHeaders map[string]string
+	authenticate := func(login string, signedHeaders []byte) ([]byte, error) {
+		gotLogin = login
+		gotHeaders = map[string]string{}
+		if err := json.Unmarshal(signedHeaders, &gotHeaders); err != nil {
+			return nil, err
+		}
+		return []byte("token"), nil
+	}
+
+	t.Run("Signs with credentials from the environment", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "session-token")
+
+		authenticator := IAMAuthenticator{
+			Login:        "host/myapp/123456789012/MyRole",
+			Authenticate: authenticate,
+			now:          now,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "token", string(token))
+		assert.Equal(t, "host/myapp/123456789012/MyRole", gotLogin)
+		assert.Equal(t, "sts.amazonaws.com", gotHeaders["host"])
+		assert.Equal(t, "20230102T030405Z", gotHeaders["x-amz-date"])
+		assert.Equal(t, "session-token", gotHeaders["x-amz-security-token"])
+		assert.Equal(t, awsEmptyBodySHA256, gotHeaders["x-amz-content-sha256"])
+		assert.True(t, strings.HasPrefix(gotHeaders["authorization"],
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230102/us-east-1/sts/aws4_request, "+
+				"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature="))
+	})
+
+	t.Run("Signs for a regional STS endpoint", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "")
+
+		authenticator := IAMAuthenticator{
+			Login:        "host/myapp",
+			Region:       "eu-west-1",
+			Authenticate: authenticate,
+			now:          now,
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "sts.eu-west-1.amazonaws.com", gotHeaders["host"])
+		assert.NotContains(t, gotHeaders, "x-amz-security-token")
+		assert.Contains(t, gotHeaders["authorization"], "/eu-west-1/sts/aws4_request")
+	})
+
+	t.Run("Signs with web identity credentials", func(t *testing.T) {
+		sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "AssumeRoleWithWebIdentity", r.URL.Query().Get("Action"))
+			assert.Equal(t, "arn:aws:iam::123456789012:role/MyRole", r.URL.Query().Get("RoleArn"))
+			assert.Equal(t, "web-identity-token", r.URL.Query().Get("WebIdentityToken"))
+			fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>AKIDWEBIDENTITY</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>web-session-token</SessionToken>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+		}))
+		defer sts.Close()
+
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		assert.NoError(t, os.WriteFile(tokenFile, []byte("web-identity-token\n"), 0600))
+
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+		t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/MyRole")
+
+		authenticator := IAMAuthenticator{
+			Login:        "host/myapp",
+			Authenticate: authenticate,
+			credentials:  awsCredentialsProvider{stsURL: sts.URL},
+			now:          now,
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "web-session-token", gotHeaders["x-amz-security-token"])
+		assert.Contains(t, gotHeaders["authorization"], "Credential=AKIDWEBIDENTITY/")
+	})
+
+	t.Run("Signs with instance profile credentials", func(t *testing.T) {
+		imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+				fmt.Fprint(w, "imds-token")
+			case r.Header.Get("X-aws-ec2-metadata-token") != "imds-token":
+				w.WriteHeader(http.StatusUnauthorized)
+			case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+				fmt.Fprint(w, "MyRole")
+			case r.URL.Path == "/latest/meta-data/iam/security-credentials/MyRole":
+				fmt.Fprint(w, `{"AccessKeyId":"AKIDINSTANCE","SecretAccessKey":"secret","Token":"instance-token"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer imds.Close()
+
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+
+		authenticator := IAMAuthenticator{
+			Login:        "host/myapp",
+			Authenticate: authenticate,
+			credentials:  awsCredentialsProvider{metadataURL: imds.URL},
+			now:          now,
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "instance-token", gotHeaders["x-amz-security-token"])
+		assert.Contains(t, gotHeaders["authorization"], "Credential=AKIDINSTANCE/")
+	})
+
+	t.Run("Returns error when no credentials are available", func(t *testing.T) {
+		imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer imds.Close()
+
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+
+		authenticator := IAMAuthenticator{
+			Login:        "host/myapp",
+			Authenticate: authenticate,
+			credentials:  awsCredentialsProvider{metadataURL: imds.URL},
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.Nil(t, token)
+		assert.ErrorContains(t, err, "HTTP status 404")
+	})
+}
+
+func TestIAMAuthenticator_NeedsTokenRefresh(t *testing.T) {
+	t.Run("Returns false", func(t *testing.T) {
+		authenticator := IAMAuthenticator{}
+
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
@@ -0,0 +1,112 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// identityTokenMargin is how long before its expiry an Identity token is
+// replaced, so that it doesn't expire while being exchanged.
+const identityTokenMargin = time.Minute
+
+// IdentityAuthenticator authenticates to Conjur Cloud as a CyberArk Identity
+// service user. It obtains an OAuth access token from the Identity tenant with
+// the client credentials grant, and exchanges it for a Conjur access token.
+// The Identity token is reused until it nears expiry.
+type IdentityAuthenticator struct {
+	// IdentityURL is the Identity tenant, such as
+	// "https://abc1234.id.cyberark.cloud".
+	IdentityURL  string
+	ClientID     string
+	ClientSecret string
+	Authenticate func(identityToken string) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, identityToken string) ([]byte, error)
+	// HTTPClient fetches the Identity tokens, a client with a 10 second
+	// timeout when nil.
+	HTTPClient *http.Client
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (a *IdentityAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *IdentityAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	identityToken, err := a.identityToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, identityToken)
+	}
+	return a.Authenticate(identityToken)
+}
+
+func (a *IdentityAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func (a *IdentityAuthenticator) identityToken(ctx context.Context) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != "" && time.Now().Add(identityTokenMargin).Before(a.expiresAt) {
+		return a.token, nil
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	data.Set("client_id", a.ClientID)
+	data.Set("client_secret", a.ClientSecret)
+
+	tokenURL := strings.TrimSuffix(a.IdentityURL, "/") + "/oauth2/platformtoken"
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch CyberArk Identity token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unable to fetch CyberArk Identity token: HTTP status %d", resp.StatusCode)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("unable to fetch CyberArk Identity token: response has no access_token")
+	}
+
+	a.token = token.AccessToken
+	a.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return a.token, nil
+}
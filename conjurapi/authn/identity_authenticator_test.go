@@ -0,0 +1,92 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityAuthenticator_RefreshToken(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/oauth2/platformtoken", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "svc@cyberark.cloud.1234", r.PostForm.Get("client_id"))
+		assert.Equal(t, "s3cret", r.PostForm.Get("client_secret"))
+		w.Write([]byte(`{"access_token":"identity-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	var exchanged []string
+	authenticator := &IdentityAuthenticator{
+		IdentityURL:  ts.URL + "/",
+		ClientID:     "svc@cyberark.cloud.1234",
+		ClientSecret: "s3cret",
+		Authenticate: func(identityToken string) ([]byte, error) {
+			exchanged = append(exchanged, identityToken)
+			return []byte("conjur-token"), nil
+		},
+	}
+
+	t.Run("Exchanges an Identity token for a Conjur token", func(t *testing.T) {
+		token, err := authenticator.RefreshToken()
+		require.NoError(t, err)
+		assert.Equal(t, "conjur-token", string(token))
+		assert.Equal(t, []string{"identity-token"}, exchanged)
+	})
+
+	t.Run("Reuses the Identity token until it nears expiry", func(t *testing.T) {
+		_, err := authenticator.RefreshToken()
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+		assert.Len(t, exchanged, 2)
+	})
+}
+
+func TestIdentityAuthenticator_RefreshToken_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{
+			name:     "Rejected credentials",
+			status:   http.StatusUnauthorized,
+			body:     `{"error":"access_denied"}`,
+			expected: "unable to fetch CyberArk Identity token: HTTP status 401",
+		},
+		{
+			name:     "No access token",
+			status:   http.StatusOK,
+			body:     `{"token_type":"Bearer"}`,
+			expected: "unable to fetch CyberArk Identity token: response has no access_token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			authenticator := &IdentityAuthenticator{
+				IdentityURL: ts.URL,
+				Authenticate: func(string) ([]byte, error) {
+					t.Fatal("Authenticate shouldn't be called")
+					return nil, nil
+				},
+			}
+			_, err := authenticator.RefreshToken()
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type JWTAuthenticator struct {
+	JWT          string
+	JWTFilePath  string
+	HostID       string
+	Authenticate func(jwt, hostID string) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, jwt, hostID string) ([]byte, error)
+}
+
+// RefreshToken re-reads the JWT, when it is sourced from a file, and exchanges
+// it for a new Conjur access token.
+func (a *JWTAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *JWTAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	err := a.RefreshJWT()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh JWT: %w", err)
+	}
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, a.JWT, a.HostID)
+	}
+	return a.Authenticate(a.JWT, a.HostID)
+}
+
+func (a *JWTAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+// RefreshJWT reads the JWT from JWTFilePath, if set. Platforms such as
+// Kubernetes rotate projected service account tokens, so the file is read on
+// every refresh rather than once.
+func (a *JWTAuthenticator) RefreshJWT() error {
+	if a.JWTFilePath == "" {
+		if a.JWT == "" {
+			return fmt.Errorf("must specify a JWT or a JWT file path")
+		}
+		return nil
+	}
+
+	jwt, err := os.ReadFile(a.JWTFilePath)
+	if err != nil {
+		return err
+	}
+	a.JWT = strings.TrimSpace(string(jwt))
+	return nil
+}
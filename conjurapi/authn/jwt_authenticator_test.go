@@ -0,0 +1,78 @@
+package authn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTAuthenticator_RefreshToken(t *testing.T) {
+	authenticate := func(jwt, hostID string) ([]byte, error) {
+		if jwt == "valid-jwt" {
+			return []byte(fmt.Sprintf("token-for-%s", hostID)), nil
+		}
+		return nil, fmt.Errorf("401 Invalid")
+	}
+
+	t.Run("Authenticates with JWT string", func(t *testing.T) {
+		authenticator := JWTAuthenticator{
+			JWT:          "valid-jwt",
+			HostID:       "my-host",
+			Authenticate: authenticate,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "token-for-my-host", string(token))
+	})
+
+	t.Run("Reads the JWT from file on every refresh", func(t *testing.T) {
+		jwtFile := filepath.Join(t.TempDir(), "token")
+		err := os.WriteFile(jwtFile, []byte("invalid-jwt"), 0600)
+		assert.NoError(t, err)
+
+		authenticator := JWTAuthenticator{
+			JWTFilePath:  jwtFile,
+			Authenticate: authenticate,
+		}
+
+		_, err = authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "401")
+
+		err = os.WriteFile(jwtFile, []byte("valid-jwt\n"), 0600)
+		assert.NoError(t, err)
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "token-for-", string(token))
+	})
+
+	t.Run("Returns error when JWT file is missing", func(t *testing.T) {
+		authenticator := JWTAuthenticator{
+			JWTFilePath:  "/path/to/non-existent-jwt",
+			Authenticate: authenticate,
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.Nil(t, token)
+		assert.ErrorContains(t, err, "failed to refresh JWT")
+	})
+
+	t.Run("Returns error when no JWT is provided", func(t *testing.T) {
+		authenticator := JWTAuthenticator{Authenticate: authenticate}
+
+		_, err := authenticator.RefreshToken()
+		assert.EqualError(t, err, "failed to refresh JWT: must specify a JWT or a JWT file path")
+	})
+}
+
+func TestJWTAuthenticator_NeedsTokenRefresh(t *testing.T) {
+	t.Run("Returns false", func(t *testing.T) {
+		authenticator := JWTAuthenticator{}
+
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
@@ -0,0 +1,175 @@
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	K8sDefaultClientCertPath = "/etc/conjur/ssl/client.pem"
+	K8sDefaultMaxWaitTime    = 10 * time.Second
+)
+
+// K8sAuthenticator authenticates a Kubernetes pod with authn-k8s. On first use,
+// and whenever the client certificate has expired, it generates a new key and
+// CSR, asks Conjur to inject a signed certificate into the pod, waits for the
+// certificate to appear at ClientCertPath, and then authenticates over mutual
+// TLS using that certificate.
+type K8sAuthenticator struct {
+	// Login is the host ID of the pod's Conjur identity, e.g.
+	// host/conjur/authn-k8s/my-authenticator/apps/my-app.
+	Login          string
+	PodName        string
+	PodNamespace   string
+	ClientCertPath string
+	MaxWaitTime    time.Duration
+
+	InjectClientCert func(csr []byte, hostIDPrefix string) error
+	Authenticate     func(cert tls.Certificate, login string) ([]byte, error)
+	// AuthenticateWithContext, when set, is used instead of Authenticate by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext func(ctx context.Context, cert tls.Certificate, login string) ([]byte, error)
+
+	clientCert *tls.Certificate
+}
+
+func (a *K8sAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *K8sAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	if a.needsClientCert() {
+		if err := a.login(); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, *a.clientCert, a.Login)
+	}
+	return a.Authenticate(*a.clientCert, a.Login)
+}
+
+func (a *K8sAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func (a *K8sAuthenticator) needsClientCert() bool {
+	return a.clientCert == nil ||
+		a.clientCert.Leaf == nil ||
+		time.Now().After(a.clientCert.Leaf.NotAfter)
+}
+
+// login performs the certificate injection flow and stores the resulting
+// client certificate for subsequent authentication requests.
+func (a *K8sAuthenticator) login() error {
+	hostIDPrefix, commonName, err := splitK8sLogin(a.Login)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("unable to generate private key: %w", err)
+	}
+
+	csr, err := a.generateCSR(privateKey, commonName)
+	if err != nil {
+		return err
+	}
+
+	certPath := a.clientCertPath()
+	// Remove any stale certificate so that we only pick up the newly
+	// injected one.
+	if err := os.Remove(certPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := a.InjectClientCert(csr, hostIDPrefix); err != nil {
+		return err
+	}
+
+	var timeout <-chan time.Time
+	if a.MaxWaitTime != -1 {
+		maxWaitTime := a.MaxWaitTime
+		if maxWaitTime == 0 {
+			maxWaitTime = K8sDefaultMaxWaitTime
+		}
+		timeout = time.After(maxWaitTime)
+	}
+
+	certPEM, err := waitForTextFile(certPath, timeout)
+	if err != nil {
+		return err
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to load injected client certificate: %w", err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse injected client certificate: %w", err)
+	}
+
+	a.clientCert = &cert
+	return nil
+}
+
+func (a *K8sAuthenticator) generateCSR(privateKey *rsa.PrivateKey, commonName string) ([]byte, error) {
+	spiffeID, err := url.Parse(fmt.Sprintf(
+		"spiffe://cluster.local/namespace/%s/pod/%s",
+		a.PodNamespace,
+		a.PodName,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+		URIs:    []*url.URL{spiffeID},
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CSR: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+func (a *K8sAuthenticator) clientCertPath() string {
+	if a.ClientCertPath == "" {
+		return K8sDefaultClientCertPath
+	}
+	return a.ClientCertPath
+}
+
+// splitK8sLogin splits a host ID into the dot-separated prefix expected in
+// the Host-Id-Prefix header and the final path segment used as the CSR's
+// common name.
+//
+// Example:
+// splitK8sLogin("host/conjur/authn-k8s/my-id/apps/my-app") => "host.conjur.authn-k8s.my-id.apps", "my-app", nil
+func splitK8sLogin(login string) (prefix, commonName string, err error) {
+	tokens := strings.Split(login, "/")
+	if len(tokens) < 2 || tokens[0] != "host" {
+		return "", "", fmt.Errorf("invalid authn-k8s login '%s': must be of form host/<path>/<identifier>", login)
+	}
+	return strings.Join(tokens[:len(tokens)-1], "."), tokens[len(tokens)-1], nil
+}
@@ -0,0 +1,154 @@
+package authn
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signTestCSR signs a PEM-encoded CSR with a throwaway CA, mimicking what
+// authn-k8s does before injecting the certificate into the pod.
+func signTestCSR(t *testing.T, csrPEM []byte, validFor time.Duration) []byte {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	block, _ := pem.Decode(csrPEM)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		URIs:         csr.URIs,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(validFor),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, csr.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestK8sAuthenticator_RefreshToken(t *testing.T) {
+	login := "host/conjur/authn-k8s/my-id/apps/my-app"
+
+	t.Run("Injects client certificate and authenticates with it", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "client.pem")
+		injectCount := 0
+
+		authenticator := K8sAuthenticator{
+			Login:          login,
+			PodName:        "my-pod",
+			PodNamespace:   "my-namespace",
+			ClientCertPath: certPath,
+			MaxWaitTime:    time.Second,
+			InjectClientCert: func(csr []byte, hostIDPrefix string) error {
+				injectCount++
+				assert.Equal(t, "host.conjur.authn-k8s.my-id.apps", hostIDPrefix)
+				return os.WriteFile(certPath, signTestCSR(t, csr, time.Hour), 0600)
+			},
+			Authenticate: func(cert tls.Certificate, login string) ([]byte, error) {
+				assert.Equal(t, "my-app", cert.Leaf.Subject.CommonName)
+				assert.Equal(t, "spiffe://cluster.local/namespace/my-namespace/pod/my-pod", cert.Leaf.URIs[0].String())
+				return []byte("token-for-" + login), nil
+			},
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "token-for-"+login, string(token))
+
+		// The certificate is reused while it is still valid
+		_, err = authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, injectCount)
+	})
+
+	t.Run("Requests a new certificate when the current one has expired", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "client.pem")
+		injectCount := 0
+
+		authenticator := K8sAuthenticator{
+			Login:          login,
+			ClientCertPath: certPath,
+			MaxWaitTime:    time.Second,
+			InjectClientCert: func(csr []byte, hostIDPrefix string) error {
+				injectCount++
+				return os.WriteFile(certPath, signTestCSR(t, csr, -time.Second), 0600)
+			},
+			Authenticate: func(cert tls.Certificate, login string) ([]byte, error) {
+				return []byte("token"), nil
+			},
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		_, err = authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, injectCount)
+	})
+
+	t.Run("Returns error when injection fails", func(t *testing.T) {
+		authenticator := K8sAuthenticator{
+			Login:          login,
+			ClientCertPath: filepath.Join(t.TempDir(), "client.pem"),
+			InjectClientCert: func(csr []byte, hostIDPrefix string) error {
+				return errors.New("403 Forbidden")
+			},
+		}
+
+		token, err := authenticator.RefreshToken()
+		assert.Nil(t, token)
+		assert.EqualError(t, err, "403 Forbidden")
+	})
+
+	t.Run("Times out when the certificate is never injected", func(t *testing.T) {
+		authenticator := K8sAuthenticator{
+			Login:          login,
+			ClientCertPath: filepath.Join(t.TempDir(), "client.pem"),
+			MaxWaitTime:    10 * time.Millisecond,
+			InjectClientCert: func(csr []byte, hostIDPrefix string) error {
+				return nil
+			},
+		}
+
+		_, err := authenticator.RefreshToken()
+		assert.EqualError(t, err, "operation waitForTextFile timed out")
+	})
+
+	t.Run("Returns error for a login which is not a host", func(t *testing.T) {
+		authenticator := K8sAuthenticator{Login: "alice"}
+
+		_, err := authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "invalid authn-k8s login 'alice'")
+	})
+}
+
+func TestK8sAuthenticator_NeedsTokenRefresh(t *testing.T) {
+	t.Run("Returns false", func(t *testing.T) {
+		authenticator := K8sAuthenticator{}
+
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
@@ -0,0 +1,95 @@
+package authn
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveAuthnLocal listens on a Unix socket like authn-local, passing the
+// claims of each request to respond.
+func serveAuthnLocal(t *testing.T, respond func(claims map[string]interface{}) string) string {
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir may
+	// exceed
+	dir, err := os.MkdirTemp("", "authn-local")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, ".socket")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			claims := map[string]interface{}{}
+			json.Unmarshal(line, &claims)
+			conn.Write([]byte(respond(claims)))
+			conn.Close()
+		}
+	}()
+	return socketPath
+}
+
+func TestLocalAuthenticator_RefreshToken(t *testing.T) {
+	t.Run("Mints a token for the role", func(t *testing.T) {
+		var received map[string]interface{}
+		socketPath := serveAuthnLocal(t, func(claims map[string]interface{}) string {
+			received = claims
+			return `{"protected":"e30=","payload":"e30=","signature":"c2ln"}` + "\n"
+		})
+
+		authenticator := LocalAuthenticator{SocketPath: socketPath, Account: "cucumber", Login: "host/myapp"}
+		token, err := authenticator.RefreshToken()
+		require.NoError(t, err)
+		assert.Equal(t, `{"protected":"e30=","payload":"e30=","signature":"c2ln"}`, string(token))
+		assert.Equal(t, map[string]interface{}{"account": "cucumber", "sub": "host/myapp"}, received)
+	})
+
+	t.Run("Sends the expiration and CIDR", func(t *testing.T) {
+		var received map[string]interface{}
+		socketPath := serveAuthnLocal(t, func(claims map[string]interface{}) string {
+			received = claims
+			return `{"protected":"e30=","payload":"e30=","signature":"c2ln"}`
+		})
+
+		authenticator := LocalAuthenticator{
+			SocketPath: socketPath,
+			Account:    "cucumber",
+			Login:      "admin",
+			Expiration: time.Hour,
+			CIDR:       []string{"10.0.0.0/8"},
+		}
+		_, err := authenticator.RefreshToken()
+		require.NoError(t, err)
+		assert.InDelta(t, float64(time.Now().Add(time.Hour).Unix()), received["exp"], 5)
+		assert.Equal(t, []interface{}{"10.0.0.0/8"}, received["cidr"])
+	})
+
+	t.Run("Returns an error for unknown roles", func(t *testing.T) {
+		// authn-local closes the connection without a token
+		socketPath := serveAuthnLocal(t, func(claims map[string]interface{}) string { return "" })
+
+		authenticator := LocalAuthenticator{SocketPath: socketPath, Account: "cucumber", Login: "missing"}
+		_, err := authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "unable to read authn-local response")
+	})
+
+	t.Run("Returns an error when the socket is missing", func(t *testing.T) {
+		authenticator := LocalAuthenticator{SocketPath: filepath.Join(t.TempDir(), "missing"), Account: "cucumber", Login: "admin"}
+		_, err := authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "unable to connect to authn-local")
+	})
+}
@@ -0,0 +1,11 @@
+package authn
+
+import "github.com/cyberark/conjur-api-go/v2/conjurapi/storage"
+
+// StoreCredentials saves a login and API key for a machine, such as
+// "https://conjur.example.com/authn", in a .netrc file, as the Conjur CLI
+// does. The file is created if needed and is always written atomically with
+// permissions 0600, so it can be used to persist a rotated API key.
+func StoreCredentials(netrcPath, machine, login, apiKey string) error {
+	return storage.NewNetrcStorageProvider(netrcPath, machine).StoreCredentials(login, apiKey)
+}
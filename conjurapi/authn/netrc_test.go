@@ -0,0 +1,29 @@
+package authn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreCredentials(t *testing.T) {
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+
+	err := StoreCredentials(netrcPath, "https://conjur.example.com/authn", "host/app", "old-key")
+	assert.NoError(t, err)
+	err = StoreCredentials(netrcPath, "https://conjur.example.com/authn", "host/app", "rotated-key")
+	assert.NoError(t, err)
+
+	info, err := os.Stat(netrcPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	contents, err := os.ReadFile(netrcPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "machine https://conjur.example.com/authn")
+	assert.Contains(t, string(contents), "login host/app")
+	assert.Contains(t, string(contents), "password rotated-key")
+	assert.NotContains(t, string(contents), "old-key")
+}
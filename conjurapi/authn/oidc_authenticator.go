@@ -1,13 +1,37 @@
 package authn
 
+import "context"
+
 type OidcAuthenticator struct {
 	Code         string
 	Nonce        string
 	CodeVerifier string
-	Authenticate func(code, noce, code_verifier string) ([]byte, error)
+	// IDToken, when set, is exchanged for an access token instead of an
+	// authorization code.
+	IDToken             string
+	Authenticate        func(code, noce, code_verifier string) ([]byte, error)
+	AuthenticateIDToken func(idToken string) ([]byte, error)
+	// AuthenticateWithContext and AuthenticateIDTokenWithContext, when set,
+	// are used instead of Authenticate and AuthenticateIDToken by
+	// RefreshTokenWithContext.
+	AuthenticateWithContext        func(ctx context.Context, code, nonce, code_verifier string) ([]byte, error)
+	AuthenticateIDTokenWithContext func(ctx context.Context, idToken string) ([]byte, error)
 }
 
 func (a *OidcAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *OidcAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	if a.IDToken != "" {
+		if a.AuthenticateIDTokenWithContext != nil {
+			return a.AuthenticateIDTokenWithContext(ctx, a.IDToken)
+		}
+		return a.AuthenticateIDToken(a.IDToken)
+	}
+	if a.AuthenticateWithContext != nil {
+		return a.AuthenticateWithContext(ctx, a.Code, a.Nonce, a.CodeVerifier)
+	}
 	return a.Authenticate(a.Code, a.Nonce, a.CodeVerifier)
 }
 
@@ -20,6 +20,23 @@ func TestOidcAuthenticator_RefreshToken(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []byte("token"), token)
 	})
+
+	t.Run("Calls AuthenticateIDToken when an ID token is set", func(t *testing.T) {
+		authenticator := OidcAuthenticator{
+			IDToken: "id-token",
+			Authenticate: func(code, nonce, code_verifier string) ([]byte, error) {
+				return []byte("code-token"), nil
+			},
+			AuthenticateIDToken: func(idToken string) ([]byte, error) {
+				return []byte("token-for-" + idToken), nil
+			},
+		}
+
+		token, err := authenticator.RefreshToken()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("token-for-id-token"), token)
+	})
 }
 
 func TestOidcAuthenticator_NeedsTokenRefresh(t *testing.T) {
@@ -0,0 +1,107 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Authenticator obtains Conjur access tokens on behalf of a client. Any value
+// implementing it can be used with conjurapi.Client.
+type Authenticator interface {
+	RefreshToken() ([]byte, error)
+	NeedsTokenRefresh() bool
+}
+
+// ContextAuthenticator is implemented by authenticators which can stop
+// obtaining an access token when the request needing it is cancelled, as the
+// built-in ones do. conjurapi.Client uses RefreshToken for those which don't
+// implement it.
+type ContextAuthenticator interface {
+	Authenticator
+	RefreshTokenWithContext(ctx context.Context) ([]byte, error)
+}
+
+// RoleAuthenticator is implemented by authenticators which can obtain access
+// tokens for any role without its credentials, such as LocalAuthenticator.
+// conjurapi.Client.WithRole uses it to impersonate roles.
+type RoleAuthenticator interface {
+	Authenticator
+	// ForRole returns an authenticator for the role with the given login,
+	// such as "alice" or "host/myapp".
+	ForRole(login string) Authenticator
+}
+
+// TokenCacheAuthenticator is implemented by authenticators whose identity
+// can't otherwise be told before authenticating, such as custom ones, to have
+// their access tokens kept in conjurapi's token cache.
+type TokenCacheAuthenticator interface {
+	Authenticator
+	// TokenCacheIdentity returns a value which differs between the roles the
+	// authenticator may log in as, such as the role's ID or the path of its
+	// credentials.
+	TokenCacheIdentity() string
+}
+
+// FactoryConfig holds the connection settings passed to an authenticator
+// factory when a client is created.
+type FactoryConfig struct {
+	ApplianceURL string
+	Account      string
+	ServiceID    string
+	// HTTPClient is configured with the client's TLS settings and should be
+	// used for any requests the authenticator sends to Conjur.
+	HTTPClient *http.Client
+}
+
+// Factory creates an Authenticator for a client using the given settings.
+type Factory func(config FactoryConfig) (Authenticator, error)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{}
+)
+
+// Register makes an authenticator factory available under the given name, so
+// that it can be selected with the AuthnType config setting. Register is meant
+// to be called from an init function and panics if the name is empty, the
+// factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if name == "" {
+		panic("authn: Register name is empty")
+	}
+	if factory == nil {
+		panic("authn: Register factory is nil")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("authn: Register called twice for authenticator %s", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered under the given name.
+func Lookup(name string) (Factory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// Registered returns the sorted names of all registered authenticators.
+func Registered() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package authn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	factory := func(config FactoryConfig) (Authenticator, error) {
+		return &TokenAuthenticator{Token: config.Account}, nil
+	}
+	cleanup := func(name string) {
+		registryMutex.Lock()
+		defer registryMutex.Unlock()
+		delete(registry, name)
+	}
+
+	t.Run("Registers a factory by name", func(t *testing.T) {
+		defer cleanup("test-authn")
+		Register("test-authn", factory)
+
+		registered, ok := Lookup("test-authn")
+		assert.True(t, ok)
+		assert.Contains(t, Registered(), "test-authn")
+
+		authenticator, err := registered(FactoryConfig{Account: "my-account"})
+		assert.NoError(t, err)
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "my-account", string(token))
+	})
+
+	t.Run("Returns false for unknown names", func(t *testing.T) {
+		_, ok := Lookup("unknown-authn")
+		assert.False(t, ok)
+	})
+
+	t.Run("Panics when registering a name twice", func(t *testing.T) {
+		defer cleanup("test-authn")
+		Register("test-authn", factory)
+
+		assert.PanicsWithValue(t, "authn: Register called twice for authenticator test-authn", func() {
+			Register("test-authn", factory)
+		})
+	})
+
+	t.Run("Panics with a nil factory or empty name", func(t *testing.T) {
+		assert.Panics(t, func() { Register("test-authn", nil) })
+		assert.Panics(t, func() { Register("", factory) })
+	})
+}
@@ -1,10 +1,24 @@
 package authn
 
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// TokenAuthenticator uses a pre-fetched access token, given either as the
+// token's JSON or base64-encoded, as in the Authorization header. The token
+// is never renewed, so requests fail once it expires.
 type TokenAuthenticator struct {
 	Token string `env:"CONJUR_AUTHN_TOKEN"`
 }
 
 func (a *TokenAuthenticator) RefreshToken() ([]byte, error) {
+	token := strings.TrimSpace(a.Token)
+	if !strings.HasPrefix(token, "{") {
+		if decoded, err := base64.StdEncoding.DecodeString(token); err == nil {
+			return decoded, nil
+		}
+	}
 	return []byte(a.Token), nil
 }
 
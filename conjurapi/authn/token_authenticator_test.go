@@ -1,6 +1,7 @@
 package authn
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,24 @@ func TestTokenAuthenticator_RefreshToken(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []byte("token"), token)
 	})
+
+	t.Run("Decodes base64-encoded tokens", func(t *testing.T) {
+		raw := `{"protected":"e30=","payload":"e30=","signature":"c2ln"}`
+		authenticator := TokenAuthenticator{
+			Token: base64.StdEncoding.EncodeToString([]byte(raw)) + "\n",
+		}
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, raw, string(token))
+	})
+
+	t.Run("Returns JSON tokens unchanged", func(t *testing.T) {
+		raw := `{"protected":"e30=","payload":"e30=","signature":"c2ln"}`
+		authenticator := TokenAuthenticator{Token: raw}
+		token, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, raw, string(token))
+	})
 }
 
 func TestTokenAuthenticator_NeedsTokenRefresh(t *testing.T) {
@@ -2,16 +2,28 @@ package authn
 
 import (
 	"os"
+	"sync"
 	"time"
 )
 
+// TokenFileAuthenticator reads access tokens from a file kept up to date by
+// another process, such as the authn-k8s sidecar or the Secrets Provider. It
+// never authenticates with Conjur itself.
+//
+// The file is re-read when the current token is due for refresh, or when the
+// file has been modified or replaced since it was last read.
 type TokenFileAuthenticator struct {
-	TokenFile   string `env:"CONJUR_AUTHN_TOKEN_FILE"`
-	mTime       time.Time
+	TokenFile string `env:"CONJUR_AUTHN_TOKEN_FILE"`
+	// MaxWaitTime is how long RefreshToken waits for the file to exist. -1
+	// waits indefinitely.
 	MaxWaitTime time.Duration
+
+	mutex    sync.Mutex
+	fileInfo os.FileInfo
 }
 
-//  TODO: is this implementation concurrent ?
+// RefreshToken returns the contents of the token file, waiting for it to
+// exist for up to MaxWaitTime.
 func (a *TokenFileAuthenticator) RefreshToken() ([]byte, error) {
 	maxWaitTime := a.MaxWaitTime
 	var timeout <-chan time.Time
@@ -23,13 +35,30 @@ func (a *TokenFileAuthenticator) RefreshToken() ([]byte, error) {
 
 	bytes, err := waitForTextFile(a.TokenFile, timeout)
 	if err == nil {
-		fi, _ := os.Stat(a.TokenFile)
-		a.mTime = fi.ModTime()
+		fileInfo, statErr := os.Stat(a.TokenFile)
+		if statErr == nil {
+			a.mutex.Lock()
+			a.fileInfo = fileInfo
+			a.mutex.Unlock()
+		}
 	}
 	return bytes, err
 }
 
+// NeedsTokenRefresh reports whether the token file has been modified or
+// replaced since it was last read. A missing file doesn't need a refresh, so
+// the current token is used until it's due for refresh.
 func (a *TokenFileAuthenticator) NeedsTokenRefresh() bool {
-	fi, _ := os.Stat(a.TokenFile)
-	return a.mTime != fi.ModTime()
+	fileInfo, err := os.Stat(a.TokenFile)
+	if err != nil {
+		return false
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.fileInfo == nil ||
+		!os.SameFile(a.fileInfo, fileInfo) ||
+		!a.fileInfo.ModTime().Equal(fileInfo.ModTime()) ||
+		a.fileInfo.Size() != fileInfo.Size()
 }
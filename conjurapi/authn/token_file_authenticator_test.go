@@ -20,7 +20,7 @@ func ensureWriteFile(filepath, filecontents string) {
 	}
 
 	// Register the previous ModTime, otherwise there is no previous file so fall back to a second before this is
-	if err != nil {
+	if err == nil {
 		prevModTime = info.ModTime()
 	} else {
 		prevModTime = time.Now().Add(-time.Second)
@@ -37,7 +37,7 @@ func ensureWriteFile(filepath, filecontents string) {
 		select {
 		// Timeout after 10 seconds. Clearly there's something wrong with i/o
 		case <-timeout:
-			err := fmt.Errorf("ensureWriteFile timed out.")
+			err := fmt.Errorf("ensureWriteFile timed out")
 
 			panic(err)
 		// Return only if the current ModTime is greater than the previous ModTime
@@ -104,7 +104,7 @@ func TestTokenFileAuthenticator_RefreshToken(t *testing.T) {
 
 		assert.Nil(t, token)
 		assert.Error(t, err)
-		assert.Equal(t, "Operation waitForTextFile timed out.", err.Error())
+		assert.Equal(t, "operation waitForTextFile timed out", err.Error())
 	})
 
 	t.Run("Doesn't time out if MaxWaitTime is -1", func(t *testing.T) {
@@ -156,3 +156,59 @@ func TestTokenFileAuthenticator_NeedsTokenRefresh(t *testing.T) {
 		assert.True(t, authenticator.NeedsTokenRefresh())
 	})
 }
+
+func TestTokenFileAuthenticator_NeedsTokenRefreshEdgeCases(t *testing.T) {
+	t.Run("Doesn't need refresh while the file is missing", func(t *testing.T) {
+		authenticator := TokenFileAuthenticator{TokenFile: path.Join(t.TempDir(), "token")}
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+
+	t.Run("Needs refresh before the first read", func(t *testing.T) {
+		tokenFile := path.Join(t.TempDir(), "token")
+		ensureWriteFile(tokenFile, "token")
+
+		authenticator := TokenFileAuthenticator{TokenFile: tokenFile}
+		assert.True(t, authenticator.NeedsTokenRefresh())
+	})
+
+	t.Run("Needs refresh when the file is replaced", func(t *testing.T) {
+		dir := t.TempDir()
+		tokenFile := path.Join(dir, "token")
+		ensureWriteFile(tokenFile, "token")
+
+		authenticator := TokenFileAuthenticator{TokenFile: tokenFile, MaxWaitTime: time.Second}
+		_, err := authenticator.RefreshToken()
+		assert.NoError(t, err)
+
+		// Atomically replace the file with one with the same size and mtime
+		info, err := os.Stat(tokenFile)
+		assert.NoError(t, err)
+		replacement := path.Join(dir, "token.new")
+		assert.NoError(t, os.WriteFile(replacement, []byte("other"), 0600))
+		assert.NoError(t, os.Chtimes(replacement, info.ModTime(), info.ModTime()))
+		assert.NoError(t, os.Rename(replacement, tokenFile))
+
+		assert.True(t, authenticator.NeedsTokenRefresh())
+	})
+
+	t.Run("Is safe for concurrent use", func(t *testing.T) {
+		tokenFile := path.Join(t.TempDir(), "token")
+		ensureWriteFile(tokenFile, "token")
+		authenticator := TokenFileAuthenticator{TokenFile: tokenFile, MaxWaitTime: time.Second}
+
+		done := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			go func() {
+				defer func() { done <- struct{}{} }()
+				for j := 0; j < 20; j++ {
+					authenticator.NeedsTokenRefresh()
+					authenticator.RefreshToken()
+				}
+			}()
+		}
+		for i := 0; i < 4; i++ {
+			<-done
+		}
+		assert.False(t, authenticator.NeedsTokenRefresh())
+	})
+}
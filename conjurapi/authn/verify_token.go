@@ -0,0 +1,154 @@
+package authn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/internal/fips"
+)
+
+// slosiloSaltSize is the size of the random salt appended to Slosilo
+// signatures.
+const slosiloSaltSize = 32
+
+var (
+	// ErrInvalidTokenSignature is returned by VerifyToken when a token
+	// wasn't signed by any of the given keys.
+	ErrInvalidTokenSignature = errors.New("access token signature is invalid")
+	// ErrTokenExpired is returned by VerifyToken when a token has expired.
+	ErrTokenExpired = errors.New("access token has expired")
+	// ErrTokenVerificationNotApproved is returned by VerifyToken in FIPS
+	// mode, since Slosilo signs with PKCS #1 v1.5 signatures without a digest
+	// algorithm identifier, which FIPS 186 doesn't approve.
+	ErrTokenVerificationNotApproved = errors.New("access token signatures can't be verified in FIPS mode")
+)
+
+// VerifyToken checks that an access token was signed by one of Conjur's
+// token-signing keys and hasn't expired, so that services receiving Conjur
+// access tokens can authenticate their callers without contacting Conjur. It
+// returns the parsed token.
+//
+// Conjur signs tokens with the Slosilo keys of its accounts, which aren't
+// served by its API; parse them with ParseTokenSigningKeys.
+//
+// In FIPS mode VerifyToken returns ErrTokenVerificationNotApproved; services
+// bound by FIPS should ask Conjur to authenticate their callers instead.
+func VerifyToken(data []byte, keys []*rsa.PublicKey) (*AuthnToken, error) {
+	return verifyToken(data, keys, time.Now())
+}
+
+func verifyToken(data []byte, keys []*rsa.PublicKey, now time.Time) (*AuthnToken, error) {
+	if fips.Enabled() {
+		return nil, ErrTokenVerificationNotApproved
+	}
+
+	token, err := NewToken(data)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := decodeTokenSegment(token.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("access token field 'signature' is not valid base64")
+	}
+	if len(signature) <= slosiloSaltSize {
+		return nil, ErrInvalidTokenSignature
+	}
+
+	// Slosilo signs the SHA-256 digest of the salt followed by the value, and
+	// appends the salt to the signature
+	encrypted, salt := signature[:len(signature)-slosiloSaltSize], signature[len(signature)-slosiloSaltSize:]
+	digest := sha256.Sum256(append(append([]byte{}, salt...), token.Protected+"."+token.Payload...))
+
+	verified := false
+	for _, key := range keys {
+		if rsa.VerifyPKCS1v15(key, crypto.Hash(0), digest[:], encrypted) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidTokenSignature
+	}
+
+	if !now.Before(token.ExpiresAt()) {
+		return nil, ErrTokenExpired
+	}
+	return token, nil
+}
+
+// decodeTokenSegment decodes a field of an access token, which may be
+// base64url or standard base64, with or without padding.
+func decodeTokenSegment(segment string) ([]byte, error) {
+	segment = strings.TrimRight(segment, "=")
+	if decoded, err := base64.RawURLEncoding.DecodeString(segment); err == nil {
+		return decoded, nil
+	}
+	return base64.RawStdEncoding.DecodeString(segment)
+}
+
+// ParseTokenSigningKeys parses the PEM-encoded RSA keys used by Conjur to sign
+// access tokens, for use with VerifyToken. Public keys may be given in PKIX or
+// PKCS #1 form. Private keys, such as those exported from Conjur's Slosilo key
+// store, are reduced to their public keys.
+func ParseTokenSigningKeys(pemData []byte) ([]*rsa.PublicKey, error) {
+	keys := []*rsa.PublicKey{}
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+
+		key, err := parseRSAKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse token-signing key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		if len(bytes.TrimSpace(pemData)) > 0 {
+			return nil, fmt.Errorf("unable to parse token-signing key: not PEM-encoded")
+		}
+		return nil, fmt.Errorf("no token-signing keys found")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(block *pem.Block) (*rsa.PublicKey, error) {
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type '%s'", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return &k.PublicKey, nil
+	}
+	return nil, fmt.Errorf("not an RSA key")
+}
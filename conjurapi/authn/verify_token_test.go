@@ -0,0 +1,133 @@
+package authn
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/internal/fips"
+)
+
+// signSlosiloToken creates an access token signed the way Conjur signs them.
+func signSlosiloToken(t *testing.T, key *rsa.PrivateKey, payload string) []byte {
+	protected := base64.URLEncoding.EncodeToString([]byte(`{"alg":"conjur.org/slosilo/v2","kid":"test"}`))
+	encodedPayload := base64.StdEncoding.EncodeToString([]byte(payload))
+
+	salt := make([]byte, slosiloSaltSize)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	digest := sha256.Sum256(append(salt, protected+"."+encodedPayload...))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.Hash(0), digest[:])
+	require.NoError(t, err)
+
+	token, err := json.Marshal(map[string]string{
+		"protected": protected,
+		"payload":   encodedPayload,
+		"signature": base64.URLEncoding.EncodeToString(append(signature, salt...)),
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifyToken(t *testing.T) {
+	if fips.Enabled() {
+		t.Skip("Slosilo signatures aren't FIPS-approved")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Now()
+	payload := fmt.Sprintf(`{"sub":"host/myapp","iat":%d,"exp":%d}`, now.Unix(), now.Add(8*time.Minute).Unix())
+	token := signSlosiloToken(t, key, payload)
+
+	t.Run("Accepts tokens signed by any of the keys", func(t *testing.T) {
+		verified, err := verifyToken(token, []*rsa.PublicKey{&otherKey.PublicKey, &key.PublicKey}, now)
+		require.NoError(t, err)
+		assert.Equal(t, token, verified.Raw())
+	})
+
+	t.Run("Rejects tokens signed by other keys", func(t *testing.T) {
+		_, err := verifyToken(token, []*rsa.PublicKey{&otherKey.PublicKey}, now)
+		assert.ErrorIs(t, err, ErrInvalidTokenSignature)
+	})
+
+	t.Run("Rejects tampered tokens", func(t *testing.T) {
+		tampered := map[string]string{}
+		require.NoError(t, json.Unmarshal(token, &tampered))
+		tampered["payload"] = base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"admin","iat":%d}`, now.Unix())))
+		data, err := json.Marshal(tampered)
+		require.NoError(t, err)
+
+		_, err = verifyToken(data, []*rsa.PublicKey{&key.PublicKey}, now)
+		assert.ErrorIs(t, err, ErrInvalidTokenSignature)
+	})
+
+	t.Run("Rejects expired tokens", func(t *testing.T) {
+		_, err := verifyToken(token, []*rsa.PublicKey{&key.PublicKey}, now.Add(8*time.Minute))
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("Rejects malformed tokens", func(t *testing.T) {
+		_, err := VerifyToken([]byte(`{"protected":"e30","payload":"e30","signature":"!"}`), []*rsa.PublicKey{&key.PublicKey})
+		assert.Error(t, err)
+
+		_, err = VerifyToken([]byte("not a token"), []*rsa.PublicKey{&key.PublicKey})
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyToken_FIPS(t *testing.T) {
+	if !fips.Enabled() {
+		t.Skip("FIPS mode is not enabled")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = VerifyToken([]byte(`{"protected":"e30","payload":"e30","signature":"e30"}`), []*rsa.PublicKey{&key.PublicKey})
+	assert.ErrorIs(t, err, ErrTokenVerificationNotApproved)
+}
+
+func TestParseTokenSigningKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	t.Run("Parses public and private keys", func(t *testing.T) {
+		pemData := append(
+			pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...,
+		)
+
+		keys, err := ParseTokenSigningKeys(pemData)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.True(t, key.PublicKey.Equal(keys[0]))
+		assert.True(t, key.PublicKey.Equal(keys[1]))
+	})
+
+	t.Run("Rejects other data", func(t *testing.T) {
+		_, err := ParseTokenSigningKeys([]byte("not a key"))
+		assert.EqualError(t, err, "unable to parse token-signing key: not PEM-encoded")
+
+		_, err = ParseTokenSigningKeys(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("x")}))
+		assert.EqualError(t, err, "unable to parse token-signing key: unsupported PEM block type 'CERTIFICATE'")
+
+		_, err = ParseTokenSigningKeys(nil)
+		assert.EqualError(t, err, "no token-signing keys found")
+	})
+}
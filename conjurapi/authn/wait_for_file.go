@@ -16,10 +16,10 @@ waiting_loop:
 	for {
 		select {
 		case <-timeout:
-			err = fmt.Errorf("Operation waitForTextFile timed out.")
+			err = fmt.Errorf("operation waitForTextFile timed out")
 			break waiting_loop
 		default:
-			if _, err := os.Stat(fileName); os.IsNotExist(err) {
+			if _, statErr := os.Stat(fileName); os.IsNotExist(statErr) {
 				time.Sleep(100 * time.Millisecond)
 			} else {
 				fileBytes, err = os.ReadFile(fileName)
@@ -12,7 +12,7 @@ func Test_waitForTextFile(t *testing.T) {
 	t.Run("Times out for non-existent filename", func(t *testing.T) {
 		bytes, err := waitForTextFile("path/to/non-existent/file", time.After(0))
 		assert.Error(t, err)
-		assert.Equal(t, err.Error(), "Operation waitForTextFile timed out.")
+		assert.Equal(t, err.Error(), "operation waitForTextFile timed out")
 		assert.Nil(t, bytes)
 	})
 
@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -8,10 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var sample_token = `{"protected":"eyJhbGciOiJjb25qdXIub3JnL3Nsb3NpbG8vdjIiLCJraWQiOiI5M2VjNTEwODRmZTM3Zjc3M2I1ODhlNTYyYWVjZGMxMSJ9","payload":"eyJzdWIiOiJhZG1pbiIsImlhdCI6MTUxMDc1MzI1OSwiZXhwIjo0MTAzMzc5MTY0fQo=","signature":"raCufKOf7sKzciZInQTphu1mBbLhAdIJM72ChLB4m5wKWxFnNz_7LawQ9iYEI_we1-tdZtTXoopn_T1qoTplR9_Bo3KkpI5Hj3DB7SmBpR3CSRTnnEwkJ0_aJ8bql5Cbst4i4rSftyEmUqX-FDOqJdAztdi9BUJyLfbeKTW9OGg-QJQzPX1ucB7IpvTFCEjMoO8KUxZpbHj-KpwqAMZRooG4ULBkxp5nSfs-LN27JupU58oRgIfaWASaDmA98O2x6o88MFpxK_M0FeFGuDKewNGrRc8lCOtTQ9cULA080M5CSnruCqu1Qd52r72KIOAfyzNIiBCLTkblz2fZyEkdSKQmZ8J3AakxQE2jyHmMT-eXjfsEIzEt-IRPJIirI3Qm"}`
@@ -112,6 +117,46 @@ func TestClient_RotateCurrentUserAPIKey(t *testing.T) {
 		_, err = conjur.Authenticate(authn.LoginPair{Login: "alice", APIKey: string(newAPIKey)})
 		assert.NoError(t, err)
 	})
+
+	t.Run("Stores and uses the new API key", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "alice", username)
+			assert.Equal(t, "old-api-key", password)
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "/authn/cucumber/api_key", r.URL.Path)
+			w.Write([]byte("new-api-key"))
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+		conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "old-api-key"})
+		assert.NoError(t, err)
+		storage := &mockStorageProvider{username: "alice", password: "old-api-key"}
+		conjur.storage = storage
+
+		newAPIKey, err := conjur.RotateCurrentUserAPIKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "new-api-key", string(newAPIKey))
+		assert.Equal(t, "new-api-key", storage.password)
+		assert.Equal(t, "new-api-key", conjur.authenticator.(*authn.APIKeyAuthenticator).APIKey)
+	})
+
+	t.Run("Fails without credential storage", func(t *testing.T) {
+		conjur := &Client{}
+		_, err := conjur.RotateCurrentUserAPIKey()
+		assert.EqualError(t, err, "rotating the current user's API key requires credential storage")
+	})
+}
+
+func TestClient_RotateAPIKeyRequest(t *testing.T) {
+	conjur := &Client{config: Config{Account: "cucumber", ApplianceURL: "https://conjur"}}
+
+	req, err := conjur.RotateAPIKeyRequest("host:apps/my host&co")
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", req.Method)
+	assert.Equal(t, "cucumber:host:apps/my host&co", req.URL.Query().Get("role"))
 }
 
 type rotateHostAPIKeyTestCase struct {
@@ -157,7 +202,7 @@ func TestClient_RotateHostAPIKey(t *testing.T) {
 			assertions: func(t *testing.T, tc rotateHostAPIKeyTestCase, conjur *Client) {
 				_, err := conjur.RotateUserAPIKey(tc.hostID)
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "Malformed ID")
+				assert.Contains(t, err.Error(), "malformed ID")
 			},
 		},
 	}
@@ -231,7 +276,7 @@ func TestClient_RotateUserAPIKey(t *testing.T) {
 			assertions: func(t *testing.T, tc rotateUserAPIKeyTestCase, conjur *Client) {
 				_, err := conjur.RotateUserAPIKey(tc.userID)
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "Malformed ID")
+				assert.Contains(t, err.Error(), "malformed ID")
 			},
 		},
 	}
@@ -274,6 +319,43 @@ func TestClient_Whoami(t *testing.T) {
 	})
 }
 
+func TestClient_WhoAmIDetails(t *testing.T) {
+	t.Run("Parses the whoami response", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/whoami", r.URL.Path)
+			w.Write([]byte(`{"client_ip":"10.0.0.1","user_agent":"Go-http-client/1.1","account":"cucumber","username":"host/apps/app","token_issued_at":"2023-08-01T12:30:00.000+00:00"}`))
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		whoami, err := conjur.WhoAmIDetails()
+		assert.NoError(t, err)
+		assert.Equal(t, "cucumber", whoami.Account)
+		assert.Equal(t, "host/apps/app", whoami.Username)
+		assert.Equal(t, "10.0.0.1", whoami.ClientIP)
+		assert.Equal(t, "Go-http-client/1.1", whoami.UserAgent)
+		assert.True(t, time.Date(2023, 8, 1, 12, 30, 0, 0, time.UTC).Equal(whoami.TokenIssuedAt))
+	})
+
+	t.Run("Returns Conjur errors", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		_, err = conjur.WhoAmIDetails()
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+}
+
 func TestClient_ListOidcProviders(t *testing.T) {
 	t.Run("List OIDC Providers", func(t *testing.T) {
 		ts, client := setupTestClient(t)
@@ -292,9 +374,9 @@ func TestClient_Login(t *testing.T) {
 		ts, client := setupTestClient(t)
 		defer ts.Close()
 
-		token, err := client.Login("alice", "password")
+		apiKey, err := client.Login("alice", "password")
 		assert.NoError(t, err)
-		assert.Equal(t, "test-api-key", string(token))
+		assert.Equal(t, "test-api-key", apiKey)
 
 		// Check that api key was cached to the correct location
 		contents, err := os.ReadFile(client.GetConfig().NetRCPath)
@@ -303,11 +385,54 @@ func TestClient_Login(t *testing.T) {
 		assert.Contains(t, string(contents), "test-api-key")
 
 		// Check that we can authenticate with the cached api key
-		token, err = client.Authenticate(authn.LoginPair{Login: "alice", APIKey: string(token)})
+		token, err := client.Authenticate(authn.LoginPair{Login: "alice", APIKey: apiKey})
 		assert.NoError(t, err)
 		assert.Equal(t, "test-token", string(token))
 	})
 
+	t.Run("Authenticates with the API key of a client without an authenticator", func(t *testing.T) {
+		ts, client := setupTestClient(t)
+		defer ts.Close()
+
+		_, err := client.Login("alice", "password")
+		assert.NoError(t, err)
+
+		token, err := client.InternalAuthenticate()
+		assert.NoError(t, err)
+		assert.Equal(t, "test-token", string(token))
+	})
+
+	t.Run("Sets the authenticator while the client is in use", func(t *testing.T) {
+		ts, client := setupTestClient(t)
+		defer ts.Close()
+
+		req, err := client.RetrieveSecretRequest("db/password")
+		assert.NoError(t, err)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for client.GetAuthenticator() == nil {
+				client.canReplayUnauthorized(req)
+			}
+		}()
+
+		_, err = client.Login("alice", "password")
+		assert.NoError(t, err)
+		<-done
+	})
+
+	t.Run("Keeps the authenticator of a client", func(t *testing.T) {
+		ts, client := setupTestClient(t)
+		defer ts.Close()
+
+		authenticator := &authn.TokenAuthenticator{Token: "existing-token"}
+		client.authenticator = authenticator
+
+		_, err := client.Login("alice", "password")
+		assert.NoError(t, err)
+		assert.Same(t, authenticator, client.authenticator)
+	})
+
 	t.Run("OIDC authentication", func(t *testing.T) {
 		ts, client := setupTestClient(t)
 		defer ts.Close()
@@ -329,6 +454,45 @@ func TestClient_Login(t *testing.T) {
 		assert.Contains(t, string(contents), client.GetConfig().ApplianceURL+"/authn-oidc/test-service-id")
 		assert.Contains(t, string(contents), "test-token-oidc")
 	})
+
+	t.Run("LDAP authentication", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, _ := r.BasicAuth()
+			body, _ := io.ReadAll(r.Body)
+			if r.URL.Path == "/authn-ldap/test-ldap/cucumber/login" && username == "alice" && password == "ldap-password" {
+				w.Write([]byte("test-api-key-ldap"))
+			} else if r.URL.Path == "/authn-ldap/test-ldap/cucumber/alice/authenticate" && string(body) == "test-api-key-ldap" {
+				w.Write([]byte("test-token-ldap"))
+			} else {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{
+			Account:           "cucumber",
+			ApplianceURL:      mockConjurServer.URL,
+			NetRCPath:         filepath.Join(t.TempDir(), ".netrc"),
+			CredentialStorage: "file",
+			AuthnType:         "ldap",
+			ServiceID:         "test-ldap",
+		}
+		client, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "test-api-key-ldap"})
+		assert.NoError(t, err)
+
+		apiKey, err := client.Login("alice", "ldap-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "test-api-key-ldap", apiKey)
+
+		// Check that the api key was cached under the LDAP authenticator
+		contents, err := os.ReadFile(config.NetRCPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), mockConjurServer.URL+"/authn-ldap/test-ldap")
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "test-token-ldap", string(token))
+	})
 }
 
 func TestClient_AuthenticateReader(t *testing.T) {
@@ -453,7 +617,7 @@ machine https://conjur/authn
 			CredentialStorage: "invalid",
 		}
 		err := PurgeCredentials(config)
-		assert.EqualError(t, err, "Unknown credential storage type")
+		assert.EqualError(t, err, "unknown credential storage type")
 	})
 }
 
@@ -502,12 +666,12 @@ func TestClient_InternalAuthenticate(t *testing.T) {
 
 	t.Run("Returns re-login message when using OIDC and token is expired", func(t *testing.T) {
 		_, err := runOIDCInternalAuthenticateTest(t, expired_token, nil)
-		assert.EqualError(t, err, "No valid OIDC token found. Please login again.")
+		assert.EqualError(t, err, "no valid OIDC token found, please login again")
 	})
 
 	t.Run("Returns error if storage returns error", func(t *testing.T) {
 		_, err := runOIDCInternalAuthenticateTest(t, "", errors.New("error"))
-		assert.EqualError(t, err, "No valid OIDC token found. Please login again.")
+		assert.EqualError(t, err, "no valid OIDC token found, please login again")
 	})
 }
 
@@ -573,6 +737,187 @@ func TestClient_RefreshToken(t *testing.T) {
 	})
 }
 
+// slowAuthenticator counts its calls and takes a while to return its token.
+type slowAuthenticator struct {
+	calls int32
+	token string
+	err   error
+}
+
+func (a *slowAuthenticator) RefreshToken() ([]byte, error) {
+	atomic.AddInt32(&a.calls, 1)
+	time.Sleep(50 * time.Millisecond)
+	return []byte(a.token), a.err
+}
+
+func (a *slowAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestClient_RefreshToken_Concurrent(t *testing.T) {
+	config := Config{
+		Account:      "cucumber",
+		ApplianceURL: "https://conjur",
+	}
+
+	refreshConcurrently := func(refresh func() error) []error {
+		errs := make([]error, 20)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = refresh()
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("Authenticates once for concurrent callers", func(t *testing.T) {
+		authenticator := &slowAuthenticator{token: sample_token}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		for _, err := range refreshConcurrently(client.RefreshToken) {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&authenticator.calls))
+		assert.Equal(t, sample_token, string(client.getAuthToken().Raw()))
+	})
+
+	t.Run("Shares the error with concurrent callers", func(t *testing.T) {
+		authenticator := &slowAuthenticator{err: errors.New("authentication failed")}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		for _, err := range refreshConcurrently(client.RefreshToken) {
+			assert.EqualError(t, err, "authentication failed")
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&authenticator.calls))
+
+		// Later calls try again
+		assert.Error(t, client.RefreshToken())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+	})
+
+	t.Run("Joins a refresh in progress when forced", func(t *testing.T) {
+		authenticator := &slowAuthenticator{token: sample_token}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		for _, err := range refreshConcurrently(client.ForceRefreshToken) {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&authenticator.calls))
+	})
+}
+
+// contextAuthenticator blocks its first refresh until the context is done.
+type contextAuthenticator struct {
+	calls   int32
+	started chan struct{}
+}
+
+func (a *contextAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenWithContext(context.Background())
+}
+
+func (a *contextAuthenticator) RefreshTokenWithContext(ctx context.Context) ([]byte, error) {
+	if atomic.AddInt32(&a.calls, 1) == 1 {
+		close(a.started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return []byte(sample_token), nil
+}
+
+func (a *contextAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestClient_RefreshTokenWithContext(t *testing.T) {
+	config := Config{
+		Account:      "cucumber",
+		ApplianceURL: "https://conjur",
+	}
+
+	t.Run("Aborts a blocking authn request when the context is cancelled", func(t *testing.T) {
+		authenticating := make(chan struct{}, 1)
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/authn/cucumber/alice/authenticate", r.URL.Path)
+			// The server only notices the client going away once the body is
+			// read
+			io.ReadAll(r.Body)
+			authenticating <- struct{}{}
+			<-r.Context().Done()
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+		conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "api-key"})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-authenticating
+			cancel()
+		}()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := conjur.RetrieveSecretWithContext(ctx, "db/password")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the authn request wasn't aborted")
+		}
+	})
+
+	t.Run("Stops waiting for a refresh in progress when the context is done", func(t *testing.T) {
+		authenticator := &contextAuthenticator{started: make(chan struct{})}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		require.NoError(t, err)
+
+		refreshCtx, cancelRefresh := context.WithCancel(context.Background())
+		defer cancelRefresh()
+		go client.RefreshTokenWithContext(refreshCtx)
+		<-authenticator.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, client.RefreshTokenWithContext(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("Refreshes again when a refresh in progress is cancelled", func(t *testing.T) {
+		authenticator := &contextAuthenticator{started: make(chan struct{})}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		require.NoError(t, err)
+
+		refreshCtx, cancelRefresh := context.WithCancel(context.Background())
+		refreshed := make(chan error, 1)
+		go func() {
+			refreshed <- client.RefreshTokenWithContext(refreshCtx)
+		}()
+		<-authenticator.started
+
+		waited := make(chan error, 1)
+		go func() {
+			waited <- client.RefreshToken()
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancelRefresh()
+
+		assert.ErrorIs(t, <-refreshed, context.Canceled)
+		assert.NoError(t, <-waited)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+		assert.Equal(t, sample_token, string(client.getAuthToken().Raw()))
+	})
+}
+
 func TestClient_ForceRefreshToken(t *testing.T) {
 	config := Config{
 		Account:      "cucumber",
@@ -698,10 +1043,10 @@ func runChangeUserPasswordAssertions(t *testing.T, tc changeUserPasswordTestCase
 	_, err = conjur.ChangeUserPassword(tc.login, string(userAPIKey), tc.newPassword)
 	assert.NoError(t, err)
 
-	userAPIKey, err = conjur.Login(tc.login, tc.newPassword)
+	apiKey, err := conjur.Login(tc.login, tc.newPassword)
 	assert.NoError(t, err)
 
-	_, err = conjur.Authenticate(authn.LoginPair{Login: tc.login, APIKey: string(userAPIKey)})
+	_, err = conjur.Authenticate(authn.LoginPair{Login: tc.login, APIKey: apiKey})
 	assert.NoError(t, err)
 }
 
@@ -750,13 +1095,47 @@ func runChangeCurrentUserPasswordAssertions(t *testing.T, tc changeCurrentUserPa
 	_, err = conjur.ChangeCurrentUserPassword(tc.newPassword)
 	assert.NoError(t, err)
 
-	userAPIKey, err = conjur.Login("alice", tc.newPassword)
+	apiKey, err := conjur.Login("alice", tc.newPassword)
 	assert.NoError(t, err)
 
-	_, err = conjur.Authenticate(authn.LoginPair{Login: "alice", APIKey: string(userAPIKey)})
+	_, err = conjur.Authenticate(authn.LoginPair{Login: "alice", APIKey: apiKey})
 	assert.NoError(t, err)
 }
 
+func TestClient_ChangeCurrentUserPasswordWithStoredCredentials(t *testing.T) {
+	t.Run("Authenticates with the stored credentials", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "alice", username)
+			assert.Equal(t, "alice-api-key", password)
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "/authn/cucumber/password", r.URL.Path)
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "SUp3r$3cr3t!!", string(body))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer mockConjurServer.Close()
+
+		storage := &mockStorageProvider{username: "alice", password: "alice-api-key"}
+		conjur := &Client{
+			config:     Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL},
+			httpClient: mockConjurServer.Client(),
+			storage:    storage,
+		}
+
+		_, err := conjur.ChangeCurrentUserPassword("SUp3r$3cr3t!!")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice-api-key", storage.password)
+	})
+
+	t.Run("Fails without credential storage", func(t *testing.T) {
+		conjur := &Client{}
+		_, err := conjur.ChangeCurrentUserPassword("SUp3r$3cr3t!!")
+		assert.EqualError(t, err, "changing the current user's password requires credential storage")
+	})
+}
+
 var publicKeysTestPolicy = `
 - !user
   id: alice
@@ -0,0 +1,177 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBulkConcurrency is the number of concurrent requests made by bulk
+// secret operations when BulkOptions.Concurrency isn't set.
+const DefaultBulkConcurrency = 8
+
+// BulkOptions controls bulk secret operations such as AddSecrets and
+// MirrorSecrets.
+type BulkOptions struct {
+	// Concurrency is the maximum number of variables processed at once.
+	Concurrency int
+	// FailFast stops processing new variables after the first failure. The
+	// variables which weren't processed are listed in BulkError.Skipped.
+	FailFast bool
+}
+
+// BulkError is returned by bulk secret operations when some of the variables
+// couldn't be processed.
+type BulkError struct {
+	// Errors holds the error for each variable which failed.
+	Errors map[string]error
+	// Skipped lists the variables which weren't processed because of
+	// FailFast or because the context was done, sorted.
+	Skipped []string
+}
+
+func (e *BulkError) Error() string {
+	variableIDs := make([]string, 0, len(e.Errors))
+	for variableID := range e.Errors {
+		variableIDs = append(variableIDs, variableID)
+	}
+	sort.Strings(variableIDs)
+
+	messages := make([]string, 0, len(variableIDs))
+	for _, variableID := range variableIDs {
+		messages = append(messages, fmt.Sprintf("%s: %s", variableID, e.Errors[variableID]))
+	}
+
+	message := fmt.Sprintf("failed to process %d variable(s): %s", len(variableIDs), strings.Join(messages, "; "))
+	if len(e.Skipped) > 0 {
+		message += fmt.Sprintf(" (%d skipped)", len(e.Skipped))
+	}
+	return message
+}
+
+// Unwrap returns the errors of the failed variables, so that errors.Is and
+// errors.As match any of them.
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// AddSecrets adds values to many variables, given as a map of variable ID to
+// value, using concurrent requests. It returns a *BulkError if any of the
+// values couldn't be added.
+//
+// The authenticated user must have update privilege on all variables.
+func (c *Client) AddSecrets(secrets map[string]string, options BulkOptions) error {
+	return c.AddSecretsWithContext(context.Background(), secrets, options)
+}
+
+// AddSecretsWithContext is like AddSecrets but uses the provided context for
+// the underlying requests.
+func (c *Client) AddSecretsWithContext(ctx context.Context, secrets map[string]string, options BulkOptions) error {
+	variableIDs := make([]string, 0, len(secrets))
+	for variableID := range secrets {
+		variableIDs = append(variableIDs, variableID)
+	}
+
+	return runBulk(ctx, variableIDs, options, func(ctx context.Context, variableID string) error {
+		return c.AddSecretWithContext(ctx, variableID, secrets[variableID])
+	})
+}
+
+// MirrorSecrets copies the values of variables from one client to another,
+// such as between Conjur accounts or instances, using concurrent requests.
+// Each variable ID is resolved against the account of each client, and the
+// variables must already exist in the target. It returns a *BulkError if any
+// of the values couldn't be copied.
+//
+// The source client must have execute privilege, and the target client update
+// privilege, on all variables.
+func MirrorSecrets(source, target *Client, variableIDs []string, options BulkOptions) error {
+	return MirrorSecretsWithContext(context.Background(), source, target, variableIDs, options)
+}
+
+// MirrorSecretsWithContext is like MirrorSecrets but uses the provided context
+// for the underlying requests.
+func MirrorSecretsWithContext(ctx context.Context, source, target *Client, variableIDs []string, options BulkOptions) error {
+	return runBulk(ctx, variableIDs, options, func(ctx context.Context, variableID string) error {
+		value, err := source.RetrieveSecretWithContext(ctx, variableID)
+		if err != nil {
+			return fmt.Errorf("failed to read from source: %w", err)
+		}
+
+		if err := target.AddSecretWithContext(ctx, variableID, string(value)); err != nil {
+			return fmt.Errorf("failed to write to target: %w", err)
+		}
+		return nil
+	})
+}
+
+// runBulk calls fn for each variable ID from a bounded pool of goroutines and
+// collects the failures into a *BulkError.
+func runBulk(ctx context.Context, variableIDs []string, options BulkOptions, fn func(ctx context.Context, variableID string) error) error {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	if concurrency > len(variableIDs) {
+		concurrency = len(variableIDs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mutex   sync.Mutex
+		bulkErr = &BulkError{Errors: map[string]error{}}
+		wg      sync.WaitGroup
+		ids     = make(chan string)
+	)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for variableID := range ids {
+				err := fn(ctx, variableID)
+				if err == nil {
+					continue
+				}
+
+				mutex.Lock()
+				bulkErr.Errors[variableID] = err
+				mutex.Unlock()
+				if options.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	sorted := append([]string(nil), variableIDs...)
+	sort.Strings(sorted)
+send:
+	for i, variableID := range sorted {
+		select {
+		case ids <- variableID:
+		case <-ctx.Done():
+			bulkErr.Skipped = sorted[i:]
+			break send
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	if len(bulkErr.Errors) == 0 && len(bulkErr.Skipped) == 0 {
+		return nil
+	}
+	if len(bulkErr.Errors) == 0 {
+		// Only the caller's context can skip variables without a failure
+		return ctx.Err()
+	}
+	return bulkErr
+}
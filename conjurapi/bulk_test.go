@@ -0,0 +1,132 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretsServer is a minimal in-memory secrets endpoint which tracks the
+// number of requests in flight.
+type secretsServer struct {
+	mutex       sync.Mutex
+	values      map[string]string
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (s *secretsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	inFlight := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxInFlight)
+		if inFlight <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, inFlight) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	id := strings.TrimPrefix(r.URL.Path, "/secrets/cucumber/variable/")
+	if strings.HasPrefix(id, "broken") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		s.values[id] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		value, ok := s.values[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(value))
+	}
+}
+
+func newSecretsServerClient(t *testing.T, values map[string]string) (*Client, *secretsServer) {
+	server := &secretsServer{values: values}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+	return conjur, server
+}
+
+func TestClient_AddSecrets(t *testing.T) {
+	t.Run("Adds all values with bounded concurrency", func(t *testing.T) {
+		conjur, server := newSecretsServerClient(t, map[string]string{})
+		secrets := map[string]string{}
+		for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+			secrets[id] = "value-" + id
+		}
+
+		err := conjur.AddSecrets(secrets, BulkOptions{Concurrency: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, secrets, server.values)
+		assert.LessOrEqual(t, server.maxInFlight, int32(2))
+	})
+
+	t.Run("Reports every failure", func(t *testing.T) {
+		conjur, server := newSecretsServerClient(t, map[string]string{})
+
+		err := conjur.AddSecrets(map[string]string{"ok": "1", "broken-1": "2", "broken-2": "3"}, BulkOptions{})
+		var bulkErr *BulkError
+		require.ErrorAs(t, err, &bulkErr)
+		assert.Len(t, bulkErr.Errors, 2)
+		assert.Empty(t, bulkErr.Skipped)
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.Contains(t, err.Error(), "failed to process 2 variable(s): broken-1: ")
+		assert.Equal(t, map[string]string{"ok": "1"}, server.values)
+	})
+
+	t.Run("Stops after the first failure with FailFast", func(t *testing.T) {
+		conjur, server := newSecretsServerClient(t, map[string]string{})
+
+		err := conjur.AddSecrets(map[string]string{"a": "1", "broken": "2", "c": "3", "d": "4"}, BulkOptions{Concurrency: 1, FailFast: true})
+		var bulkErr *BulkError
+		require.ErrorAs(t, err, &bulkErr)
+		assert.Len(t, bulkErr.Errors, 1)
+		assert.Equal(t, []string{"c", "d"}, bulkErr.Skipped)
+		assert.Equal(t, map[string]string{"a": "1"}, server.values)
+	})
+
+	t.Run("Returns the context error when cancelled", func(t *testing.T) {
+		conjur, _ := newSecretsServerClient(t, map[string]string{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := conjur.AddSecretsWithContext(ctx, map[string]string{"a": "1"}, BulkOptions{})
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestMirrorSecrets(t *testing.T) {
+	source, _ := newSecretsServerClient(t, map[string]string{"db/password": "secret", "api-key": "key"})
+	target, targetServer := newSecretsServerClient(t, map[string]string{})
+
+	err := MirrorSecrets(source, target, []string{"db/password", "api-key"}, BulkOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db/password": "secret", "api-key": "key"}, targetServer.values)
+
+	err = MirrorSecrets(source, target, []string{"missing"}, BulkOptions{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Contains(t, err.Error(), "missing: failed to read from source: ")
+}
@@ -0,0 +1,213 @@
+package conjurapi
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a CachingClient keeps secrets when
+// CacheOptions.TTL is not set.
+const DefaultCacheTTL = time.Minute
+
+// CacheOptions configures a CachingClient.
+type CacheOptions struct {
+	// TTL is how long a retrieved secret is served from the cache before it
+	// is fetched from Conjur again. Defaults to DefaultCacheTTL.
+	TTL time.Duration
+	// MaxEntries limits the number of cached secrets, evicting the least
+	// recently used. Zero means no limit.
+	MaxEntries int
+}
+
+// CachingClient is a Client that memoizes the results of RetrieveSecret in
+// memory. Cached values are never written to disk. AddSecret,
+// AddSecretWithOptions and AddSecrets invalidate the variables they write.
+// All other methods are passed through to the underlying Client, so other
+// reads, such as RetrieveSecretWithVersion and RetrieveBatchSecrets, bypass
+// the cache.
+type CachingClient struct {
+	*Client
+	options CacheOptions
+	now     func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	variableID string
+	value      []byte
+	expiresAt  time.Time
+}
+
+// NewCachingClient wraps a client so that secrets it retrieves are cached
+// according to the given options.
+func NewCachingClient(client *Client, options CacheOptions) *CachingClient {
+	if options.TTL <= 0 {
+		options.TTL = DefaultCacheTTL
+	}
+
+	return &CachingClient{
+		Client:  client,
+		options: options,
+		now:     time.Now,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// RetrieveSecret fetches a secret from a variable, serving it from the cache
+// when a value retrieved within the TTL is available.
+func (c *CachingClient) RetrieveSecret(variableID string) ([]byte, error) {
+	return c.RetrieveSecretWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretWithContext is like RetrieveSecret but uses the provided
+// context for the underlying request.
+func (c *CachingClient) RetrieveSecretWithContext(ctx context.Context, variableID string) ([]byte, error) {
+	key := c.cacheKey(variableID)
+	if value, ok := c.get(key); ok {
+		return value, nil
+	}
+
+	value, err := c.Client.RetrieveSecretWithContext(ctx, variableID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(key, value)
+	return copyBytes(value), nil
+}
+
+// AddSecret adds a value to a variable, and removes the variable's secret
+// from the cache.
+func (c *CachingClient) AddSecret(variableID string, secretValue string) error {
+	return c.AddSecretWithContext(context.Background(), variableID, secretValue)
+}
+
+// AddSecretWithContext is like AddSecret but uses the provided context for the
+// underlying request.
+func (c *CachingClient) AddSecretWithContext(ctx context.Context, variableID string, secretValue string) error {
+	// A failed request may still have been applied, so the secret is
+	// invalidated either way
+	defer c.Invalidate(variableID)
+	return c.Client.AddSecretWithContext(ctx, variableID, secretValue)
+}
+
+// AddSecretWithOptions adds a value to a variable, as the Client method does,
+// and removes the variable's secret from the cache.
+func (c *CachingClient) AddSecretWithOptions(variableID string, secretValue string, options SecretOptions) error {
+	return c.AddSecretWithOptionsWithContext(context.Background(), variableID, secretValue, options)
+}
+
+// AddSecretWithOptionsWithContext is like AddSecretWithOptions but uses the
+// provided context for the underlying request.
+func (c *CachingClient) AddSecretWithOptionsWithContext(ctx context.Context, variableID string, secretValue string, options SecretOptions) error {
+	defer c.Invalidate(variableID)
+	return c.Client.AddSecretWithOptionsWithContext(ctx, variableID, secretValue, options)
+}
+
+// AddSecrets adds values to many variables, as the Client method does, and
+// removes their secrets from the cache.
+func (c *CachingClient) AddSecrets(secrets map[string]string, options BulkOptions) error {
+	return c.AddSecretsWithContext(context.Background(), secrets, options)
+}
+
+// AddSecretsWithContext is like AddSecrets but uses the provided context for
+// the underlying requests.
+func (c *CachingClient) AddSecretsWithContext(ctx context.Context, secrets map[string]string, options BulkOptions) error {
+	defer func() {
+		for variableID := range secrets {
+			c.Invalidate(variableID)
+		}
+	}()
+	return c.Client.AddSecretsWithContext(ctx, secrets, options)
+}
+
+// Invalidate removes a variable's secret from the cache, so that the next
+// retrieval fetches it from Conjur.
+func (c *CachingClient) Invalidate(variableID string) {
+	key := c.cacheKey(variableID)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// InvalidateAll removes all secrets from the cache.
+func (c *CachingClient) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, element := range c.entries {
+		c.remove(element)
+	}
+}
+
+// cacheKey qualifies the variable ID so that partially- and fully-qualified
+// IDs of the same variable share a cache entry.
+func (c *CachingClient) cacheKey(variableID string) string {
+	return makeFullId(c.config.Account, "variable", variableID)
+}
+
+func (c *CachingClient) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return copyBytes(entry.value), true
+}
+
+func (c *CachingClient) put(key string, value []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	entry := &cacheEntry{
+		variableID: key,
+		value:      copyBytes(value),
+		expiresAt:  c.now().Add(c.options.TTL),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+
+	if c.options.MaxEntries > 0 {
+		for c.lru.Len() > c.options.MaxEntries {
+			c.remove(c.lru.Back())
+		}
+	}
+}
+
+// remove drops an element from the cache and zeroes its value. The caller
+// must hold the mutex.
+func (c *CachingClient) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	for i := range entry.value {
+		entry.value[i] = 0
+	}
+	delete(c.entries, entry.variableID)
+	c.lru.Remove(element)
+}
+
+func copyBytes(value []byte) []byte {
+	return append([]byte(nil), value...)
+}
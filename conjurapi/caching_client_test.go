@@ -0,0 +1,165 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachingTestClient(t *testing.T, options CacheOptions) (*CachingClient, map[string]int) {
+	var mutex sync.Mutex
+	requests := map[string]int{}
+
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/secrets/cucumber/variable/")
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mutex.Lock()
+		requests[id]++
+		w.Write([]byte(id + "-value"))
+		mutex.Unlock()
+	}))
+	t.Cleanup(mockConjurServer.Close)
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	return NewCachingClient(conjur, options), requests
+}
+
+func TestCachingClient_RetrieveSecret(t *testing.T) {
+	t.Run("Serves repeated retrievals from the cache", func(t *testing.T) {
+		client, requests := newCachingTestClient(t, CacheOptions{TTL: time.Hour})
+
+		for i := 0; i < 3; i++ {
+			value, err := client.RetrieveSecret("db-password")
+			assert.NoError(t, err)
+			assert.Equal(t, "db-password-value", string(value))
+		}
+		_, err := client.RetrieveSecret("cucumber:variable:db-password")
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, requests["db-password"])
+	})
+
+	t.Run("Fetches again after the TTL", func(t *testing.T) {
+		client, requests := newCachingTestClient(t, CacheOptions{TTL: time.Minute})
+		now := time.Now()
+		client.now = func() time.Time { return now }
+
+		_, err := client.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		now = now.Add(time.Minute)
+		_, err = client.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, requests["db-password"])
+	})
+
+	t.Run("Evicts the least recently used entry", func(t *testing.T) {
+		client, requests := newCachingTestClient(t, CacheOptions{MaxEntries: 2})
+
+		for _, id := range []string{"one", "two", "one", "three", "one", "two"} {
+			_, err := client.RetrieveSecret(id)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, requests["one"])
+		assert.Equal(t, 2, requests["two"])
+		assert.Equal(t, 1, requests["three"])
+	})
+
+	t.Run("Does not cache errors", func(t *testing.T) {
+		client, _ := newCachingTestClient(t, CacheOptions{})
+
+		_, err := client.RetrieveSecret("missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Empty(t, client.entries)
+	})
+
+	t.Run("Returns copies of cached values", func(t *testing.T) {
+		client, _ := newCachingTestClient(t, CacheOptions{})
+
+		value, err := client.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		value[0] = 'X'
+
+		value, err = client.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "db-password-value", string(value))
+	})
+}
+
+func TestCachingClient_Invalidate(t *testing.T) {
+	client, requests := newCachingTestClient(t, CacheOptions{})
+
+	_, err := client.RetrieveSecret("one")
+	assert.NoError(t, err)
+	_, err = client.RetrieveSecret("two")
+	assert.NoError(t, err)
+
+	client.Invalidate("cucumber:variable:one")
+	_, err = client.RetrieveSecret("one")
+	assert.NoError(t, err)
+	_, err = client.RetrieveSecret("two")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["one"])
+	assert.Equal(t, 1, requests["two"])
+
+	client.InvalidateAll()
+	_, err = client.RetrieveSecret("two")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["two"])
+}
+
+func TestCachingClient_AddSecret(t *testing.T) {
+	var mutex sync.Mutex
+	values := map[string]string{"one": "old", "two": "old", "three": "old"}
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/secrets/cucumber/variable/")
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			values[id] = string(body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(values[id]))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+	client := NewCachingClient(conjur, CacheOptions{TTL: time.Hour})
+
+	assertSecret := func(variableID, expected string) {
+		value, err := client.RetrieveSecret(variableID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(value))
+	}
+	for _, variableID := range []string{"one", "two", "three"} {
+		assertSecret(variableID, "old")
+	}
+
+	require.NoError(t, client.AddSecret("cucumber:variable:one", "new"))
+	assertSecret("one", "new")
+
+	require.NoError(t, client.AddSecretWithOptions("two", "new", SecretOptions{}))
+	assertSecret("two", "new")
+
+	require.NoError(t, client.AddSecrets(map[string]string{"three": "new"}, BulkOptions{}))
+	assertSecret("three", "new")
+}
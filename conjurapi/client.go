@@ -1,8 +1,11 @@
 package conjurapi
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,17 +13,20 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/internal/fips"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/storage"
 )
 
-type Authenticator interface {
-	RefreshToken() ([]byte, error)
-	NeedsTokenRefresh() bool
-}
+// Authenticator obtains access tokens for a Client. Custom implementations can
+// be passed to NewClientFromAuthenticator or registered by name with
+// authn.Register.
+type Authenticator = authn.Authenticator
 
 type CredentialStorageProvider interface {
 	StoreCredentials(login string, password string) error
@@ -30,27 +36,216 @@ type CredentialStorageProvider interface {
 	PurgeCredentials() error
 }
 
+// Client makes requests to the Conjur API. A Client is safe for concurrent use
+// by multiple goroutines, which share its access token: when the token needs
+// refreshing, only one of them authenticates while the others wait for the
+// new token.
 type Client struct {
 	config        Config
 	authToken     *authn.AuthnToken
 	httpClient    *http.Client
 	authenticator Authenticator
 	storage       CredentialStorageProvider
+	logger        Logger
+	telemetry     Telemetry
+	// transport, when set by WithTransport, is the base of the HTTP client
+	// built from the config
+	transport http.RoundTripper
+
+	// authTokenMutex guards authToken, which may be renewed in the background
+	authTokenMutex sync.RWMutex
+	// authenticatorMutex guards authenticator, which Login sets for clients
+	// created without one
+	authenticatorMutex sync.RWMutex
+	// refreshMutex guards refreshing, the token refresh in progress if any
+	refreshMutex sync.Mutex
+	refreshing   *tokenRefresh
+	// wipeTokens wipes access tokens from memory once they're replaced
+	wipeTokens bool
+	// asyncRefresh, when set, renews tokens in the background instead of on
+	// the request path. It's guarded by refreshMutex.
+	asyncRefresh *asyncTokenRefresh
+	// tokenCache, when Config.TokenCachePath is set, keeps access tokens on
+	// disk between processes
+	tokenCache           *storage.TokenCache
+	tokenCachePassphrase []byte
+	// noUnauthorizedReplay and replayAllUnauthorized control which requests
+	// rejected with 401 are replayed with a new token
+	noUnauthorizedReplay  bool
+	replayAllUnauthorized bool
+	// noRequestIDs stops request IDs from being generated
+	noRequestIDs bool
+	// userAgent, when set by WithApplication, is the User-Agent header sent
+	userAgent string
+}
+
+// NewClientFromAuthenticator creates a client which obtains access tokens from
+// the provided authenticator.
+func NewClientFromAuthenticator(config Config, authenticator Authenticator, opts ...ClientOption) (*Client, error) {
+	if authenticator == nil {
+		return nil, fmt.Errorf("must specify an Authenticator")
+	}
+	return newClientWithAuthenticator(config, authenticator, opts...)
+}
+
+// newClientFromRegisteredAuthenticator creates a client using the authenticator
+// factory registered for the config's AuthnType.
+func newClientFromRegisteredAuthenticator(config Config, factory authn.Factory, opts ...ClientOption) (*Client, error) {
+	client, err := NewClient(config, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, err := factory(authn.FactoryConfig{
+		ApplianceURL: client.config.ApplianceURL,
+		Account:      config.Account,
+		ServiceID:    config.ServiceID,
+		HTTPClient:   client.httpClientFor(operationAuthn),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if authenticator == nil {
+		return nil, fmt.Errorf("authenticator factory for %s returned no authenticator", config.AuthnType)
+	}
+
+	client.authenticator = authenticator
+	return client, nil
 }
 
-func NewClientFromKey(config Config, loginPair authn.LoginPair) (*Client, error) {
+func NewClientFromKey(config Config, loginPair authn.LoginPair, opts ...ClientOption) (*Client, error) {
 	authenticator := &authn.APIKeyAuthenticator{
 		LoginPair: loginPair,
 	}
 	client, err := newClientWithAuthenticator(
 		config,
 		authenticator,
+		opts...,
 	)
 	authenticator.Authenticate = client.Authenticate
+	authenticator.AuthenticateWithContext = client.AuthenticateWithContext
 	return client, err
 }
 
-func NewClientFromOidcCode(config Config, code, nonce, code_verifier string) (*Client, error) {
+// NewClientFromJWTAuthenticator creates a client which authenticates with
+// authn-jwt using the JWT settings of the provided config. The JWT is exchanged
+// for a new access token whenever the current one needs to be refreshed.
+func NewClientFromJWTAuthenticator(config Config, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.JWTAuthenticator{
+		JWT:         config.JWTContent,
+		JWTFilePath: config.JWTFilePath,
+		HostID:      config.JWTHostID,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.Authenticate = client.JWTAuthenticate
+		authenticator.AuthenticateWithContext = client.JWTAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromK8s creates a client which authenticates a Kubernetes pod with
+// authn-k8s. The login is the pod's Conjur host ID; podName and podNamespace
+// identify the pod into which Conjur injects the client certificate.
+func NewClientFromK8s(config Config, login, podName, podNamespace string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.K8sAuthenticator{
+		Login:        login,
+		PodName:      podName,
+		PodNamespace: podNamespace,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.InjectClientCert = client.K8sInjectClientCert
+		authenticator.Authenticate = client.K8sAuthenticate
+		authenticator.AuthenticateWithContext = client.K8sAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromIAM creates a client which authenticates an AWS workload with
+// authn-iam, signing requests with the credentials available in the
+// environment: AWS_* variables, a web identity token or the instance profile.
+func NewClientFromIAM(config Config, login string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.IAMAuthenticator{
+		Login: login,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.Authenticate = client.IAMAuthenticate
+		authenticator.AuthenticateWithContext = client.IAMAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromAzure creates a client which authenticates an Azure workload
+// with authn-azure using the managed identity selected by the config's
+// AzureClientID, or the system-assigned identity when it is empty.
+func NewClientFromAzure(config Config, login string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.AzureAuthenticator{
+		Login:    login,
+		ClientID: config.AzureClientID,
+		Resource: config.AzureResource,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.Authenticate = client.AzureAuthenticate
+		authenticator.AuthenticateWithContext = client.AzureAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromGCP creates a client which authenticates a Google Cloud workload
+// with authn-gcp using identity tokens from the metadata server.
+func NewClientFromGCP(config Config, login string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.GCPAuthenticator{
+		Account: config.Account,
+		Login:   login,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.Authenticate = client.GCPAuthenticate
+		authenticator.AuthenticateWithContext = client.GCPAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromAuthnLocal creates a client which mints access tokens for the
+// role given by login, such as "admin" or "host/myapp", through the
+// authn-local socket of a colocated Conjur server. The socket is
+// Config.AuthnLocalSocket, or authn.DefaultAuthnLocalSocket when unset.
+func NewClientFromAuthnLocal(config Config, login string, opts ...ClientOption) (*Client, error) {
+	return newClientWithAuthenticator(
+		config,
+		&authn.LocalAuthenticator{
+			SocketPath: config.AuthnLocalSocket,
+			Account:    config.Account,
+			Login:      login,
+		},
+		opts...,
+	)
+}
+
+func NewClientFromOidcCode(config Config, code, nonce, code_verifier string, opts ...ClientOption) (*Client, error) {
 	authenticator := &authn.OidcAuthenticator{
 		Code:         code,
 		Nonce:        nonce,
@@ -59,9 +254,29 @@ func NewClientFromOidcCode(config Config, code, nonce, code_verifier string) (*C
 	client, err := newClientWithAuthenticator(
 		config,
 		authenticator,
+		opts...,
 	)
 	if err == nil {
 		authenticator.Authenticate = client.OidcAuthenticate
+		authenticator.AuthenticateWithContext = client.OidcAuthenticateWithContext
+	}
+	return client, err
+}
+
+// NewClientFromOidcIDToken creates a client which authenticates with
+// authn-oidc by exchanging an ID token previously obtained from the provider.
+func NewClientFromOidcIDToken(config Config, idToken string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.OidcAuthenticator{
+		IDToken: idToken,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.AuthenticateIDToken = client.OidcIDTokenAuthenticate
+		authenticator.AuthenticateIDTokenWithContext = client.OidcIDTokenAuthenticateWithContext
 	}
 	return client, err
 }
@@ -72,20 +287,26 @@ func ReadResponseBody(response io.ReadCloser) ([]byte, error) {
 	return io.ReadAll(response)
 }
 
-func NewClientFromToken(config Config, token string) (*Client, error) {
+// NewClientFromToken creates a client which uses a pre-fetched access token,
+// given either as the token's JSON or base64-encoded. The token is never
+// renewed; use TokenExpiresAt to find out when the client stops working.
+// NewClientFromEnvironment uses it when CONJUR_AUTHN_TOKEN is set.
+func NewClientFromToken(config Config, token string, opts ...ClientOption) (*Client, error) {
 	return newClientWithAuthenticator(
 		config,
-		&authn.TokenAuthenticator{token},
+		&authn.TokenAuthenticator{Token: token},
+		opts...,
 	)
 }
 
-func NewClientFromTokenFile(config Config, tokenFile string) (*Client, error) {
+func NewClientFromTokenFile(config Config, tokenFile string, opts ...ClientOption) (*Client, error) {
 	return newClientWithAuthenticator(
 		config,
 		&authn.TokenFileAuthenticator{
 			TokenFile:   tokenFile,
 			MaxWaitTime: -1,
 		},
+		opts...,
 	)
 }
 
@@ -97,7 +318,7 @@ func LoginPairFromEnv() (*authn.LoginPair, error) {
 }
 
 // TODO: Create a version of this function for creating an authenticator from environment
-func NewClientFromEnvironment(config Config) (*Client, error) {
+func NewClientFromEnvironment(config Config, opts ...ClientOption) (*Client, error) {
 	err := config.Validate()
 
 	if err != nil {
@@ -106,28 +327,67 @@ func NewClientFromEnvironment(config Config) (*Client, error) {
 
 	authnTokenFile := os.Getenv("CONJUR_AUTHN_TOKEN_FILE")
 	if authnTokenFile != "" {
-		return NewClientFromTokenFile(config, authnTokenFile)
+		return NewClientFromTokenFile(config, authnTokenFile, opts...)
 	}
 
 	authnToken := os.Getenv("CONJUR_AUTHN_TOKEN")
 	if authnToken != "" {
-		return NewClientFromToken(config, authnToken)
+		return NewClientFromToken(config, authnToken, opts...)
+	}
+
+	// Authenticators registered by name take precedence over built-in types
+	if factory, ok := authn.Lookup(config.AuthnType); ok {
+		return newClientFromRegisteredAuthenticator(config, factory, opts...)
+	}
+
+	if config.AuthnType == "jwt" {
+		return NewClientFromJWTAuthenticator(config, opts...)
+	}
+
+	if config.AuthnType == "k8s" {
+		return NewClientFromK8s(
+			config,
+			os.Getenv("CONJUR_AUTHN_LOGIN"),
+			os.Getenv("MY_POD_NAME"),
+			os.Getenv("MY_POD_NAMESPACE"),
+			opts...,
+		)
+	}
+
+	if config.AuthnType == "iam" {
+		return NewClientFromIAM(config, os.Getenv("CONJUR_AUTHN_LOGIN"), opts...)
+	}
+
+	if config.AuthnType == "azure" {
+		return NewClientFromAzure(config, os.Getenv("CONJUR_AUTHN_LOGIN"), opts...)
+	}
+
+	if config.AuthnType == "gcp" {
+		return NewClientFromGCP(config, os.Getenv("CONJUR_AUTHN_LOGIN"), opts...)
+	}
+
+	if config.AuthnType == "local" {
+		return NewClientFromAuthnLocal(config, os.Getenv("CONJUR_AUTHN_LOGIN"), opts...)
+	}
+
+	if config.AuthnType == "identity" {
+		return NewClientFromIdentity(config, config.IdentityURL, os.Getenv("CONJUR_AUTHN_LOGIN"), os.Getenv("CONJUR_AUTHN_API_KEY"), opts...)
 	}
 
 	authnJwtServiceID := os.Getenv("CONJUR_AUTHN_JWT_SERVICE_ID")
 	if authnJwtServiceID != "" {
-		return NewClientFromJwt(config, authnJwtServiceID)
+		return NewClientFromJwt(config, authnJwtServiceID, opts...)
 	}
 
 	loginPair, err := LoginPairFromEnv()
 	if err == nil && loginPair.Login != "" && loginPair.APIKey != "" {
-		return NewClientFromKey(config, *loginPair)
+		return NewClientFromKey(config, *loginPair, opts...)
 	}
 
-	return newClientFromStoredCredentials(config)
+	return newClientFromStoredCredentials(config, opts...)
 }
 
-func NewClientFromJwt(config Config, authnJwtServiceID string) (*Client, error) {
+func NewClientFromJwt(config Config, authnJwtServiceID string, opts ...ClientOption) (*Client, error) {
 	var jwtTokenString string
 	jwtToken := os.Getenv("CONJUR_AUTHN_JWT_TOKEN")
 	jwtTokenString = fmt.Sprintf("jwt=%s", jwtToken)
@@ -141,23 +401,16 @@ func NewClientFromJwt(config Config, authnJwtServiceID string) (*Client, error)
 		if err != nil {
 			return nil, err
 		}
-		jwtTokenString = fmt.Sprintf("jwt=%s", string(jwtToken))
+		jwtTokenString = fmt.Sprintf("jwt=%s", strings.TrimSpace(string(jwtToken)))
 	}
 
-	var httpClient *http.Client
-	if config.IsHttps() {
-		cert, err := config.ReadSSLCert()
-		if err != nil {
-			return nil, err
-		}
-		httpClient, err = newHTTPSClient(cert, config)
-		if err != nil {
-			return nil, err
-		}
-
-	} else {
-		httpClient = &http.Client{Timeout: time.Second * time.Duration(config.GetHttpTimeout())}
+	jwtClient := &Client{config: config}
+	jwtClient.applyOptions(opts)
+	config = jwtClient.config
+	if err := jwtClient.setupHTTPClient(); err != nil {
+		return nil, err
 	}
+	httpClient := jwtClient.httpClient
 
 	authnJwtHostID := os.Getenv("CONJUR_AUTHN_JWT_HOST_ID")
 	var authnJwtUrl string
@@ -183,12 +436,12 @@ func NewClientFromJwt(config Config, authnJwtServiceID string) (*Client, error)
 		return nil, err
 	}
 
-	return NewClientFromToken(config, string(token))
+	return NewClientFromToken(config, string(token), opts...)
 }
 
-func newClientFromStoredCredentials(config Config) (*Client, error) {
+func newClientFromStoredCredentials(config Config, opts ...ClientOption) (*Client, error) {
 	if config.AuthnType == "oidc" {
-		return newClientFromStoredOidcCredentials(config)
+		return newClientFromStoredOidcCredentials(config, opts...)
 	}
 
 	// Attempt to load credentials from whatever storage provider is configured
@@ -198,30 +451,34 @@ func newClientFromStoredCredentials(config Config) (*Client, error) {
 			return nil, err
 		}
 		if login != "" && password != "" {
-			return NewClientFromKey(config, authn.LoginPair{Login: login, APIKey: password})
+			return NewClientFromKey(config, authn.LoginPair{Login: login, APIKey: password}, opts...)
 		}
 	}
 
-	return nil, fmt.Errorf("No valid credentials found. Please login again.")
+	return nil, fmt.Errorf("no valid credentials found, please login again")
 }
 
-func newClientFromStoredOidcCredentials(config Config) (*Client, error) {
-	client, err := NewClientFromOidcCode(config, "", "", "")
+func newClientFromStoredOidcCredentials(config Config, opts ...ClientOption) (*Client, error) {
+	client, err := NewClientFromOidcCode(config, "", "", "", opts...)
 	if err != nil {
 		return nil, err
 	}
 	token := client.readCachedAccessToken()
-	if token != nil && !token.ShouldRefresh() {
+	if token != nil && !client.tokenDueForRefresh(token) {
 		return client, nil
 	}
-	return nil, fmt.Errorf("No valid OIDC token found. Please login again.")
+	return nil, fmt.Errorf("no valid OIDC token found, please login again")
 }
 
 func (c *Client) GetAuthenticator() Authenticator {
+	c.authenticatorMutex.RLock()
+	defer c.authenticatorMutex.RUnlock()
 	return c.authenticator
 }
 
 func (c *Client) SetAuthenticator(authenticator Authenticator) {
+	c.authenticatorMutex.Lock()
+	defer c.authenticatorMutex.Unlock()
 	c.authenticator = authenticator
 }
 
@@ -243,12 +500,16 @@ func (c *Client) SubmitRequest(req *http.Request) (resp *http.Response, err erro
 		return
 	}
 
-	return c.submitRequestWithCustomAuth(req)
+	resp, err = c.submitRequestWithCustomAuth(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.canReplayUnauthorized(req) {
+		return c.replayUnauthorized(req, resp)
+	}
+	return
 }
 
 func (c *Client) submitRequestWithCustomAuth(req *http.Request) (resp *http.Response, err error) {
-	logging.ApiLog.Debugf("req: %+v\n", req)
-	resp, err = c.httpClient.Do(req)
+	logging.ApiLog.Debugf("req: %s %s\n", req.Method, req.URL.Path)
+	resp, err = c.send(operationOf(req.Context()), req)
 	if err != nil {
 		return
 	}
@@ -256,11 +517,44 @@ func (c *Client) submitRequestWithCustomAuth(req *http.Request) (resp *http.Resp
 	return
 }
 
+// send sends a request with the HTTP client for its category of requests,
+// setting its request ID and User-Agent.
+func (c *Client) send(op operation, req *http.Request) (*http.Response, error) {
+	return c.httpClientFor(op).Do(c.withUserAgent(withIdempotencyKey(c.withRequestID(req))))
+}
+
 func (c *Client) WhoAmIRequest() (*http.Request, error) {
 	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "whoami").String(), nil)
 }
 
+// ServerInfoRequest crafts an HTTP request to Conjur's /info endpoint, which
+// describes the server's version, role and configuration.
+func (c *Client) ServerInfoRequest() (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "info").String(), nil)
+}
+
+// HealthRequest crafts an HTTP request to Conjur's /health endpoint, which
+// reports the health of the appliance's services and database.
+func (c *Client) HealthRequest() (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "health").String(), nil)
+}
+
+// RemoteHealthRequest crafts an HTTP request to Conjur's /remote_health
+// endpoint, which reports the health of another appliance in the cluster.
+func (c *Client) RemoteHealthRequest(host string) (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "remote_health", url.PathEscape(host)).String(), nil)
+}
+
+// AuthenticatorsRequest crafts an HTTP request to Conjur's /authenticators
+// endpoint, which lists the installed, configured and enabled authenticators.
+func (c *Client) AuthenticatorsRequest() (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "authenticators").String(), nil)
+}
+
 func (c *Client) LoginRequest(login string, password string) (*http.Request, error) {
+	if c.config.V4 {
+		return c.v4LoginRequest(login, password)
+	}
 	authenticateURL := makeRouterURL(c.authnURL(), "login").String()
 
 	req, err := http.NewRequest("GET", authenticateURL, nil)
@@ -274,6 +568,9 @@ func (c *Client) LoginRequest(login string, password string) (*http.Request, err
 }
 
 func (c *Client) AuthenticateRequest(loginPair authn.LoginPair) (*http.Request, error) {
+	if c.config.V4 {
+		return c.v4AuthenticateRequest(loginPair)
+	}
 	authenticateURL := makeRouterURL(c.authnURL(), url.QueryEscape(loginPair.Login), "authenticate").String()
 
 	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(loginPair.APIKey))
@@ -285,6 +582,92 @@ func (c *Client) AuthenticateRequest(loginPair authn.LoginPair) (*http.Request,
 	return req, nil
 }
 
+// JWTAuthenticateRequest crafts an HTTP request to exchange a JWT for an access
+// token with authn-jwt. When hostID is empty, the identity is taken from the
+// JWT claims as configured on the authenticator.
+func (c *Client) JWTAuthenticateRequest(jwt, hostID string) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.authnURL(), "authenticate").String()
+	if hostID != "" {
+		authenticateURL = makeRouterURL(c.authnURL(), url.PathEscape(hostID), "authenticate").String()
+	}
+
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(fmt.Sprintf("jwt=%s", jwt)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// K8sInjectClientCertRequest crafts an HTTP request asking authn-k8s to sign
+// the given CSR and inject the resulting certificate into the requesting pod.
+func (c *Client) K8sInjectClientCertRequest(csr []byte, hostIDPrefix string) (*http.Request, error) {
+	injectURL := makeRouterURL(c.config.ApplianceURL, "authn-k8s", c.config.ServiceID, "inject_client_cert").String()
+
+	req, err := http.NewRequest("POST", injectURL, bytes.NewReader(csr))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Host-Id-Prefix", hostIDPrefix)
+
+	return req, nil
+}
+
+// K8sAuthenticateRequest crafts an HTTP request to obtain an access token from
+// authn-k8s. The request must be sent over a connection presenting the
+// injected client certificate.
+func (c *Client) K8sAuthenticateRequest(login string) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.authnURL(), url.QueryEscape(login), "authenticate").String()
+
+	return http.NewRequest("POST", authenticateURL, nil)
+}
+
+// IAMAuthenticateRequest crafts an HTTP request to obtain an access token from
+// authn-iam. signedHeaders is the JSON-encoded set of headers of a signed STS
+// GetCallerIdentity request.
+func (c *Client) IAMAuthenticateRequest(login string, signedHeaders []byte) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.authnURL(), url.QueryEscape(login), "authenticate").String()
+
+	req, err := http.NewRequest("POST", authenticateURL, bytes.NewReader(signedHeaders))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	return req, nil
+}
+
+// AzureAuthenticateRequest crafts an HTTP request to exchange an Azure managed
+// identity token for an access token with authn-azure.
+func (c *Client) AzureAuthenticateRequest(login, azureToken string) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.authnURL(), url.QueryEscape(login), "authenticate").String()
+
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(fmt.Sprintf("jwt=%s", azureToken)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// GCPAuthenticateRequest crafts an HTTP request to exchange a GCP identity
+// token for an access token with authn-gcp. The host identity is taken from
+// the token's audience.
+func (c *Client) GCPAuthenticateRequest(identityToken string) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.config.ApplianceURL, "authn-gcp", c.config.Account, "authenticate").String()
+
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(fmt.Sprintf("jwt=%s", identityToken)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
 func (c *Client) ListOidcProvidersRequest() (*http.Request, error) {
 	return http.NewRequest("GET", c.oidcProvidersUrl(), nil)
 }
@@ -300,6 +683,40 @@ func (c *Client) OidcAuthenticateRequest(code, nonce, code_verifier string) (*ht
 	return req, nil
 }
 
+// OidcIDTokenAuthenticateRequest crafts an HTTP request to exchange an OIDC ID
+// token for an access token with authn-oidc.
+func (c *Client) OidcIDTokenAuthenticateRequest(idToken string) (*http.Request, error) {
+	authenticateURL := makeRouterURL(c.authnURL(), "authenticate").String()
+
+	body := url.Values{"id_token": {idToken}}.Encode()
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// IdentityAuthenticateRequest crafts an HTTP request to exchange a CyberArk
+// Identity token for an access token with Conjur Cloud's authn-oidc service.
+func (c *Client) IdentityAuthenticateRequest(identityToken string) (*http.Request, error) {
+	serviceID := c.config.ServiceID
+	if serviceID == "" {
+		serviceID = ConjurCloudIdentityServiceID
+	}
+	authenticateURL := makeRouterURL(c.config.ApplianceURL, "authn-oidc", serviceID, c.config.Account, "authenticate").String()
+
+	body := url.Values{"id_token": {identityToken}}.Encode()
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
 // RotateAPIKeyRequest requires roleID argument to be at least partially-qualified
 // ID of from [<account>:]<kind>:<identifier>.
 func (c *Client) RotateAPIKeyRequest(roleID string) (*http.Request, error) {
@@ -309,7 +726,7 @@ func (c *Client) RotateAPIKeyRequest(roleID string) (*http.Request, error) {
 	}
 	roleID = fmt.Sprintf("%s:%s:%s", account, kind, identifier)
 
-	rotateURL := makeRouterURL(c.authnURL(), "api_key").withFormattedQuery("role=%s", roleID).String()
+	rotateURL := makeRouterURL(c.authnURL(), "api_key").withFormattedQuery("role=%s", url.QueryEscape(roleID)).String()
 
 	return http.NewRequest(
 		"PUT",
@@ -416,6 +833,39 @@ func (c *Client) ResourceRequest(resourceID string) (*http.Request, error) {
 }
 
 func (c *Client) ResourcesRequest(filter *ResourceFilter) (*http.Request, error) {
+	query, err := c.resourceFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	requestURL := makeRouterURL(c.resourcesURL(c.config.Account)).withQuery(query.Encode())
+
+	return http.NewRequest(
+		"GET",
+		requestURL.String(),
+		nil,
+	)
+}
+
+// ResourcesCountRequest crafts an HTTP request for the number of resources
+// matching the filter.
+func (c *Client) ResourcesCountRequest(filter *ResourceFilter) (*http.Request, error) {
+	query, err := c.resourceFilterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	query.Del("limit")
+	query.Del("offset")
+	query.Set("count", "true")
+	requestURL := makeRouterURL(c.resourcesURL(c.config.Account)).withQuery(query.Encode())
+
+	return http.NewRequest(
+		"GET",
+		requestURL.String(),
+		nil,
+	)
+}
+
+func (c *Client) resourceFilterQuery(filter *ResourceFilter) (url.Values, error) {
 	query := url.Values{}
 
 	if filter != nil {
@@ -434,18 +884,20 @@ func (c *Client) ResourcesRequest(filter *ResourceFilter) (*http.Request, error)
 			query.Add("offset", strconv.Itoa(filter.Offset))
 		}
 
-		if filter.Role != "" {
-			query.Add("acting_as", filter.Role)
+		actingAs := filter.ActingAs
+		if actingAs == "" {
+			actingAs = filter.Role
+		}
+		if actingAs != "" {
+			account, kind, id, err := c.parseID(actingAs)
+			if err != nil {
+				return nil, err
+			}
+			query.Add("acting_as", makeFullId(account, kind, id))
 		}
 	}
 
-	requestURL := makeRouterURL(c.resourcesURL(c.config.Account)).withQuery(query.Encode())
-
-	return http.NewRequest(
-		"GET",
-		requestURL.String(),
-		nil,
-	)
+	return query, nil
 }
 
 func (c *Client) PermittedRolesRequest(resourceID string, privilege string) (*http.Request, error) {
@@ -490,6 +942,22 @@ func (c *Client) RoleMembersRequest(roleID string) (*http.Request, error) {
 	)
 }
 
+// RoleMembersPageRequest crafts an HTTP request for the page of at most
+// limit members of a role starting at offset.
+func (c *Client) RoleMembersPageRequest(roleID string, limit, offset int) (*http.Request, error) {
+	account, kind, id, err := c.parseID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	roleMembersURL := makeRouterURL(c.rolesURL(account), kind, url.QueryEscape(id)).withFormattedQuery("members&limit=%d&offset=%d", limit, offset)
+
+	return http.NewRequest(
+		"GET",
+		roleMembersURL.String(),
+		nil,
+	)
+}
+
 func (c *Client) RoleMembershipsRequest(roleID string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(roleID)
 	if err != nil {
@@ -522,7 +990,7 @@ func (c *Client) LoadPolicyRequest(mode PolicyMode, policyID string, policy io.R
 	case PolicyModePut:
 		method = "PUT"
 	default:
-		return nil, fmt.Errorf("Invalid PolicyMode: %d", mode)
+		return nil, fmt.Errorf("invalid PolicyMode: %d", mode)
 	}
 
 	return http.NewRequest(
@@ -532,7 +1000,99 @@ func (c *Client) LoadPolicyRequest(mode PolicyMode, policyID string, policy io.R
 	)
 }
 
+// DryRunPolicyRequest crafts an HTTP request which validates a policy load
+// without applying it. It requires Conjur 1.21 or later.
+func (c *Client) DryRunPolicyRequest(mode PolicyMode, policyID string, policy io.Reader) (*http.Request, error) {
+	req, err := c.LoadPolicyRequest(mode, policyID, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	query.Set("dryRun", "true")
+	req.URL.RawQuery = query.Encode()
+
+	return req, nil
+}
+
+// FetchPolicyRequest crafts an HTTP request which fetches the effective policy
+// of a branch. It requires Conjur 1.21.1 or later.
+func (c *Client) FetchPolicyRequest(policyID string, options FetchPolicyOptions) (*http.Request, error) {
+	account, kind, id, err := c.parseID(makeFullId(c.config.Account, "policy", policyID))
+	if err != nil {
+		return nil, err
+	}
+	policyURL := makeRouterURL(c.policiesURL(account), kind, url.QueryEscape(id))
+
+	query := url.Values{}
+	if options.Depth > 0 {
+		query.Set("depth", strconv.FormatUint(uint64(options.Depth), 10))
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.FormatUint(uint64(options.Limit), 10))
+	}
+	if len(query) > 0 {
+		policyURL = policyURL.withQuery(query.Encode())
+	}
+
+	return http.NewRequest("GET", policyURL.String(), nil)
+}
+
+// AuditEventsRequest crafts an HTTP request for the audit events of the
+// account.
+func (c *Client) AuditEventsRequest(filter *AuditFilter) (*http.Request, error) {
+	return auditRequest(makeRouterURL(c.config.ApplianceURL, "audit"), filter)
+}
+
+// ResourceAuditEventsRequest crafts an HTTP request for the audit events
+// involving a resource.
+func (c *Client) ResourceAuditEventsRequest(resourceID string, filter *AuditFilter) (*http.Request, error) {
+	account, kind, id, err := c.parseID(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	fullID := makeFullId(account, kind, id)
+	return auditRequest(makeRouterURL(c.config.ApplianceURL, "audit", "resources", url.QueryEscape(fullID)), filter)
+}
+
+// RoleAuditEventsRequest crafts an HTTP request for the audit events
+// involving a role.
+func (c *Client) RoleAuditEventsRequest(roleID string, filter *AuditFilter) (*http.Request, error) {
+	account, kind, id, err := c.parseID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	fullID := makeFullId(account, kind, id)
+	return auditRequest(makeRouterURL(c.config.ApplianceURL, "audit", "roles", url.QueryEscape(fullID)), filter)
+}
+
+func auditRequest(auditURL routerURL, filter *AuditFilter) (*http.Request, error) {
+	query := url.Values{}
+	if filter != nil {
+		if !filter.Since.IsZero() {
+			query.Set("since", filter.Since.UTC().Format(time.RFC3339))
+		}
+		if !filter.Until.IsZero() {
+			query.Set("till", filter.Until.UTC().Format(time.RFC3339))
+		}
+		if filter.Limit != 0 {
+			query.Set("limit", strconv.Itoa(filter.Limit))
+		}
+		if filter.Offset != 0 {
+			query.Set("offset", strconv.Itoa(filter.Offset))
+		}
+	}
+	if len(query) > 0 {
+		auditURL = auditURL.withQuery(query.Encode())
+	}
+
+	return http.NewRequest("GET", auditURL.String(), nil)
+}
+
 func (c *Client) RetrieveBatchSecretsRequest(variableIDs []string, base64Flag bool) (*http.Request, error) {
+	if c.config.V4 {
+		return c.v4RetrieveBatchSecretsRequest(variableIDs, base64Flag)
+	}
 	fullVariableIDs := []string{}
 	for _, variableID := range variableIDs {
 		fullVariableID := makeFullId(c.config.Account, "variable", variableID)
@@ -557,6 +1117,9 @@ func (c *Client) RetrieveBatchSecretsRequest(variableIDs []string, base64Flag bo
 }
 
 func (c *Client) RetrieveSecretRequest(variableID string) (*http.Request, error) {
+	if c.config.V4 {
+		return c.v4RetrieveSecretRequest(variableID, 0)
+	}
 	fullVariableID := makeFullId(c.config.Account, "variable", variableID)
 
 	variableURL, err := c.variableURL(fullVariableID)
@@ -572,6 +1135,9 @@ func (c *Client) RetrieveSecretRequest(variableID string) (*http.Request, error)
 }
 
 func (c *Client) RetrieveSecretWithVersionRequest(variableID string, version int) (*http.Request, error) {
+	if c.config.V4 {
+		return c.v4RetrieveSecretRequest(variableID, version)
+	}
 	fullVariableID := makeFullId(c.config.Account, "variable", variableID)
 
 	variableURL, err := c.variableWithVersionURL(fullVariableID, version)
@@ -659,6 +1225,34 @@ func (c *Client) PublicKeysRequest(kind string, identifier string) (*http.Reques
 	return http.NewRequest("GET", publicKeysURL.String(), nil)
 }
 
+// AccountsRequest crafts an HTTP request which lists the accounts of the
+// Conjur server.
+func (c *Client) AccountsRequest() (*http.Request, error) {
+	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "accounts").String(), nil)
+}
+
+// CreateAccountRequest crafts an HTTP request which creates an account.
+func (c *Client) CreateAccountRequest(account string) (*http.Request, error) {
+	data := url.Values{}
+	data.Set("id", account)
+
+	request, err := http.NewRequest(
+		"POST",
+		makeRouterURL(c.config.ApplianceURL, "accounts").String(),
+		strings.NewReader(data.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	return request, nil
+}
+
+// DeleteAccountRequest crafts an HTTP request which deletes an account.
+func (c *Client) DeleteAccountRequest(account string) (*http.Request, error) {
+	return http.NewRequest("DELETE", makeRouterURL(c.config.ApplianceURL, "accounts", url.PathEscape(account)).String(), nil)
+}
+
 func (c *Client) createTokenURL() string {
 	return makeRouterURL(c.config.ApplianceURL, "host_factory_tokens").String()
 }
@@ -748,7 +1342,7 @@ func makeFullId(account, kind, id string) string {
 func (c *Client) parseID(id string) (account, kind, identifier string, err error) {
 	account, kind, identifier = c.unopinionatedParseID(id)
 	if identifier == "" || kind == "" {
-		return "", "", "", fmt.Errorf("Malformed ID '%s': must be fully- or partially-qualified, of form [<account>:]<kind>:<identifier>", id)
+		return "", "", "", fmt.Errorf("malformed ID '%s': must be fully- or partially-qualified, of form [<account>:]<kind>:<identifier>", id)
 	}
 	if account == "" {
 		account = c.config.Account
@@ -771,7 +1365,7 @@ func (c *Client) parseID(id string) (account, kind, identifier string, err error
 func (c *Client) parseIDandEnforceKind(id, enforcedKind string) (account, kind, identifier string, err error) {
 	account, kind, identifier = c.unopinionatedParseID(id)
 	if (identifier == "") || (kind != "" && kind != enforcedKind) {
-		return "", "", "", fmt.Errorf("Malformed ID '%s', must represent a %s, of form [[<account>:]%s:]<identifier>", id, enforcedKind, enforcedKind)
+		return "", "", "", fmt.Errorf("malformed ID '%s', must represent a %s, of form [[<account>:]%s:]<identifier>", id, enforcedKind, enforcedKind)
 	}
 	if kind == "" {
 		kind = enforcedKind
@@ -793,30 +1387,36 @@ func (c *Client) unopinionatedParseID(id string) (account, kind, identifier stri
 	return tokens[0], tokens[1], tokens[2]
 }
 
-func NewClient(config Config) (*Client, error) {
+func NewClient(config Config, opts ...ClientOption) (*Client, error) {
 	var err error
 
+	client := &Client{config: config}
+	client.applyOptions(opts)
+	config = client.config
+
+	if config.ApplianceURL == "" && len(config.ApplianceURLs) > 0 {
+		config.ApplianceURL = config.ApplianceURLs[0]
+	}
+	config.resolveConjurCloud()
 	err = config.Validate()
 
 	if err != nil {
 		return nil, err
 	}
+	config.resolveUnixSocket()
+	client.config = config
 
-	httpClient, err := createHttpClient(config)
-	if err != nil {
+	if err := client.setupHTTPClient(); err != nil {
 		return nil, err
 	}
 
-	storageProvider, err := createStorageProvider(config)
+	client.storage, err = createStorageProvider(config)
 	if err != nil {
 		return nil, err
 	}
+	client.setupTokenCache()
 
-	return &Client{
-		config:     config,
-		httpClient: httpClient,
-		storage:    storageProvider,
-	}, nil
+	return client, nil
 }
 
 func createHttpClient(config Config) (*http.Client, error) {
@@ -834,11 +1434,101 @@ func createHttpClient(config Config) (*http.Client, error) {
 	} else {
 		httpClient = &http.Client{Timeout: time.Second * time.Duration(config.GetHttpTimeout())}
 	}
+
+	if config.HasSSLClientCert() {
+		clientCert, err := config.ReadSSLClientCert()
+		if err != nil {
+			return nil, err
+		}
+		httpClient = withClientCert(httpClient, clientCert)
+	}
+
+	if config.SSLServerName != "" {
+		httpClient = withTLSConfig(httpClient, func(tlsConfig *tls.Config) {
+			tlsConfig.ServerName = config.SSLServerName
+		})
+	}
+
+	if len(config.SSLPinnedPublicKeys) > 0 || config.SSLInsecureSkipVerify {
+		if config.SSLInsecureSkipVerify {
+			logging.ApiLog.Warn("TLS certificate verification is disabled by SSLInsecureSkipVerify; " +
+				"connections to Conjur can be intercepted. Do not use this setting in production.")
+		}
+		httpClient = withTLSConfig(httpClient, func(tlsConfig *tls.Config) {
+			tlsConfig.InsecureSkipVerify = config.SSLInsecureSkipVerify
+			if len(config.SSLPinnedPublicKeys) > 0 {
+				tlsConfig.VerifyConnection = verifyPinnedPublicKey(config.SSLPinnedPublicKeys, config.SSLInsecureSkipVerify)
+			}
+		})
+	}
+
+	if fips.Enabled() {
+		httpClient = withTLSConfig(httpClient, fips.ConfigureTLS)
+	}
+
+	if config.hasTransportSettings() {
+		httpClient = withHTTPTransport(httpClient, func(transport *http.Transport) {
+			configureHTTPTransport(transport, config)
+		})
+	}
+
+	if config.DialContext != nil {
+		httpClient = withHTTPTransport(httpClient, func(transport *http.Transport) {
+			transport.DialContext = config.DialContext
+		})
+	}
+
+	if config.hasConnectionPoolSettings() {
+		httpClient = withHTTPTransport(httpClient, func(transport *http.Transport) {
+			if config.MaxIdleConns != 0 {
+				transport.MaxIdleConns = config.MaxIdleConns
+			}
+			if config.MaxIdleConnsPerHost != 0 {
+				transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+			}
+			if config.MaxConnsPerHost != 0 {
+				transport.MaxConnsPerHost = config.MaxConnsPerHost
+			}
+			if config.IdleConnTimeout != 0 {
+				transport.IdleConnTimeout = config.IdleConnTimeout
+			}
+		})
+	}
+
+	if config.RedirectPolicy != "" {
+		httpClient.CheckRedirect = checkRedirect(config.RedirectPolicy)
+	}
+
+	httpClient.Transport = withConfiguredLayers(httpClient.Transport, config)
 	return httpClient, nil
 }
 
-func newClientWithAuthenticator(config Config, authenticator Authenticator) (*Client, error) {
-	client, err := NewClient(config)
+// withConfiguredLayers wraps a transport with the response size limit, rate
+// limiting, failover, read splitting and retries set up by the config.
+func withConfiguredLayers(transport http.RoundTripper, config Config) http.RoundTripper {
+	if config.MaxResponseBodySize > 0 {
+		transport = newResponseLimitTransport(transport, config.MaxResponseBodySize)
+	}
+	if config.RateLimit > 0 {
+		transport = newRateLimitTransport(transport, config.RateLimit, config.RateLimitBurst)
+	}
+	writes := transport
+	if urls := config.applianceURLs(); len(urls) > 1 {
+		writes = newFailoverTransport(transport, urls)
+	}
+	if readURLs := config.readApplianceURLs(); len(readURLs) > 0 {
+		transport = newReadSplitTransport(transport, writes, config.applianceURLs()[0], readURLs)
+	} else {
+		transport = writes
+	}
+	if !config.RetryPolicy.Disabled {
+		transport = newRetryTransport(transport, config.RetryPolicy)
+	}
+	return transport
+}
+
+func newClientWithAuthenticator(config Config, authenticator Authenticator, opts ...ClientOption) (*Client, error) {
+	client, err := NewClient(config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -847,11 +1537,132 @@ func newClientWithAuthenticator(config Config, authenticator Authenticator) (*Cl
 	return client, nil
 }
 
+// httpClientWithClientCert returns a copy of the client's HTTP client which
+// presents the given certificate during the TLS handshake.
+func (c *Client) httpClientWithClientCert(cert tls.Certificate) *http.Client {
+	return withClientCert(c.httpClient, cert)
+}
+
+// withClientCert returns a copy of an HTTP client which presents the given
+// certificate during the TLS handshake.
+func withClientCert(client *http.Client, cert tls.Certificate) *http.Client {
+	return withTLSConfig(client, func(tlsConfig *tls.Config) {
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	})
+}
+
+// withTLSConfig returns a copy of an HTTP client whose transport's TLS config
+// has been modified by configure.
+func withTLSConfig(client *http.Client, configure func(tlsConfig *tls.Config)) *http.Client {
+	return withHTTPTransport(client, func(transport *http.Transport) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		configure(transport.TLSClientConfig)
+	})
+}
+
+// withHTTPTransport returns a copy of an HTTP client whose transport has been
+// modified by configure, keeping any retry, logging, telemetry and other
+// layers in front of it.
+func withHTTPTransport(client *http.Client, configure func(transport *http.Transport)) *http.Client {
+	httpClient := *client
+	httpClient.Transport = reconfigureTransport(client.Transport, configure)
+	return &httpClient
+}
+
+func reconfigureTransport(base http.RoundTripper, configure func(transport *http.Transport)) http.RoundTripper {
+	switch t := base.(type) {
+	case *retryTransport:
+		retry := *t
+		retry.base = reconfigureTransport(t.base, configure)
+		return &retry
+	case *loggingTransport:
+		logged := *t
+		logged.base = reconfigureTransport(t.base, configure)
+		return &logged
+	case *telemetryTransport:
+		instrumented := *t
+		instrumented.base = reconfigureTransport(t.base, configure)
+		return &instrumented
+	case *failoverTransport:
+		// The copy shares the selector, so both clients prefer the same appliance
+		failover := *t
+		failover.base = reconfigureTransport(t.base, configure)
+		return &failover
+	case *readSplitTransport:
+		return &readSplitTransport{
+			reads:  reconfigureTransport(t.reads, configure),
+			writes: reconfigureTransport(t.writes, configure),
+		}
+	case *responseLimitTransport:
+		capped := *t
+		capped.base = reconfigureTransport(t.base, configure)
+		return &capped
+	case *rateLimitTransport:
+		// The copy shares the limiter, so both clients count towards one limit
+		limited := *t
+		limited.base = reconfigureTransport(t.base, configure)
+		return &limited
+	}
+
+	transport, ok := base.(*http.Transport)
+	if ok {
+		transport = transport.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	configure(transport)
+	return transport
+}
+
+// verifyPinnedPublicKey returns a TLS connection verifier which accepts only
+// servers whose certificate chain contains a public key with one of the given
+// base64-encoded SHA-256 SPKI hashes.
+//
+// Only the verified chains count, since a server can append any certificate
+// to the ones it sends. Without verification, only the server's own
+// certificate does.
+func verifyPinnedPublicKey(pins []string, skipVerify bool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		certs := []*x509.Certificate{}
+		if skipVerify {
+			if len(state.PeerCertificates) > 0 {
+				certs = state.PeerCertificates[:1]
+			}
+		} else {
+			for _, chain := range state.VerifiedChains {
+				certs = append(certs, chain...)
+			}
+		}
+
+		for _, cert := range certs {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			encoded := base64.StdEncoding.EncodeToString(hash[:])
+			for _, pin := range pins {
+				if strings.TrimPrefix(pin, "sha256/") == encoded {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("server certificate does not match any pinned public key")
+	}
+}
+
 func newHTTPSClient(cert []byte, config Config) (*http.Client, error) {
 	pool := x509.NewCertPool()
+	if config.SSLUseSystemCerts {
+		// On Windows, this pool verifies against the system certificate store
+		// as well as the certificates appended to it
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("can't load the system's certificates: %w", err)
+		}
+		pool = systemPool
+	}
 	ok := pool.AppendCertsFromPEM(cert)
 	if !ok {
-		return nil, fmt.Errorf("Can't append Conjur SSL cert")
+		return nil, fmt.Errorf("can't append Conjur SSL cert")
 	}
 	//TODO: Test what happens if this cert is expired
 	//TODO: What if server cert is rotated
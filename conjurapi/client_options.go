@@ -0,0 +1,99 @@
+package conjurapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClientOption customizes a Client as it is constructed. Options are applied
+// in order before the client's Config is validated and its HTTP client is
+// built, so options which adjust the Config, such as WithAccount, take effect
+// as if they had been set in it.
+type ClientOption func(c *Client)
+
+// WithAccount sets the Conjur account of the client's Config.
+func WithAccount(account string) ClientOption {
+	return func(c *Client) {
+		c.config.Account = account
+	}
+}
+
+// WithHTTPTimeout sets the timeout of the client's requests, rounded up to
+// whole seconds like Config.HttpTimeout. A timeout of zero or less disables
+// it.
+func WithHTTPTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		if timeout <= 0 {
+			c.config.HttpTimeout = -1
+			return
+		}
+		c.config.HttpTimeout = int((timeout + time.Second - 1) / time.Second)
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client's Config.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.config.RetryPolicy = policy
+	}
+}
+
+// WithHTTPClient makes the client send all requests with the provided HTTP
+// client. The HTTP client is used as given: the Config's SSL certificate,
+// timeout and retry policy are not applied to it.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+			c.transport = nil
+		}
+	}
+}
+
+// WithTransport makes the client send requests through the provided
+// RoundTripper, for example to add tracing or proxy handling. The Config's
+// timeout, retry policy, failover and rate limit still apply, but its SSL
+// certificate does not, so a transport that talks to an HTTPS appliance must
+// trust its CA itself.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		if transport != nil {
+			c.transport = transport
+			c.httpClient = nil
+		}
+	}
+}
+
+func (c *Client) applyOptions(opts []ClientOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// setupHTTPClient builds the client's HTTP client from its Config, unless
+// WithHTTPClient provided one, and wraps it with logging and telemetry.
+func (c *Client) setupHTTPClient() error {
+	switch {
+	case c.httpClient != nil:
+	case c.transport != nil:
+		c.httpClient = &http.Client{
+			Transport: withConfiguredLayers(c.transport, c.config),
+			Timeout:   time.Second * time.Duration(c.config.GetHttpTimeout()),
+		}
+	default:
+		httpClient, err := createHttpClient(c.config)
+		if err != nil {
+			return err
+		}
+		c.httpClient = httpClient
+	}
+
+	// Logging and telemetry wrap whichever HTTP client the options settled on
+	if c.logger != nil {
+		c.httpClient = withLogging(c.httpClient, c.logger)
+	}
+	if c.telemetry != nil {
+		c.httpClient = withTelemetry(c.httpClient, c.telemetry, c.config.ApplianceURL)
+	}
+	return nil
+}
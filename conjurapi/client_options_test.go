@@ -0,0 +1,125 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerTransport struct {
+	base   http.RoundTripper
+	header string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Test-Middleware", t.header)
+	return t.base.RoundTrip(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "injected", r.Header.Get("X-Test-Middleware"))
+		w.Write([]byte("secret"))
+	}))
+	defer ts.Close()
+
+	httpClient := &http.Client{Transport: &headerTransport{base: http.DefaultTransport, header: "injected"}}
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}
+
+	conjur, err := NewClientFromToken(config, sample_token, WithHTTPClient(httpClient))
+	assert.NoError(t, err)
+	assert.Same(t, httpClient, conjur.GetHttpClient())
+
+	value, err := conjur.RetrieveSecret("db-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(value))
+}
+
+func TestWithTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "injected", r.Header.Get("X-Test-Middleware"))
+		w.Write([]byte("secret"))
+	}))
+	defer ts.Close()
+
+	transport := &headerTransport{base: http.DefaultTransport, header: "injected"}
+
+	t.Run("Keeps the configured timeout and retries", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", HttpTimeout: 3}
+		conjur, err := NewClientFromToken(config, sample_token, WithTransport(transport))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "3s", conjur.GetHttpClient().Timeout.String())
+		retry, ok := conjur.GetHttpClient().Transport.(*retryTransport)
+		assert.True(t, ok)
+		assert.Same(t, transport, retry.base)
+
+		value, err := conjur.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Uses the transport directly when retries are disabled", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", RetryPolicy: RetryPolicy{Disabled: true}}
+		conjur, err := NewClientFromToken(config, sample_token, WithTransport(transport))
+		assert.NoError(t, err)
+		assert.Same(t, transport, conjur.GetHttpClient().Transport)
+	})
+}
+
+func TestConfigOptions(t *testing.T) {
+	config := Config{ApplianceURL: "https://conjur.example.com", CredentialStorage: "none"}
+
+	t.Run("WithAccount sets the account before validation", func(t *testing.T) {
+		_, err := NewClientFromToken(config, sample_token)
+		assert.EqualError(t, err, "must specify an Account")
+
+		conjur, err := NewClientFromToken(config, sample_token, WithAccount("cucumber"))
+		assert.NoError(t, err)
+		assert.Equal(t, "cucumber", conjur.GetConfig().Account)
+	})
+
+	t.Run("WithHTTPTimeout rounds up to whole seconds", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token, WithAccount("cucumber"), WithHTTPTimeout(1500*time.Millisecond))
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, conjur.GetHttpClient().Timeout)
+
+		conjur, err = NewClientFromToken(config, sample_token, WithAccount("cucumber"), WithHTTPTimeout(0))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), conjur.GetHttpClient().Timeout)
+	})
+
+	t.Run("WithRetryPolicy sets the retry policy", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token, WithAccount("cucumber"), WithRetryPolicy(RetryPolicy{Disabled: true}))
+		assert.NoError(t, err)
+		_, retried := conjur.GetHttpClient().Transport.(*retryTransport)
+		assert.False(t, retried)
+	})
+
+	t.Run("Config options apply to WithTransport regardless of order", func(t *testing.T) {
+		transport := &headerTransport{base: http.DefaultTransport, header: "injected"}
+		conjur, err := NewClientFromToken(config, sample_token, WithTransport(transport), WithAccount("cucumber"), WithHTTPTimeout(3*time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Second, conjur.GetHttpClient().Timeout)
+		retry, ok := conjur.GetHttpClient().Transport.(*retryTransport)
+		assert.True(t, ok)
+		assert.Same(t, transport, retry.base)
+	})
+
+	t.Run("The last of WithHTTPClient and WithTransport wins", func(t *testing.T) {
+		httpClient := &http.Client{}
+		transport := &headerTransport{base: http.DefaultTransport, header: "injected"}
+
+		conjur, err := NewClientFromToken(config, sample_token, WithAccount("cucumber"), WithTransport(transport), WithHTTPClient(httpClient))
+		assert.NoError(t, err)
+		assert.Same(t, httpClient, conjur.GetHttpClient())
+
+		conjur, err = NewClientFromToken(config, sample_token, WithAccount("cucumber"), WithHTTPClient(httpClient), WithTransport(transport))
+		assert.NoError(t, err)
+		assert.NotSame(t, httpClient, conjur.GetHttpClient())
+	})
+}
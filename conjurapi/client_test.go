@@ -1,17 +1,31 @@
 package conjurapi
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var sample_cert = `
@@ -74,6 +88,107 @@ func TestNewClientFromTokenFile(t *testing.T) {
 		assert.EqualError(t, err, "open fake-path: no such file or directory")
 		assert.Nil(t, client)
 	})
+	t.Run("Picks up tokens rewritten in the file", func(t *testing.T) {
+		var authorization string
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.Write([]byte("secret"))
+		}))
+		defer mockConjurServer.Close()
+
+		tokenFile := filepath.Join(t.TempDir(), "access-token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte(sample_token), 0600))
+
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+		conjur, err := NewClientFromTokenFile(config, tokenFile)
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db-password")
+		require.NoError(t, err)
+		assert.Contains(t, authorization, base64.StdEncoding.EncodeToString([]byte(sample_token)))
+
+		payload := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"host/app","iat":%d}`, time.Now().Unix())))
+		newToken := fmt.Sprintf(`{"protected":"e30=","payload":"%s","signature":"c2ln"}`, payload)
+		require.NoError(t, os.WriteFile(tokenFile, []byte(newToken), 0600))
+		// Make sure the modification is visible on filesystems with coarse mtimes
+		require.NoError(t, os.Chtimes(tokenFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+		_, err = conjur.RetrieveSecret("db-password")
+		require.NoError(t, err)
+		assert.Contains(t, authorization, base64.StdEncoding.EncodeToString([]byte(newToken)))
+	})
+}
+
+func TestClient_TokenExpiresAt(t *testing.T) {
+	config := Config{Account: "cucumber", ApplianceURL: "https://conjur.example.com", CredentialStorage: "none"}
+
+	t.Run("Returns the expiry of a pre-fetched token", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		expiresAt, err := conjur.TokenExpiresAt()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(4103379164, 0), expiresAt)
+	})
+
+	t.Run("Accepts base64-encoded tokens from the environment", func(t *testing.T) {
+		t.Setenv("CONJUR_AUTHN_TOKEN", base64.StdEncoding.EncodeToString([]byte(sample_token)))
+		conjur, err := NewClientFromEnvironment(config)
+		require.NoError(t, err)
+
+		expiresAt, err := conjur.TokenExpiresAt()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Unix(4103379164, 0), expiresAt)
+	})
+
+	t.Run("Returns an error for invalid tokens", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, "not-a-token")
+		require.NoError(t, err)
+
+		_, err = conjur.TokenExpiresAt()
+		assert.ErrorContains(t, err, "unable to unmarshal token")
+	})
+}
+
+func TestClient_TokenRefreshAt(t *testing.T) {
+	config := Config{Account: "cucumber", ApplianceURL: "https://conjur.example.com", CredentialStorage: "none"}
+	issuedAt, expiresAt := time.Unix(1510753259, 0), time.Unix(4103379164, 0)
+
+	t.Run("Defaults to 85% of the token's lifetime", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		refreshAt, err := conjur.TokenRefreshAt()
+		assert.NoError(t, err)
+		assert.Equal(t, issuedAt.Add(time.Duration(float64(expiresAt.Sub(issuedAt))*0.85)), refreshAt)
+	})
+
+	t.Run("Uses the configured threshold", func(t *testing.T) {
+		config := config
+		config.TokenRefreshThreshold = 0.5
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		refreshAt, err := conjur.TokenRefreshAt()
+		assert.NoError(t, err)
+		assert.Equal(t, issuedAt.Add(expiresAt.Sub(issuedAt)/2), refreshAt)
+	})
+
+	t.Run("Refreshes tokens once they pass the threshold", func(t *testing.T) {
+		authenticator := &gatedAuthenticator{first: tokenExpiringIn(5 * time.Minute), release: make(chan struct{})}
+		close(authenticator.release)
+
+		conjur, err := NewClientFromAuthenticator(config, authenticator)
+		require.NoError(t, err)
+		require.NoError(t, conjur.RefreshToken())
+		assert.False(t, conjur.NeedsTokenRefresh())
+
+		// The token was issued a minute ago and lives for six
+		conjur.config.TokenRefreshThreshold = 0.1
+		assert.True(t, conjur.NeedsTokenRefresh())
+		require.NoError(t, conjur.RefreshToken())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+	})
 }
 
 func TestNewClientFromEnvironment(t *testing.T) {
@@ -112,7 +227,7 @@ func TestNewClientFromEnvironment(t *testing.T) {
 	t.Run("Returns error when config is invalid", func(t *testing.T) {
 		config := Config{Account: ""}
 		client, err := NewClientFromEnvironment(config)
-		assert.ErrorContains(t, err, "Must specify an Account")
+		assert.ErrorContains(t, err, "must specify an Account")
 		assert.Nil(t, client)
 	})
 
@@ -122,7 +237,7 @@ func TestNewClientFromEnvironment(t *testing.T) {
 		t.Setenv("CONJUR_AUTHN_API_KEY", "")
 
 		client, err := NewClientFromEnvironment(config)
-		assert.EqualError(t, err, "No valid credentials found. Please login again.")
+		assert.EqualError(t, err, "no valid credentials found, please login again")
 		assert.Nil(t, client)
 	})
 
@@ -232,13 +347,401 @@ func TestNewClientFromJwt(t *testing.T) {
 	})
 }
 
+func TestNewClientFromJWTAuthenticator(t *testing.T) {
+	t.Run("Has authenticator of type JWTAuthenticator", func(t *testing.T) {
+		config := Config{Account: "myaccount", ApplianceURL: "appliance-url", AuthnType: "jwt", ServiceID: "jwt-service", JWTContent: "jwt-token"}
+		client, err := NewClientFromJWTAuthenticator(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.JWTAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is jwt", func(t *testing.T) {
+		config := Config{Account: "myaccount", ApplianceURL: "appliance-url", AuthnType: "jwt", ServiceID: "jwt-service", JWTFilePath: "jwt-file"}
+		client, err := NewClientFromEnvironment(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.JWTAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Authenticates against authn-jwt", func(t *testing.T) {
+		mockConjurServer := mockConjurServerWithJWT()
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "myaccount", ApplianceURL: mockConjurServer.URL, AuthnType: "jwt", ServiceID: "jwt-service", JWTContent: "jwt-token"}
+		client, err := NewClientFromJWTAuthenticator(config)
+		assert.NoError(t, err)
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "test-api-key", string(token))
+	})
+
+	t.Run("Appends JWT Host ID to authn URL", func(t *testing.T) {
+		mockConjurServer := mockConjurServerWithJWT()
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "myaccount", ApplianceURL: mockConjurServer.URL, AuthnType: "jwt", ServiceID: "jwt-service", JWTContent: "jwt-token", JWTHostID: "my-host"}
+		client, err := NewClientFromJWTAuthenticator(config)
+		assert.NoError(t, err)
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "test-api-key-from-host", string(token))
+	})
+
+	t.Run("Returns error with incorrect JWT", func(t *testing.T) {
+		mockConjurServer := mockConjurServerWithJWT()
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "myaccount", ApplianceURL: mockConjurServer.URL, AuthnType: "jwt", ServiceID: "jwt-service", JWTContent: "incorrect-jwt-token"}
+		client, err := NewClientFromJWTAuthenticator(config)
+		assert.NoError(t, err)
+
+		_, err = client.authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "401 Unauthorized")
+	})
+}
+
+func TestNewClientFromK8s(t *testing.T) {
+	login := "host/conjur/authn-k8s/my-id/apps/my-app"
+
+	t.Run("Has authenticator of type K8sAuthenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "k8s", ServiceID: "my-id"}
+		client, err := NewClientFromK8s(config, login, "my-pod", "my-namespace")
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.K8sAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is k8s", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "k8s", ServiceID: "my-id"}
+		t.Setenv("CONJUR_AUTHN_LOGIN", login)
+		t.Setenv("MY_POD_NAME", "my-pod")
+		t.Setenv("MY_POD_NAMESPACE", "my-namespace")
+
+		client, err := NewClientFromEnvironment(config)
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.K8sAuthenticator{}, client.authenticator)
+
+		authenticator := client.authenticator.(*authn.K8sAuthenticator)
+		assert.Equal(t, login, authenticator.Login)
+		assert.Equal(t, "my-pod", authenticator.PodName)
+		assert.Equal(t, "my-namespace", authenticator.PodNamespace)
+	})
+
+	t.Run("Injects client certificate and authenticates over mutual TLS", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "client.pem")
+		mockConjurServer := mockConjurServerWithK8s(t, certPath)
+		defer mockConjurServer.Close()
+
+		serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mockConjurServer.Certificate().Raw})
+		config := Config{
+			Account:      "cucumber",
+			ApplianceURL: mockConjurServer.URL,
+			SSLCert:      string(serverCert),
+			AuthnType:    "k8s",
+			ServiceID:    "my-id",
+		}
+		client, err := NewClientFromK8s(config, login, "my-pod", "my-namespace")
+		assert.NoError(t, err)
+		client.authenticator.(*authn.K8sAuthenticator).ClientCertPath = certPath
+
+		err = client.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, sample_token, string(client.authToken.Raw()))
+	})
+}
+
+func TestNewClientFromIAM(t *testing.T) {
+	login := "host/myapp/123456789012/MyRole"
+
+	t.Run("Has authenticator of type IAMAuthenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "iam", ServiceID: "prod"}
+		client, err := NewClientFromIAM(config, login)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.IAMAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is iam", func(t *testing.T) {
+		t.Setenv("CONJUR_AUTHN_LOGIN", login)
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "iam", ServiceID: "prod"}
+		client, err := NewClientFromEnvironment(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.IAMAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Authenticates against authn-iam with signed headers", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "session-token")
+
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.EscapedPath() != "/authn-iam/prod/account/host%2Fmyapp%2F123456789012%2FMyRole/authenticate" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			headers := map[string]string{}
+			if err := json.Unmarshal(body, &headers); err != nil || headers["x-amz-security-token"] != "session-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("iam-token"))
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "account", ApplianceURL: mockConjurServer.URL, AuthnType: "iam", ServiceID: "prod"}
+		client, err := NewClientFromIAM(config, login)
+		assert.NoError(t, err)
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "iam-token", string(token))
+	})
+}
+
+func TestNewClientFromAzure(t *testing.T) {
+	t.Run("Has authenticator of type AzureAuthenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "azure", ServiceID: "prod", AzureClientID: "client-id"}
+		client, err := NewClientFromAzure(config, "host/azure-apps/my-app")
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.AzureAuthenticator{}, client.authenticator)
+		assert.Equal(t, "client-id", client.authenticator.(*authn.AzureAuthenticator).ClientID)
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is azure", func(t *testing.T) {
+		t.Setenv("CONJUR_AUTHN_LOGIN", "host/azure-apps/my-app")
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "azure", ServiceID: "prod"}
+		client, err := NewClientFromEnvironment(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.AzureAuthenticator{}, client.authenticator)
+		assert.Equal(t, "host/azure-apps/my-app", client.authenticator.(*authn.AzureAuthenticator).Login)
+	})
+
+	t.Run("Exchanges the Azure token with authn-azure", func(t *testing.T) {
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" || r.URL.EscapedPath() != "/authn-azure/prod/account/host%2Fazure-apps%2Fmy-app/authenticate" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			r.ParseForm()
+			if r.Form.Get("jwt") != "azure-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("azure-conjur-token"))
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "account", ApplianceURL: mockConjurServer.URL, AuthnType: "azure", ServiceID: "prod"}
+		client, err := NewClientFromAzure(config, "host/azure-apps/my-app")
+		assert.NoError(t, err)
+
+		token, err := client.AzureAuthenticate("host/azure-apps/my-app", "azure-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "azure-conjur-token", string(token))
+
+		_, err = client.AzureAuthenticate("host/azure-apps/my-app", "wrong-token")
+		assert.ErrorContains(t, err, "401")
+	})
+}
+
+func TestNewClientFromAuthnLocal(t *testing.T) {
+	t.Run("Has authenticator of type LocalAuthenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "local", AuthnLocalSocket: "/tmp/authn-local.socket"}
+		client, err := NewClientFromAuthnLocal(config, "host/myapp")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &authn.LocalAuthenticator{SocketPath: "/tmp/authn-local.socket", Account: "account", Login: "host/myapp"}, client.authenticator)
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is local", func(t *testing.T) {
+		t.Setenv("CONJUR_AUTHN_LOGIN", "host/myapp")
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "local"}
+		client, err := NewClientFromEnvironment(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.LocalAuthenticator{}, client.authenticator)
+		assert.Equal(t, "host/myapp", client.authenticator.(*authn.LocalAuthenticator).Login)
+	})
+}
+
+func TestNewClientFromGCP(t *testing.T) {
+	t.Run("Has authenticator of type GCPAuthenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "gcp"}
+		client, err := NewClientFromGCP(config, "host/gcp-apps/my-app")
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.GCPAuthenticator{}, client.authenticator)
+		assert.Equal(t, "conjur/account/host/gcp-apps/my-app", client.authenticator.(*authn.GCPAuthenticator).Audience())
+	})
+
+	t.Run("Is used by NewClientFromEnvironment when AuthnType is gcp", func(t *testing.T) {
+		t.Setenv("CONJUR_AUTHN_LOGIN", "host/gcp-apps/my-app")
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "gcp"}
+		client, err := NewClientFromEnvironment(config)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &authn.GCPAuthenticator{}, client.authenticator)
+	})
+
+	t.Run("Authenticates against authn-gcp", func(t *testing.T) {
+		metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("gcp-identity-token"))
+		}))
+		defer metadataServer.Close()
+
+		mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			if r.Method != "POST" || r.URL.Path != "/authn-gcp/account/authenticate" || r.Form.Get("jwt") != "gcp-identity-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("gcp-conjur-token"))
+		}))
+		defer mockConjurServer.Close()
+
+		config := Config{Account: "account", ApplianceURL: mockConjurServer.URL, AuthnType: "gcp"}
+		client, err := NewClientFromGCP(config, "host/gcp-apps/my-app")
+		assert.NoError(t, err)
+		client.authenticator.(*authn.GCPAuthenticator).MetadataURL = metadataServer.URL
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "gcp-conjur-token", string(token))
+	})
+}
+
+type customAuthenticator struct {
+	config authn.FactoryConfig
+}
+
+func (a *customAuthenticator) RefreshToken() ([]byte, error) {
+	return []byte(sample_token), nil
+}
+
+func (a *customAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestNewClientFromAuthenticator(t *testing.T) {
+	t.Run("Uses the provided authenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url"}
+		authenticator := &customAuthenticator{}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+
+		assert.NoError(t, err)
+		assert.Same(t, authenticator, client.GetAuthenticator())
+	})
+
+	t.Run("Returns error without an authenticator", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "appliance-url"}
+		_, err := NewClientFromAuthenticator(config, nil)
+
+		assert.EqualError(t, err, "must specify an Authenticator")
+	})
+
+	t.Run("Selects a registered authenticator by AuthnType", func(t *testing.T) {
+		if _, ok := authn.Lookup("test-custom"); !ok {
+			authn.Register("test-custom", func(config authn.FactoryConfig) (authn.Authenticator, error) {
+				return &customAuthenticator{config: config}, nil
+			})
+		}
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "test-custom", ServiceID: "my-service"}
+		client, err := NewClientFromEnvironment(config)
+		assert.NoError(t, err)
+
+		authenticator, ok := client.GetAuthenticator().(*customAuthenticator)
+		assert.True(t, ok)
+		assert.Equal(t, "account", authenticator.config.Account)
+		assert.Equal(t, "my-service", authenticator.config.ServiceID)
+		assert.Same(t, client.GetHttpClient(), authenticator.config.HTTPClient)
+
+		err = client.RefreshToken()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Returns factory errors", func(t *testing.T) {
+		if _, ok := authn.Lookup("test-failing"); !ok {
+			authn.Register("test-failing", func(config authn.FactoryConfig) (authn.Authenticator, error) {
+				return nil, fmt.Errorf("missing settings")
+			})
+		}
+
+		config := Config{Account: "account", ApplianceURL: "appliance-url", AuthnType: "test-failing"}
+		_, err := NewClientFromEnvironment(config)
+
+		assert.EqualError(t, err, "missing settings")
+	})
+}
+
+func mockConjurServerWithK8s(t *testing.T, certPath string) *httptest.Server {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	mockConjurServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/authn-k8s/my-id/inject_client_cert" {
+			// Sign the CSR and "inject" it by writing it to the pod's cert path
+			body, _ := io.ReadAll(r.Body)
+			block, _ := pem.Decode(body)
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil || r.Header.Get("Host-Id-Prefix") != "host.conjur.authn-k8s.my-id.apps" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      csr.Subject,
+				NotBefore:    time.Now().Add(-time.Minute),
+				NotAfter:     time.Now().Add(time.Hour),
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}
+			der, _ := x509.CreateCertificate(rand.Reader, template, caTemplate, csr.PublicKey, caKey)
+			os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+			w.WriteHeader(http.StatusAccepted)
+		} else if r.URL.EscapedPath() == "/authn-k8s/my-id/cucumber/host%2Fconjur%2Fauthn-k8s%2Fmy-id%2Fapps%2Fmy-app/authenticate" {
+			// Only authenticate requests presenting the injected certificate
+			if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "my-app" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(sample_token))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	mockConjurServer.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	mockConjurServer.StartTLS()
+	return mockConjurServer
+}
+
 func Test_newClientWithAuthenticator(t *testing.T) {
 	t.Run("Returns nil and error for invalid config", func(t *testing.T) {
 		client, err := newClientWithAuthenticator(Config{}, nil)
 
 		assert.Nil(t, client)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "Must specify")
+		assert.Contains(t, err.Error(), "must specify")
 	})
 
 	t.Run("Returns client without error for valid config", func(t *testing.T) {
@@ -411,7 +914,7 @@ func TestClient_newHTTPSClient(t *testing.T) {
 		config := Config{}
 		client, err := newHTTPSClient([]byte("invalid cert"), config)
 
-		assert.EqualError(t, err, "Can't append Conjur SSL cert")
+		assert.EqualError(t, err, "can't append Conjur SSL cert")
 		assert.Nil(t, client)
 	})
 	t.Run("New HTTPS client with valid cert", func(t *testing.T) {
@@ -450,6 +953,47 @@ func TestClient_HttpClientTimeoutValue(t *testing.T) {
 	})
 }
 
+func TestClient_HttpClientConnectionPool(t *testing.T) {
+	transportOf := func(client *http.Client) *http.Transport {
+		transport := client.Transport
+		if retry, ok := transport.(*retryTransport); ok {
+			transport = retry.base
+		}
+		return transport.(*http.Transport)
+	}
+
+	t.Run("Applies connection pool settings to the transport", func(t *testing.T) {
+		config := Config{
+			Account:             "account",
+			ApplianceURL:        "http://appliance-url",
+			MaxIdleConns:        200,
+			MaxIdleConnsPerHost: 50,
+			MaxConnsPerHost:     64,
+			IdleConnTimeout:     45 * time.Second,
+		}
+		client, err := createHttpClient(config)
+		assert.NoError(t, err)
+
+		transport := transportOf(client)
+		assert.Equal(t, 200, transport.MaxIdleConns)
+		assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 64, transport.MaxConnsPerHost)
+		assert.Equal(t, 45*time.Second, transport.IdleConnTimeout)
+		assert.NotSame(t, http.DefaultTransport, transport)
+	})
+
+	t.Run("Keeps the HTTPS settings and defaults for unset values", func(t *testing.T) {
+		config := Config{Account: "account", ApplianceURL: "https://appliance-url", SSLCert: sample_cert, MaxIdleConnsPerHost: 50}
+		client, err := createHttpClient(config)
+		assert.NoError(t, err)
+
+		transport := transportOf(client)
+		assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 0, transport.MaxIdleConns)
+		assert.NotNil(t, transport.TLSClientConfig.RootCAs)
+	})
+}
+
 func mockConjurServerWithJWT() *httptest.Server {
 	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Listen for requests to the JWT authenticate endpoint
@@ -473,3 +1017,260 @@ func mockConjurServerWithJWT() *httptest.Server {
 	}))
 	return mockConjurServer
 }
+
+// generateTestClientCert returns a self-signed client certificate and its
+// private key, PEM-encoded.
+func generateTestClientCert(t *testing.T, commonName string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestNewClient_SSLClientCert(t *testing.T) {
+	mockConjurServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "my-app" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("secret"))
+	}))
+	mockConjurServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	mockConjurServer.StartTLS()
+	defer mockConjurServer.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mockConjurServer.Certificate().Raw})
+	certPEM, keyPEM := generateTestClientCert(t, "my-app")
+
+	t.Run("Presents the client certificate from PEM", func(t *testing.T) {
+		config := Config{
+			Account:           "cucumber",
+			ApplianceURL:      mockConjurServer.URL,
+			CredentialStorage: "none",
+			SSLCert:           string(caPEM),
+			SSLClientCert:     certPEM,
+			SSLClientKey:      keyPEM,
+		}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		value, err := conjur.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Presents the client certificate from files", func(t *testing.T) {
+		certPath, err := TempFileForTesting("client-cert", certPEM, t)
+		assert.NoError(t, err)
+		keyPath, err := TempFileForTesting("client-key", keyPEM, t)
+		assert.NoError(t, err)
+
+		config := Config{
+			Account:           "cucumber",
+			ApplianceURL:      mockConjurServer.URL,
+			CredentialStorage: "none",
+			SSLCert:           string(caPEM),
+			SSLClientCertPath: certPath,
+			SSLClientKeyPath:  keyPath,
+		}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		value, err := conjur.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Fails the handshake without a client certificate", func(t *testing.T) {
+		config := Config{
+			Account:           "cucumber",
+			ApplianceURL:      mockConjurServer.URL,
+			CredentialStorage: "none",
+			SSLCert:           string(caPEM),
+			RetryPolicy:       RetryPolicy{Disabled: true},
+		}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db-password")
+		assert.Error(t, err)
+	})
+
+	t.Run("Returns error for an invalid key pair", func(t *testing.T) {
+		otherCertPEM, _ := generateTestClientCert(t, "other")
+		config := Config{
+			Account:       "cucumber",
+			ApplianceURL:  mockConjurServer.URL,
+			SSLClientCert: otherCertPEM,
+			SSLClientKey:  keyPEM,
+		}
+
+		_, err := NewClientFromToken(config, sample_token)
+		assert.ErrorContains(t, err, "can't load Conjur SSL client cert")
+	})
+}
+
+func TestNewClient_TLSVerification(t *testing.T) {
+	mockConjurServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer mockConjurServer.Close()
+
+	serverCert := mockConjurServer.Certificate()
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Raw}))
+	spkiHash := sha256.Sum256(serverCert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(spkiHash[:])
+
+	retrieve := func(config Config) ([]byte, error) {
+		config.Account = "cucumber"
+		config.ApplianceURL = mockConjurServer.URL
+		config.CredentialStorage = "none"
+		config.RetryPolicy = RetryPolicy{Disabled: true}
+
+		conjur, err := NewClientFromToken(config, sample_token)
+		if err != nil {
+			return nil, err
+		}
+		return conjur.RetrieveSecret("db-password")
+	}
+
+	t.Run("Trusts CAs from multiple bundle files", func(t *testing.T) {
+		otherCertPEM, _ := generateTestClientCert(t, "other-ca")
+		otherPath, err := TempFileForTesting("other-ca", otherCertPEM, t)
+		assert.NoError(t, err)
+		caPath, err := TempFileForTesting("server-ca", caPEM, t)
+		assert.NoError(t, err)
+
+		value, err := retrieve(Config{SSLCertPaths: []string{otherPath, caPath}})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Rejects servers signed by an untrusted CA", func(t *testing.T) {
+		_, err := retrieve(Config{})
+		assert.ErrorContains(t, err, "certificate")
+	})
+
+	t.Run("Accepts a server matching a pinned public key", func(t *testing.T) {
+		value, err := retrieve(Config{SSLCert: caPEM, SSLPinnedPublicKeys: []string{"sha256/" + pin}})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Rejects a server not matching any pinned public key", func(t *testing.T) {
+		otherHash := sha256.Sum256([]byte("other key"))
+		otherPin := base64.StdEncoding.EncodeToString(otherHash[:])
+
+		_, err := retrieve(Config{SSLCert: caPEM, SSLPinnedPublicKeys: []string{otherPin}})
+		assert.ErrorContains(t, err, "server certificate does not match any pinned public key")
+	})
+
+	t.Run("Verifies the certificate against SSLServerName", func(t *testing.T) {
+		value, err := retrieve(Config{SSLCert: caPEM, SSLServerName: "example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+
+		_, err = retrieve(Config{SSLCert: caPEM, SSLServerName: "conjur.example.org"})
+		assert.ErrorContains(t, err, "certificate is valid for example.com")
+	})
+
+	t.Run("Trusts the system's certificates alongside SSLCert", func(t *testing.T) {
+		value, err := retrieve(Config{SSLCert: caPEM, SSLUseSystemCerts: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Skips verification when SSLInsecureSkipVerify is set", func(t *testing.T) {
+		value, err := retrieve(Config{SSLInsecureSkipVerify: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Enforces pins when SSLInsecureSkipVerify is set", func(t *testing.T) {
+		otherHash := sha256.Sum256([]byte("other key"))
+		otherPin := base64.StdEncoding.EncodeToString(otherHash[:])
+
+		_, err := retrieve(Config{SSLInsecureSkipVerify: true, SSLPinnedPublicKeys: []string{otherPin}})
+		assert.ErrorContains(t, err, "does not match any pinned public key")
+	})
+}
+
+func TestNewClient_PinnedPublicKeyChain(t *testing.T) {
+	// The server presents its own certificate followed by the pinned one,
+	// which doesn't vouch for the connection.
+	pinnedPEM, _ := generateTestClientCert(t, "pinned")
+	pinnedBlock, _ := pem.Decode([]byte(pinnedPEM))
+	pinnedCert, err := x509.ParseCertificate(pinnedBlock.Bytes)
+	require.NoError(t, err)
+	pinHash := sha256.Sum256(pinnedCert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(pinHash[:])
+
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "attacker"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, template, template, &serverKey.PublicKey, serverKey)
+	require.NoError(t, err)
+	serverPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverDER}))
+
+	mockConjurServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	mockConjurServer.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{serverDER, pinnedCert.Raw},
+		PrivateKey:  serverKey,
+	}}}
+	mockConjurServer.StartTLS()
+	defer mockConjurServer.Close()
+
+	retrieve := func(config Config) ([]byte, error) {
+		config.Account = "cucumber"
+		config.ApplianceURL = mockConjurServer.URL
+		config.CredentialStorage = "none"
+		config.RetryPolicy = RetryPolicy{Disabled: true}
+		config.SSLPinnedPublicKeys = []string{pin}
+
+		conjur, err := NewClientFromToken(config, sample_token)
+		if err != nil {
+			return nil, err
+		}
+		return conjur.RetrieveSecret("db-password")
+	}
+
+	t.Run("Ignores pinned certificates outside the verified chain", func(t *testing.T) {
+		_, err := retrieve(Config{SSLCert: serverPEM})
+		assert.ErrorContains(t, err, "does not match any pinned public key")
+	})
+
+	t.Run("Matches only the server's certificate without verification", func(t *testing.T) {
+		_, err := retrieve(Config{SSLInsecureSkipVerify: true})
+		assert.ErrorContains(t, err, "does not match any pinned public key")
+	})
+}
+
+func generateTestTLSCertificate(t *testing.T) tls.Certificate {
+	certPEM, keyPEM := generateTestClientCert(t, "test")
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	assert.NoError(t, err)
+	return cert
+}
@@ -0,0 +1,81 @@
+package conjurapi
+
+import (
+	"fmt"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+)
+
+// Clone returns a client which shares this client's Config, HTTP client and
+// credential storage, and so its connection pool, but keeps its own access
+// token. Cloning is cheap, and clones are independent: refreshing the token of
+// one doesn't affect the others. A TokenRefresher started on this client
+// doesn't renew the clone's token.
+func (c *Client) Clone() *Client {
+	clone := c.clone(c.GetAuthenticator())
+	if token := c.getAuthToken(); token != nil {
+		// The token is copied since it may be wiped once this client replaces
+		// it
+		if copied, err := authn.NewToken(append([]byte(nil), token.Raw()...)); err == nil {
+			clone.authToken = copied
+		}
+	}
+	return clone
+}
+
+// WithAuthenticator returns a clone of the client, as with Clone, which
+// authenticates with other credentials. API key and JWT authenticators
+// without an Authenticate function authenticate through the clone, like those
+// created by NewClientFromKey and NewClientFromJWTAuthenticator.
+func (c *Client) WithAuthenticator(authenticator Authenticator) *Client {
+	clone := c.clone(authenticator)
+	switch a := authenticator.(type) {
+	case *authn.APIKeyAuthenticator:
+		if a.Authenticate == nil && a.AuthenticateWithContext == nil {
+			a.Authenticate = clone.Authenticate
+			a.AuthenticateWithContext = clone.AuthenticateWithContext
+		}
+	case *authn.JWTAuthenticator:
+		if a.Authenticate == nil && a.AuthenticateWithContext == nil {
+			a.Authenticate = clone.JWTAuthenticate
+			a.AuthenticateWithContext = clone.JWTAuthenticateWithContext
+		}
+	}
+	return clone
+}
+
+// WithRole returns a clone of the client, as with Clone, which authenticates
+// as another role, given as a login such as "host/myapp" or a role ID such as
+// "cucumber:host:myapp". The client's authenticator must be able to obtain
+// tokens for any role, such as authn.LocalAuthenticator.
+func (c *Client) WithRole(actingAs string) (*Client, error) {
+	authenticator, ok := c.GetAuthenticator().(authn.RoleAuthenticator)
+	if !ok {
+		return nil, fmt.Errorf("the client's authenticator can't authenticate as other roles")
+	}
+
+	login := actingAs
+	if _, kind, id, err := c.parseID(actingAs); err == nil {
+		login = CreatedRole{ID: c.config.Account + ":" + kind + ":" + id}.Login()
+	}
+	return c.clone(authenticator.ForRole(login)), nil
+}
+
+func (c *Client) clone(authenticator Authenticator) *Client {
+	return &Client{
+		config:                c.config,
+		httpClient:            c.httpClient,
+		authenticator:         authenticator,
+		storage:               c.storage,
+		logger:                c.logger,
+		telemetry:             c.telemetry,
+		transport:             c.transport,
+		wipeTokens:            c.wipeTokens,
+		tokenCache:            c.tokenCache,
+		tokenCachePassphrase:  c.tokenCachePassphrase,
+		noUnauthorizedReplay:  c.noUnauthorizedReplay,
+		replayAllUnauthorized: c.replayAllUnauthorized,
+		noRequestIDs:          c.noRequestIDs,
+		userAgent:             c.userAgent,
+	}
+}
@@ -0,0 +1,65 @@
+package conjurapi
+
+import (
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Clone(t *testing.T) {
+	config := Config{Account: "cucumber", ApplianceURL: "https://conjur.example.com", CredentialStorage: "none"}
+
+	t.Run("Shares the HTTP client but not the token", func(t *testing.T) {
+		authenticator := &countingAuthenticator{}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+
+		clone := client.Clone()
+		assert.Same(t, client.httpClient, clone.httpClient)
+		assert.Equal(t, sample_token, string(clone.getAuthToken().Raw()))
+		assert.NotSame(t, client.getAuthToken(), clone.getAuthToken())
+
+		require.NoError(t, clone.ForceRefreshToken())
+		assert.Equal(t, int32(2), authenticator.calls)
+	})
+
+	t.Run("Authenticates with other credentials", func(t *testing.T) {
+		client, err := NewClientFromAuthenticator(config, &countingAuthenticator{})
+		require.NoError(t, err)
+
+		clone := client.WithAuthenticator(&authn.APIKeyAuthenticator{LoginPair: authn.LoginPair{Login: "host/app", APIKey: "key"}})
+		require.IsType(t, &authn.APIKeyAuthenticator{}, clone.authenticator)
+		assert.NotNil(t, clone.authenticator.(*authn.APIKeyAuthenticator).Authenticate)
+		assert.Same(t, client.httpClient, clone.httpClient)
+		assert.Nil(t, clone.getAuthToken())
+	})
+
+	t.Run("Impersonates roles with authn-local", func(t *testing.T) {
+		local := &authn.LocalAuthenticator{Account: "cucumber", Login: "admin"}
+		client, err := NewClientFromAuthenticator(config, local)
+		require.NoError(t, err)
+
+		for roleID, login := range map[string]string{
+			"host/app":           "host/app",
+			"cucumber:host:app":  "host/app",
+			"user:alice":         "alice",
+			"cucumber:layer:web": "layer/web",
+		} {
+			clone, err := client.WithRole(roleID)
+			require.NoError(t, err)
+			assert.Equal(t, login, clone.authenticator.(*authn.LocalAuthenticator).Login)
+		}
+		assert.Equal(t, "admin", local.Login)
+	})
+
+	t.Run("Rejects authenticators which can't impersonate roles", func(t *testing.T) {
+		client, err := NewClientFromAuthenticator(config, &countingAuthenticator{})
+		require.NoError(t, err)
+
+		_, err = client.WithRole("host/app")
+		assert.EqualError(t, err, "the client's authenticator can't authenticate as other roles")
+	})
+}
@@ -0,0 +1,123 @@
+package conjurapi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+const (
+	// ConjurCloudAccount is the account of every Conjur Cloud tenant.
+	ConjurCloudAccount = "conjur"
+	// ConjurCloudIdentityServiceID is the authn-oidc service through which
+	// Conjur Cloud accepts CyberArk Identity tokens.
+	ConjurCloudIdentityServiceID = "cyberark"
+)
+
+// ConjurCloudSuffixes are the domain suffixes of Conjur Cloud tenants, which
+// are recognized without setting Config.ConjurCloud.
+var ConjurCloudSuffixes = []string{".secretsmgr.cyberark.cloud", ".secretsmgr.integration-cyberark.cloud"}
+
+// IsConjurCloud reports whether the config points to a Conjur Cloud tenant,
+// either because ConjurCloud is set or because the ApplianceURL is in one of
+// the ConjurCloudSuffixes domains.
+func (c *Config) IsConjurCloud() bool {
+	if c.ConjurCloud {
+		return true
+	}
+
+	u, err := url.Parse(c.ApplianceURL)
+	if err != nil {
+		return false
+	}
+	for _, suffix := range ConjurCloudSuffixes {
+		if strings.HasSuffix(u.Hostname(), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveConjurCloud adapts a Conjur Cloud config to the tenant's API, which
+// is served under /api, and defaults its Account.
+func (c *Config) resolveConjurCloud() {
+	if !c.IsConjurCloud() {
+		return
+	}
+
+	c.ApplianceURL = withAPIPath(c.ApplianceURL)
+	applianceURLs := make([]string, 0, len(c.ApplianceURLs))
+	for _, applianceURL := range c.ApplianceURLs {
+		applianceURLs = append(applianceURLs, withAPIPath(applianceURL))
+	}
+	if len(applianceURLs) > 0 {
+		c.ApplianceURLs = applianceURLs
+	}
+	if c.Account == "" {
+		c.Account = ConjurCloudAccount
+	}
+}
+
+// withAPIPath appends the /api path, under which Conjur Cloud and Conjur v4
+// serve their APIs, to an appliance URL which lacks it.
+func withAPIPath(applianceURL string) string {
+	applianceURL = strings.TrimSuffix(applianceURL, "/")
+	if applianceURL == "" || strings.HasSuffix(applianceURL, "/api") {
+		return applianceURL
+	}
+	return applianceURL + "/api"
+}
+
+// NewClientFromIdentity creates a client which authenticates to Conjur Cloud as
+// a CyberArk Identity service user. It obtains OAuth tokens from the Identity
+// tenant at identityURL, such as "https://abc1234.id.cyberark.cloud", with the
+// client credentials grant, and exchanges them for Conjur access tokens.
+func NewClientFromIdentity(config Config, identityURL, clientID, clientSecret string, opts ...ClientOption) (*Client, error) {
+	authenticator := &authn.IdentityAuthenticator{
+		IdentityURL:  identityURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}
+	client, err := newClientWithAuthenticator(
+		config,
+		authenticator,
+		opts...,
+	)
+	if err == nil {
+		authenticator.Authenticate = client.IdentityAuthenticate
+		authenticator.AuthenticateWithContext = client.IdentityAuthenticateWithContext
+	}
+	return client, err
+}
+
+// IdentityAuthenticate exchanges a CyberArk Identity token for a Conjur access
+// token, using the authn-oidc service given by the Config's ServiceID, or
+// ConjurCloudIdentityServiceID when unset.
+func (c *Client) IdentityAuthenticate(identityToken string) ([]byte, error) {
+	return c.IdentityAuthenticateWithContext(context.Background(), identityToken)
+}
+
+// IdentityAuthenticateWithContext is like IdentityAuthenticate but uses the
+// provided context for the underlying request.
+func (c *Client) IdentityAuthenticateWithContext(ctx context.Context, identityToken string) ([]byte, error) {
+	req, err := c.IdentityAuthenticateRequest(identityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationAuthn, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := response.DataResponse(res)
+
+	if err == nil && c.storage != nil {
+		c.storage.StoreAuthnToken(resp)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,119 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_IsConjurCloud(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{
+			name:     "Conjur Cloud tenant",
+			config:   Config{ApplianceURL: "https://acme.secretsmgr.cyberark.cloud"},
+			expected: true,
+		},
+		{
+			name:     "Conjur Cloud integration tenant",
+			config:   Config{ApplianceURL: "https://acme.secretsmgr.integration-cyberark.cloud/api"},
+			expected: true,
+		},
+		{
+			name:     "Explicit flag",
+			config:   Config{ApplianceURL: "https://conjur.example.com", ConjurCloud: true},
+			expected: true,
+		},
+		{
+			name:     "Self-hosted Conjur",
+			config:   Config{ApplianceURL: "https://conjur.example.com"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.config.IsConjurCloud())
+		})
+	}
+}
+
+func TestConfig_resolveConjurCloud(t *testing.T) {
+	t.Run("Adds the API path and default account", func(t *testing.T) {
+		applianceURLs := []string{"https://acme.secretsmgr.cyberark.cloud/", "https://acme-dr.secretsmgr.cyberark.cloud"}
+		config := Config{
+			ApplianceURL:  "https://acme.secretsmgr.cyberark.cloud/",
+			ApplianceURLs: applianceURLs,
+		}
+		config.resolveConjurCloud()
+
+		assert.Equal(t, "https://acme.secretsmgr.cyberark.cloud/api", config.ApplianceURL)
+		assert.Equal(t, []string{"https://acme.secretsmgr.cyberark.cloud/api", "https://acme-dr.secretsmgr.cyberark.cloud/api"}, config.ApplianceURLs)
+		assert.Equal(t, "conjur", config.Account)
+		assert.Equal(t, "https://acme.secretsmgr.cyberark.cloud/", applianceURLs[0])
+	})
+
+	t.Run("Keeps an existing API path", func(t *testing.T) {
+		config := Config{ApplianceURL: "https://conjur.example.com/api", Account: "acme", ConjurCloud: true}
+		config.resolveConjurCloud()
+
+		assert.Equal(t, "https://conjur.example.com/api", config.ApplianceURL)
+		assert.Equal(t, "acme", config.Account)
+	})
+
+	t.Run("Leaves self-hosted Conjur alone", func(t *testing.T) {
+		config := Config{ApplianceURL: "https://conjur.example.com"}
+		config.resolveConjurCloud()
+
+		assert.Equal(t, "https://conjur.example.com", config.ApplianceURL)
+		assert.Equal(t, "", config.Account)
+	})
+}
+
+func TestClient_NewClientFromIdentity(t *testing.T) {
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth2/platformtoken", r.URL.Path)
+		w.Write([]byte(`{"access_token":"identity-token","expires_in":3600}`))
+	}))
+	defer identity.Close()
+
+	conjur := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/authn-oidc/cyberark/conjur/authenticate":
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "identity-token", r.PostForm.Get("id_token"))
+			w.Write(tokenExpiringIn(8 * time.Minute))
+		case "/api/secrets/conjur/variable/data/db-password":
+			w.Write([]byte("secret"))
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer conjur.Close()
+
+	config := Config{ApplianceURL: conjur.URL, ConjurCloud: true, CredentialStorage: "none"}
+	client, err := NewClientFromIdentity(config, identity.URL, "svc@cyberark.cloud.1234", "s3cret")
+	require.NoError(t, err)
+
+	secret, err := client.RetrieveSecret("data/db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(secret))
+}
+
+func TestConfig_Validate_Identity(t *testing.T) {
+	config := Config{ApplianceURL: "https://acme.secretsmgr.cyberark.cloud", AuthnType: "identity"}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "must specify an IdentityURL when using identity")
+
+	config.IdentityURL = "https://abc1234.id.cyberark.cloud"
+	assert.NoError(t, config.Validate())
+}
@@ -1,72 +1,290 @@
 package conjurapi
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"os"
-	"path"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
 )
 
 const (
 	HttpTimeoutDefaultValue = 10
 )
 
-var supportedAuthnTypes = []string{"authn", "ldap", "oidc"}
+var supportedAuthnTypes = []string{"authn", "ldap", "oidc", "jwt", "k8s", "iam", "azure", "gcp", "local", "identity"}
+var authnTypesRequiringServiceID = []string{"ldap", "oidc", "jwt", "k8s", "iam", "azure"}
 
 type Config struct {
-	Account           string `yaml:"account,omitempty"`
-	ApplianceURL      string `yaml:"appliance_url,omitempty"`
-	NetRCPath         string `yaml:"netrc_path,omitempty"`
-	SSLCert           string `yaml:"-"`
-	SSLCertPath       string `yaml:"cert_file,omitempty"`
-	AuthnType         string `yaml:"authn_type,omitempty"`
-	ServiceID         string `yaml:"service_id,omitempty"`
-	CredentialStorage string `yaml:"credential_storage,omitempty"`
-	HttpTimeout       int    `yaml:"-"`
+	Account               string          `yaml:"account,omitempty" json:"account,omitempty"`
+	ApplianceURL          string          `yaml:"appliance_url,omitempty" json:"appliance_url,omitempty"`
+	ApplianceURLs         []string        `yaml:"appliance_urls,omitempty" json:"appliance_urls,omitempty"`
+	ReadApplianceURLs     []string        `yaml:"read_appliance_urls,omitempty" json:"read_appliance_urls,omitempty"`
+	NetRCPath             string          `yaml:"netrc_path,omitempty" json:"netrc_path,omitempty"`
+	SSLCert               string          `yaml:"-" json:"-"`
+	SSLCertPath           string          `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`
+	SSLCertPaths          []string        `yaml:"cert_files,omitempty" json:"cert_files,omitempty"`
+	SSLUseSystemCerts     bool            `yaml:"ssl_use_system_certs,omitempty" json:"ssl_use_system_certs,omitempty"`
+	AuthnType             string          `yaml:"authn_type,omitempty" json:"authn_type,omitempty"`
+	ServiceID             string          `yaml:"service_id,omitempty" json:"service_id,omitempty"`
+	CredentialStorage     string          `yaml:"credential_storage,omitempty" json:"credential_storage,omitempty"`
+	TokenCachePath        string          `yaml:"token_cache_path,omitempty" json:"token_cache_path,omitempty"`
+	NoTokenCache          bool            `yaml:"-" json:"-"`
+	HttpTimeout           int             `yaml:"-" json:"-"`
+	AuthnTimeout          time.Duration   `yaml:"-" json:"-"`
+	SecretReadTimeout     time.Duration   `yaml:"-" json:"-"`
+	PolicyLoadTimeout     time.Duration   `yaml:"-" json:"-"`
+	JWTHostID             string          `yaml:"jwt_host_id,omitempty" json:"jwt_host_id,omitempty"`
+	JWTContent            string          `yaml:"-" json:"-"`
+	JWTFilePath           string          `yaml:"jwt_file,omitempty" json:"jwt_file,omitempty"`
+	AzureClientID         string          `yaml:"azure_client_id,omitempty" json:"azure_client_id,omitempty"`
+	AzureResource         string          `yaml:"azure_resource,omitempty" json:"azure_resource,omitempty"`
+	AuthnLocalSocket      string          `yaml:"authn_local_socket,omitempty" json:"authn_local_socket,omitempty"`
+	SSLClientCert         string          `yaml:"-" json:"-"`
+	SSLClientCertPath     string          `yaml:"client_cert_file,omitempty" json:"client_cert_file,omitempty"`
+	SSLClientKey          string          `yaml:"-" json:"-"`
+	SSLClientKeyPath      string          `yaml:"client_key_file,omitempty" json:"client_key_file,omitempty"`
+	SSLPinnedPublicKeys   []string        `yaml:"ssl_pinned_public_keys,omitempty" json:"ssl_pinned_public_keys,omitempty"`
+	SSLServerName         string          `yaml:"ssl_server_name,omitempty" json:"ssl_server_name,omitempty"`
+	SSLInsecureSkipVerify bool            `yaml:"ssl_insecure_skip_verify,omitempty" json:"ssl_insecure_skip_verify,omitempty"`
+	MaxIdleConns          int             `yaml:"-" json:"-"`
+	MaxIdleConnsPerHost   int             `yaml:"-" json:"-"`
+	MaxConnsPerHost       int             `yaml:"-" json:"-"`
+	IdleConnTimeout       time.Duration   `yaml:"-" json:"-"`
+	RetryPolicy           RetryPolicy     `yaml:"-" json:"-"`
+	RateLimit             float64         `yaml:"-" json:"-"`
+	RateLimitBurst        int             `yaml:"-" json:"-"`
+	MaxResponseBodySize   int64           `yaml:"-" json:"-"`
+	TokenRefreshThreshold float64         `yaml:"-" json:"-"`
+	DialContext           DialContextFunc `yaml:"-" json:"-"`
+	Proxy                 string          `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	HTTPVersion           string          `yaml:"http_version,omitempty" json:"http_version,omitempty"`
+	RedirectPolicy        string          `yaml:"redirect_policy,omitempty" json:"redirect_policy,omitempty"`
+	ConjurCloud           bool            `yaml:"conjur_cloud,omitempty" json:"conjur_cloud,omitempty"`
+	IdentityURL           string          `yaml:"identity_url,omitempty" json:"identity_url,omitempty"`
+	V4                    bool            `yaml:"v4,omitempty" json:"v4,omitempty"`
 }
 
 func (c *Config) IsHttps() bool {
-	return c.SSLCertPath != "" || c.SSLCert != ""
+	return c.SSLCertPath != "" || c.SSLCert != "" || len(c.SSLCertPaths) > 0
 }
 
+// String describes the configuration with the secrets it may hold,
+// SSLClientKey and JWTContent, redacted, so that they aren't leaked by
+// logging it.
+func (c Config) String() string {
+	return fmt.Sprintf("%+v", c.redacted())
+}
+
+func (c Config) GoString() string {
+	return "conjurapi.Config" + strings.TrimPrefix(fmt.Sprintf("%#v", c.redacted()), "conjurapi.configFile")
+}
+
+func (c Config) redacted() configFile {
+	if c.SSLClientKey != "" {
+		c.SSLClientKey = "[REDACTED]"
+	}
+	if c.JWTContent != "" {
+		c.JWTContent = "[REDACTED]"
+	}
+	return configFile(c)
+}
+
+// ConfigError is returned by Config.Validate. It lists every problem found
+// with the configuration.
+type ConfigError struct {
+	Problems []string
+	// config describes the configuration when debug logging is enabled
+	config string
+}
+
+func (e *ConfigError) Error() string {
+	problems := e.Problems
+	if e.config != "" {
+		problems = append(append([]string{}, problems...), e.config)
+	}
+	return strings.Join(problems, " -- ")
+}
+
+// Validate checks that the configuration is complete and consistent. It
+// returns a *ConfigError listing all the problems found, rather than only the
+// first.
 func (c *Config) Validate() error {
 	errors := []string{}
 
-	if c.ApplianceURL == "" {
-		errors = append(errors, "Must specify an ApplianceURL")
+	if c.ApplianceURL == "" && len(c.ApplianceURLs) == 0 {
+		errors = append(errors, "must specify an ApplianceURL")
+	}
+	for _, applianceURL := range append(c.applianceURLs(), c.readApplianceURLs()...) {
+		if u, err := url.Parse(applianceURL); err != nil {
+			errors = append(errors, fmt.Sprintf("ApplianceURL '%s' is not a valid URL", applianceURL))
+		} else if u.Scheme == "unix" {
+			if strings.Trim(u.Host+u.Path, "/") == "" {
+				errors = append(errors, fmt.Sprintf("ApplianceURL '%s' must give the socket path", applianceURL))
+			}
+		} else if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+			errors = append(errors, fmt.Sprintf("ApplianceURL must use http, https or unix, not '%s'", u.Scheme))
+		}
+	}
+	if c.hasUnixSocket() && len(c.applianceURLs()) > 1 {
+		errors = append(errors, "ApplianceURLs can't fail over to or from a Unix socket")
+	}
+	if len(c.readApplianceURLs()) > 0 && c.hasUnixSocket() {
+		errors = append(errors, "ReadApplianceURLs can't be used with a Unix socket")
+	}
+
+	if c.Account == "" && !c.IsConjurCloud() && !c.V4 {
+		errors = append(errors, "must specify an Account")
+	}
+
+	_, registered := authn.Lookup(c.AuthnType)
+	if c.AuthnType != "" && !contains(supportedAuthnTypes, c.AuthnType) && !registered {
+		authnTypes := append(append([]string{}, supportedAuthnTypes...), authn.Registered()...)
+		errors = append(errors, fmt.Sprintf("AuthnType must be one of %v", authnTypes))
 	}
 
-	if c.Account == "" {
-		errors = append(errors, "Must specify an Account")
+	if contains(authnTypesRequiringServiceID, c.AuthnType) && c.ServiceID == "" {
+		errors = append(errors, fmt.Sprintf("must specify a ServiceID when using %s", c.AuthnType))
 	}
 
-	if c.AuthnType != "" && !contains(supportedAuthnTypes, c.AuthnType) {
-		errors = append(errors, fmt.Sprintf("AuthnType must be one of %v", supportedAuthnTypes))
+	if c.V4 && c.AuthnType != "" && c.AuthnType != "authn" {
+		errors = append(errors, fmt.Sprintf("AuthnType %s is not supported in V4 mode", c.AuthnType))
 	}
 
-	if (c.AuthnType == "ldap" || c.AuthnType == "oidc") && c.ServiceID == "" {
-		errors = append(errors, fmt.Sprintf("Must specify a ServiceID when using %s", c.AuthnType))
+	if c.AuthnType == "identity" && c.IdentityURL == "" {
+		errors = append(errors, "must specify an IdentityURL when using identity")
+	}
+
+	if c.AuthnType == "jwt" && c.JWTContent == "" && c.JWTFilePath == "" {
+		errors = append(errors, "must specify a JWTContent or JWTFilePath when using jwt")
+	}
+
+	credentialStorageTypes := []string{CredentialStorageFile, CredentialStorageKeyring, CredentialStorageNone}
+	if c.CredentialStorage != "" && !contains(credentialStorageTypes, c.CredentialStorage) {
+		errors = append(errors, fmt.Sprintf("CredentialStorage must be one of %v", credentialStorageTypes))
+	}
+
+	hasClientCert := c.HasSSLClientCert()
+	hasClientKey := c.SSLClientKey != "" || c.SSLClientKeyPath != ""
+	if hasClientCert != hasClientKey {
+		errors = append(errors, "must specify both an SSL client certificate and key")
+	}
+
+	for _, pin := range c.SSLPinnedPublicKeys {
+		hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(hash) != sha256.Size {
+			errors = append(errors, fmt.Sprintf("SSLPinnedPublicKeys entry '%s' is not a base64-encoded SHA-256 hash", pin))
+		}
+	}
+
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 || c.IdleConnTimeout < 0 {
+		errors = append(errors, "connection pool settings must not be negative")
+	}
+
+	if c.RateLimit < 0 || c.RateLimitBurst < 0 {
+		errors = append(errors, "rate limit settings must not be negative")
+	}
+
+	if c.MaxResponseBodySize < 0 {
+		errors = append(errors, "MaxResponseBodySize must not be negative")
+	}
+
+	if err := c.validateProxy(); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	if c.HTTPVersion != "" && !contains(httpVersions, c.HTTPVersion) {
+		errors = append(errors, fmt.Sprintf("HTTPVersion must be one of %v", httpVersions))
+	}
+
+	if c.RedirectPolicy != "" && !contains(redirectPolicies, c.RedirectPolicy) {
+		errors = append(errors, fmt.Sprintf("RedirectPolicy must be one of %v", redirectPolicies))
+	}
+
+	if c.TokenRefreshThreshold < 0 || c.TokenRefreshThreshold > 1 {
+		errors = append(errors, "TokenRefreshThreshold must be between 0 and 1")
 	}
 
 	if len(errors) == 0 {
 		return nil
-	} else if logging.ApiLog.Level == logrus.DebugLevel {
-		errors = append(errors, fmt.Sprintf("config: %+v", c))
 	}
-	return fmt.Errorf("%s", strings.Join(errors, " -- "))
+	configErr := &ConfigError{Problems: errors}
+	if logging.ApiLog.Level == logrus.DebugLevel {
+		configErr.config = fmt.Sprintf("config: %+v", c)
+	}
+	return configErr
 }
 
+// ReadSSLCert returns the PEM-encoded CA certificates used to verify Conjur:
+// SSLCert, or else the contents of SSLCertPath, followed by the contents of
+// each of SSLCertPaths.
 func (c *Config) ReadSSLCert() ([]byte, error) {
-	if c.SSLCert != "" {
-		return []byte(c.SSLCert), nil
+	cert := []byte(c.SSLCert)
+	if c.SSLCert == "" && (c.SSLCertPath != "" || len(c.SSLCertPaths) == 0) {
+		var err error
+		if cert, err = os.ReadFile(c.SSLCertPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, certPath := range c.SSLCertPaths {
+		bundle, err := os.ReadFile(certPath)
+		if err != nil {
+			return nil, err
+		}
+		cert = append(append(cert, '\n'), bundle...)
+	}
+	return cert, nil
+}
+
+// hasConnectionPoolSettings reports whether any of the HTTP transport's
+// connection pool settings are overridden.
+func (c *Config) hasConnectionPoolSettings() bool {
+	return c.MaxIdleConns != 0 || c.MaxIdleConnsPerHost != 0 || c.MaxConnsPerHost != 0 || c.IdleConnTimeout != 0
+}
+
+// HasSSLClientCert reports whether a client certificate is configured for
+// mutual TLS.
+func (c *Config) HasSSLClientCert() bool {
+	return c.SSLClientCert != "" || c.SSLClientCertPath != ""
+}
+
+// ReadSSLClientCert loads the client certificate and private key presented to
+// Conjur for mutual TLS, preferring in-memory PEM over files.
+func (c *Config) ReadSSLClientCert() (tls.Certificate, error) {
+	certPEM := []byte(c.SSLClientCert)
+	if c.SSLClientCert == "" {
+		var err error
+		if certPEM, err = os.ReadFile(c.SSLClientCertPath); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	keyPEM := []byte(c.SSLClientKey)
+	if c.SSLClientKey == "" {
+		var err error
+		if keyPEM, err = os.ReadFile(c.SSLClientKeyPath); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("can't load Conjur SSL client cert: %w", err)
 	}
-	return os.ReadFile(c.SSLCertPath)
+	return cert, nil
 }
 
 func (c *Config) BaseURL() string {
@@ -104,15 +322,66 @@ func mergeValue(a, b string) string {
 	return a
 }
 
-func (c *Config) merge(o *Config) {
+// mergeBool returns b if its source sets it, even to false, and a otherwise.
+func mergeBool(a, b, set bool) bool {
+	if set {
+		return b
+	}
+	return a
+}
+
+// configBools records which of the bool settings a config source sets, so that
+// a source can turn off a setting turned on by one of lower precedence.
+type configBools struct {
+	SSLInsecureSkipVerify bool
+	ConjurCloud           bool
+	V4                    bool
+	NoTokenCache          bool
+	SSLUseSystemCerts     bool
+}
+
+func (c *Config) merge(o *Config, set configBools) {
 	c.ApplianceURL = mergeValue(c.ApplianceURL, o.ApplianceURL)
 	c.Account = mergeValue(c.Account, o.Account)
 	c.SSLCert = mergeValue(c.SSLCert, o.SSLCert)
 	c.SSLCertPath = mergeValue(c.SSLCertPath, o.SSLCertPath)
 	c.NetRCPath = mergeValue(c.NetRCPath, o.NetRCPath)
 	c.CredentialStorage = mergeValue(c.CredentialStorage, o.CredentialStorage)
+	c.TokenCachePath = mergeValue(c.TokenCachePath, o.TokenCachePath)
 	c.AuthnType = mergeValue(c.AuthnType, o.AuthnType)
 	c.ServiceID = mergeValue(c.ServiceID, o.ServiceID)
+	c.JWTHostID = mergeValue(c.JWTHostID, o.JWTHostID)
+	c.JWTContent = mergeValue(c.JWTContent, o.JWTContent)
+	c.JWTFilePath = mergeValue(c.JWTFilePath, o.JWTFilePath)
+	c.AzureClientID = mergeValue(c.AzureClientID, o.AzureClientID)
+	c.AzureResource = mergeValue(c.AzureResource, o.AzureResource)
+	c.AuthnLocalSocket = mergeValue(c.AuthnLocalSocket, o.AuthnLocalSocket)
+	c.Proxy = mergeValue(c.Proxy, o.Proxy)
+	c.HTTPVersion = mergeValue(c.HTTPVersion, o.HTTPVersion)
+	c.RedirectPolicy = mergeValue(c.RedirectPolicy, o.RedirectPolicy)
+	c.IdentityURL = mergeValue(c.IdentityURL, o.IdentityURL)
+	c.SSLClientCert = mergeValue(c.SSLClientCert, o.SSLClientCert)
+	c.SSLClientCertPath = mergeValue(c.SSLClientCertPath, o.SSLClientCertPath)
+	c.SSLClientKey = mergeValue(c.SSLClientKey, o.SSLClientKey)
+	c.SSLClientKeyPath = mergeValue(c.SSLClientKeyPath, o.SSLClientKeyPath)
+	c.SSLServerName = mergeValue(c.SSLServerName, o.SSLServerName)
+	if len(o.ApplianceURLs) > 0 {
+		c.ApplianceURLs = o.ApplianceURLs
+	}
+	if len(o.ReadApplianceURLs) > 0 {
+		c.ReadApplianceURLs = o.ReadApplianceURLs
+	}
+	if len(o.SSLCertPaths) > 0 {
+		c.SSLCertPaths = o.SSLCertPaths
+	}
+	if len(o.SSLPinnedPublicKeys) > 0 {
+		c.SSLPinnedPublicKeys = o.SSLPinnedPublicKeys
+	}
+	c.SSLInsecureSkipVerify = mergeBool(c.SSLInsecureSkipVerify, o.SSLInsecureSkipVerify, set.SSLInsecureSkipVerify)
+	c.ConjurCloud = mergeBool(c.ConjurCloud, o.ConjurCloud, set.ConjurCloud)
+	c.V4 = mergeBool(c.V4, o.V4, set.V4)
+	c.NoTokenCache = mergeBool(c.NoTokenCache, o.NoTokenCache, set.NoTokenCache)
+	c.SSLUseSystemCerts = mergeBool(c.SSLUseSystemCerts, o.SSLUseSystemCerts, set.SSLUseSystemCerts)
 }
 
 func (c *Config) mergeYAML(filename string) error {
@@ -125,25 +394,59 @@ func (c *Config) mergeYAML(filename string) error {
 		return nil
 	}
 
-	// Parse the YAML file into a new struct containing the same
+	config := Config{}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		logging.ApiLog.Errorf("Parsing error %s: %s\n", filename, err)
+		return err
+	}
+
+	// Tell the bool settings which are set to false from those left out
+	bools := struct {
+		SSLInsecureSkipVerify *bool `yaml:"ssl_insecure_skip_verify"`
+		ConjurCloud           *bool `yaml:"conjur_cloud"`
+		V4                    *bool `yaml:"v4"`
+		SSLUseSystemCerts     *bool `yaml:"ssl_use_system_certs"`
+	}{}
+	yaml.Unmarshal(buf, &bools)
+
+	// Now merge the parsed config into the current config object
+	logging.ApiLog.Debugf("Config from %s: %+v\n", filename, config)
+	c.merge(&config, configBools{
+		SSLInsecureSkipVerify: bools.SSLInsecureSkipVerify != nil,
+		ConjurCloud:           bools.ConjurCloud != nil,
+		V4:                    bools.V4 != nil,
+		SSLUseSystemCerts:     bools.SSLUseSystemCerts != nil,
+	})
+	return nil
+}
+
+// configFile is the conjurrc format of a Config, without its methods so that
+// it can be marshalled by them.
+type configFile Config
+
+// MarshalYAML writes the conjurrc format read by LoadConfig. Settings which
+// can't be stored in files, such as SSLCert and DialContext, are left out.
+func (c Config) MarshalYAML() (interface{}, error) {
+	return configFile(c), nil
+}
+
+// UnmarshalYAML reads the conjurrc format, as LoadConfig does. Unknown keys
+// are ignored, so that files shared with other Conjur clients can be read.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Parse the YAML into a new struct containing the same
 	// fields as Config, plus a few extra fields for compatibility
 	aux := struct {
 		ConjurVersion string `yaml:"version"`
-		Config        `yaml:",inline"`
+		configFile    `yaml:",inline"`
 		// BEGIN COMPATIBILITY WITH PYTHON CLI
 		ConjurURL     string `yaml:"conjur_url"`
 		ConjurAccount string `yaml:"conjur_account"`
 		// END COMPATIBILITY WITH PYTHON CLI
 	}{}
-
-	if err := yaml.Unmarshal(buf, &aux); err != nil {
-		logging.ApiLog.Errorf("Parsing error %s: %s\n", filename, err)
+	if err := unmarshal(&aux); err != nil {
 		return err
 	}
-
-	// Now merge the parsed config into the current config object
-	logging.ApiLog.Debugf("Config from %s: %+v\n", filename, aux.Config)
-	c.merge(&aux.Config)
+	*c = Config(aux.configFile)
 
 	// BEGIN COMPATIBILITY WITH PYTHON CLI
 	// The Python CLI uses the keys conjur_url and conjur_account
@@ -169,12 +472,44 @@ func (c *Config) mergeEnv() {
 		Account:           os.Getenv("CONJUR_ACCOUNT"),
 		NetRCPath:         os.Getenv("CONJUR_NETRC_PATH"),
 		CredentialStorage: os.Getenv("CONJUR_CREDENTIAL_STORAGE"),
+		TokenCachePath:    os.Getenv("CONJUR_TOKEN_CACHE_PATH"),
 		AuthnType:         os.Getenv("CONJUR_AUTHN_TYPE"),
 		ServiceID:         os.Getenv("CONJUR_SERVICE_ID"),
+		JWTHostID:         os.Getenv("CONJUR_AUTHN_JWT_HOST_ID"),
+		JWTContent:        os.Getenv("CONJUR_AUTHN_JWT_TOKEN"),
+		JWTFilePath:       os.Getenv("JWT_TOKEN_PATH"),
+		AzureClientID:     os.Getenv("CONJUR_AUTHN_AZURE_CLIENT_ID"),
+		AzureResource:     os.Getenv("CONJUR_AUTHN_AZURE_RESOURCE"),
+		AuthnLocalSocket:  os.Getenv("CONJUR_AUTHN_LOCAL_SOCKET"),
+		IdentityURL:       os.Getenv("CONJUR_IDENTITY_URL"),
+		SSLClientCert:     os.Getenv("CONJUR_SSL_CLIENT_CERTIFICATE"),
+		SSLClientCertPath: os.Getenv("CONJUR_CLIENT_CERT_FILE"),
+		SSLClientKey:      os.Getenv("CONJUR_SSL_CLIENT_KEY"),
+		SSLClientKeyPath:  os.Getenv("CONJUR_CLIENT_KEY_FILE"),
+		SSLServerName:     os.Getenv("CONJUR_SSL_SERVER_NAME"),
+	}
+	if applianceURLs := os.Getenv("CONJUR_APPLIANCE_URLS"); applianceURLs != "" {
+		env.ApplianceURLs = strings.Split(applianceURLs, ",")
+	}
+	set := configBools{}
+	if majorVersion := os.Getenv("CONJUR_MAJOR_VERSION"); majorVersion != "" {
+		env.V4 = majorVersion == "4"
+		set.V4 = true
+	}
+	if readApplianceURLs := os.Getenv("CONJUR_READ_APPLIANCE_URLS"); readApplianceURLs != "" {
+		env.ReadApplianceURLs = strings.Split(readApplianceURLs, ",")
+	}
+	if noTokenCache, err := strconv.ParseBool(os.Getenv("CONJUR_NO_TOKEN_CACHE")); err == nil {
+		env.NoTokenCache = noTokenCache
+		set.NoTokenCache = true
+	}
+	if useSystemCerts, err := strconv.ParseBool(os.Getenv("CONJUR_SSL_USE_SYSTEM_CERTS")); err == nil {
+		env.SSLUseSystemCerts = useSystemCerts
+		set.SSLUseSystemCerts = true
 	}
 
 	logging.ApiLog.Debugf("Config from environment: %+v\n", env)
-	c.merge(&env)
+	c.merge(&env, set)
 }
 
 func (c *Config) Conjurrc() []byte {
@@ -182,6 +517,19 @@ func (c *Config) Conjurrc() []byte {
 	return data
 }
 
+// LoadConfig loads the configuration from, in increasing order of
+// precedence:
+//
+//   - the default NetRCPath, ~/.netrc
+//   - the system-wide file, /etc/conjur.conf (C:\windows\conjur.conf on
+//     Windows)
+//   - on Windows, %APPDATA%\conjur\conjurrc
+//   - the user's file, $CONJURRC or else ~/.conjurrc, which is
+//     %USERPROFILE%\.conjurrc on Windows
+//   - the CONJUR_* environment variables, as with LoadConfigFromEnv
+//
+// Missing files are skipped, but files which can't be parsed are reported as
+// errors. The result isn't validated; the NewClient functions call Validate.
 func LoadConfig() (Config, error) {
 	config := Config{}
 
@@ -193,20 +541,18 @@ func LoadConfig() (Config, error) {
 	// Default to using ~/.netrc, subsequent configuration can
 	// override it if the home dir is set.
 	if home != "" {
-		config = Config{NetRCPath: path.Join(home, ".netrc")}
+		config = Config{NetRCPath: filepath.Join(home, ".netrc")}
 	}
 
-	err = config.mergeYAML(path.Join(getSystemPath(), "conjur.conf"))
+	err = config.mergeYAML(filepath.Join(getSystemPath(), "conjur.conf"))
 	if err != nil {
 		return config, err
 	}
 
-	conjurrc := os.Getenv("CONJURRC")
-	if conjurrc == "" && home != "" {
-		conjurrc = path.Join(home, ".conjurrc")
-	}
-	if conjurrc != "" {
-		config.mergeYAML(conjurrc)
+	for _, conjurrc := range userConfigFiles(runtime.GOOS, home) {
+		if err := config.mergeYAML(conjurrc); err != nil {
+			return config, err
+		}
 	}
 
 	config.mergeEnv()
@@ -215,6 +561,14 @@ func LoadConfig() (Config, error) {
 	return config, nil
 }
 
+// LoadConfigFromEnv loads the configuration from the CONJUR_* environment
+// variables only, such as CONJUR_APPLIANCE_URL and CONJUR_ACCOUNT.
+func LoadConfigFromEnv() Config {
+	config := Config{}
+	config.mergeEnv()
+	return config
+}
+
 func getSystemPath() string {
 	if runtime.GOOS == "windows" {
 		//No way to use SHGetKnownFolderPath()
@@ -225,6 +579,23 @@ func getSystemPath() string {
 	}
 }
 
+// userConfigFiles returns the user's configuration files, in increasing order
+// of precedence. $CONJURRC replaces them all when it's set.
+func userConfigFiles(goos, home string) []string {
+	if conjurrc := os.Getenv("CONJURRC"); conjurrc != "" {
+		return []string{conjurrc}
+	}
+
+	files := []string{}
+	if appData := os.Getenv("APPDATA"); goos == "windows" && appData != "" {
+		files = append(files, filepath.Join(appData, "conjur", "conjurrc"))
+	}
+	if home != "" {
+		files = append(files, filepath.Join(home, ".conjurrc"))
+	}
+	return files
+}
+
 func contains(s []string, str string) bool {
 	for _, v := range s {
 		if v == str {
@@ -0,0 +1,144 @@
+// Package conjurtest provides test doubles for code that uses the Conjur API
+// client, so that it can be tested without a live Conjur server.
+package conjurtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// SecretsProvider is an in-memory conjurapi.SecretsProvider. Variable IDs may
+// be given fully- or partially-qualified, as with the real client, and batch
+// results are keyed by fully-qualified ID.
+type SecretsProvider struct {
+	account string
+
+	mutex     sync.Mutex
+	secrets   map[string][]byte
+	errors    map[string]error
+	retrieved []string
+}
+
+var _ conjurapi.SecretsProvider = (*SecretsProvider)(nil)
+
+// NewSecretsProvider creates a SecretsProvider for the given account holding
+// the given secrets, keyed by variable ID.
+func NewSecretsProvider(account string, secrets map[string]string) *SecretsProvider {
+	p := &SecretsProvider{
+		account: account,
+		secrets: map[string][]byte{},
+		errors:  map[string]error{},
+	}
+	for id, value := range secrets {
+		p.SetSecret(id, value)
+	}
+	return p
+}
+
+// SetSecret stores a secret value for a variable.
+func (p *SecretsProvider) SetSecret(variableID string, value string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.secrets[qualifyID(p.account, "variable", variableID)] = []byte(value)
+}
+
+// SetError makes retrievals of a variable fail with the given error, or
+// succeed again if err is nil.
+func (p *SecretsProvider) SetError(variableID string, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := qualifyID(p.account, "variable", variableID)
+	if err == nil {
+		delete(p.errors, id)
+		return
+	}
+	p.errors[id] = err
+}
+
+// Retrieved returns the fully-qualified IDs of all variables retrieved so
+// far, in order.
+func (p *SecretsProvider) Retrieved() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]string(nil), p.retrieved...)
+}
+
+// RetrieveSecret returns the stored value of a variable. Variables without a
+// value fail with an error matching conjurapi.ErrNotFound.
+func (p *SecretsProvider) RetrieveSecret(variableID string) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := qualifyID(p.account, "variable", variableID)
+	p.retrieved = append(p.retrieved, id)
+	return p.lookup(id)
+}
+
+// RetrieveBatchSecrets returns the stored values of all the variables, keyed
+// by fully-qualified ID. As with Conjur, the whole batch fails if any of the
+// variables can't be retrieved.
+func (p *SecretsProvider) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	values := map[string][]byte{}
+	for _, variableID := range variableIDs {
+		id := qualifyID(p.account, "variable", variableID)
+		p.retrieved = append(p.retrieved, id)
+
+		value, err := p.lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		values[id] = value
+	}
+	return values, nil
+}
+
+// lookup returns a copy of a variable's value. The caller must hold the
+// mutex.
+func (p *SecretsProvider) lookup(id string) ([]byte, error) {
+	if err, ok := p.errors[id]; ok {
+		return nil, err
+	}
+
+	value, ok := p.secrets[id]
+	if !ok {
+		return nil, notFoundError(id)
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// notFoundError returns the error the client reports when Conjur responds
+// that a variable is empty or doesn't exist.
+func notFoundError(id string) error {
+	return &conjurapi.ConjurError{
+		Code:    http.StatusNotFound,
+		Message: "404 Not Found",
+		Details: &response.ConjurErrorDetails{
+			Code:    "not_found",
+			Message: fmt.Sprintf("CONJ00076E Variable %s is empty or not found", id),
+		},
+	}
+}
+
+// qualifyID expands an ID of the form [[<account>:]<kind>:]<identifier> into
+// a fully-qualified ID.
+func qualifyID(account, kind, id string) string {
+	tokens := strings.SplitN(id, ":", 3)
+	switch len(tokens) {
+	case 1:
+		tokens = []string{account, kind, tokens[0]}
+	case 2:
+		tokens = []string{account, tokens[0], tokens[1]}
+	}
+	return strings.Join(tokens, ":")
+}
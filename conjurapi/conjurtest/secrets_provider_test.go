@@ -0,0 +1,72 @@
+package conjurtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsProvider_RetrieveSecret(t *testing.T) {
+	provider := NewSecretsProvider("cucumber", map[string]string{
+		"db-password":                 "secret",
+		"cucumber:variable:api-token": "token",
+	})
+
+	t.Run("Returns values for partially- and fully-qualified IDs", func(t *testing.T) {
+		value, err := provider.RetrieveSecret("cucumber:variable:db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+
+		value, err = provider.RetrieveSecret("variable:api-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "token", string(value))
+	})
+
+	t.Run("Returns ErrNotFound for missing variables", func(t *testing.T) {
+		_, err := provider.RetrieveSecret("missing")
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+		assert.EqualError(t, err, "404 Not Found. CONJ00076E Variable cucumber:variable:missing is empty or not found.")
+	})
+
+	t.Run("Returns injected errors", func(t *testing.T) {
+		injected := errors.New("connection refused")
+		provider.SetError("db-password", injected)
+		_, err := provider.RetrieveSecret("db-password")
+		assert.Same(t, injected, err)
+
+		provider.SetError("db-password", nil)
+		_, err = provider.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Returns copies of stored values", func(t *testing.T) {
+		value, _ := provider.RetrieveSecret("db-password")
+		value[0] = 'X'
+
+		value, _ = provider.RetrieveSecret("db-password")
+		assert.Equal(t, "secret", string(value))
+	})
+}
+
+func TestSecretsProvider_RetrieveBatchSecrets(t *testing.T) {
+	provider := NewSecretsProvider("cucumber", map[string]string{"one": "1", "two": "2"})
+
+	values, err := provider.RetrieveBatchSecrets([]string{"one", "cucumber:variable:two"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"cucumber:variable:one": []byte("1"),
+		"cucumber:variable:two": []byte("2"),
+	}, values)
+
+	_, err = provider.RetrieveBatchSecrets([]string{"one", "three"})
+	assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+
+	assert.Equal(t, []string{
+		"cucumber:variable:one",
+		"cucumber:variable:two",
+		"cucumber:variable:one",
+		"cucumber:variable:three",
+	}, provider.Retrieved())
+}
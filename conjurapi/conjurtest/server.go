@@ -0,0 +1,666 @@
+package conjurtest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"gopkg.in/yaml.v3"
+)
+
+// TokenTTL is the lifetime of the access tokens issued by Server.
+const TokenTTL = 8 * time.Minute
+
+// Server is an in-memory emulation of a Conjur server for hermetic
+// integration tests. It supports API key login and authentication, reading
+// and updating secrets, and loading policies which declare users, hosts,
+// variables and nested policies.
+//
+// Access is not enforced: any valid access token may read and update every
+// secret and load any policy. Permission statements such as !permit and
+// !grant are accepted but ignored.
+type Server struct {
+	*httptest.Server
+	account    string
+	signingKey []byte
+
+	mutex     sync.Mutex
+	resources map[string]string
+	apiKeys   map[string]string
+	secrets   map[string][][]byte
+	policies  map[string][]string
+}
+
+// NewServer starts a Server for the given account with an "admin" user. The
+// caller must call Close when done.
+func NewServer(account string) *Server {
+	s := &Server{
+		account:    account,
+		signingKey: randomBytes(32),
+		resources:  map[string]string{},
+		apiKeys:    map[string]string{},
+		secrets:    map[string][][]byte{},
+		policies:   map[string][]string{},
+	}
+	s.resources[s.fullID("policy", "root")] = ""
+	s.AddRole("admin")
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config returns a client configuration for the server which doesn't store
+// credentials.
+func (s *Server) Config() conjurapi.Config {
+	return conjurapi.Config{
+		Account:           s.account,
+		ApplianceURL:      s.URL,
+		CredentialStorage: "none",
+	}
+}
+
+// NewClient returns a client authenticated as the given role, such as
+// "admin" or "host/myapp".
+func (s *Server) NewClient(login string, opts ...conjurapi.ClientOption) (*conjurapi.Client, error) {
+	apiKey, ok := s.APIKey(login)
+	if !ok {
+		return nil, fmt.Errorf("role '%s' does not exist", login)
+	}
+	return conjurapi.NewClientFromKey(s.Config(), authn.LoginPair{Login: login, APIKey: apiKey}, opts...)
+}
+
+// AddRole creates a user or host, given by its login such as "alice" or
+// "host/myapp", and returns its API key. If the role exists, its API key is
+// returned unchanged.
+func (s *Server) AddRole(login string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	roleID := s.loginRoleID(login)
+	if apiKey, ok := s.apiKeys[roleID]; ok {
+		return apiKey
+	}
+	s.resources[roleID] = "root"
+	s.apiKeys[roleID] = newAPIKey()
+	return s.apiKeys[roleID]
+}
+
+// APIKey returns the API key of a user or host, given by its login.
+func (s *Server) APIKey(login string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	apiKey, ok := s.apiKeys[s.loginRoleID(login)]
+	return apiKey, ok
+}
+
+// SetSecret adds a new version of a variable's value, declaring the
+// variable in the root policy if needed.
+func (s *Server) SetSecret(variableID string, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := qualifyID(s.account, "variable", variableID)
+	if _, ok := s.resources[id]; !ok {
+		s.resources[id] = "root"
+	}
+	s.secrets[id] = append(s.secrets[id], []byte(value))
+}
+
+// Secret returns the latest value of a variable.
+func (s *Server) Secret(variableID string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	versions := s.secrets[qualifyID(s.account, "variable", variableID)]
+	if len(versions) == 0 {
+		return nil, false
+	}
+	return append([]byte(nil), versions[len(versions)-1]...), true
+}
+
+// ResourceExists reports whether a resource, such as "variable:db-password",
+// has been declared.
+func (s *Server) ResourceExists(resourceID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.resources[qualifyID(s.account, "", resourceID)]
+	return ok
+}
+
+// Policies returns the text of every version of a policy loaded so far,
+// oldest first.
+func (s *Server) Policies(policyID string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string(nil), s.policies[qualifyID(s.account, "policy", policyID)]...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+
+	switch {
+	case len(segments) == 3 && segments[0] == "authn" && segments[2] == "login" && r.Method == http.MethodGet:
+		s.serveLogin(w, r, segments[1])
+	case len(segments) == 4 && segments[0] == "authn" && segments[3] == "authenticate" && r.Method == http.MethodPost:
+		s.serveAuthenticate(w, r, segments[1], segments[2])
+	case len(segments) == 1 && segments[0] == "secrets" && r.Method == http.MethodGet:
+		s.authorized(w, r, s.serveBatchSecrets)
+	case len(segments) == 4 && segments[0] == "secrets" && segments[2] == "variable":
+		s.authorized(w, r, func(w http.ResponseWriter, r *http.Request) {
+			s.serveSecret(w, r, segments[1], segments[3])
+		})
+	case len(segments) == 4 && segments[0] == "policies" && segments[2] == "policy":
+		s.authorized(w, r, func(w http.ResponseWriter, r *http.Request) {
+			s.servePolicy(w, r, segments[1], segments[3])
+		})
+	default:
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("No route matches %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) serveLogin(w http.ResponseWriter, r *http.Request, account string) {
+	login, apiKey, ok := r.BasicAuth()
+	if !ok || !s.validAPIKey(account, login, apiKey) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, apiKey)
+}
+
+func (s *Server) serveAuthenticate(w http.ResponseWriter, r *http.Request, account, escapedLogin string) {
+	login, err := url.QueryUnescape(escapedLogin)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	apiKey, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if !s.validAPIKey(account, login, string(apiKey)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.issueToken(login))
+}
+
+func (s *Server) serveSecret(w http.ResponseWriter, r *http.Request, account, escapedID string) {
+	variableID, err := url.PathUnescape(escapedID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	id := strings.Join([]string{account, "variable", variableID}, ":")
+
+	switch r.Method {
+	case http.MethodGet:
+		version := 0
+		if v := r.URL.Query().Get("version"); v != "" {
+			if version, err = strconv.Atoi(v); err != nil || version < 1 {
+				writeError(w, http.StatusUnprocessableEntity, "validation_failed", fmt.Sprintf("Invalid version '%s'", v))
+				return
+			}
+		}
+		value, ok := s.secretVersion(id, version)
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("CONJ00076E Variable %s is empty or not found", id))
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(value)
+	case http.MethodPost:
+		value, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		if !s.addSecretVersion(id, value) {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Variable %s not found", id))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveBatchSecrets(w http.ResponseWriter, r *http.Request) {
+	ids := strings.Split(r.URL.Query().Get("variable_ids"), ",")
+	encode := r.Header.Get("Accept-Encoding") == "base64"
+
+	values := map[string]string{}
+	for _, id := range ids {
+		value, ok := s.secretVersion(id, 0)
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("CONJ00076E Variable %s is empty or not found", id))
+			return
+		}
+		switch {
+		case encode:
+			values[id] = base64.StdEncoding.EncodeToString(value)
+		case !utf8.Valid(value):
+			writeError(w, http.StatusNotAcceptable, "not_acceptable", fmt.Sprintf("Variable %s cannot be encoded as UTF-8", id))
+			return
+		default:
+			values[id] = string(value)
+		}
+	}
+
+	if encode {
+		w.Header().Set("Content-Encoding", "base64")
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func (s *Server) servePolicy(w http.ResponseWriter, r *http.Request, account, escapedID string) {
+	policyID, err := url.QueryUnescape(escapedID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if account != s.account {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Account '%s' not found", account))
+		return
+	}
+
+	var mode conjurapi.PolicyMode
+	switch r.Method {
+	case http.MethodPost:
+		mode = conjurapi.PolicyModePost
+	case http.MethodPut:
+		mode = conjurapi.PolicyModePut
+	case http.MethodPatch:
+		mode = conjurapi.PolicyModePatch
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	text, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	statements, err := parsePolicy(text, mode)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", err.Error())
+		return
+	}
+
+	policyResponse, ok := s.loadPolicy(mode, s.fullID("policy", policyID), string(text), statements)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Policy '%s' not found in account '%s'", policyID, account))
+		return
+	}
+	writeJSON(w, http.StatusCreated, policyResponse)
+}
+
+// authorized calls handler if the request carries a valid access token
+// issued by the server, and responds with 401 Unauthorized otherwise.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request, handler http.HandlerFunc) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, `Token token="`) || !strings.HasSuffix(header, `"`) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	encoded := strings.TrimSuffix(strings.TrimPrefix(header, `Token token="`), `"`)
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !s.validToken(raw) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	handler(w, r)
+}
+
+// issueToken returns a signed access token for the given login, in the
+// format produced by Conjur.
+func (s *Server) issueToken(login string) []byte {
+	now := time.Now()
+	protected, _ := json.Marshal(map[string]string{"alg": "conjur.org/slosilo/v2", "kid": "conjurtest"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"sub": login,
+		"iat": now.Unix(),
+		"exp": now.Add(TokenTTL).Unix(),
+	})
+
+	token := map[string]string{
+		"protected": base64.URLEncoding.EncodeToString(protected),
+		"payload":   base64.StdEncoding.EncodeToString(payload),
+	}
+	token["signature"] = s.sign(token["protected"], token["payload"])
+
+	data, _ := json.Marshal(token)
+	return data
+}
+
+// validToken reports whether a token was signed by the server and hasn't
+// expired.
+func (s *Server) validToken(raw []byte) bool {
+	var token map[string]string
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return false
+	}
+	expected := s.sign(token["protected"], token["payload"])
+	if !hmac.Equal([]byte(token["signature"]), []byte(expected)) {
+		return false
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(token["payload"])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+	return time.Now().Before(time.Unix(claims.Exp, 0))
+}
+
+func (s *Server) sign(protected, payload string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(protected + "." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) validAPIKey(account, login, apiKey string) bool {
+	if account != s.account {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expected, ok := s.apiKeys[s.loginRoleID(login)]
+	return ok && hmac.Equal([]byte(expected), []byte(apiKey))
+}
+
+// secretVersion returns a version of a variable's value, or the latest
+// value if version is 0.
+func (s *Server) secretVersion(id string, version int) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	versions := s.secrets[id]
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 1 || version > len(versions) {
+		return nil, false
+	}
+	return versions[version-1], true
+}
+
+func (s *Server) addSecretVersion(id string, value []byte) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.resources[id]; !ok {
+		return false
+	}
+	s.secrets[id] = append(s.secrets[id], value)
+	return true
+}
+
+// loadPolicy applies the statements of a policy loaded into policyID. It
+// returns false if the policy doesn't exist.
+func (s *Server) loadPolicy(mode conjurapi.PolicyMode, policyID, text string, statements []policyStatement) (*conjurapi.PolicyResponse, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.resources[policyID]; !ok {
+		return nil, false
+	}
+
+	_, _, namespace := splitID(policyID)
+	if namespace == "root" {
+		namespace = ""
+	}
+
+	declared := map[string]bool{}
+	created := map[string]conjurapi.CreatedRole{}
+	for _, statement := range statements {
+		id := s.fullID(statement.kind, statement.qualifiedID(namespace))
+		if statement.delete {
+			s.deleteResource(id)
+			continue
+		}
+
+		declared[id] = true
+		if _, ok := s.resources[id]; ok {
+			continue
+		}
+		s.resources[id] = policyID
+		if statement.kind == "user" || statement.kind == "host" {
+			s.apiKeys[id] = newAPIKey()
+			created[id] = conjurapi.CreatedRole{ID: id, APIKey: s.apiKeys[id]}
+		}
+	}
+
+	// Replacing a policy deletes everything it declared before which isn't
+	// in the new version.
+	if mode == conjurapi.PolicyModePut {
+		for id, owner := range s.resources {
+			if owner == policyID && !declared[id] {
+				s.deleteResource(id)
+			}
+		}
+	}
+
+	s.policies[policyID] = append(s.policies[policyID], text)
+	return &conjurapi.PolicyResponse{
+		CreatedRoles: created,
+		Version:      uint32(len(s.policies[policyID])),
+	}, true
+}
+
+func (s *Server) deleteResource(id string) {
+	delete(s.resources, id)
+	delete(s.apiKeys, id)
+	delete(s.secrets, id)
+}
+
+func (s *Server) fullID(kind, id string) string {
+	return strings.Join([]string{s.account, kind, id}, ":")
+}
+
+// loginRoleID returns the role ID for a login such as "alice" or
+// "host/myapp".
+func (s *Server) loginRoleID(login string) string {
+	if strings.HasPrefix(login, "host/") {
+		return s.fullID("host", strings.TrimPrefix(login, "host/"))
+	}
+	return s.fullID("user", login)
+}
+
+// policyStatement is a record declared or deleted by a policy.
+type policyStatement struct {
+	kind   string
+	id     string
+	policy string
+	delete bool
+}
+
+// qualifiedID returns the statement's ID within the namespace of the policy
+// it's loaded into. As in Conjur, users outside the root policy are named
+// "<id>@<policy-path-with-dashes>".
+func (st policyStatement) qualifiedID(namespace string) string {
+	policy := strings.Trim(strings.Join([]string{namespace, st.policy}, "/"), "/")
+	if policy == "" {
+		return st.id
+	}
+	if st.kind == "user" {
+		return st.id + "@" + strings.ReplaceAll(policy, "/", "-")
+	}
+	return policy + "/" + st.id
+}
+
+var declarationKinds = map[string]bool{
+	"user":         true,
+	"host":         true,
+	"group":        true,
+	"layer":        true,
+	"variable":     true,
+	"webservice":   true,
+	"policy":       true,
+	"host-factory": true,
+}
+
+var ignoredKinds = map[string]bool{
+	"permit": true,
+	"deny":   true,
+	"grant":  true,
+	"revoke": true,
+}
+
+// parsePolicy extracts the declarations and deletions from a policy
+// document.
+func parsePolicy(text []byte, mode conjurapi.PolicyMode) ([]policyStatement, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(text, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	return parseStatements(document.Content[0], "", mode)
+}
+
+func parseStatements(node *yaml.Node, policy string, mode conjurapi.PolicyMode) ([]policyStatement, error) {
+	nodes := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		nodes = node.Content
+	}
+
+	var statements []policyStatement
+	for _, node := range nodes {
+		kind := strings.TrimPrefix(node.Tag, "!")
+		switch {
+		case ignoredKinds[kind]:
+			continue
+		case kind == "delete":
+			if mode != conjurapi.PolicyModePatch {
+				return nil, fmt.Errorf("line %d: !delete is only allowed when updating a policy", node.Line)
+			}
+			record := mappingValue(node, "record")
+			if record == nil {
+				return nil, fmt.Errorf("line %d: !delete requires a record", node.Line)
+			}
+			statement, err := parseDeclaration(record, policy)
+			if err != nil {
+				return nil, err
+			}
+			statement.delete = true
+			statements = append(statements, statement)
+		case declarationKinds[kind]:
+			statement, err := parseDeclaration(node, policy)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, statement)
+
+			if body := mappingValue(node, "body"); kind == "policy" && body != nil {
+				nested, err := parseStatements(body, strings.Trim(policy+"/"+statement.id, "/"), mode)
+				if err != nil {
+					return nil, err
+				}
+				statements = append(statements, nested...)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unrecognized policy statement '%s'", node.Line, node.Tag)
+		}
+	}
+	return statements, nil
+}
+
+func parseDeclaration(node *yaml.Node, policy string) (policyStatement, error) {
+	statement := policyStatement{
+		kind:   strings.TrimPrefix(node.Tag, "!"),
+		policy: policy,
+	}
+	if !declarationKinds[statement.kind] {
+		return statement, fmt.Errorf("line %d: unrecognized record type '%s'", node.Line, node.Tag)
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		statement.id = node.Value
+	case yaml.MappingNode:
+		if id := mappingValue(node, "id"); id != nil {
+			statement.id = id.Value
+		}
+	}
+	if statement.id == "" {
+		return statement, fmt.Errorf("line %d: %s requires an id", node.Line, node.Tag)
+	}
+	return statement, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func splitID(id string) (account, kind, identifier string) {
+	tokens := strings.SplitN(id, ":", 3)
+	for len(tokens) < 3 {
+		tokens = append([]string{""}, tokens...)
+	}
+	return tokens[0], tokens[1], tokens[2]
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError responds with an error in the format used by Conjur.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
+
+func newAPIKey() string {
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes(32)))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
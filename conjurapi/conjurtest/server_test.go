@@ -0,0 +1,187 @@
+package conjurtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Authentication(t *testing.T) {
+	server := NewServer("cucumber")
+	defer server.Close()
+
+	t.Run("Authenticates with the admin API key", func(t *testing.T) {
+		conjur, err := server.NewClient("admin")
+		require.NoError(t, err)
+
+		token, err := conjur.InternalAuthenticate()
+		assert.NoError(t, err)
+		assert.Contains(t, string(token), `"payload"`)
+	})
+
+	t.Run("Logs in with an API key", func(t *testing.T) {
+		apiKey := server.AddRole("alice")
+		conjur, err := conjurapi.NewClientFromToken(server.Config(), "")
+		require.NoError(t, err)
+
+		loginAPIKey, err := conjur.Login("alice", apiKey)
+		assert.NoError(t, err)
+		assert.Equal(t, apiKey, loginAPIKey)
+
+		_, err = conjur.Login("alice", "wrong")
+		assert.ErrorIs(t, err, conjurapi.ErrUnauthorized)
+	})
+
+	t.Run("Rejects invalid credentials", func(t *testing.T) {
+		conjur, err := conjurapi.NewClientFromKey(server.Config(), authn.LoginPair{Login: "admin", APIKey: "wrong"})
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db-password")
+		assert.ErrorIs(t, err, conjurapi.ErrUnauthorized)
+	})
+
+	t.Run("Rejects tokens it didn't issue", func(t *testing.T) {
+		other := NewServer("cucumber")
+		defer other.Close()
+		otherClient, err := other.NewClient("admin")
+		require.NoError(t, err)
+		token, err := otherClient.InternalAuthenticate()
+		require.NoError(t, err)
+
+		conjur, err := conjurapi.NewClientFromToken(server.Config(), string(token))
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db-password")
+		assert.ErrorIs(t, err, conjurapi.ErrUnauthorized)
+	})
+}
+
+func TestServer_Secrets(t *testing.T) {
+	server := NewServer("cucumber")
+	defer server.Close()
+	server.SetSecret("db/password", "initial")
+
+	conjur, err := server.NewClient("admin")
+	require.NoError(t, err)
+
+	t.Run("Retrieves secrets", func(t *testing.T) {
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "initial", string(value))
+	})
+
+	t.Run("Adds and retrieves versions", func(t *testing.T) {
+		require.NoError(t, conjur.AddSecret("db/password", "updated"))
+
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "updated", string(value))
+
+		value, err = conjur.RetrieveSecretWithVersion("db/password", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "initial", string(value))
+
+		value, ok := server.Secret("db/password")
+		assert.True(t, ok)
+		assert.Equal(t, "updated", string(value))
+	})
+
+	t.Run("Retrieves batches", func(t *testing.T) {
+		server.SetSecret("binary", "\xff\xfe")
+
+		values, err := conjur.RetrieveBatchSecrets([]string{"db/password", "binary"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"cucumber:variable:db/password": []byte("updated"),
+			"cucumber:variable:binary":      []byte("\xff\xfe"),
+		}, values)
+	})
+
+	t.Run("Returns ErrNotFound for missing variables", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("missing")
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+
+		err = conjur.AddSecret("missing", "value")
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+
+		_, err = conjur.RetrieveBatchSecrets([]string{"db/password", "missing"})
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+	})
+}
+
+func TestServer_LoadPolicy(t *testing.T) {
+	server := NewServer("cucumber")
+	defer server.Close()
+
+	conjur, err := server.NewClient("admin")
+	require.NoError(t, err)
+
+	t.Run("Declares resources and creates roles", func(t *testing.T) {
+		policy := `
+- !policy
+  id: apps
+  body:
+  - !host myapp
+  - !user alice
+  - !variable
+    id: db-password
+  - !permit
+    role: !host myapp
+    privileges: [ read, execute ]
+    resource: !variable db-password
+`
+		resp, err := conjur.LoadPolicy(conjurapi.PolicyModePost, "root", strings.NewReader(policy))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), resp.Version)
+		assert.Len(t, resp.CreatedRoles, 2)
+		assert.Contains(t, resp.CreatedRoles, "cucumber:host:apps/myapp")
+		assert.Contains(t, resp.CreatedRoles, "cucumber:user:alice@apps")
+		assert.True(t, server.ResourceExists("policy:apps"))
+		assert.True(t, server.ResourceExists("variable:apps/db-password"))
+		assert.Equal(t, []string{policy}, server.Policies("root"))
+
+		apiKey := resp.CreatedRoles["cucumber:host:apps/myapp"].APIKey
+		host, err := conjurapi.NewClientFromKey(server.Config(), authn.LoginPair{Login: "host/apps/myapp", APIKey: apiKey})
+		require.NoError(t, err)
+
+		require.NoError(t, conjur.AddSecret("apps/db-password", "secret"))
+		value, err := host.RetrieveSecret("apps/db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+	})
+
+	t.Run("Loads into nested policies", func(t *testing.T) {
+		resp, err := conjur.LoadPolicy(conjurapi.PolicyModePost, "apps", strings.NewReader("- !variable api-token"))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), resp.Version)
+		assert.True(t, server.ResourceExists("variable:apps/api-token"))
+	})
+
+	t.Run("Deletes records when updating", func(t *testing.T) {
+		_, err := conjur.LoadPolicy(conjurapi.PolicyModePatch, "apps", strings.NewReader("- !delete\n  record: !variable api-token"))
+		require.NoError(t, err)
+		assert.False(t, server.ResourceExists("variable:apps/api-token"))
+	})
+
+	t.Run("Removes undeclared records when replacing", func(t *testing.T) {
+		resp, err := conjur.LoadPolicy(conjurapi.PolicyModePut, "root", strings.NewReader("- !policy apps\n- !variable shared"))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), resp.Version)
+		assert.Empty(t, resp.CreatedRoles)
+		assert.True(t, server.ResourceExists("variable:shared"))
+		assert.False(t, server.ResourceExists("host:apps/myapp"))
+		assert.False(t, server.ResourceExists("variable:apps/db-password"))
+	})
+
+	t.Run("Rejects invalid policies", func(t *testing.T) {
+		_, err := conjur.LoadPolicy(conjurapi.PolicyModePost, "root", strings.NewReader("- !unknown thing"))
+		assert.ErrorContains(t, err, "unrecognized policy statement '!unknown'")
+
+		_, err = conjur.LoadPolicy(conjurapi.PolicyModePost, "missing", strings.NewReader("- !variable x"))
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+	})
+}
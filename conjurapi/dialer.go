@@ -0,0 +1,53 @@
+package conjurapi
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// unixSocketApplianceURL is the ApplianceURL of clients which reach Conjur
+// over a Unix socket. Requests are addressed to it, and dialed to the socket.
+const unixSocketApplianceURL = "http://localhost"
+
+// DialContextFunc dials the connections of the client's HTTP transport, like
+// net.Dialer.DialContext.
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// unixSocketPath returns the socket path of an ApplianceURL of the form
+// unix:///path/to/socket, and whether it's such a URL.
+func unixSocketPath(applianceURL string) (string, bool) {
+	u, err := url.Parse(applianceURL)
+	if err != nil || u.Scheme != "unix" {
+		return "", false
+	}
+	return u.Host + u.Path, true
+}
+
+// resolveUnixSocket makes a config whose ApplianceURL is a Unix socket
+// address send its requests over the socket.
+func (c *Config) resolveUnixSocket() {
+	socketPath, ok := unixSocketPath(c.ApplianceURL)
+	if !ok {
+		return
+	}
+
+	c.ApplianceURL = unixSocketApplianceURL
+	c.ApplianceURLs = nil
+	c.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dialer := net.Dialer{}
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+}
+
+// hasUnixSocket reports whether any of the config's appliance URLs, including
+// ReadApplianceURLs, is a Unix socket address.
+func (c *Config) hasUnixSocket() bool {
+	for _, applianceURL := range append(c.applianceURLs(), c.readApplianceURLs()...) {
+		if strings.HasPrefix(applianceURL, "unix:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package conjurapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func secretHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/secrets/cucumber/variable/db-password", r.URL.Path)
+		w.Write([]byte("secret"))
+	}
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir may
+	// exceed
+	dir, err := os.MkdirTemp("", "conjur")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "conjur.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	ts := httptest.NewUnstartedServer(secretHandler(t))
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: "unix://" + socketPath, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	secret, err := conjur.RetrieveSecret("db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(secret))
+	assert.Equal(t, "http://localhost", conjur.GetConfig().ApplianceURL)
+}
+
+func TestClient_DialContext(t *testing.T) {
+	ts := httptest.NewServer(secretHandler(t))
+	defer ts.Close()
+
+	var dials int32
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      "http://conjur.internal",
+		CredentialStorage: "none",
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			assert.Equal(t, "conjur.internal:80", address)
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, ts.Listener.Addr().String())
+		},
+	}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	secret, err := conjur.RetrieveSecret("db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(secret))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+}
+
+func TestConfig_Validate_UnixSocket(t *testing.T) {
+	config := Config{Account: "cucumber", ApplianceURL: "unix:///"}
+	assert.EqualError(t, config.Validate(), "ApplianceURL 'unix://' must give the socket path")
+
+	config = Config{Account: "cucumber", ApplianceURLs: []string{"unix:///run/conjur.sock", "https://follower"}}
+	assert.EqualError(t, config.Validate(), "ApplianceURLs can't fail over to or from a Unix socket")
+}
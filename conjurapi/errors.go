@@ -0,0 +1,23 @@
+package conjurapi
+
+import "github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+
+// ConjurError is returned by Client methods when Conjur responds with an
+// error. It carries the HTTP status, the Conjur error code and the message.
+type ConjurError = response.ConjurError
+
+// Sentinel errors for use with errors.Is, for example:
+//
+//	_, err := client.RetrieveSecret("db/password")
+//	if errors.Is(err, conjurapi.ErrNotFound) {
+//		// The variable doesn't exist or has no value
+//	}
+var (
+	ErrBadRequest          = response.ErrBadRequest
+	ErrUnauthorized        = response.ErrUnauthorized
+	ErrForbidden           = response.ErrForbidden
+	ErrNotFound            = response.ErrNotFound
+	ErrConflict            = response.ErrConflict
+	ErrUnprocessableEntity = response.ErrUnprocessableEntity
+	ErrServerError         = response.ErrServerError
+)
@@ -0,0 +1,63 @@
+package conjurapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConjurError_Is(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"not_found","message":"CONJ00076E Variable cucumber:variable:missing is empty or not found."}}`))
+		case strings.HasSuffix(r.URL.Path, "/forbidden"):
+			w.WriteHeader(http.StatusForbidden)
+		case strings.HasSuffix(r.URL.Path, "/expired"):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Matches ErrNotFound and exposes the Conjur error code", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NotErrorIs(t, err, ErrForbidden)
+
+		var conjurError *ConjurError
+		assert.True(t, errors.As(err, &conjurError))
+		assert.Equal(t, http.StatusNotFound, conjurError.Code)
+		assert.Equal(t, "not_found", conjurError.ErrorCode())
+	})
+
+	t.Run("Matches ErrForbidden", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("forbidden")
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		var conjurError *ConjurError
+		assert.True(t, errors.As(err, &conjurError))
+		assert.Equal(t, "", conjurError.ErrorCode())
+	})
+
+	t.Run("Matches ErrUnauthorized", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("expired")
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("Matches ErrServerError for any 5xx status", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("other")
+		assert.ErrorIs(t, err, ErrServerError)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,216 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// FailoverHealthCheckInterval is how often the appliances configured
+	// with Config.ApplianceURLs are health-checked to pick the nearest one.
+	FailoverHealthCheckInterval = time.Minute
+	// FailoverHealthCheckTimeout bounds each health check.
+	FailoverHealthCheckTimeout = 2 * time.Second
+)
+
+// applianceURLs returns the appliances to choose between: ApplianceURL
+// followed by ApplianceURLs, without duplicates or trailing slashes.
+func (c *Config) applianceURLs() []string {
+	urls := []string{}
+	for _, applianceURL := range append([]string{c.ApplianceURL}, c.ApplianceURLs...) {
+		applianceURL = strings.TrimSuffix(applianceURL, "/")
+		if applianceURL != "" && !contains(urls, applianceURL) {
+			urls = append(urls, applianceURL)
+		}
+	}
+	return urls
+}
+
+// failoverTransport spreads requests over several Conjur appliances, such as
+// the followers of a cluster. Requests are built against the primary
+// ApplianceURL and sent to the nearest healthy appliance instead. When an
+// appliance can't be reached, the request is sent to the next one.
+type failoverTransport struct {
+	base     http.RoundTripper
+	primary  string
+	selector *applianceSelector
+}
+
+func newFailoverTransport(base http.RoundTripper, urls []string) *failoverTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &failoverTransport{
+		base:     base,
+		primary:  urls[0],
+		selector: &applianceSelector{urls: urls, now: time.Now},
+	}
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestURL := req.URL.String()
+	path := strings.TrimPrefix(requestURL, t.primary)
+	if path == requestURL || (path != "" && path[0] != '/' && path[0] != '?') {
+		return t.base.RoundTrip(req)
+	}
+
+	var lastErr error
+	for i, applianceURL := range t.selector.ranked(t.base) {
+		attempt := req.Clone(req.Context())
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+
+		attemptURL, err := url.Parse(applianceURL + path)
+		if err != nil {
+			return nil, err
+		}
+		attempt.URL = attemptURL
+		attempt.Host = ""
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err == nil || req.Context().Err() != nil || !isConnectionError(err) {
+			return resp, err
+		}
+		t.selector.demote(applianceURL)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// isConnectionError reports whether a request failed because the appliance
+// couldn't be reached, rather than because of the request itself.
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// applianceSelector keeps the appliances of a failoverTransport in order of
+// preference. It's shared by copies of the transport, such as those made for
+// client certificates.
+type applianceSelector struct {
+	urls []string
+	now  func() time.Time
+
+	mutex     sync.Mutex
+	order     []string
+	checkedAt time.Time
+}
+
+// ranked returns the appliances in order of preference. When they haven't
+// been checked recently, the caller health-checks them and gets the new order,
+// while concurrent callers keep getting the last known one rather than
+// waiting for the checks. Only the first call, without a known order, blocks
+// them.
+func (s *applianceSelector) ranked(base http.RoundTripper) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.order == nil {
+		s.order = rankAppliances(base, s.urls)
+		s.checkedAt = s.now()
+	} else if s.now().Sub(s.checkedAt) >= FailoverHealthCheckInterval {
+		// Claim the check, so that concurrent callers don't start their own
+		s.checkedAt = s.now()
+
+		s.mutex.Unlock()
+		order := rankAppliances(base, s.urls)
+		s.mutex.Lock()
+		s.order = order
+	}
+	return append([]string(nil), s.order...)
+}
+
+// demote moves an appliance which couldn't be reached to the end of the
+// order, until the next health check.
+func (s *applianceSelector) demote(applianceURL string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	order := make([]string, 0, len(s.order))
+	for _, u := range s.order {
+		if u != applianceURL {
+			order = append(order, u)
+		}
+	}
+	s.order = append(order, applianceURL)
+}
+
+// rankAppliances health-checks the appliances concurrently and orders the
+// healthy ones by response time, followed by the unhealthy ones in their
+// configured order as a last resort.
+func rankAppliances(base http.RoundTripper, urls []string) []string {
+	latencies := make([]time.Duration, len(urls))
+	healthy := make([]bool, len(urls))
+
+	var wg sync.WaitGroup
+	for i, applianceURL := range urls {
+		wg.Add(1)
+		go func(i int, applianceURL string) {
+			defer wg.Done()
+			latencies[i], healthy[i] = checkApplianceHealth(base, applianceURL)
+		}(i, applianceURL)
+	}
+	wg.Wait()
+
+	indexes := make([]int, len(urls))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		i, j := indexes[a], indexes[b]
+		if healthy[i] != healthy[j] {
+			return healthy[i]
+		}
+		return healthy[i] && latencies[i] < latencies[j]
+	})
+
+	ranked := make([]string, len(urls))
+	for i, index := range indexes {
+		ranked[i] = urls[index]
+	}
+	return ranked
+}
+
+// checkApplianceHealth requests an appliance's /health endpoint, which
+// responds with 200 OK when all of its services are healthy.
+func checkApplianceHealth(base http.RoundTripper, applianceURL string) (time.Duration, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), FailoverHealthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", makeRouterURL(applianceURL, "health").String(), nil)
+	if err != nil {
+		return 0, false
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return 0, false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return time.Since(start), resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,223 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAppliance serves secrets with its name as the value, and reports
+// its health with the given status after the given delay.
+func newTestAppliance(name string, healthStatus int, healthDelay time.Duration) (*httptest.Server, *int32) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			time.Sleep(healthDelay)
+			w.WriteHeader(healthStatus)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		if r.Method == "POST" {
+			body, _ := io.ReadAll(r.Body)
+			w.Write(append([]byte(name+":"), body...))
+			return
+		}
+		w.Write([]byte(name))
+	}))
+	return ts, &requests
+}
+
+func newTestFailoverClient(t *testing.T, urls ...string) *Client {
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      urls[0],
+		ApplianceURLs:     urls[1:],
+		CredentialStorage: "none",
+	}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+	return conjur
+}
+
+func TestClient_Failover(t *testing.T) {
+	t.Run("Fails over when an appliance can't be reached", func(t *testing.T) {
+		down, _ := newTestAppliance("down", http.StatusOK, 0)
+		down.Close()
+		follower, _ := newTestAppliance("follower", http.StatusOK, 0)
+		defer follower.Close()
+
+		conjur := newTestFailoverClient(t, down.URL, follower.URL)
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "follower", string(value))
+	})
+
+	t.Run("Prefers healthy appliances", func(t *testing.T) {
+		unhealthy, unhealthyRequests := newTestAppliance("unhealthy", http.StatusServiceUnavailable, 0)
+		defer unhealthy.Close()
+		follower, _ := newTestAppliance("follower", http.StatusOK, 0)
+		defer follower.Close()
+
+		conjur := newTestFailoverClient(t, unhealthy.URL, follower.URL)
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "follower", string(value))
+		assert.Equal(t, int32(0), atomic.LoadInt32(unhealthyRequests))
+	})
+
+	t.Run("Prefers the nearest appliance", func(t *testing.T) {
+		far, _ := newTestAppliance("far", http.StatusOK, 100*time.Millisecond)
+		defer far.Close()
+		near, _ := newTestAppliance("near", http.StatusOK, 0)
+		defer near.Close()
+
+		conjur := newTestFailoverClient(t, far.URL, near.URL)
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "near", string(value))
+	})
+
+	t.Run("Uses unhealthy appliances as a last resort", func(t *testing.T) {
+		down, _ := newTestAppliance("down", http.StatusOK, 0)
+		down.Close()
+		unhealthy, _ := newTestAppliance("unhealthy", http.StatusServiceUnavailable, 0)
+		defer unhealthy.Close()
+
+		conjur := newTestFailoverClient(t, down.URL, unhealthy.URL)
+		value, err := conjur.RetrieveSecret("db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, "unhealthy", string(value))
+	})
+
+	t.Run("Replays request bodies", func(t *testing.T) {
+		follower, _ := newTestAppliance("follower", http.StatusOK, 0)
+		defer follower.Close()
+
+		config := Config{Account: "cucumber", ApplianceURLs: []string{"http://127.0.0.1:1", follower.URL}, CredentialStorage: "none"}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		// Rank the unreachable appliance first, as if it had just recovered
+		selector := conjur.httpClient.Transport.(*retryTransport).base.(*failoverTransport).selector
+		selector.ranked(http.DefaultTransport)
+		selector.order = []string{"http://127.0.0.1:1", follower.URL}
+
+		req, err := http.NewRequest("POST", "http://127.0.0.1:1/secrets", strings.NewReader("value"))
+		require.NoError(t, err)
+		resp, err := conjur.httpClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "follower:value", string(body))
+	})
+
+	t.Run("Doesn't fail over on error responses", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer failing.Close()
+		follower, followerRequests := newTestAppliance("follower", http.StatusOK, 100*time.Millisecond)
+		defer follower.Close()
+
+		conjur := newTestFailoverClient(t, failing.URL, follower.URL)
+		_, err := conjur.RetrieveSecret("db/password")
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.Equal(t, int32(0), atomic.LoadInt32(followerRequests))
+	})
+}
+
+func TestApplianceSelector(t *testing.T) {
+	t.Run("Demotes appliances until the next health check", func(t *testing.T) {
+		now := time.Now()
+		selector := &applianceSelector{
+			urls: []string{"http://a", "http://b", "http://c"},
+			now:  func() time.Time { return now },
+		}
+		selector.order = []string{"http://a", "http://b", "http://c"}
+		selector.checkedAt = now
+
+		selector.demote("http://a")
+		assert.Equal(t, []string{"http://b", "http://c", "http://a"}, selector.ranked(nil))
+
+		now = now.Add(FailoverHealthCheckInterval)
+		unreachable := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, io.ErrUnexpectedEOF
+		})
+		assert.Equal(t, []string{"http://a", "http://b", "http://c"}, selector.ranked(unreachable))
+	})
+
+	t.Run("Serves the last order to other requests during a health check", func(t *testing.T) {
+		now := time.Now()
+		selector := &applianceSelector{
+			urls: []string{"http://a", "http://b"},
+			now:  func() time.Time { return now.Add(FailoverHealthCheckInterval) },
+		}
+		selector.order = []string{"http://b", "http://a"}
+		selector.checkedAt = now
+
+		checking := make(chan struct{}, 2)
+		release := make(chan struct{})
+		blocking := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			checking <- struct{}{}
+			<-release
+			return nil, io.ErrUnexpectedEOF
+		})
+
+		rechecked := make(chan []string)
+		go func() {
+			rechecked <- selector.ranked(blocking)
+		}()
+		<-checking
+
+		served := make(chan []string)
+		go func() {
+			selector.demote("http://b")
+			served <- selector.ranked(blocking)
+		}()
+		select {
+		case order := <-served:
+			assert.Equal(t, []string{"http://a", "http://b"}, order)
+		case <-time.After(time.Second):
+			t.Fatal("request waited for another request's health check")
+		}
+
+		close(release)
+		assert.Equal(t, []string{"http://a", "http://b"}, <-rechecked)
+	})
+}
+
+func TestConfig_ApplianceURLs(t *testing.T) {
+	t.Run("Defaults ApplianceURL to the first entry", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURLs: []string{"https://follower-1/", "https://follower-2"}, CredentialStorage: "none"}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+		config = conjur.GetConfig()
+		assert.Equal(t, "https://follower-1/", config.ApplianceURL)
+		assert.Equal(t, []string{"https://follower-1", "https://follower-2"}, config.applianceURLs())
+	})
+
+	t.Run("Validates every URL", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "https://leader", ApplianceURLs: []string{"ftp://follower"}}
+		err := config.Validate()
+		assert.EqualError(t, err, "ApplianceURL must use http, https or unix, not 'ftp'")
+	})
+
+	t.Run("Reads a comma-separated list from the environment", func(t *testing.T) {
+		e := ClearEnv()
+		defer e.RestoreEnv()
+		t.Setenv("CONJUR_APPLIANCE_URLS", "https://follower-1,https://follower-2")
+
+		config := LoadConfigFromEnv()
+		assert.Equal(t, []string{"https://follower-1", "https://follower-2"}, config.ApplianceURLs)
+	})
+}
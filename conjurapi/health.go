@@ -0,0 +1,167 @@
+package conjurapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// HealthStatus describes the health of a Conjur appliance, as returned by its
+// /health endpoint.
+type HealthStatus struct {
+	// OK is true when all of the appliance's services and its database are
+	// healthy.
+	OK       bool           `json:"ok"`
+	Role     string         `json:"role"`
+	Services ServicesHealth `json:"services"`
+	Database DatabaseHealth `json:"database"`
+	// Raw is the full response, including any details not described above.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ServicesHealth holds the status of each service running on an appliance,
+// such as "possum" or "ui", which is "ok" when the service is healthy.
+type ServicesHealth struct {
+	OK       bool
+	Statuses map[string]string
+}
+
+func (h *ServicesHealth) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	h.Statuses = map[string]string{}
+	for name, value := range fields {
+		if name == "ok" {
+			if err := json.Unmarshal(value, &h.OK); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var status string
+		if err := json.Unmarshal(value, &status); err == nil {
+			h.Statuses[name] = status
+		}
+	}
+	return nil
+}
+
+// DatabaseHealth describes the appliance's database.
+type DatabaseHealth struct {
+	OK bool `json:"ok"`
+	// Connect holds the status of each database connection, "ok" when
+	// connected.
+	Connect   map[string]string        `json:"connect"`
+	FreeSpace map[string]DatabaseSpace `json:"free_space"`
+	// ReplicationStatus is only reported by appliances which replicate their
+	// database, such as the leader and standbys of a cluster.
+	ReplicationStatus *ReplicationStatus `json:"replication_status"`
+}
+
+// DatabaseSpace is the free space left on a database's volume.
+type DatabaseSpace struct {
+	KBytes int64 `json:"kbytes"`
+	Inodes int64 `json:"inodes"`
+}
+
+// ReplicationStatus describes database replication. A leader reports its
+// current location and the state of each replica, while a standby or
+// follower reports how far it has received and replayed the leader's log.
+type ReplicationStatus struct {
+	CurrentLocation       string          `json:"pg_current_xlog_location,omitempty"`
+	Replicas              []ReplicaStatus `json:"pg_stat_replication,omitempty"`
+	LastReceivedLocation  string          `json:"pg_last_xlog_receive_location,omitempty"`
+	LastReplayedLocation  string          `json:"pg_last_xlog_replay_location,omitempty"`
+	LastReplayedTimestamp string          `json:"pg_last_xact_replay_timestamp,omitempty"`
+}
+
+// ReplicaStatus describes a replica streaming the leader's database.
+type ReplicaStatus struct {
+	User                string `json:"usename"`
+	ApplicationName     string `json:"application_name"`
+	ClientAddress       string `json:"client_addr"`
+	State               string `json:"state"`
+	SentLocation        string `json:"sent_location"`
+	ReplayLocation      string `json:"replay_location"`
+	SyncState           string `json:"sync_state"`
+	ReplicationLagBytes int64  `json:"replication_lag_bytes"`
+}
+
+// Health fetches the health of the Conjur appliance. It doesn't require
+// authentication.
+//
+// An unhealthy appliance responds with an error status, in which case the
+// error is returned along with the HealthStatus describing what's unhealthy.
+// The /health endpoint is only available on Conjur Enterprise; Conjur Open
+// Source responds with ErrNotFound.
+func (c *Client) Health() (*HealthStatus, error) {
+	return c.HealthWithContext(context.Background())
+}
+
+// HealthWithContext is like Health but uses the provided context for the
+// underlying request.
+func (c *Client) HealthWithContext(ctx context.Context) (*HealthStatus, error) {
+	req, err := c.HealthRequest()
+	if err != nil {
+		return nil, err
+	}
+	return c.healthResponse(req.WithContext(ctx))
+}
+
+// RemoteHealth asks the Conjur appliance to check the health of another
+// appliance in its cluster, given by hostname, such as a standby checked from
+// the leader. Errors are returned as in Health.
+func (c *Client) RemoteHealth(host string) (*HealthStatus, error) {
+	return c.RemoteHealthWithContext(context.Background(), host)
+}
+
+// RemoteHealthWithContext is like RemoteHealth but uses the provided context
+// for the underlying request.
+func (c *Client) RemoteHealthWithContext(ctx context.Context, host string) (*HealthStatus, error) {
+	req, err := c.RemoteHealthRequest(host)
+	if err != nil {
+		return nil, err
+	}
+	return c.healthResponse(req.WithContext(ctx))
+}
+
+func (c *Client) healthResponse(req *http.Request) (*HealthStatus, error) {
+	res, err := c.send(operationDefault, req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	status := HealthStatus{}
+	if res.StatusCode >= 300 {
+		err := response.NewConjurError(res)
+		// Only unhealthy appliances respond with a health report
+		report := struct {
+			OK *bool `json:"ok"`
+		}{}
+		if json.Unmarshal(body, &report) != nil || report.OK == nil || json.Unmarshal(body, &status) != nil {
+			return nil, err
+		}
+		status.Raw = body
+		return &status, err
+	}
+
+	if err := response.JSONResponse(res, &status); err != nil {
+		return nil, err
+	}
+	status.Raw = body
+	return &status, nil
+}
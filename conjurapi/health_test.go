@@ -0,0 +1,124 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const leaderHealth = `{
+	"services": {"possum": "ok", "ui": "ok", "ok": true},
+	"database": {
+		"ok": true,
+		"connect": {"main": "ok"},
+		"free_space": {"main": {"kbytes": 12345678, "inodes": 987654}},
+		"replication_status": {
+			"pg_current_xlog_location": "0/3000060",
+			"pg_stat_replication": [{
+				"usename": "standby",
+				"application_name": "conjur-standby",
+				"client_addr": "10.0.0.2",
+				"state": "streaming",
+				"sent_location": "0/3000060",
+				"replay_location": "0/3000000",
+				"sync_state": "async",
+				"replication_lag_bytes": 96
+			}]
+		}
+	},
+	"audit": {"ok": true, "processed": 12},
+	"role": "master",
+	"ok": true
+}`
+
+const unhealthyFollowerHealth = `{
+	"services": {"possum": "ok", "ui": "failed", "ok": false},
+	"database": {
+		"ok": true,
+		"connect": {"main": "ok"},
+		"replication_status": {"pg_last_xlog_receive_location": "0/3000060", "pg_last_xlog_replay_location": "0/3000000"}
+	},
+	"role": "follower",
+	"ok": false
+}`
+
+func TestClient_Health(t *testing.T) {
+	t.Run("Parses a healthy leader's report", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/health", r.URL.Path)
+			w.Write([]byte(leaderHealth))
+		}))
+		defer ts.Close()
+
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		require.NoError(t, err)
+
+		health, err := conjur.Health()
+		require.NoError(t, err)
+		assert.True(t, health.OK)
+		assert.Equal(t, "master", health.Role)
+		assert.True(t, health.Services.OK)
+		assert.Equal(t, map[string]string{"possum": "ok", "ui": "ok"}, health.Services.Statuses)
+		assert.Equal(t, map[string]string{"main": "ok"}, health.Database.Connect)
+		assert.Equal(t, DatabaseSpace{KBytes: 12345678, Inodes: 987654}, health.Database.FreeSpace["main"])
+		require.NotNil(t, health.Database.ReplicationStatus)
+		assert.Equal(t, "0/3000060", health.Database.ReplicationStatus.CurrentLocation)
+		require.Len(t, health.Database.ReplicationStatus.Replicas, 1)
+		assert.Equal(t, "conjur-standby", health.Database.ReplicationStatus.Replicas[0].ApplicationName)
+		assert.Equal(t, int64(96), health.Database.ReplicationStatus.Replicas[0].ReplicationLagBytes)
+		assert.Contains(t, string(health.Raw), `"audit"`)
+	})
+
+	t.Run("Returns the report of an unhealthy appliance with the error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(unhealthyFollowerHealth))
+		}))
+		defer ts.Close()
+
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		require.NoError(t, err)
+
+		health, err := conjur.Health()
+		assert.ErrorIs(t, err, ErrServerError)
+		require.NotNil(t, health)
+		assert.False(t, health.OK)
+		assert.Equal(t, "failed", health.Services.Statuses["ui"])
+		assert.Equal(t, "0/3000000", health.Database.ReplicationStatus.LastReplayedLocation)
+	})
+
+	t.Run("Returns ErrNotFound on Conjur Open Source", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "not_found", "message": "Not found"}}`))
+		}))
+		defer ts.Close()
+
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		require.NoError(t, err)
+
+		health, err := conjur.Health()
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, health)
+	})
+}
+
+func TestClient_RemoteHealth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/remote_health/conjur-standby.example.com", r.URL.Path)
+		w.Write([]byte(`{"services": {"possum": "ok", "ok": true}, "database": {"ok": true}, "role": "standby", "ok": true}`))
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	health, err := conjur.RemoteHealth("conjur-standby.example.com")
+	require.NoError(t, err)
+	assert.True(t, health.OK)
+	assert.Equal(t, "standby", health.Role)
+}
@@ -1,9 +1,10 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 	"net/url"
 	"time"
 )
@@ -25,6 +26,12 @@ type HostFactoryHostResponse struct {
 }
 
 func (c *Client) CreateToken(durationStr string, hostFactory string, cidrs []string, count int) ([]HostFactoryTokenResponse, error) {
+	return c.CreateTokenWithContext(context.Background(), durationStr, hostFactory, cidrs, count)
+}
+
+// CreateTokenWithContext is like CreateToken but uses the provided context for
+// the underlying request.
+func (c *Client) CreateTokenWithContext(ctx context.Context, durationStr string, hostFactory string, cidrs []string, count int) ([]HostFactoryTokenResponse, error) {
 
 	data := url.Values{}
 	duration, err := time.ParseDuration(durationStr)
@@ -43,10 +50,10 @@ func (c *Client) CreateToken(durationStr string, hostFactory string, cidrs []str
 	for _, cidr := range cidrs {
 		data.Add("cidr[]", cidr)
 	}
-	return c.createToken(data)
+	return c.createToken(ctx, data)
 }
 
-func (c *Client) createToken(data url.Values) ([]HostFactoryTokenResponse, error) {
+func (c *Client) createToken(ctx context.Context, data url.Values) ([]HostFactoryTokenResponse, error) {
 
 	encodedData := data.Encode()
 
@@ -55,7 +62,7 @@ func (c *Client) createToken(data url.Values) ([]HostFactoryTokenResponse, error
 		return nil, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -73,13 +80,18 @@ func (c *Client) createToken(data url.Values) ([]HostFactoryTokenResponse, error
 }
 
 func (c *Client) DeleteToken(token string) error {
+	return c.DeleteTokenWithContext(context.Background(), token)
+}
 
+// DeleteTokenWithContext is like DeleteToken but uses the provided context for
+// the underlying request.
+func (c *Client) DeleteTokenWithContext(ctx context.Context, token string) error {
 	req, err := c.DeleteTokenRequest(token)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -87,12 +99,18 @@ func (c *Client) DeleteToken(token string) error {
 }
 
 func (c *Client) CreateHost(id string, token string) (HostFactoryHostResponse, error) {
+	return c.CreateHostWithContext(context.Background(), id, token)
+}
+
+// CreateHostWithContext is like CreateHost but uses the provided context for
+// the underlying request.
+func (c *Client) CreateHostWithContext(ctx context.Context, id string, token string) (HostFactoryHostResponse, error) {
 	data := url.Values{}
 	data.Set("id", id)
-	return c.createHost(data, token)
+	return c.createHost(ctx, data, token)
 }
 
-func (c *Client) createHost(data url.Values, token string) (HostFactoryHostResponse, error) {
+func (c *Client) createHost(ctx context.Context, data url.Values, token string) (HostFactoryHostResponse, error) {
 
 	var jsonResponse HostFactoryHostResponse
 	encodedData := data.Encode()
@@ -101,7 +119,7 @@ func (c *Client) createHost(data url.Values, token string) (HostFactoryHostRespo
 		return jsonResponse, err
 	}
 
-	resp, err := c.submitRequestWithCustomAuth(req)
+	resp, err := c.submitRequestWithCustomAuth(req.WithContext(ctx))
 	if err != nil {
 		return jsonResponse, err
 	}
@@ -2,7 +2,7 @@ package conjurapi
 
 import (
 	"fmt"
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"strings"
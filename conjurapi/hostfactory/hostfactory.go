@@ -0,0 +1,159 @@
+// Package hostfactory provides typed access to the Conjur host factory API,
+// which provisioning pipelines use to enroll new hosts without an API key.
+package hostfactory
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// Token is a host factory token, which can be used to create hosts until it
+// expires or is revoked.
+type Token struct {
+	Token      string    `json:"token"`
+	Expiration time.Time `json:"expiration"`
+	CIDR       []string  `json:"cidr"`
+}
+
+// Host is a host created with a host factory token.
+type Host struct {
+	ID           string                         `json:"id"`
+	Owner        string                         `json:"owner"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	APIKey       string                         `json:"api_key"`
+	Permissions  []conjurapi.ResourcePermission `json:"permissions"`
+	Annotations  []conjurapi.ResourceAnnotation `json:"annotations"`
+	RestrictedTo []string                       `json:"restricted_to"`
+}
+
+// Client manages host factory tokens and hosts using a Conjur client.
+type Client struct {
+	conjur *conjurapi.Client
+}
+
+// NewClient creates a host factory client. Token management requires the
+// Conjur client to be authenticated as a role with execute privilege on the
+// host factory; host creation only requires a host factory token.
+func NewClient(conjur *conjurapi.Client) *Client {
+	return &Client{conjur: conjur}
+}
+
+// CreateHostFactoryTokens creates count tokens for the host factory, valid
+// until expiration. When cidrs is non-empty, hosts may only be created from
+// those address ranges, and the created hosts are restricted to them.
+func (c *Client) CreateHostFactoryTokens(hostFactoryID string, expiration time.Time, cidrs []string, count int) ([]Token, error) {
+	return c.CreateHostFactoryTokensWithContext(context.Background(), hostFactoryID, expiration, cidrs, count)
+}
+
+// CreateHostFactoryTokensWithContext is like CreateHostFactoryTokens but uses
+// the provided context for the underlying request.
+func (c *Client) CreateHostFactoryTokensWithContext(ctx context.Context, hostFactoryID string, expiration time.Time, cidrs []string, count int) ([]Token, error) {
+	fullID, err := c.qualifyHostFactoryID(hostFactoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := url.Values{}
+	data.Set("host_factory", fullID)
+	data.Set("expiration", expiration.UTC().Format(time.RFC3339))
+	if count > 0 {
+		data.Set("count", fmt.Sprint(count))
+	}
+	for _, cidr := range cidrs {
+		data.Add("cidr[]", cidr)
+	}
+
+	req, err := c.conjur.CreateTokenRequest(data.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.conjur.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []Token{}
+	if err := response.JSONResponse(resp, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes a host factory token, so that it can no longer be used
+// to create hosts.
+func (c *Client) RevokeToken(token string) error {
+	return c.RevokeTokenWithContext(context.Background(), token)
+}
+
+// RevokeTokenWithContext is like RevokeToken but uses the provided context for
+// the underlying request.
+func (c *Client) RevokeTokenWithContext(ctx context.Context, token string) error {
+	req, err := c.conjur.DeleteTokenRequest(url.PathEscape(token))
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.conjur.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	return response.EmptyResponse(resp)
+}
+
+// CreateHost creates a host in the host factory's layers using a host factory
+// token, and returns it along with its API key. The annotations are set on the
+// new host.
+func (c *Client) CreateHost(token, hostID string, annotations map[string]string) (*Host, error) {
+	return c.CreateHostWithContext(context.Background(), token, hostID, annotations)
+}
+
+// CreateHostWithContext is like CreateHost but uses the provided context for
+// the underlying request.
+func (c *Client) CreateHostWithContext(ctx context.Context, token, hostID string, annotations map[string]string) (*Host, error) {
+	data := url.Values{}
+	data.Set("id", hostID)
+	for name, value := range annotations {
+		data.Set(fmt.Sprintf("annotations[%s]", name), value)
+	}
+
+	req, err := c.conjur.CreateHostRequest(data.Encode(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	// The host factory token authenticates the request, not the client's token
+	resp, err := c.conjur.GetHttpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	host := Host{}
+	if err := response.JSONResponse(resp, &host); err != nil {
+		return nil, err
+	}
+	return &host, nil
+}
+
+// qualifyHostFactoryID returns the fully-qualified ID of a host factory given
+// as <id>, host_factory:<id> or <account>:host_factory:<id>.
+func (c *Client) qualifyHostFactoryID(hostFactoryID string) (string, error) {
+	tokens := strings.SplitN(hostFactoryID, ":", 3)
+	switch len(tokens) {
+	case 1:
+		tokens = []string{c.conjur.GetConfig().Account, "host_factory", tokens[0]}
+	case 2:
+		tokens = []string{c.conjur.GetConfig().Account, tokens[0], tokens[1]}
+	}
+
+	if tokens[1] != "host_factory" || tokens[2] == "" {
+		return "", fmt.Errorf("host factory ID '%s' is not valid", hostFactoryID)
+	}
+	return strings.Join(tokens, ":"), nil
+}
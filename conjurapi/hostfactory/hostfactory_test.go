@@ -0,0 +1,142 @@
+package hostfactory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sampleToken = `{"protected":"eyJhbGciOiJjb25qdXIub3JnL3Nsb3NpbG8vdjIiLCJraWQiOiI5M2VjNTEwODRmZTM3Zjc3M2I1ODhlNTYyYWVjZGMxMSJ9","payload":"eyJzdWIiOiJhZG1pbiIsImlhdCI6MTUxMDc1MzI1OSwiZXhwIjo0MTAzMzc5MTY0fQo=","signature":"raCufKOf7sKzciZInQTphu1mBbLhAdIJM72ChLB4m5wKWxFnNz_7LawQ9iYEI_we1-tdZtTXoopn_T1qoTplR9_Bo3KkpI5Hj3DB7SmBpR3CSRTnnEwkJ0_aJ8bql5Cbst4i4rSftyEmUqX-FDOqJdAztdi9BUJyLfbeKTW9OGg-QJQzPX1ucB7IpvTFCEjMoO8KUxZpbHj-KpwqAMZRooG4ULBkxp5nSfs-LN27JupU58oRgIfaWASaDmA98O2x6o88MFpxK_M0FeFGuDKewNGrRc8lCOtTQ9cULA080M5CSnruCqu1Qd52r72KIOAfyzNIiBCLTkblz2fZyEkdSKQmZ8J3AakxQE2jyHmMT-eXjfsEIzEt-IRPJIirI3Qm"}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	conjur, err := conjurapi.NewClientFromToken(conjurapi.Config{
+		Account:           "cucumber",
+		ApplianceURL:      ts.URL,
+		CredentialStorage: "none",
+	}, sampleToken)
+	require.NoError(t, err)
+	return NewClient(conjur)
+}
+
+func TestClient_CreateHostFactoryTokens(t *testing.T) {
+	expiration := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("Sends the qualified host factory ID and options", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/host_factory_tokens", r.URL.Path)
+			assert.Contains(t, r.Header.Get("Authorization"), "Token token=")
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "cucumber:host_factory:apps/hf", r.PostForm.Get("host_factory"))
+			assert.Equal(t, "2030-01-02T03:04:05Z", r.PostForm.Get("expiration"))
+			assert.Equal(t, "2", r.PostForm.Get("count"))
+			assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, r.PostForm["cidr[]"])
+
+			w.Write([]byte(`[
+				{"expiration":"2030-01-02T03:04:05Z","cidr":["10.0.0.0/8","192.168.1.1/32"],"token":"token-1"},
+				{"expiration":"2030-01-02T03:04:05Z","cidr":["10.0.0.0/8","192.168.1.1/32"],"token":"token-2"}
+			]`))
+		})
+
+		tokens, err := client.CreateHostFactoryTokens("apps/hf", expiration, []string{"10.0.0.0/8", "192.168.1.1"}, 2)
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		assert.Equal(t, "token-1", tokens[0].Token)
+		assert.Equal(t, "token-2", tokens[1].Token)
+		assert.True(t, expiration.Equal(tokens[0].Expiration))
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1/32"}, tokens[0].CIDR)
+	})
+
+	t.Run("Omits count when not positive", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "other:host_factory:hf", r.PostForm.Get("host_factory"))
+			_, ok := r.PostForm["count"]
+			assert.False(t, ok)
+			w.Write([]byte(`[]`))
+		})
+
+		tokens, err := client.CreateHostFactoryTokens("other:host_factory:hf", expiration, nil, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("Rejects IDs of other kinds", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("unexpected request")
+		})
+
+		_, err := client.CreateHostFactoryTokens("layer:apps", expiration, nil, 1)
+		assert.EqualError(t, err, "host factory ID 'layer:apps' is not valid")
+	})
+
+	t.Run("Returns Conjur errors", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := client.CreateHostFactoryTokens("hf", expiration, nil, 1)
+		assert.ErrorIs(t, err, conjurapi.ErrNotFound)
+	})
+}
+
+func TestClient_RevokeToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/host_factory_tokens/token-1", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.NoError(t, client.RevokeToken("token-1"))
+}
+
+func TestClient_CreateHost(t *testing.T) {
+	t.Run("Authenticates with the host factory token", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/host_factories/hosts", r.URL.Path)
+			assert.Equal(t, `Token token="hf-token"`, r.Header.Get("Authorization"))
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "new-host", r.PostForm.Get("id"))
+			assert.Equal(t, "prod", r.PostForm.Get("annotations[env]"))
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{
+				"created_at": "2030-01-02T03:04:05.000+00:00",
+				"id": "cucumber:host:apps/new-host",
+				"owner": "cucumber:host_factory:apps/hf",
+				"permissions": [{"privilege":"read","role":"cucumber:user:admin"}],
+				"annotations": [{"name":"env","value":"prod"}],
+				"restricted_to": ["10.0.0.0/8"],
+				"api_key": "the-api-key"
+			}`))
+		})
+
+		host, err := client.CreateHost("hf-token", "new-host", map[string]string{"env": "prod"})
+		require.NoError(t, err)
+		assert.Equal(t, "cucumber:host:apps/new-host", host.ID)
+		assert.Equal(t, "cucumber:host_factory:apps/hf", host.Owner)
+		assert.Equal(t, "the-api-key", host.APIKey)
+		assert.Equal(t, []conjurapi.ResourcePermission{{Privilege: "read", Role: "cucumber:user:admin"}}, host.Permissions)
+		assert.Equal(t, []conjurapi.ResourceAnnotation{{Name: "env", Value: "prod"}}, host.Annotations)
+		assert.Equal(t, []string{"10.0.0.0/8"}, host.RestrictedTo)
+		assert.Equal(t, 2030, host.CreatedAt.Year())
+	})
+
+	t.Run("Returns Conjur errors", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		_, err := client.CreateHost("bad-token", "new-host", nil)
+		assert.ErrorIs(t, err, conjurapi.ErrUnauthorized)
+	})
+}
@@ -0,0 +1,77 @@
+package conjurapi
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// ProxyEnvironment makes the client use the proxy given by the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyEnvironment = "environment"
+	// ProxyNone makes the client connect to Conjur directly, ignoring the
+	// proxy environment variables.
+	ProxyNone = "none"
+
+	// HTTPVersion1 makes the client only speak HTTP/1.1, for load balancers
+	// which misbehave with HTTP/2.
+	HTTPVersion1 = "1.1"
+	// HTTPVersion2 makes the client negotiate HTTP/2 with HTTPS appliances
+	// which support it.
+	HTTPVersion2 = "2"
+)
+
+var httpVersions = []string{HTTPVersion1, HTTPVersion2}
+
+// hasTransportSettings reports whether the config overrides the proxy or HTTP
+// version of the client's transport.
+func (c *Config) hasTransportSettings() bool {
+	return c.Proxy != "" || c.HTTPVersion != ""
+}
+
+// validateProxy checks that Proxy is ProxyEnvironment, ProxyNone or a proxy
+// URL.
+func (c *Config) validateProxy() error {
+	if c.Proxy == "" || c.Proxy == ProxyEnvironment || c.Proxy == ProxyNone {
+		return nil
+	}
+	_, err := c.proxyURL()
+	return err
+}
+
+func (c *Config) proxyURL() (*url.URL, error) {
+	u, err := url.Parse(c.Proxy)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+		return nil, fmt.Errorf("proxy must be '%s', '%s' or an http, https or socks5 URL", ProxyEnvironment, ProxyNone)
+	}
+	return u, nil
+}
+
+// configureHTTPTransport applies the config's proxy and HTTP version to a
+// transport.
+func configureHTTPTransport(transport *http.Transport, config Config) {
+	switch config.Proxy {
+	case "":
+	case ProxyEnvironment:
+		transport.Proxy = http.ProxyFromEnvironment
+	case ProxyNone:
+		transport.Proxy = nil
+	default:
+		// Validate has checked the URL
+		if proxyURL, err := config.proxyURL(); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	switch config.HTTPVersion {
+	case HTTPVersion1:
+		// A non-nil, empty TLSNextProto disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case HTTPVersion2:
+		transport.ForceAttemptHTTP2 = true
+		transport.TLSNextProto = nil
+	}
+}
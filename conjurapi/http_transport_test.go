@@ -0,0 +1,88 @@
+package conjurapi
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Proxy(t *testing.T) {
+	transportOf := func(t *testing.T, config Config) *http.Transport {
+		client, err := createHttpClient(config)
+		require.NoError(t, err)
+		return client.Transport.(*retryTransport).base.(*http.Transport)
+	}
+
+	t.Run("Sends requests through the proxy URL", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Proxied requests carry the absolute URL
+			assert.Equal(t, "http://conjur.example/secrets/cucumber/variable/db-password", r.URL.String())
+			w.Write([]byte("secret"))
+		}))
+		defer proxy.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: "http://conjur.example", CredentialStorage: "none", Proxy: proxy.URL}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		secret, err := conjur.RetrieveSecret("db-password")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Honors the environment when asked", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "https://conjur.example", SSLCert: sample_cert, Proxy: ProxyEnvironment}
+		assert.NotNil(t, transportOf(t, config).Proxy)
+
+		// HTTPS clients don't use the environment by default
+		config.Proxy = ""
+		assert.Nil(t, transportOf(t, config).Proxy)
+	})
+
+	t.Run("Connects directly when disabled", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "http://conjur.example", Proxy: ProxyNone}
+		assert.Nil(t, transportOf(t, config).Proxy)
+	})
+
+	t.Run("Rejects invalid proxies", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "http://conjur.example", Proxy: "proxy.example:3128"}
+		assert.EqualError(t, config.Validate(), "proxy must be 'environment', 'none' or an http, https or socks5 URL")
+	})
+}
+
+func TestClient_HTTPVersion(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	cert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+
+	protocol := func(t *testing.T, httpVersion string) string {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, SSLCert: cert, CredentialStorage: "none", HTTPVersion: httpVersion}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		proto, err := conjur.RetrieveSecret("proto")
+		require.NoError(t, err)
+		return string(proto)
+	}
+
+	t.Run("Negotiates HTTP/2 when asked", func(t *testing.T) {
+		assert.Equal(t, "HTTP/2.0", protocol(t, HTTPVersion2))
+	})
+
+	t.Run("Forces HTTP/1.1", func(t *testing.T) {
+		assert.Equal(t, "HTTP/1.1", protocol(t, HTTPVersion1))
+	})
+
+	t.Run("Rejects unknown versions", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, HTTPVersion: "3"}
+		assert.EqualError(t, config.Validate(), "HTTPVersion must be one of [1.1 2]")
+	})
+}
@@ -0,0 +1,6 @@
+//go:build fips && boringcrypto
+
+package fips
+
+// Restrict crypto/tls to FIPS settings in BoringCrypto builds.
+import _ "crypto/tls/fipsonly"
@@ -0,0 +1,36 @@
+// Package fips reports whether the library is restricted to FIPS-approved
+// cryptography, and applies those restrictions.
+//
+// The restrictions apply when the library is built with the "fips" build tag,
+// or when Go's FIPS 140 module is enabled, as with GODEBUG=fips140=on in Go
+// 1.24 and later. Conjur's cryptography is only provided by a validated module
+// when Go's own module is enabled, or when the "fips" tag is combined with
+// GOEXPERIMENT=boringcrypto, which restricts crypto/tls to FIPS settings.
+package fips
+
+import "crypto/tls"
+
+// MinHMACKeySize is the smallest HMAC key, in bytes, allowed in FIPS mode.
+const MinHMACKeySize = 14
+
+// Enabled reports whether only FIPS-approved cryptography may be used.
+func Enabled() bool {
+	return tagged || moduleEnabled()
+}
+
+// ConfigureTLS restricts a TLS config to FIPS-approved protocol versions,
+// cipher suites and curves. TLS 1.3 is only allowed when Go's FIPS 140 module
+// is enabled, since its cipher suites can't be configured otherwise.
+func ConfigureTLS(tlsConfig *tls.Config) {
+	tlsConfig.MinVersion = tls.VersionTLS12
+	if !moduleEnabled() {
+		tlsConfig.MaxVersion = tls.VersionTLS12
+	}
+	tlsConfig.CipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	}
+	tlsConfig.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+}
@@ -0,0 +1,44 @@
+package fips
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTLS(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "conjur.example.com"}
+	ConfigureTLS(tlsConfig)
+
+	assert.Equal(t, "conjur.example.com", tlsConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, []tls.CurveID{tls.CurveP256, tls.CurveP384}, tlsConfig.CurvePreferences)
+	for _, id := range tlsConfig.CipherSuites {
+		suite := tls.CipherSuiteName(id)
+		assert.Contains(t, suite, "_GCM_", "cipher suite %s", suite)
+		assert.Contains(t, suite, "ECDHE", "cipher suite %s", suite)
+	}
+}
+
+func TestNewGCM(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	sealed := aead.Seal(append([]byte{}, nonce...), nonce, []byte("token"), nil)
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(plaintext))
+}
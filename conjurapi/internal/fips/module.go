@@ -0,0 +1,23 @@
+//go:build go1.24
+
+package fips
+
+import (
+	"crypto/cipher"
+	"crypto/fips140"
+)
+
+func moduleEnabled() bool {
+	return fips140.Enabled()
+}
+
+// NewGCM returns a GCM cipher for messages encrypted with random nonces. When
+// Go's FIPS 140 module is enabled, the module generates the nonce itself: the
+// cipher's NonceSize is zero, and Seal prepends the nonce to the ciphertext,
+// as callers which store the nonce before the ciphertext would.
+func NewGCM(block cipher.Block) (cipher.AEAD, error) {
+	if fips140.Enabled() {
+		return cipher.NewGCMWithRandomNonce(block)
+	}
+	return cipher.NewGCM(block)
+}
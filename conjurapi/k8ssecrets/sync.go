@@ -0,0 +1,140 @@
+// Package k8ssecrets fills Kubernetes Secrets with values fetched from
+// Conjur, for controllers such as secrets providers. It works on the Data and
+// Annotations fields of a corev1.Secret rather than on the type itself, so
+// that the client doesn't depend on the Kubernetes API modules:
+//
+//	values, err := k8ssecrets.Fetch(conjur, map[string]string{
+//		"password": "prod/db/password",
+//	})
+//	if values.Apply(&secret.Data, &secret.Annotations) {
+//		// update the Secret with the Kubernetes client
+//	}
+package k8ssecrets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+)
+
+// HashAnnotation is the Secret annotation recording the hash of the values
+// last applied, which can be copied to a pod template to restart pods when
+// the values change.
+const HashAnnotation = "conjur.org/secrets-hash"
+
+// Values are the values of a Secret's keys fetched from Conjur.
+type Values struct {
+	// Data maps the Secret's keys to their values.
+	Data map[string][]byte
+	// Hash is the SHA-256 hash of the keys and values, hex-encoded.
+	Hash string
+}
+
+// Fetch fetches the values of a Secret's keys with a single batch request.
+// The mapping maps each key to the ID of the Conjur variable holding its
+// value, like the conjur-map of the Secrets Provider for Kubernetes, as
+// several keys may hold the same variable. Variable IDs may be qualified with
+// the account.
+func Fetch(provider conjurapi.SecretsProvider, mapping map[string]string) (*Values, error) {
+	variableIDs := []string{}
+	for _, variableID := range mapping {
+		if !contains(variableIDs, variableID) {
+			variableIDs = append(variableIDs, variableID)
+		}
+	}
+	sort.Strings(variableIDs)
+
+	secrets := map[string][]byte{}
+	if len(variableIDs) > 0 {
+		var err error
+		secrets, err = provider.RetrieveBatchSecrets(variableIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	values := &Values{Data: make(map[string][]byte, len(mapping))}
+	for key, variableID := range mapping {
+		value, ok := lookup(secrets, variableID)
+		if !ok {
+			return nil, fmt.Errorf("no value was returned for variable '%s' of key '%s'", variableID, key)
+		}
+		values.Data[key] = value
+	}
+	values.Hash = hash(values.Data)
+	return values, nil
+}
+
+// Apply sets the fetched keys of a Secret, given its Data and Annotations
+// fields, along with the HashAnnotation. Other keys are left as they are.
+// Either map may be nil, in which case it's created. Apply reports whether
+// anything changed, and so whether the Secret needs updating.
+func (v *Values) Apply(data *map[string][]byte, annotations *map[string]string) bool {
+	if *data == nil {
+		*data = map[string][]byte{}
+	}
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+
+	changed := (*annotations)[HashAnnotation] != v.Hash
+	for key, value := range v.Data {
+		if current, ok := (*data)[key]; !ok || !bytes.Equal(current, value) {
+			(*data)[key] = value
+			changed = true
+		}
+	}
+	(*annotations)[HashAnnotation] = v.Hash
+	return changed
+}
+
+// lookup finds a variable's value in a batch result, which is keyed by fully
+// qualified ID.
+func lookup(secrets map[string][]byte, variableID string) ([]byte, bool) {
+	if value, ok := secrets[variableID]; ok {
+		return value, true
+	}
+	for id, value := range secrets {
+		parts := strings.SplitN(id, ":", 3)
+		if len(parts) == 3 && (parts[2] == variableID || parts[1]+":"+parts[2] == variableID) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// hash hashes the keys and values with their lengths, so that different data
+// can't produce the same input.
+func hash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	length := make([]byte, 8)
+	for _, key := range keys {
+		for _, field := range [][]byte{[]byte(key), data[key]} {
+			binary.BigEndian.PutUint64(length, uint64(len(field)))
+			h.Write(length)
+			h.Write(field)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package k8ssecrets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/conjurtest"
+)
+
+func TestFetch(t *testing.T) {
+	provider := conjurtest.NewSecretsProvider("cucumber", map[string]string{
+		"prod/db/password": "s3cret",
+		"prod/db/user":     "app",
+	})
+
+	values, err := Fetch(provider, map[string]string{
+		"password":    "prod/db/password",
+		"DB_PASSWORD": "cucumber:variable:prod/db/password",
+		"username":    "variable:prod/db/user",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"password":    []byte("s3cret"),
+		"DB_PASSWORD": []byte("s3cret"),
+		"username":    []byte("app"),
+	}, values.Data)
+	assert.Len(t, values.Hash, 64)
+	assert.Len(t, provider.Retrieved(), 3)
+
+	t.Run("Hashes keys and values", func(t *testing.T) {
+		renamed, err := Fetch(provider, map[string]string{"pass": "prod/db/password"})
+		require.NoError(t, err)
+		other, err := Fetch(provider, map[string]string{"password": "prod/db/password"})
+		require.NoError(t, err)
+		assert.NotEqual(t, renamed.Hash, other.Hash)
+		assert.NotEqual(t, values.Hash, other.Hash)
+	})
+
+	t.Run("Fails when a variable can't be retrieved", func(t *testing.T) {
+		provider.SetError("prod/db/user", errors.New("forbidden"))
+		defer provider.SetError("prod/db/user", nil)
+
+		_, err := Fetch(provider, map[string]string{"username": "prod/db/user"})
+		assert.EqualError(t, err, "forbidden")
+	})
+}
+
+func TestValues_Apply(t *testing.T) {
+	provider := conjurtest.NewSecretsProvider("cucumber", map[string]string{"prod/db/password": "s3cret"})
+	values, err := Fetch(provider, map[string]string{"password": "prod/db/password"})
+	require.NoError(t, err)
+
+	var data map[string][]byte
+	var annotations map[string]string
+	assert.True(t, values.Apply(&data, &annotations))
+	assert.Equal(t, []byte("s3cret"), data["password"])
+	assert.Equal(t, values.Hash, annotations[HashAnnotation])
+
+	t.Run("Reports unchanged Secrets", func(t *testing.T) {
+		assert.False(t, values.Apply(&data, &annotations))
+	})
+
+	t.Run("Restores edited values and keeps other keys", func(t *testing.T) {
+		data["password"] = []byte("edited")
+		data["other"] = []byte("kept")
+		assert.True(t, values.Apply(&data, &annotations))
+		assert.Equal(t, []byte("s3cret"), data["password"])
+		assert.Equal(t, []byte("kept"), data["other"])
+	})
+}
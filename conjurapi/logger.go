@@ -0,0 +1,117 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LogLevel is the severity of a Logger event.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// Logger receives structured events about the client's HTTP requests to
+// Conjur: the method and URL path, response status, latency and retries.
+// Fields never contain query strings, request or response bodies, secret
+// values, credentials or access tokens.
+type Logger interface {
+	Log(level LogLevel, message string, fields map[string]interface{})
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface.
+type LoggerFunc func(level LogLevel, message string, fields map[string]interface{})
+
+// Log calls f(level, message, fields).
+func (f LoggerFunc) Log(level LogLevel, message string, fields map[string]interface{}) {
+	f(level, message, fields)
+}
+
+// WithLogger makes the client report its HTTP requests to the given logger.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// loggingTransport is an http.RoundTripper that reports each request made
+// through the wrapped transport to a Logger.
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	fields := requestLogFields(req)
+	fields["latency"] = time.Since(start)
+	if err != nil {
+		fields["error"] = err.Error()
+		t.logger.Log(LogLevelError, "Conjur request failed", fields)
+		return resp, err
+	}
+
+	fields["status"] = resp.StatusCode
+	level := LogLevelDebug
+	switch {
+	case resp.StatusCode >= 500:
+		level = LogLevelWarn
+	case resp.StatusCode >= 400:
+		level = LogLevelInfo
+	}
+	t.logger.Log(level, "Conjur request", fields)
+	return resp, nil
+}
+
+// requestLogFields describes a request without its query string, headers or
+// body, any of which may carry credentials, but with its request ID.
+func requestLogFields(req *http.Request) map[string]interface{} {
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+	if requestID := req.Header.Get(RequestIDHeader); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	return fields
+}
+
+// withLogging returns a copy of an HTTP client which reports its requests,
+// and any retries, to the logger.
+func withLogging(client *http.Client, logger Logger) *http.Client {
+	httpClient := *client
+	if retry, ok := client.Transport.(*retryTransport); ok {
+		logged := *retry
+		logged.base = &loggingTransport{base: retry.base, logger: logger}
+		logged.logger = logger
+		httpClient.Transport = &logged
+	} else {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		httpClient.Transport = &loggingTransport{base: base, logger: logger}
+	}
+	return &httpClient
+}
@@ -0,0 +1,147 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logEvent struct {
+	level   LogLevel
+	message string
+	fields  map[string]interface{}
+}
+
+type recordingLogger struct {
+	mutex  sync.Mutex
+	events []logEvent
+}
+
+func (l *recordingLogger) Log(level LogLevel, message string, fields map[string]interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, logEvent{level, message, fields})
+}
+
+func TestLogLevel_String(t *testing.T) {
+	assert.Equal(t, "debug", LogLevelDebug.String())
+	assert.Equal(t, "info", LogLevelInfo.String())
+	assert.Equal(t, "warn", LogLevelWarn.String())
+	assert.Equal(t, "error", LogLevelError.String())
+	assert.Equal(t, "LogLevel(7)", LogLevel(7).String())
+}
+
+func TestWithLogger(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/secrets/cucumber/variable/flaky":
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/secrets/cucumber/variable/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("super-secret-value"))
+	}))
+	defer ts.Close()
+
+	newClient := func(t *testing.T, logger Logger, opts ...ClientOption) *Client {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", RetryPolicy: RetryPolicy{BackoffBase: time.Millisecond}}
+		conjur, err := NewClientFromToken(config, sample_token, append([]ClientOption{WithLogger(logger)}, opts...)...)
+		assert.NoError(t, err)
+		return conjur
+	}
+
+	t.Run("Logs requests without secrets or tokens", func(t *testing.T) {
+		logger := &recordingLogger{}
+		conjur := newClient(t, logger)
+
+		_, err := conjur.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+
+		assert.Len(t, logger.events, 1)
+		event := logger.events[0]
+		assert.Equal(t, LogLevelDebug, event.level)
+		assert.Equal(t, "Conjur request", event.message)
+		assert.Equal(t, "GET", event.fields["method"])
+		assert.Equal(t, "/secrets/cucumber/variable/db-password", event.fields["path"])
+		assert.Equal(t, 200, event.fields["status"])
+		assert.IsType(t, time.Duration(0), event.fields["latency"])
+
+		logged := fmt.Sprint(logger.events)
+		assert.NotContains(t, logged, "super-secret-value")
+		assert.NotContains(t, logged, "Token token")
+	})
+
+	t.Run("Logs client errors at info level", func(t *testing.T) {
+		logger := &recordingLogger{}
+		conjur := newClient(t, logger)
+
+		_, err := conjur.RetrieveSecret("missing")
+		assert.Error(t, err)
+		assert.Len(t, logger.events, 1)
+		assert.Equal(t, LogLevelInfo, logger.events[0].level)
+		assert.Equal(t, 404, logger.events[0].fields["status"])
+	})
+
+	t.Run("Logs retries", func(t *testing.T) {
+		logger := &recordingLogger{}
+		conjur := newClient(t, logger)
+
+		_, err := conjur.RetrieveSecret("flaky")
+		assert.NoError(t, err)
+
+		assert.Len(t, logger.events, 3)
+		assert.Equal(t, LogLevelWarn, logger.events[0].level)
+		assert.Equal(t, 503, logger.events[0].fields["status"])
+		assert.Equal(t, "Retrying Conjur request", logger.events[1].message)
+		assert.Equal(t, 2, logger.events[1].fields["attempt"])
+		assert.Equal(t, 503, logger.events[1].fields["status"])
+		assert.Equal(t, 200, logger.events[2].fields["status"])
+	})
+
+	t.Run("Logs transport errors", func(t *testing.T) {
+		logger := &recordingLogger{}
+		failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("dial tcp: lookup conjur: no such host")
+		})
+		conjur := newClient(t, logger, WithTransport(failing))
+
+		_, err := conjur.RetrieveSecret("db-password")
+		assert.Error(t, err)
+		assert.Len(t, logger.events, 1)
+		assert.Equal(t, LogLevelError, logger.events[0].level)
+		assert.Equal(t, "dial tcp: lookup conjur: no such host", logger.events[0].fields["error"])
+	})
+
+	t.Run("Omits query strings", func(t *testing.T) {
+		logger := &recordingLogger{}
+		conjur := newClient(t, logger)
+
+		_, err := conjur.RetrieveSecretWithVersion("db-password", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "/secrets/cucumber/variable/db-password", logger.events[0].fields["path"])
+	})
+
+	t.Run("Keeps logging when the transport is reconfigured", func(t *testing.T) {
+		logger := &recordingLogger{}
+		conjur := newClient(t, logger)
+
+		httpClient := conjur.httpClientWithClientCert(generateTestTLSCertificate(t))
+		retry, ok := httpClient.Transport.(*retryTransport)
+		assert.True(t, ok)
+		assert.Same(t, logger, retry.logger)
+		logged, ok := retry.base.(*loggingTransport)
+		assert.True(t, ok)
+		assert.IsType(t, &http.Transport{}, logged.base)
+	})
+}
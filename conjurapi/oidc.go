@@ -0,0 +1,135 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+)
+
+// NewClientFromOidcCodeFlow completes the authn-oidc authorization code flow
+// the same way the Conjur CLI does. It looks up the provider matching the
+// config's ServiceID, passes its authorization URL to openBrowser and listens
+// on the provider's localhost redirect URI for the resulting code, which is
+// then exchanged for an access token.
+//
+// The flow is aborted when ctx is done before the redirect is received.
+func NewClientFromOidcCodeFlow(ctx context.Context, config Config, openBrowser func(authURL string) error, opts ...ClientOption) (*Client, error) {
+	client, err := NewClientFromOidcCode(config, "", "", "", opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := client.ListOidcProvidersWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider *OidcProvider
+	for i := range providers {
+		if providers[i].ServiceID == config.ServiceID {
+			provider = &providers[i]
+			break
+		}
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("OIDC provider with service ID '%s' not found", config.ServiceID)
+	}
+
+	code, err := waitForOidcCode(ctx, provider.RedirectURI, openBrowser)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator := client.GetAuthenticator().(*authn.OidcAuthenticator)
+	authenticator.Code = code
+	authenticator.Nonce = provider.Nonce
+	authenticator.CodeVerifier = provider.CodeVerifier
+
+	if err := client.ForceRefreshTokenWithContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// waitForOidcCode opens authURL with openBrowser and serves the redirect URI
+// it references until the provider redirects back with an authorization code.
+func waitForOidcCode(ctx context.Context, authURL string, openBrowser func(string) error) (string, error) {
+	parsedAuthURL, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	expectedState := parsedAuthURL.Query().Get("state")
+
+	redirectURL, err := url.Parse(parsedAuthURL.Query().Get("redirect_uri"))
+	if err != nil {
+		return "", err
+	}
+	host := redirectURL.Hostname()
+	if host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		return "", fmt.Errorf("OIDC redirect URI must point to localhost, got '%s'", redirectURL.String())
+	}
+
+	listener, err := net.Listen("tcp", redirectURL.Host)
+	if err != nil {
+		return "", fmt.Errorf("unable to listen on OIDC redirect URI: %w", err)
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	path := redirectURL.Path
+	if path == "" {
+		path = "/"
+	}
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var res result
+		switch {
+		case query.Get("error") != "":
+			res.err = fmt.Errorf("OIDC provider returned an error: %s %s", query.Get("error"), query.Get("error_description"))
+		case expectedState != "" && query.Get("state") != expectedState:
+			res.err = errors.New("OIDC redirect state does not match the authorization request")
+		case query.Get("code") == "":
+			res.err = errors.New("OIDC redirect did not include an authorization code")
+		default:
+			res.code = query.Get("code")
+		}
+
+		if res.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, "Login failed. You may close this window.")
+		} else {
+			fmt.Fprintln(w, "Logged in successfully. You may close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := openBrowser(authURL); err != nil {
+		return "", err
+	}
+
+	select {
+	case res := <-results:
+		return res.code, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockConjurServerWithOidcProvider(t *testing.T) (*httptest.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+	listener.Close()
+
+	authURL := "https://idp.example.com/authorize?" + url.Values{
+		"redirect_uri": {redirectURI},
+		"state":        {"test-state"},
+	}.Encode()
+
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authn-oidc/cucumber/providers":
+			fmt.Fprintf(w, `[{"service_id":"test-service-id","nonce":"test-nonce","code_verifier":"test-verifier","redirect_uri":%q}]`, authURL)
+		case "/authn-oidc/test-service-id/cucumber/authenticate":
+			query := r.URL.Query()
+			if r.Method == "GET" && query.Get("code") == "test-code" && query.Get("nonce") == "test-nonce" && query.Get("code_verifier") == "test-verifier" {
+				w.Write([]byte(sample_token))
+				return
+			}
+			r.ParseForm()
+			if r.Method == "POST" && r.Form.Get("id_token") == "test-id-token" {
+				w.Write([]byte(sample_token))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return mockConjurServer, authURL
+}
+
+func TestNewClientFromOidcCodeFlow(t *testing.T) {
+	redirectTo := func(query url.Values) func(string) error {
+		return func(authURL string) error {
+			parsed, _ := url.Parse(authURL)
+			go http.Get(parsed.Query().Get("redirect_uri") + "?" + query.Encode())
+			return nil
+		}
+	}
+
+	t.Run("Completes the authorization code flow", func(t *testing.T) {
+		ts, expectedAuthURL := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		var openedURL string
+		openBrowser := func(authURL string) error {
+			openedURL = authURL
+			return redirectTo(url.Values{"code": {"test-code"}, "state": {"test-state"}})(authURL)
+		}
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		client, err := NewClientFromOidcCodeFlow(context.Background(), config, openBrowser)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedAuthURL, openedURL)
+		assert.NotNil(t, client.authToken)
+	})
+
+	t.Run("Rejects a redirect with mismatched state", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		_, err := NewClientFromOidcCodeFlow(context.Background(), config, redirectTo(url.Values{"code": {"test-code"}, "state": {"other"}}))
+
+		assert.EqualError(t, err, "OIDC redirect state does not match the authorization request")
+	})
+
+	t.Run("Returns provider errors", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		_, err := NewClientFromOidcCodeFlow(context.Background(), config, redirectTo(url.Values{"error": {"access_denied"}}))
+
+		assert.ErrorContains(t, err, "access_denied")
+	})
+
+	t.Run("Returns error for unknown provider", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "unknown"}
+		_, err := NewClientFromOidcCodeFlow(context.Background(), config, redirectTo(nil))
+
+		assert.EqualError(t, err, "OIDC provider with service ID 'unknown' not found")
+	})
+
+	t.Run("Stops waiting when the context is done", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		_, err := NewClientFromOidcCodeFlow(ctx, config, func(string) error { return nil })
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestNewClientFromOidcIDToken(t *testing.T) {
+	t.Run("Exchanges the ID token with authn-oidc", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		client, err := NewClientFromOidcIDToken(config, "test-id-token")
+		assert.NoError(t, err)
+
+		token, err := client.authenticator.RefreshToken()
+		assert.NoError(t, err)
+		assert.Equal(t, sample_token, string(token))
+	})
+
+	t.Run("Returns error for invalid ID token", func(t *testing.T) {
+		ts, _ := mockConjurServerWithOidcProvider(t)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, AuthnType: "oidc", ServiceID: "test-service-id"}
+		client, err := NewClientFromOidcIDToken(config, "invalid")
+		assert.NoError(t, err)
+
+		_, err = client.authenticator.RefreshToken()
+		assert.ErrorContains(t, err, "401")
+	})
+}
@@ -0,0 +1,234 @@
+package conjurapi
+
+import (
+	"context"
+	"sync"
+)
+
+const PagerDefaultPageSize = 100
+
+// PagerOptions configures a Pager.
+type PagerOptions struct {
+	// PageSize is the number of values fetched per request. Defaults to
+	// PagerDefaultPageSize.
+	PageSize int
+	// MaxResults caps the total number of values returned. Zero or less
+	// returns every value.
+	MaxResults int
+	// Concurrency is the number of pages fetched at once. Values are still
+	// returned in order. Pages past the last one may be requested, and
+	// discarded, when it's more than one.
+	Concurrency int
+}
+
+// PageFunc fetches the page of at most limit values starting at offset. A
+// page shorter than limit is the last one.
+type PageFunc[T any] func(ctx context.Context, limit, offset int) ([]T, error)
+
+// Pager iterates over the values of a list endpoint, fetching them page by
+// page as they're needed:
+//
+//	pager := conjur.ResourcesPager(&conjurapi.ResourceFilter{Kind: "variable"}, conjurapi.PagerOptions{})
+//	for pager.Next() {
+//	    resource := pager.Value()
+//	    // ...
+//	}
+//	if err := pager.Err(); err != nil {
+//	    // ...
+//	}
+//
+// A Pager isn't safe for concurrent use.
+type Pager[T any] struct {
+	ctx     context.Context
+	fetch   PageFunc[T]
+	options PagerOptions
+
+	offset   int
+	returned int
+	buffered []T
+	value    T
+	done     bool
+	err      error
+}
+
+// NewPager creates a Pager over the pages returned by fetch, for list
+// endpoints without a pager of their own.
+func NewPager[T any](ctx context.Context, fetch PageFunc[T], options PagerOptions) *Pager[T] {
+	if options.PageSize <= 0 {
+		options.PageSize = PagerDefaultPageSize
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	return &Pager[T]{ctx: ctx, fetch: fetch, options: options}
+}
+
+// Next advances to the next value, fetching more pages if needed. It returns
+// false once the values are exhausted, MaxResults is reached or a request
+// fails.
+func (p *Pager[T]) Next() bool {
+	if p.options.MaxResults > 0 && p.returned >= p.options.MaxResults {
+		return false
+	}
+	for len(p.buffered) == 0 {
+		if p.done {
+			return false
+		}
+		p.fetchPages()
+	}
+
+	p.value = p.buffered[0]
+	p.buffered = p.buffered[1:]
+	p.returned++
+	return true
+}
+
+// Value returns the current value.
+func (p *Pager[T]) Value() T {
+	return p.value
+}
+
+// Err returns the error which stopped the Pager, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// fetchPages fetches the next Concurrency pages, or fewer if MaxResults is
+// reached first, and buffers their values up to the first short or failed
+// page.
+func (p *Pager[T]) fetchPages() {
+	pageSize := p.options.PageSize
+	limits := []int{}
+	remaining := p.options.MaxResults - p.returned
+	for len(limits) < p.options.Concurrency {
+		limit := pageSize
+		if p.options.MaxResults > 0 {
+			if remaining <= 0 {
+				break
+			}
+			if remaining < limit {
+				limit = remaining
+			}
+			remaining -= limit
+		}
+		limits = append(limits, limit)
+	}
+
+	pages := make([][]T, len(limits))
+	errs := make([]error, len(limits))
+	var wg sync.WaitGroup
+	for i, limit := range limits {
+		wg.Add(1)
+		go func(i, limit, offset int) {
+			defer wg.Done()
+			pages[i], errs[i] = p.fetch(p.ctx, limit, offset)
+		}(i, limit, p.offset+i*pageSize)
+	}
+	wg.Wait()
+
+	p.offset += len(limits) * pageSize
+	p.done = len(limits) == 0
+	for i, page := range pages {
+		if errs[i] != nil {
+			p.err = errs[i]
+			p.done = true
+			return
+		}
+		p.buffered = append(p.buffered, page...)
+		if len(page) < limits[i] {
+			p.done = true
+			return
+		}
+	}
+}
+
+// ResourcesPager pages through the resources listed by ListResources. The
+// filter's Limit and Offset are ignored in favour of the options.
+func (c *Client) ResourcesPager(filter *ResourceFilter, options PagerOptions) *Pager[Resource] {
+	return c.ResourcesPagerWithContext(context.Background(), filter, options)
+}
+
+// ResourcesPagerWithContext is like ResourcesPager but uses the provided
+// context for the underlying requests.
+func (c *Client) ResourcesPagerWithContext(ctx context.Context, filter *ResourceFilter, options PagerOptions) *Pager[Resource] {
+	pageFilter := ResourceFilter{}
+	if filter != nil {
+		pageFilter = *filter
+	}
+	return NewPager(ctx, func(ctx context.Context, limit, offset int) ([]Resource, error) {
+		pageFilter := pageFilter
+		pageFilter.Limit = limit
+		pageFilter.Offset = offset
+		return c.ListResourcesWithContext(ctx, &pageFilter)
+	}, options)
+}
+
+// RoleMembersPager pages through the direct members of a role.
+func (c *Client) RoleMembersPager(roleID string, options PagerOptions) *Pager[RoleGrant] {
+	return c.RoleMembersPagerWithContext(context.Background(), roleID, options)
+}
+
+// RoleMembersPagerWithContext is like RoleMembersPager but uses the provided
+// context for the underlying requests.
+func (c *Client) RoleMembersPagerWithContext(ctx context.Context, roleID string, options PagerOptions) *Pager[RoleGrant] {
+	return NewPager(ctx, func(ctx context.Context, limit, offset int) ([]RoleGrant, error) {
+		req, err := c.RoleMembersPageRequest(roleID, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		return c.roleGrantsFrom(ctx, req)
+	}, options)
+}
+
+// AuditEventsPager pages through the events returned by AuditEvents. The
+// filter's Limit and Offset are ignored in favour of the options.
+func (c *Client) AuditEventsPager(filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return c.AuditEventsPagerWithContext(context.Background(), filter, options)
+}
+
+// AuditEventsPagerWithContext is like AuditEventsPager but uses the provided
+// context for the underlying requests.
+func (c *Client) AuditEventsPagerWithContext(ctx context.Context, filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return auditEventsPager(ctx, filter, options, c.AuditEventsWithContext)
+}
+
+// ResourceAuditEventsPager pages through the events returned by
+// ResourceAuditEvents, as AuditEventsPager does.
+func (c *Client) ResourceAuditEventsPager(resourceID string, filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return c.ResourceAuditEventsPagerWithContext(context.Background(), resourceID, filter, options)
+}
+
+// ResourceAuditEventsPagerWithContext is like ResourceAuditEventsPager but
+// uses the provided context for the underlying requests.
+func (c *Client) ResourceAuditEventsPagerWithContext(ctx context.Context, resourceID string, filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return auditEventsPager(ctx, filter, options, func(ctx context.Context, filter *AuditFilter) ([]AuditEvent, error) {
+		return c.ResourceAuditEventsWithContext(ctx, resourceID, filter)
+	})
+}
+
+// RoleAuditEventsPager pages through the events returned by RoleAuditEvents,
+// as AuditEventsPager does.
+func (c *Client) RoleAuditEventsPager(roleID string, filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return c.RoleAuditEventsPagerWithContext(context.Background(), roleID, filter, options)
+}
+
+// RoleAuditEventsPagerWithContext is like RoleAuditEventsPager but uses the
+// provided context for the underlying requests.
+func (c *Client) RoleAuditEventsPagerWithContext(ctx context.Context, roleID string, filter *AuditFilter, options PagerOptions) *Pager[AuditEvent] {
+	return auditEventsPager(ctx, filter, options, func(ctx context.Context, filter *AuditFilter) ([]AuditEvent, error) {
+		return c.RoleAuditEventsWithContext(ctx, roleID, filter)
+	})
+}
+
+func auditEventsPager(ctx context.Context, filter *AuditFilter, options PagerOptions, fetch func(context.Context, *AuditFilter) ([]AuditEvent, error)) *Pager[AuditEvent] {
+	pageFilter := AuditFilter{}
+	if filter != nil {
+		pageFilter = *filter
+	}
+	return NewPager(ctx, func(ctx context.Context, limit, offset int) ([]AuditEvent, error) {
+		pageFilter := pageFilter
+		pageFilter.Limit = limit
+		pageFilter.Offset = offset
+		return fetch(ctx, &pageFilter)
+	}, options)
+}
@@ -0,0 +1,228 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pageOf returns a PageFunc over the integers 0 to total-1, recording the
+// offsets it's called with.
+func pageOf(total int, offsets *[]int) PageFunc[int] {
+	var mutex sync.Mutex
+	return func(ctx context.Context, limit, offset int) ([]int, error) {
+		mutex.Lock()
+		*offsets = append(*offsets, offset)
+		mutex.Unlock()
+
+		page := []int{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			page = append(page, i)
+		}
+		return page, nil
+	}
+}
+
+func collect[T any](pager *Pager[T]) []T {
+	values := []T{}
+	for pager.Next() {
+		values = append(values, pager.Value())
+	}
+	return values
+}
+
+func sequence(n int) []int {
+	values := make([]int, n)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
+
+func TestPager(t *testing.T) {
+	t.Run("Returns every value, page by page", func(t *testing.T) {
+		offsets := []int{}
+		pager := NewPager(context.Background(), pageOf(25, &offsets), PagerOptions{PageSize: 10})
+
+		assert.Equal(t, sequence(25), collect(pager))
+		assert.NoError(t, pager.Err())
+		assert.Equal(t, []int{0, 10, 20}, offsets)
+		assert.False(t, pager.Next())
+	})
+
+	t.Run("Fetches an empty page after a full last page", func(t *testing.T) {
+		offsets := []int{}
+		pager := NewPager(context.Background(), pageOf(20, &offsets), PagerOptions{PageSize: 10})
+
+		assert.Equal(t, sequence(20), collect(pager))
+		assert.Equal(t, []int{0, 10, 20}, offsets)
+	})
+
+	t.Run("Defaults the page size", func(t *testing.T) {
+		offsets := []int{}
+		pager := NewPager(context.Background(), pageOf(150, &offsets), PagerOptions{})
+
+		assert.Equal(t, sequence(150), collect(pager))
+		assert.Equal(t, []int{0, PagerDefaultPageSize}, offsets)
+	})
+
+	t.Run("Stops at MaxResults", func(t *testing.T) {
+		limits := []int{}
+		pager := NewPager(context.Background(), func(ctx context.Context, limit, offset int) ([]int, error) {
+			limits = append(limits, limit)
+			return sequence(100)[offset : offset+limit], nil
+		}, PagerOptions{PageSize: 10, MaxResults: 15})
+
+		assert.Equal(t, sequence(15), collect(pager))
+		assert.Equal(t, []int{10, 5}, limits)
+	})
+
+	t.Run("Fetches pages concurrently, in order", func(t *testing.T) {
+		offsets := []int{}
+		pager := NewPager(context.Background(), pageOf(45, &offsets), PagerOptions{PageSize: 10, Concurrency: 3})
+
+		assert.Equal(t, sequence(45), collect(pager))
+		assert.ElementsMatch(t, []int{0, 10, 20, 30, 40, 50}, offsets)
+	})
+
+	t.Run("Caps concurrent pages at MaxResults", func(t *testing.T) {
+		offsets := []int{}
+		pager := NewPager(context.Background(), pageOf(100, &offsets), PagerOptions{PageSize: 10, Concurrency: 4, MaxResults: 25})
+
+		assert.Equal(t, sequence(25), collect(pager))
+		assert.ElementsMatch(t, []int{0, 10, 20}, offsets)
+	})
+
+	t.Run("Returns the values before a failed page", func(t *testing.T) {
+		failure := errors.New("page failed")
+		pager := NewPager(context.Background(), func(ctx context.Context, limit, offset int) ([]int, error) {
+			if offset >= 20 {
+				return nil, failure
+			}
+			return sequence(100)[offset : offset+limit], nil
+		}, PagerOptions{PageSize: 10, Concurrency: 3})
+
+		assert.Equal(t, sequence(20), collect(pager))
+		assert.Equal(t, failure, pager.Err())
+		assert.False(t, pager.Next())
+	})
+}
+
+func TestClient_ResourcesPager(t *testing.T) {
+	conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/resources/cucumber/", r.URL.Path)
+		assert.Equal(t, "variable", r.URL.Query().Get("kind"))
+		assert.Equal(t, "db", r.URL.Query().Get("search"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset >= 4 {
+			w.Write([]byte(`[{"id": "cucumber:variable:db/4"}]`))
+			return
+		}
+		fmt.Fprintf(w, `[{"id": "cucumber:variable:db/%d"}, {"id": "cucumber:variable:db/%d"}]`, offset, offset+1)
+	})
+
+	pager := conjur.ResourcesPager(&ResourceFilter{Kind: "variable", Search: "db", Limit: 1, Offset: 3}, PagerOptions{PageSize: 2})
+	ids := []string{}
+	for pager.Next() {
+		ids = append(ids, pager.Value().ID)
+	}
+	require.NoError(t, pager.Err())
+	assert.Equal(t, []string{
+		"cucumber:variable:db/0",
+		"cucumber:variable:db/1",
+		"cucumber:variable:db/2",
+		"cucumber:variable:db/3",
+		"cucumber:variable:db/4",
+	}, ids)
+}
+
+func TestClient_RoleMembersPager(t *testing.T) {
+	conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/roles/cucumber/group/admins", r.URL.Path)
+		_, members := r.URL.Query()["members"]
+		assert.True(t, members)
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		if r.URL.Query().Get("offset") == "0" {
+			w.Write([]byte(`[{"role": "cucumber:group:admins", "member": "cucumber:user:alice"}, {"role": "cucumber:group:admins", "member": "cucumber:user:bob"}]`))
+			return
+		}
+		w.Write([]byte(`[{"role": "cucumber:group:admins", "member": "cucumber:host:app", "admin_option": true}]`))
+	})
+
+	pager := conjur.RoleMembersPager("group:admins", PagerOptions{PageSize: 2})
+	grants := collect(pager)
+	require.NoError(t, pager.Err())
+	require.Len(t, grants, 3)
+	assert.Equal(t, "cucumber:user:alice", grants[0].Member)
+	assert.Equal(t, "cucumber:user:bob", grants[1].Member)
+	assert.Equal(t, "cucumber:host:app", grants[2].Member)
+	assert.True(t, grants[2].AdminOption)
+}
+
+func TestClient_AuditEventsPager(t *testing.T) {
+	t.Run("Pages through the account's events", func(t *testing.T) {
+		offsets := []string{}
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/audit", r.URL.Path)
+			assert.Equal(t, "2024-03-01T00:00:00Z", r.URL.Query().Get("since"))
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+			offsets = append(offsets, r.URL.Query().Get("offset"))
+
+			if len(offsets) == 1 {
+				w.Write([]byte(auditEvents))
+				return
+			}
+			w.Write([]byte(`[]`))
+		})
+
+		pager := conjur.AuditEventsPager(&AuditFilter{Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, PagerOptions{PageSize: 2})
+		events := collect(pager)
+		require.NoError(t, pager.Err())
+		require.Len(t, events, 2)
+		assert.Equal(t, "3c6b1e0a", events[0].ID)
+		assert.Equal(t, []string{"", "2"}, offsets)
+	})
+
+	t.Run("Pages through a resource's events", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/audit/resources/cucumber:variable:db/password", r.URL.Path)
+			w.Write([]byte(auditEvents))
+		})
+
+		pager := conjur.ResourceAuditEventsPager("variable:db/password", nil, PagerOptions{PageSize: 10})
+		assert.Len(t, collect(pager), 2)
+		assert.NoError(t, pager.Err())
+	})
+
+	t.Run("Pages through a role's events", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/audit/roles/cucumber:host:app", r.URL.Path)
+			w.Write([]byte(auditEvents))
+		})
+
+		pager := conjur.RoleAuditEventsPager("host:app", nil, PagerOptions{PageSize: 10, MaxResults: 1})
+		assert.Len(t, collect(pager), 1)
+		assert.NoError(t, pager.Err())
+	})
+
+	t.Run("Stops at a failed page", func(t *testing.T) {
+		conjur := newAuditTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		pager := conjur.AuditEventsPager(nil, PagerOptions{})
+		assert.False(t, pager.Next())
+		assert.ErrorIs(t, pager.Err(), ErrNotFound)
+	})
+}
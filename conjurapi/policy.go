@@ -1,9 +1,17 @@
 package conjurapi
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 )
 
 // PolicyMode defines the server-sized behavior when loading a policy.
@@ -18,6 +26,20 @@ const (
 	PolicyModePatch PolicyMode = 3
 )
 
+// String returns the name of the policy mode, e.g. "post".
+func (m PolicyMode) String() string {
+	switch m {
+	case PolicyModePost:
+		return "post"
+	case PolicyModePut:
+		return "put"
+	case PolicyModePatch:
+		return "patch"
+	default:
+		return fmt.Sprintf("PolicyMode(%d)", uint(m))
+	}
+}
+
 // CreatedRole contains the full role ID and API key of a role which was created
 // by the server when loading a policy.
 type CreatedRole struct {
@@ -25,6 +47,41 @@ type CreatedRole struct {
 	APIKey string `json:"api_key"`
 }
 
+// String describes the role without its API key, so that the key isn't
+// leaked by logging the role or the PolicyResponse holding it.
+func (r CreatedRole) String() string {
+	return fmt.Sprintf("{ID:%s APIKey:[REDACTED]}", r.ID)
+}
+
+// Login returns the login with which the role authenticates, such as "alice"
+// for the user cucumber:user:alice and "host/myapp" for the host
+// cucumber:host:myapp.
+func (r CreatedRole) Login() string {
+	tokens := strings.SplitN(r.ID, ":", 3)
+	if len(tokens) != 3 {
+		return r.ID
+	}
+	if tokens[1] == "user" {
+		return tokens[2]
+	}
+	return tokens[1] + "/" + tokens[2]
+}
+
+// StoreCredentials stores the role's login and API key in the credential
+// storage of the config, the .netrc file or the keyring, where clients created
+// with the same config find them. The storage holds the credentials of one
+// role per appliance, so storing a role replaces any stored before.
+func (r CreatedRole) StoreCredentials(config Config) error {
+	storageProvider, err := createStorageProvider(config)
+	if err != nil {
+		return err
+	}
+	if storageProvider == nil {
+		return fmt.Errorf("unable to store credentials with CredentialStorage '%s'", config.CredentialStorage)
+	}
+	return storageProvider.StoreCredentials(r.Login(), r.APIKey)
+}
+
 // PolicyResponse contains information about the policy update.
 type PolicyResponse struct {
 	// Newly created roles.
@@ -33,16 +90,96 @@ type PolicyResponse struct {
 	Version uint32 `json:"version"`
 }
 
+// Roles returns the newly created roles ordered by ID.
+func (r *PolicyResponse) Roles() []CreatedRole {
+	roles := make([]CreatedRole, 0, len(r.CreatedRoles))
+	for _, role := range r.CreatedRoles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].ID < roles[j].ID
+	})
+	return roles
+}
+
+// PolicyVersion describes a version of a policy loaded on the server.
+type PolicyVersion struct {
+	ID           string     `json:"id"`
+	Version      uint32     `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	PolicyText   string     `json:"policy_text"`
+	PolicySHA256 string     `json:"policy_sha256"`
+	// The role which loaded the policy version.
+	Role     string `json:"role"`
+	ClientIP string `json:"client_ip"`
+}
+
+// DryRunPolicyResponse describes the changes a policy load would make, as
+// reported by a dry run. When the policy is invalid, Status is "Invalid YAML"
+// and Errors lists the problems found.
+type DryRunPolicyResponse struct {
+	Status  string              `json:"status"`
+	Created DryRunPolicyItems   `json:"created"`
+	Updated DryRunPolicyUpdates `json:"updated"`
+	Deleted DryRunPolicyItems   `json:"deleted"`
+	Errors  []DryRunPolicyError `json:"errors"`
+}
+
+// DryRunPolicyItems contains the resources affected by a policy change.
+type DryRunPolicyItems struct {
+	Items []DryRunPolicyItem `json:"items"`
+}
+
+// DryRunPolicyUpdates contains the state of updated resources before and
+// after the policy change.
+type DryRunPolicyUpdates struct {
+	Before DryRunPolicyItems `json:"before"`
+	After  DryRunPolicyItems `json:"after"`
+}
+
+// DryRunPolicyItem describes a resource, or a role, affected by a policy change.
+type DryRunPolicyItem struct {
+	Identifier   string              `json:"identifier"`
+	ID           string              `json:"id"`
+	Type         string              `json:"type"`
+	Owner        string              `json:"owner"`
+	Policy       string              `json:"policy"`
+	Members      []string            `json:"members"`
+	Memberships  []string            `json:"memberships"`
+	Restrictions []string            `json:"restricted_to"`
+	Annotations  map[string]string   `json:"annotations"`
+	Permissions  map[string][]string `json:"permissions"`
+}
+
+// DryRunPolicyError describes a problem found in a policy during a dry run.
+type DryRunPolicyError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// Valid reports whether the dry run found the policy to be valid.
+func (r *DryRunPolicyResponse) Valid() bool {
+	return len(r.Errors) == 0
+}
+
 // LoadPolicy submits new policy data or polciy changes to the server.
 //
 // The required permission depends on the mode.
 func (c *Client) LoadPolicy(mode PolicyMode, policyID string, policy io.Reader) (*PolicyResponse, error) {
+	return c.LoadPolicyWithContext(context.Background(), mode, policyID, policy)
+}
+
+// LoadPolicyWithContext is like LoadPolicy but uses the provided context for
+// the underlying request.
+func (c *Client) LoadPolicyWithContext(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (*PolicyResponse, error) {
 	req, err := c.LoadPolicyRequest(mode, policyID, policy)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(withOperation(ctx, operationPolicyLoad)))
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +187,89 @@ func (c *Client) LoadPolicy(mode PolicyMode, policyID string, policy io.Reader)
 	policyResponse := PolicyResponse{}
 	return &policyResponse, response.JSONResponse(resp, &policyResponse)
 }
+
+// PolicyVersions fetches the loaded versions of a policy, oldest first, from
+// the policy's resource metadata. Use it to retrieve the full version details,
+// such as the policy text, of the version returned by LoadPolicy.
+//
+// The authenticated user must have read privilege on the policy.
+func (c *Client) PolicyVersions(policyID string) ([]PolicyVersion, error) {
+	return c.PolicyVersionsWithContext(context.Background(), policyID)
+}
+
+// PolicyVersionsWithContext is like PolicyVersions but uses the provided
+// context for the underlying request.
+func (c *Client) PolicyVersionsWithContext(ctx context.Context, policyID string) ([]PolicyVersion, error) {
+	req, err := c.ResourceRequest(makeFullId(c.config.Account, "policy", policyID))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resource := struct {
+		PolicyVersions []PolicyVersion `json:"policy_versions"`
+	}{}
+	if err := response.JSONResponse(resp, &resource); err != nil {
+		return nil, err
+	}
+
+	versions := resource.PolicyVersions
+	if versions == nil {
+		versions = []PolicyVersion{}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// DryRunPolicy validates policy data or policy changes without applying them,
+// and reports the resources which would be created, updated and deleted. An
+// invalid policy is not an error: the returned response lists the problems in
+// Errors. It requires Conjur 1.21 or later.
+//
+// The required permission depends on the mode.
+func (c *Client) DryRunPolicy(mode PolicyMode, policyID string, policy io.Reader) (*DryRunPolicyResponse, error) {
+	return c.DryRunPolicyWithContext(context.Background(), mode, policyID, policy)
+}
+
+// DryRunPolicyWithContext is like DryRunPolicy but uses the provided context
+// for the underlying request.
+func (c *Client) DryRunPolicyWithContext(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (*DryRunPolicyResponse, error) {
+	req, err := c.DryRunPolicyRequest(mode, policyID, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(withOperation(ctx, operationPolicyLoad)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation errors are reported with a 422 status and a dry run body
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		dryRunResponse := DryRunPolicyResponse{}
+		if err := json.Unmarshal(body, &dryRunResponse); err == nil && len(dryRunResponse.Errors) > 0 {
+			return &dryRunResponse, nil
+		}
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	dryRunResponse := DryRunPolicyResponse{}
+	if err := response.JSONResponse(resp, &dryRunResponse); err != nil {
+		return nil, err
+	}
+	return &dryRunResponse, nil
+}
@@ -0,0 +1,278 @@
+// Package policy builds Conjur policies from Go values, so that services
+// which provision Conjur can generate policy without templating YAML:
+//
+//	app := policy.Host{ID: "myapp"}
+//	password := policy.Variable{ID: "db-password"}
+//	data, err := policy.Marshal(
+//		policy.Policy{ID: "apps", Body: []policy.Statement{
+//			app,
+//			password,
+//			policy.Permit{Role: app.Ref(), Privileges: []string{"read", "execute"}, Resources: []policy.Ref{password.Ref()}},
+//		}},
+//	)
+//
+// The result can be loaded with conjurapi.Client.LoadPolicy.
+package policy
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Statement is a policy statement, such as a record declaration or a grant.
+// Statements marshal to tagged YAML.
+type Statement interface {
+	yaml.Marshaler
+}
+
+// Ref refers to a record by kind and ID, relative to the policy it's used in,
+// and marshals to a tag such as "!group admins".
+type Ref struct {
+	Kind string
+	ID   string
+}
+
+func (r Ref) MarshalYAML() (interface{}, error) {
+	if r.Kind == "" || r.ID == "" {
+		return nil, fmt.Errorf("policy reference must have a kind and an ID, got !%s '%s'", r.Kind, r.ID)
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!" + r.Kind, Value: r.ID}, nil
+}
+
+// Policy declares a policy, whose body declares records relative to it.
+type Policy struct {
+	ID          string
+	Owner       *Ref
+	Annotations map[string]string
+	Body        []Statement
+}
+
+func (p Policy) Ref() Ref { return Ref{Kind: "policy", ID: p.ID} }
+
+func (p Policy) MarshalYAML() (interface{}, error) {
+	statement := newRecord("policy", p.ID, p.Owner, p.Annotations)
+	if len(p.Body) > 0 {
+		if err := statement.add("body", p.Body); err != nil {
+			return nil, err
+		}
+	}
+	return statement.marshal()
+}
+
+// User declares a user. RestrictedTo limits the networks, given as IP
+// addresses or CIDR ranges, it may authenticate from.
+type User struct {
+	ID           string
+	Owner        *Ref
+	Annotations  map[string]string
+	RestrictedTo []string
+}
+
+func (u User) Ref() Ref { return Ref{Kind: "user", ID: u.ID} }
+
+func (u User) MarshalYAML() (interface{}, error) {
+	statement := newRecord("user", u.ID, u.Owner, u.Annotations)
+	if err := statement.addRestrictedTo(u.RestrictedTo); err != nil {
+		return nil, err
+	}
+	return statement.marshal()
+}
+
+// Host declares a host, the identity of a machine or application.
+// RestrictedTo limits the networks, given as IP addresses or CIDR ranges, it
+// may authenticate from.
+type Host struct {
+	ID           string
+	Owner        *Ref
+	Annotations  map[string]string
+	RestrictedTo []string
+}
+
+func (h Host) Ref() Ref { return Ref{Kind: "host", ID: h.ID} }
+
+func (h Host) MarshalYAML() (interface{}, error) {
+	statement := newRecord("host", h.ID, h.Owner, h.Annotations)
+	if err := statement.addRestrictedTo(h.RestrictedTo); err != nil {
+		return nil, err
+	}
+	return statement.marshal()
+}
+
+// Group declares a group of roles.
+type Group struct {
+	ID          string
+	Owner       *Ref
+	Annotations map[string]string
+}
+
+func (g Group) Ref() Ref { return Ref{Kind: "group", ID: g.ID} }
+
+func (g Group) MarshalYAML() (interface{}, error) {
+	return newRecord("group", g.ID, g.Owner, g.Annotations).marshal()
+}
+
+// Layer declares a layer, a group of hosts.
+type Layer struct {
+	ID          string
+	Owner       *Ref
+	Annotations map[string]string
+}
+
+func (l Layer) Ref() Ref { return Ref{Kind: "layer", ID: l.ID} }
+
+func (l Layer) MarshalYAML() (interface{}, error) {
+	return newRecord("layer", l.ID, l.Owner, l.Annotations).marshal()
+}
+
+// Variable declares a variable. Kind describes the secret, such as
+// "password", and MimeType its content type.
+type Variable struct {
+	ID          string
+	Owner       *Ref
+	Annotations map[string]string
+	Kind        string
+	MimeType    string
+}
+
+func (v Variable) Ref() Ref { return Ref{Kind: "variable", ID: v.ID} }
+
+func (v Variable) MarshalYAML() (interface{}, error) {
+	statement := newRecord("variable", v.ID, v.Owner, v.Annotations)
+	if v.Kind != "" {
+		if err := statement.add("kind", v.Kind); err != nil {
+			return nil, err
+		}
+	}
+	if v.MimeType != "" {
+		if err := statement.add("mime_type", v.MimeType); err != nil {
+			return nil, err
+		}
+	}
+	return statement.marshal()
+}
+
+// Grant makes the members members of the role.
+type Grant struct {
+	Role    Ref
+	Members []Ref
+}
+
+func (g Grant) MarshalYAML() (interface{}, error) {
+	if len(g.Members) == 0 {
+		return nil, fmt.Errorf("grant of !%s '%s' must have members", g.Role.Kind, g.Role.ID)
+	}
+
+	statement := newStatement("grant")
+	if err := statement.add("role", g.Role); err != nil {
+		return nil, err
+	}
+	if err := statement.add("members", g.Members); err != nil {
+		return nil, err
+	}
+	return statement.node, nil
+}
+
+// Permit gives the role privileges, such as "read", "execute" or "update",
+// on the resources.
+type Permit struct {
+	Role       Ref
+	Privileges []string
+	Resources  []Ref
+}
+
+func (p Permit) MarshalYAML() (interface{}, error) {
+	if len(p.Privileges) == 0 || len(p.Resources) == 0 {
+		return nil, fmt.Errorf("permit for !%s '%s' must have privileges and resources", p.Role.Kind, p.Role.ID)
+	}
+
+	statement := newStatement("permit")
+	if err := statement.add("role", p.Role); err != nil {
+		return nil, err
+	}
+	if err := statement.add("privileges", p.Privileges); err != nil {
+		return nil, err
+	}
+	if err := statement.add("resources", p.Resources); err != nil {
+		return nil, err
+	}
+	return statement.node, nil
+}
+
+// Marshal encodes statements as a YAML policy.
+func Marshal(statements ...Statement) ([]byte, error) {
+	if statements == nil {
+		statements = []Statement{}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(statements); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// statement builds the tagged YAML mapping of a statement.
+type statement struct {
+	node *yaml.Node
+	id   string
+	err  error
+}
+
+func newStatement(tag string) *statement {
+	return &statement{node: &yaml.Node{Kind: yaml.MappingNode, Tag: "!" + tag}}
+}
+
+// newRecord starts the statement declaring a record, recording the first
+// error for marshal to return.
+func newRecord(tag, id string, owner *Ref, annotations map[string]string) *statement {
+	s := newStatement(tag)
+	s.id = id
+	if id == "" {
+		s.err = fmt.Errorf("policy statement !%s must have an ID", tag)
+		return s
+	}
+
+	s.err = s.add("id", id)
+	if s.err == nil && owner != nil {
+		s.err = s.add("owner", *owner)
+	}
+	if s.err == nil && len(annotations) > 0 {
+		s.err = s.add("annotations", annotations)
+	}
+	return s
+}
+
+func (s *statement) add(key string, value interface{}) error {
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	s.node.Content = append(s.node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+	return nil
+}
+
+func (s *statement) addRestrictedTo(networks []string) error {
+	if len(networks) == 0 {
+		return nil
+	}
+	return s.add("restricted_to", networks)
+}
+
+// marshal returns the statement's node, using the short form "!kind id" for
+// records which only have an ID.
+func (s *statement) marshal() (interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.node.Content) == 2 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: s.node.Tag, Value: s.id}, nil
+	}
+	return s.node, nil
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func examplePolicy() Statement {
+	admins := Group{ID: "admins"}
+	app := Host{ID: "myapp", Annotations: map[string]string{"authn/api-key": "true"}}
+	password := Variable{ID: "db-password", Kind: "password", MimeType: "text/plain"}
+
+	return Policy{
+		ID:    "apps",
+		Owner: &Ref{Kind: "user", ID: "admin"},
+		Body: []Statement{
+			admins,
+			Layer{ID: "servers"},
+			app,
+			User{ID: "alice", RestrictedTo: []string{"10.0.0.0/8"}},
+			password,
+			Grant{Role: admins.Ref(), Members: []Ref{{Kind: "user", ID: "alice"}}},
+			Permit{Role: app.Ref(), Privileges: []string{"read", "execute"}, Resources: []Ref{password.Ref()}},
+		},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("Marshals statements to policy YAML", func(t *testing.T) {
+		data, err := Marshal(examplePolicy())
+		require.NoError(t, err)
+		assert.Equal(t, `- !policy
+  id: apps
+  owner: !user admin
+  body:
+    - !group admins
+    - !layer servers
+    - !host
+      id: myapp
+      annotations:
+        authn/api-key: "true"
+    - !user
+      id: alice
+      restricted_to:
+        - 10.0.0.0/8
+    - !variable
+      id: db-password
+      kind: password
+      mime_type: text/plain
+    - !grant
+      role: !group admins
+      members:
+        - !user alice
+    - !permit
+      role: !host myapp
+      privileges:
+        - read
+        - execute
+      resources:
+        - !variable db-password
+`, string(data))
+	})
+
+	t.Run("Marshals an empty policy", func(t *testing.T) {
+		data, err := Marshal()
+		require.NoError(t, err)
+		assert.Equal(t, "[]\n", string(data))
+	})
+
+	t.Run("Rejects incomplete statements", func(t *testing.T) {
+		_, err := Marshal(Variable{Kind: "password"})
+		assert.EqualError(t, err, "policy statement !variable must have an ID")
+
+		_, err = Marshal(Policy{ID: "apps", Body: []Statement{Host{}}})
+		assert.EqualError(t, err, "policy statement !host must have an ID")
+
+		_, err = Marshal(Permit{Role: Ref{Kind: "host", ID: "myapp"}, Privileges: []string{"read"}})
+		assert.EqualError(t, err, "permit for !host 'myapp' must have privileges and resources")
+
+		_, err = Marshal(Grant{Role: Ref{Kind: "group", ID: "admins"}})
+		assert.EqualError(t, err, "grant of !group 'admins' must have members")
+
+		_, err = Marshal(Grant{Role: Ref{ID: "admins"}, Members: []Ref{{Kind: "user", ID: "alice"}}})
+		assert.EqualError(t, err, "policy reference must have a kind and an ID, got ! 'admins'")
+	})
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	data, err := Marshal(examplePolicy())
+	require.NoError(t, err)
+
+	t.Run("Parses back into the same statements", func(t *testing.T) {
+		statements, err := conjurapi.ParsePolicyStatements(data)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		assert.Equal(t, "user:admin", statements[0].Owner)
+		assert.Len(t, statements[0].Body, 7)
+		assert.Equal(t, []interface{}{"variable:db-password"}, statements[0].Body[6].Attributes["resources"])
+	})
+
+	t.Run("Loads into Conjur", func(t *testing.T) {
+		server := conjurtest.NewServer("cucumber")
+		defer server.Close()
+		conjur, err := server.NewClient("admin")
+		require.NoError(t, err)
+
+		_, err = conjur.LoadPolicy(conjurapi.PolicyModePost, "root", bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.True(t, server.ResourceExists("variable:apps/db-password"))
+		assert.True(t, server.ResourceExists("host:apps/myapp"))
+	})
+}
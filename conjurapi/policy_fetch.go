@@ -0,0 +1,209 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+	"gopkg.in/yaml.v3"
+)
+
+// FetchPolicyOptions controls how much of the policy tree FetchPolicy
+// returns. Zero values use the server's defaults.
+type FetchPolicyOptions struct {
+	// Depth is the number of levels of nested policies to include.
+	Depth uint
+	// Limit is the maximum number of records to include.
+	Limit uint
+}
+
+// PolicyStatement is a statement of a policy, such as the declaration of a
+// variable or a permission grant, as returned by FetchPolicyStatements.
+type PolicyStatement struct {
+	// Type is the statement's tag without the "!", such as "variable",
+	// "policy" or "permit".
+	Type string
+	ID   string
+	// Owner is the owner role as "kind:id", for example "group:admins".
+	Owner       string
+	Annotations map[string]string
+	// Body holds the statements of a nested policy.
+	Body []PolicyStatement
+	// Attributes holds the statement's other fields, such as the role and
+	// privileges of a permit. References to records are given as
+	// "kind:id" strings.
+	Attributes map[string]interface{}
+}
+
+// FetchPolicy fetches the effective policy of a branch: the YAML policy
+// declaring the records currently loaded under it, including those of nested
+// policies. Drift detection tools can compare it with the policy kept in
+// source control. It requires Conjur 1.21.1 or later.
+//
+// The authenticated user must have read privilege on the policy.
+func (c *Client) FetchPolicy(policyID string, options FetchPolicyOptions) ([]byte, error) {
+	return c.FetchPolicyWithContext(context.Background(), policyID, options)
+}
+
+// FetchPolicyWithContext is like FetchPolicy but uses the provided context for
+// the underlying request.
+func (c *Client) FetchPolicyWithContext(ctx context.Context, policyID string, options FetchPolicyOptions) ([]byte, error) {
+	req, err := c.FetchPolicyRequest(policyID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.DataResponse(resp)
+}
+
+// FetchPolicyStatements is like FetchPolicy but parses the effective policy
+// into statements.
+func (c *Client) FetchPolicyStatements(policyID string, options FetchPolicyOptions) ([]PolicyStatement, error) {
+	return c.FetchPolicyStatementsWithContext(context.Background(), policyID, options)
+}
+
+// FetchPolicyStatementsWithContext is like FetchPolicyStatements but uses the
+// provided context for the underlying request.
+func (c *Client) FetchPolicyStatementsWithContext(ctx context.Context, policyID string, options FetchPolicyOptions) ([]PolicyStatement, error) {
+	policy, err := c.FetchPolicyWithContext(ctx, policyID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePolicyStatements(policy)
+}
+
+// ParsePolicyStatements parses a YAML policy into statements.
+func ParsePolicyStatements(policy []byte) ([]PolicyStatement, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(policy, &document); err != nil {
+		return nil, fmt.Errorf("unable to parse policy: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return []PolicyStatement{}, nil
+	}
+
+	return parsePolicyStatements(document.Content[0])
+}
+
+func parsePolicyStatements(node *yaml.Node) ([]PolicyStatement, error) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return []PolicyStatement{}, nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("policy on line %d must be a sequence of statements", node.Line)
+	}
+
+	statements := make([]PolicyStatement, 0, len(node.Content))
+	for _, item := range node.Content {
+		statement, err := parsePolicyStatement(item)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func parsePolicyStatement(node *yaml.Node) (PolicyStatement, error) {
+	if !isPolicyTag(node.Tag) {
+		return PolicyStatement{}, fmt.Errorf("policy statement on line %d has no type tag, such as !variable", node.Line)
+	}
+
+	statement := PolicyStatement{Type: strings.TrimPrefix(node.Tag, "!")}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		statement.ID = node.Value
+		return statement, nil
+	case yaml.MappingNode:
+	default:
+		return PolicyStatement{}, fmt.Errorf("policy statement !%s on line %d must be an ID or a mapping", statement.Type, node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+
+		var err error
+		switch key {
+		case "id":
+			statement.ID = value.Value
+		case "owner":
+			statement.Owner = policyReference(value)
+		case "annotations":
+			statement.Annotations = map[string]string{}
+			err = value.Decode(&statement.Annotations)
+		case "body":
+			statement.Body, err = parsePolicyStatements(value)
+		default:
+			if statement.Attributes == nil {
+				statement.Attributes = map[string]interface{}{}
+			}
+			statement.Attributes[key], err = policyValue(value)
+		}
+		if err != nil {
+			return PolicyStatement{}, err
+		}
+	}
+	return statement, nil
+}
+
+// policyValue decodes the value of a statement's attribute, replacing
+// references to records with "kind:id" strings.
+func policyValue(node *yaml.Node) (interface{}, error) {
+	if isPolicyTag(node.Tag) {
+		return policyReference(node), nil
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := policyValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := policyValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = value
+		}
+		return values, nil
+	}
+
+	var value interface{}
+	err := node.Decode(&value)
+	return value, err
+}
+
+// policyReference formats a reference to a record, such as "!group admins",
+// as "kind:id".
+func policyReference(node *yaml.Node) string {
+	kind := strings.TrimPrefix(node.Tag, "!")
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "id" {
+				return kind + ":" + node.Content[i+1].Value
+			}
+		}
+	}
+	return kind + ":" + node.Value
+}
+
+// isPolicyTag reports whether a YAML tag is a policy tag such as !variable,
+// rather than a standard tag such as !!str.
+func isPolicyTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
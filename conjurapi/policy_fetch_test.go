@@ -0,0 +1,108 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const effectivePolicy = `- !policy
+  id: apps
+  owner: !group admins
+  annotations:
+    description: Applications
+  body:
+  - !host myapp
+  - !variable
+    id: db-password
+    kind: password
+  - !permit
+    role: !host myapp
+    privileges: [ read, execute ]
+    resources:
+    - !variable db-password
+`
+
+func TestClient_FetchPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		if r.URL.Path == "/policies/cucumber/policy/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "/policies/cucumber/policy/root", r.URL.Path)
+		w.Write([]byte(effectivePolicy))
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Returns the YAML policy", func(t *testing.T) {
+		policy, err := conjur.FetchPolicy("root", FetchPolicyOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, effectivePolicy, string(policy))
+	})
+
+	t.Run("Returns parsed statements", func(t *testing.T) {
+		statements, err := conjur.FetchPolicyStatements("root", FetchPolicyOptions{})
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+
+		policy := statements[0]
+		assert.Equal(t, "policy", policy.Type)
+		assert.Equal(t, "apps", policy.ID)
+		assert.Equal(t, "group:admins", policy.Owner)
+		assert.Equal(t, map[string]string{"description": "Applications"}, policy.Annotations)
+		assert.Equal(t, []PolicyStatement{
+			{Type: "host", ID: "myapp"},
+			{Type: "variable", ID: "db-password", Attributes: map[string]interface{}{"kind": "password"}},
+			{Type: "permit", Attributes: map[string]interface{}{
+				"role":       "host:myapp",
+				"privileges": []interface{}{"read", "execute"},
+				"resources":  []interface{}{"variable:db-password"},
+			}},
+		}, policy.Body)
+	})
+
+	t.Run("Returns ErrNotFound for missing policies", func(t *testing.T) {
+		_, err := conjur.FetchPolicy("missing", FetchPolicyOptions{})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_FetchPolicyRequest(t *testing.T) {
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: "https://conjur", CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	req, err := conjur.FetchPolicyRequest("apps/team-a", FetchPolicyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://conjur/policies/cucumber/policy/apps%2Fteam-a", req.URL.String())
+
+	req, err = conjur.FetchPolicyRequest("apps", FetchPolicyOptions{Depth: 2, Limit: 500})
+	require.NoError(t, err)
+	assert.Equal(t, "https://conjur/policies/cucumber/policy/apps?depth=2&limit=500", req.URL.String())
+}
+
+func TestParsePolicyStatements(t *testing.T) {
+	t.Run("Parses empty policies", func(t *testing.T) {
+		statements, err := ParsePolicyStatements([]byte(""))
+		assert.NoError(t, err)
+		assert.Empty(t, statements)
+
+		statements, err = ParsePolicyStatements([]byte("- !policy\n  id: empty\n  body:\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []PolicyStatement{{Type: "policy", ID: "empty", Body: []PolicyStatement{}}}, statements)
+	})
+
+	t.Run("Rejects statements without a tag", func(t *testing.T) {
+		_, err := ParsePolicyStatements([]byte("- id: untagged"))
+		assert.EqualError(t, err, "policy statement on line 1 has no type tag, such as !variable")
+
+		_, err = ParsePolicyStatements([]byte("id: not-a-list"))
+		assert.EqualError(t, err, "policy on line 1 must be a sequence of statements")
+	})
+}
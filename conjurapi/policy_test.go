@@ -2,14 +2,17 @@ package conjurapi
 
 import (
 	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,7 +36,7 @@ var testCases = []struct {
 	{
 		name:       "Invalid PolicyMode",
 		policyMode: 99,
-		expectErr:  "Invalid PolicyMode: 99",
+		expectErr:  "invalid PolicyMode: 99",
 	},
 }
 
@@ -116,3 +119,162 @@ func TestClient_LoadPolicy(t *testing.T) {
 
 	})
 }
+
+func TestPolicyResponse_Roles(t *testing.T) {
+	resp := PolicyResponse{CreatedRoles: map[string]CreatedRole{
+		"cucumber:user:alice":      {ID: "cucumber:user:alice", APIKey: "alice-key"},
+		"cucumber:host:apps/myapp": {ID: "cucumber:host:apps/myapp", APIKey: "myapp-key"},
+	}}
+
+	roles := resp.Roles()
+	assert.Equal(t, []CreatedRole{
+		{ID: "cucumber:host:apps/myapp", APIKey: "myapp-key"},
+		{ID: "cucumber:user:alice", APIKey: "alice-key"},
+	}, roles)
+	assert.Equal(t, "host/apps/myapp", roles[0].Login())
+	assert.Equal(t, "alice", roles[1].Login())
+	assert.Empty(t, (&PolicyResponse{}).Roles())
+}
+
+func TestCreatedRole_String(t *testing.T) {
+	role := CreatedRole{ID: "cucumber:user:alice", APIKey: "alice-key"}
+	assert.Equal(t, "{ID:cucumber:user:alice APIKey:[REDACTED]}", role.String())
+	assert.NotContains(t, fmt.Sprintf("%v", PolicyResponse{CreatedRoles: map[string]CreatedRole{role.ID: role}}), "alice-key")
+}
+
+func TestCreatedRole_StoreCredentials(t *testing.T) {
+	role := CreatedRole{ID: "cucumber:host:apps/myapp", APIKey: "myapp-key"}
+
+	t.Run("Stores the credentials for the config", func(t *testing.T) {
+		config := Config{
+			Account:           "cucumber",
+			ApplianceURL:      "https://conjur.example.com",
+			NetRCPath:         t.TempDir() + "/.netrc",
+			CredentialStorage: CredentialStorageFile,
+		}
+		assert.NoError(t, role.StoreCredentials(config))
+
+		storageProvider, err := createStorageProvider(config)
+		assert.NoError(t, err)
+		login, apiKey, err := storageProvider.ReadCredentials()
+		assert.NoError(t, err)
+		assert.Equal(t, "host/apps/myapp", login)
+		assert.Equal(t, "myapp-key", apiKey)
+	})
+
+	t.Run("Fails without credential storage", func(t *testing.T) {
+		err := role.StoreCredentials(Config{CredentialStorage: CredentialStorageNone})
+		assert.EqualError(t, err, "unable to store credentials with CredentialStorage 'none'")
+	})
+}
+
+func TestPolicyMode_String(t *testing.T) {
+	assert.Equal(t, "post", PolicyModePost.String())
+	assert.Equal(t, "put", PolicyModePut.String())
+	assert.Equal(t, "patch", PolicyModePatch.String())
+	assert.Equal(t, "PolicyMode(99)", PolicyMode(99).String())
+}
+
+func TestClient_PolicyVersions(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/resources/cucumber/policy/root" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{
+  "id": "cucumber:policy:root",
+  "policy_versions": [
+    {"version": 2, "created_at": "2023-06-14T15:40:00.000+00:00", "policy_text": "- !user bob\n", "policy_sha256": "def", "id": "cucumber:policy:root", "role": "cucumber:user:admin", "client_ip": "127.0.0.1"},
+    {"version": 1, "created_at": "2023-06-14T15:39:13.199+00:00", "finished_at": "2023-06-14T15:39:14.000+00:00", "policy_text": "- !user alice\n", "policy_sha256": "abc", "id": "cucumber:policy:root", "role": "cucumber:user:admin", "client_ip": "127.0.0.1"}
+  ]
+}`))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns policy versions oldest first", func(t *testing.T) {
+		versions, err := conjur.PolicyVersions("root")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 2)
+
+		assert.Equal(t, uint32(1), versions[0].Version)
+		assert.Equal(t, "- !user alice\n", versions[0].PolicyText)
+		assert.Equal(t, "abc", versions[0].PolicySHA256)
+		assert.Equal(t, "cucumber:user:admin", versions[0].Role)
+		assert.NotNil(t, versions[0].FinishedAt)
+		assert.Equal(t, 2023, versions[0].CreatedAt.Year())
+
+		assert.Equal(t, uint32(2), versions[1].Version)
+		assert.Nil(t, versions[1].FinishedAt)
+	})
+
+	t.Run("Returns ErrNotFound for an unknown policy", func(t *testing.T) {
+		_, err := conjur.PolicyVersions("unknown")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_DryRunPolicy(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/policies/cucumber/policy/root" || r.URL.Query().Get("dryRun") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "invalid") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"status":"Invalid YAML","errors":[{"line":2,"column":3,"message":"Unrecognized data type '!invalid'"}]}`))
+			return
+		}
+		if r.Method == "PUT" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{
+  "status": "Valid YAML",
+  "created": {"items": [{"identifier": "cucumber:user:alice", "id": "alice", "type": "user", "owner": "cucumber:policy:root", "policy": "cucumber:policy:root", "annotations": {"team": "dev"}, "permissions": {}, "members": [], "memberships": [], "restricted_to": []}]},
+  "updated": {"before": {"items": [{"identifier": "cucumber:policy:root", "type": "policy"}]}, "after": {"items": [{"identifier": "cucumber:policy:root", "type": "policy"}]}},
+  "deleted": {"items": []}
+}`))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns the changes the policy would make", func(t *testing.T) {
+		resp, err := conjur.DryRunPolicy(PolicyModePost, "root", strings.NewReader("- !user alice\n"))
+		assert.NoError(t, err)
+		assert.True(t, resp.Valid())
+		assert.Equal(t, "Valid YAML", resp.Status)
+		assert.Len(t, resp.Created.Items, 1)
+		assert.Equal(t, "cucumber:user:alice", resp.Created.Items[0].Identifier)
+		assert.Equal(t, "user", resp.Created.Items[0].Type)
+		assert.Equal(t, "dev", resp.Created.Items[0].Annotations["team"])
+		assert.Equal(t, "cucumber:policy:root", resp.Updated.Before.Items[0].Identifier)
+		assert.Empty(t, resp.Deleted.Items)
+	})
+
+	t.Run("Returns validation errors for an invalid policy", func(t *testing.T) {
+		resp, err := conjur.DryRunPolicy(PolicyModePatch, "root", strings.NewReader("- !user alice\n  - !invalid\n"))
+		assert.NoError(t, err)
+		assert.False(t, resp.Valid())
+		assert.Equal(t, "Invalid YAML", resp.Status)
+		assert.Equal(t, []DryRunPolicyError{{Line: 2, Column: 3, Message: "Unrecognized data type '!invalid'"}}, resp.Errors)
+	})
+
+	t.Run("Returns other errors", func(t *testing.T) {
+		resp, err := conjur.DryRunPolicy(PolicyModePut, "root", strings.NewReader("- !user alice\n"))
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("Returns error for an invalid mode", func(t *testing.T) {
+		_, err := conjur.DryRunPolicy(99, "root", strings.NewReader(""))
+		assert.EqualError(t, err, "invalid PolicyMode: 99")
+	})
+}
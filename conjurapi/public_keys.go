@@ -0,0 +1,116 @@
+package conjurapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PublicKey is an SSH public key of a user, in authorized_keys format.
+type PublicKey struct {
+	// Name is the key's comment, such as "laptop", which Conjur uses to
+	// identify it.
+	Name string
+	// Key is the whole key, such as "ssh-rsa AAAAB3... laptop".
+	Key string
+}
+
+// ListPublicKeys is like PublicKeys but returns typed PublicKey values.
+func (c *Client) ListPublicKeys(kind string, identifier string) ([]PublicKey, error) {
+	return c.ListPublicKeysWithContext(context.Background(), kind, identifier)
+}
+
+// ListPublicKeysWithContext is like ListPublicKeys but uses the provided
+// context for the underlying request.
+func (c *Client) ListPublicKeysWithContext(ctx context.Context, kind string, identifier string) ([]PublicKey, error) {
+	data, err := c.PublicKeysWithContext(ctx, kind, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []PublicKey{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		keys = append(keys, PublicKey{Name: publicKeyName(line), Key: line})
+	}
+	return keys, nil
+}
+
+// AddPublicKeys adds SSH public keys, each of the form "<type> <key> <name>",
+// to a user. Keys with the name of an existing key replace it.
+//
+// Conjur only changes public keys through policy, so like SetAnnotations this
+// updates the policy which owns the user. It has no way to remove a single
+// key; replace the policy declaring the user without the key instead.
+//
+// The authenticated user must have read privilege on the user and update
+// privilege on the policy which owns it.
+func (c *Client) AddPublicKeys(userID string, keys ...string) error {
+	return c.AddPublicKeysWithContext(context.Background(), userID, keys...)
+}
+
+// AddPublicKeysWithContext is like AddPublicKeys but uses the provided context
+// for the underlying requests.
+func (c *Client) AddPublicKeysWithContext(ctx context.Context, userID string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if publicKeyName(key) == "" {
+			return fmt.Errorf("public key '%s' must be of the form '<type> <key> <name>'", key)
+		}
+	}
+
+	account, kind, id, err := c.parseIDandEnforceKind(userID, "user")
+	if err != nil {
+		return err
+	}
+
+	policyID, _, id, err := c.declaringPolicy(ctx, makeFullId(account, kind, id))
+	if err != nil {
+		return err
+	}
+
+	policy, err := publicKeysPolicy(id, keys)
+	if err != nil {
+		return err
+	}
+	_, err = c.LoadPolicyWithContext(ctx, PolicyModePatch, policyID, bytes.NewReader(policy))
+	return err
+}
+
+// publicKeyName returns the name of a public key, its third field, or "" if
+// it has none.
+func publicKeyName(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 3 {
+		return ""
+	}
+	return fields[2]
+}
+
+// publicKeysPolicy returns a policy document which declares a user with the
+// given public keys.
+func publicKeysPolicy(id string, keys []string) ([]byte, error) {
+	// JSON strings are valid YAML scalars, which takes care of quoting
+	quotedID, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy bytes.Buffer
+	fmt.Fprintf(&policy, "- !user\n  id: %s\n  public_keys:\n", quotedID)
+	for _, key := range keys {
+		quotedKey, err := json.Marshal(strings.TrimSpace(key))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&policy, "    - %s\n", quotedKey)
+	}
+	return policy.Bytes(), nil
+}
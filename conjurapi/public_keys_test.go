@@ -0,0 +1,93 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListPublicKeys(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/public_keys/cucumber/user/alice":
+			w.Write([]byte("ssh-rsa test-key-1 laptop\nssh-ed25519 test-key-2 workstation\n"))
+		case "/public_keys/cucumber/user/bob":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Parses each key", func(t *testing.T) {
+		keys, err := conjur.ListPublicKeys("user", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []PublicKey{
+			{Name: "laptop", Key: "ssh-rsa test-key-1 laptop"},
+			{Name: "workstation", Key: "ssh-ed25519 test-key-2 workstation"},
+		}, keys)
+	})
+
+	t.Run("Returns no keys for users without keys", func(t *testing.T) {
+		keys, err := conjur.ListPublicKeys("user", "bob")
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Returns an error for missing users", func(t *testing.T) {
+		_, err := conjur.ListPublicKeys("user", "carol")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_AddPublicKeys(t *testing.T) {
+	var policyPath, policyMethod, policyBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/resources/cucumber/user/alice%40apps":
+			w.Write([]byte(`{"id": "cucumber:user:alice@apps", "policy": "cucumber:policy:apps"}`))
+		case "/policies/cucumber/policy/apps":
+			policyPath = r.URL.EscapedPath()
+			policyMethod = r.Method
+			body, _ := io.ReadAll(r.Body)
+			policyBody = string(body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"created_roles": {}, "version": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Patches the owning policy", func(t *testing.T) {
+		err := conjur.AddPublicKeys("alice@apps", "ssh-rsa test-key-1 laptop", " ssh-ed25519 test-key-2 workstation\n")
+		require.NoError(t, err)
+		assert.Equal(t, "PATCH", policyMethod)
+		assert.Equal(t, "/policies/cucumber/policy/apps", policyPath)
+		assert.Equal(t, "- !user\n  id: \"alice\"\n  public_keys:\n    - \"ssh-rsa test-key-1 laptop\"\n    - \"ssh-ed25519 test-key-2 workstation\"\n", policyBody)
+	})
+
+	t.Run("Requires named keys", func(t *testing.T) {
+		err := conjur.AddPublicKeys("alice@apps", "ssh-rsa test-key-1")
+		assert.ErrorContains(t, err, "public key 'ssh-rsa test-key-1' must be of the form '<type> <key> <name>'")
+	})
+
+	t.Run("Requires a user", func(t *testing.T) {
+		err := conjur.AddPublicKeys("host:alice@apps", "ssh-rsa test-key-1 laptop")
+		assert.ErrorContains(t, err, "must represent a user")
+	})
+
+	t.Run("Returns an error for missing users", func(t *testing.T) {
+		err := conjur.AddPublicKeys("carol", "ssh-rsa test-key-1 laptop")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,101 @@
+package conjurapi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by all the requests of a client. It
+// holds up to burst tokens and refills at rate tokens per second.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a full token bucket. A burst of 0 allows bursts of
+// one second's worth of requests, and at least one.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it. Tokens taken in advance put the bucket into debt, which keeps
+// concurrent callers in order.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// release returns a token taken by reserve which won't be used.
+func (l *rateLimiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
+
+// wait blocks until a request may be sent, or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release()
+		return ctx.Err()
+	}
+}
+
+// rateLimitTransport delays requests to keep within the client's rate limit.
+// It sits below the retry layer, so that retries count towards the limit.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func newRateLimitTransport(base http.RoundTripper, rate float64, burst int) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{base: base, limiter: newRateLimiter(rate, burst)}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
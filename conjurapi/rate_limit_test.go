@@ -0,0 +1,94 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("Allows bursts then spaces out requests", func(t *testing.T) {
+		now := time.Now()
+		limiter := newRateLimiter(10, 2)
+		limiter.now = func() time.Time { return now }
+		limiter.last = now
+
+		assert.Equal(t, time.Duration(0), limiter.reserve())
+		assert.Equal(t, time.Duration(0), limiter.reserve())
+		assert.Equal(t, 100*time.Millisecond, limiter.reserve())
+		assert.Equal(t, 200*time.Millisecond, limiter.reserve())
+
+		now = now.Add(time.Second)
+		assert.Equal(t, time.Duration(0), limiter.reserve())
+	})
+
+	t.Run("Defaults the burst to one second of requests", func(t *testing.T) {
+		assert.Equal(t, float64(5), newRateLimiter(5, 0).burst)
+		assert.Equal(t, float64(1), newRateLimiter(0.5, 0).burst)
+	})
+
+	t.Run("Returns the token when the context is done", func(t *testing.T) {
+		limiter := newRateLimiter(0.001, 1)
+		require.NoError(t, limiter.wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.wait(ctx), context.DeadlineExceeded)
+		assert.InDelta(t, 0, limiter.tokens, 0.01)
+	})
+
+	t.Run("Shares the limit between goroutines", func(t *testing.T) {
+		limiter := newRateLimiter(100, 5)
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 15; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, limiter.wait(context.Background()))
+			}()
+		}
+		wg.Wait()
+
+		// 5 requests from the burst, then 10 more at 100 per second
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("secret"))
+	}))
+	defer ts.Close()
+
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      ts.URL,
+		CredentialStorage: "none",
+		RateLimit:         0.001,
+		RateLimitBurst:    2,
+	}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := conjur.RetrieveSecret("db/password")
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = conjur.RetrieveSecretWithContext(ctx, "db/password")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
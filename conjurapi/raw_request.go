@@ -0,0 +1,31 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewRequest crafts a request to an endpoint of the Conjur API for which the
+// client has no method yet. The path, which may include a query string, is
+// relative to the ApplianceURL, for example
+// "/resources/myaccount?kind=variable". Path components must already be
+// escaped. Send the request with Do.
+func (c *Client) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	path, query, hasQuery := strings.Cut(path, "?")
+	requestURL := makeRouterURL(c.config.ApplianceURL, path)
+	if hasQuery {
+		requestURL = requestURL.withQuery(query)
+	}
+	return http.NewRequest(method, requestURL.String(), body)
+}
+
+// Do sends a request with the client's access token, authenticating first if
+// needed, and through its HTTP client, so with its TLS configuration, retries
+// and timeouts. Unlike the client's other methods, Do returns the response as
+// is, whatever its status: the caller must close its body, and can turn error
+// responses into a *response.ConjurError with response.NewConjurError. The
+// request's context is used.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.SubmitRequest(req)
+}
@@ -0,0 +1,58 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Do(t *testing.T) {
+	var requestURI, authorization, body string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.URL.RequestURI()
+		authorization = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if r.URL.Path == "/api/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL + "/api/", CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Sends requests with the access token", func(t *testing.T) {
+		req, err := conjur.NewRequest("POST", "/issuers/cucumber?limit=2", strings.NewReader(`{"id": "aws"}`))
+		require.NoError(t, err)
+
+		resp, err := conjur.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+
+		assert.Equal(t, `{"ok": true}`, string(data))
+		assert.Equal(t, "/api/issuers/cucumber?limit=2", requestURI)
+		assert.Equal(t, conjur.authorizationHeader(), authorization)
+		assert.Equal(t, `{"id": "aws"}`, body)
+	})
+
+	t.Run("Returns error responses as is", func(t *testing.T) {
+		req, err := conjur.NewRequest("GET", "missing", nil)
+		require.NoError(t, err)
+
+		resp, err := conjur.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "/api/missing", requestURI)
+	})
+}
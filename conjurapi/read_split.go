@@ -0,0 +1,53 @@
+package conjurapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// readApplianceURLs returns the appliances configured with
+// Config.ReadApplianceURLs, without duplicates or trailing slashes.
+func (c *Config) readApplianceURLs() []string {
+	urls := []string{}
+	for _, applianceURL := range c.ReadApplianceURLs {
+		applianceURL = strings.TrimSuffix(applianceURL, "/")
+		if applianceURL != "" && !contains(urls, applianceURL) {
+			urls = append(urls, applianceURL)
+		}
+	}
+	return urls
+}
+
+// readSplitTransport sends reads to a pool of followers and everything else
+// to the leader. Requests are built against the leader's ApplianceURL; GET and
+// HEAD requests are sent to the nearest healthy follower instead, failing over
+// between followers like ApplianceURLs.
+type readSplitTransport struct {
+	reads  http.RoundTripper
+	writes http.RoundTripper
+}
+
+func newReadSplitTransport(base, writes http.RoundTripper, primary string, readURLs []string) *readSplitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if writes == nil {
+		writes = base
+	}
+	return &readSplitTransport{
+		reads: &failoverTransport{
+			base:     base,
+			primary:  primary,
+			selector: &applianceSelector{urls: readURLs, now: time.Now},
+		},
+		writes: writes,
+	}
+}
+
+func (t *readSplitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" || req.Method == "HEAD" {
+		return t.reads.RoundTrip(req)
+	}
+	return t.writes.RoundTrip(req)
+}
@@ -0,0 +1,75 @@
+package conjurapi
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReadSplit(t *testing.T) {
+	leader, leaderRequests := newTestAppliance("leader", http.StatusOK, 0)
+	defer leader.Close()
+	follower, followerRequests := newTestAppliance("follower", http.StatusOK, 0)
+	defer follower.Close()
+
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      leader.URL,
+		ReadApplianceURLs: []string{follower.URL + "/"},
+		CredentialStorage: "none",
+	}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Sends reads to the followers", func(t *testing.T) {
+		value, err := conjur.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "follower", string(value))
+		assert.Equal(t, int32(0), atomic.LoadInt32(leaderRequests))
+	})
+
+	t.Run("Sends writes to the leader", func(t *testing.T) {
+		req, err := conjur.AddSecretRequest("cucumber:variable:db/password", "s3cret")
+		require.NoError(t, err)
+		resp, err := conjur.SubmitRequest(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(leaderRequests))
+		assert.Equal(t, int32(1), atomic.LoadInt32(followerRequests))
+	})
+
+	t.Run("Fails over between followers", func(t *testing.T) {
+		down, _ := newTestAppliance("down", http.StatusOK, 0)
+		down.Close()
+
+		config := config
+		config.ReadApplianceURLs = []string{down.URL, follower.URL}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		value, err := conjur.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "follower", string(value))
+	})
+}
+
+func TestConfig_Validate_ReadApplianceURLs(t *testing.T) {
+	t.Run("Rejects invalid URLs", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "https://leader", ReadApplianceURLs: []string{"ftp://follower"}}
+		err := config.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ApplianceURL must use http, https or unix, not 'ftp'")
+	})
+
+	t.Run("Rejects Unix sockets", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: "unix:///run/conjur.sock", ReadApplianceURLs: []string{"https://follower"}}
+		err := config.Validate()
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "ReadApplianceURLs can't be used with a Unix socket"))
+	})
+}
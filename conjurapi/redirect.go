@@ -0,0 +1,49 @@
+package conjurapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// RedirectPolicyAllow makes the client follow redirects, as Go's HTTP
+	// client does by default. This is the default.
+	RedirectPolicyAllow = "allow"
+	// RedirectPolicySameHost makes the client only follow redirects to the
+	// same scheme, host and port, so that a redirect can't downgrade the
+	// request to plain HTTP or send it to another server.
+	RedirectPolicySameHost = "same-host"
+	// RedirectPolicyDeny makes requests fail when Conjur, or a proxy or load
+	// balancer in front of it, redirects them.
+	RedirectPolicyDeny = "deny"
+)
+
+var redirectPolicies = []string{RedirectPolicyAllow, RedirectPolicySameHost, RedirectPolicyDeny}
+
+// maxRedirects is the number of redirects followed before giving up, as with
+// Go's HTTP client.
+const maxRedirects = 10
+
+// checkRedirect returns the http.Client CheckRedirect function enforcing a
+// redirect policy, or nil for Go's default.
+func checkRedirect(policy string) func(req *http.Request, via []*http.Request) error {
+	switch policy {
+	case RedirectPolicySameHost:
+		return func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			original := via[0].URL
+			if req.URL.Scheme != original.Scheme || req.URL.Host != original.Host {
+				return fmt.Errorf("redirect to %s://%s is not allowed by RedirectPolicy '%s'", req.URL.Scheme, req.URL.Host, policy)
+			}
+			return nil
+		}
+	case RedirectPolicyDeny:
+		return func(req *http.Request, via []*http.Request) error {
+			return errors.New("redirects are not allowed by RedirectPolicy 'deny'")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_RedirectPolicy(t *testing.T) {
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("other"))
+	}))
+	defer otherServer.Close()
+
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/secrets/cucumber/variable/same-host":
+			http.Redirect(w, r, "/secrets/cucumber/variable/target", http.StatusFound)
+		case "/secrets/cucumber/variable/other-host":
+			http.Redirect(w, r, otherServer.URL+"/secrets/cucumber/variable/target", http.StatusFound)
+		default:
+			w.Write([]byte("secret"))
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	retrieve := func(t *testing.T, policy, variableID string) (string, error) {
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none", RedirectPolicy: policy}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+		value, err := conjur.RetrieveSecret(variableID)
+		return string(value), err
+	}
+
+	t.Run("Follows redirects by default", func(t *testing.T) {
+		value, err := retrieve(t, "", "other-host")
+		require.NoError(t, err)
+		assert.Equal(t, "other", value)
+
+		value, err = retrieve(t, RedirectPolicyAllow, "other-host")
+		require.NoError(t, err)
+		assert.Equal(t, "other", value)
+	})
+
+	t.Run("Follows redirects to the same host with same-host", func(t *testing.T) {
+		value, err := retrieve(t, RedirectPolicySameHost, "same-host")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", value)
+	})
+
+	t.Run("Refuses redirects to other hosts with same-host", func(t *testing.T) {
+		_, err := retrieve(t, RedirectPolicySameHost, "other-host")
+		assert.ErrorContains(t, err, "redirect to "+otherServer.URL+" is not allowed by RedirectPolicy 'same-host'")
+	})
+
+	t.Run("Refuses all redirects with deny", func(t *testing.T) {
+		_, err := retrieve(t, RedirectPolicyDeny, "same-host")
+		assert.ErrorContains(t, err, "redirects are not allowed by RedirectPolicy 'deny'")
+
+		value, err := retrieve(t, RedirectPolicyDeny, "target")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", value)
+	})
+
+	t.Run("Rejects unknown policies", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, RedirectPolicy: "sometimes"}
+		assert.EqualError(t, config.Validate(), "RedirectPolicy must be one of [allow same-host deny]")
+	})
+}
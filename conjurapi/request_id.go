@@ -0,0 +1,91 @@
+package conjurapi
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the header identifying a request in Conjur's logs and
+// audit events.
+const RequestIDHeader = "X-Request-Id"
+
+// IdempotencyKeyHeader is the header identifying an operation to proxies and
+// gateways in front of Conjur which deduplicate repeated requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type requestIDContextKey struct{}
+
+type idempotencyKeyContextKey struct{}
+
+// WithRequestID makes the requests sent with the returned context use the
+// given request ID, for example one received by the caller's own service, so
+// that they can be found in Conjur's logs. Otherwise the client generates a
+// random UUID for each request. Retries of a request keep its ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// WithIdempotencyKey makes the requests sent with the returned context carry
+// the given idempotency key, including their retries and replays, so that a
+// deduplicating proxy or gateway in front of Conjur can recognize a POST
+// repeated by RetryPolicy.AllMethods. The key names one operation, so each
+// operation needs a context with its own key. No key is sent otherwise.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// WithoutRequestIDs stops the client from generating a request ID for
+// requests without one.
+func WithoutRequestIDs() ClientOption {
+	return func(c *Client) {
+		c.noRequestIDs = true
+	}
+}
+
+// withRequestID sets the request ID header of a request, unless it has one
+// already, from its context or else a new UUID. It returns a copy of the
+// request if it changes it.
+func (c *Client) withRequestID(req *http.Request) *http.Request {
+	if req.Header.Get(RequestIDHeader) != "" {
+		return req
+	}
+
+	requestID, _ := req.Context().Value(requestIDContextKey{}).(string)
+	if requestID == "" && !c.noRequestIDs {
+		requestID = newRequestID()
+	}
+	if requestID == "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, requestID)
+	return req
+}
+
+// withIdempotencyKey sets the idempotency key header of a request from its
+// context, unless it has one already. It returns a copy of the request if it
+// changes it.
+func withIdempotencyKey(req *http.Request) *http.Request {
+	key, _ := req.Context().Value(idempotencyKeyContextKey{}).(string)
+	if key == "" || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(IdempotencyKeyHeader, key)
+	return req
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return ""
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
@@ -0,0 +1,113 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+func TestClient_RequestIDs(t *testing.T) {
+	var requestIDs []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		switch r.URL.Path {
+		case "/secrets/cucumber/variable/echoed":
+			w.Header().Set(RequestIDHeader, "server-id")
+			w.WriteHeader(http.StatusForbidden)
+		case "/secrets/cucumber/variable/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("secret"))
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Generates a UUID for each request", func(t *testing.T) {
+		requestIDs = nil
+		conjur.RetrieveSecret("db/password")
+		conjur.RetrieveSecret("db/password")
+		require.Len(t, requestIDs, 2)
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		assert.Regexp(t, uuid, requestIDs[0])
+		assert.Regexp(t, uuid, requestIDs[1])
+		assert.NotEqual(t, requestIDs[0], requestIDs[1])
+	})
+
+	t.Run("Uses the request ID of the context", func(t *testing.T) {
+		requestIDs = nil
+		_, err := conjur.RetrieveSecretWithContext(WithRequestID(context.Background(), "caller-id"), "db/password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"caller-id"}, requestIDs)
+	})
+
+	t.Run("Exposes request IDs in errors", func(t *testing.T) {
+		var conjurError *response.ConjurError
+
+		_, err := conjur.RetrieveSecret("echoed")
+		require.True(t, errors.As(err, &conjurError))
+		assert.Equal(t, "server-id", conjurError.RequestID)
+		assert.EqualError(t, err, "403 Forbidden. (request ID server-id)")
+
+		_, err = conjur.RetrieveSecretWithContext(WithRequestID(context.Background(), "caller-id"), "missing")
+		require.True(t, errors.As(err, &conjurError))
+		assert.Equal(t, "caller-id", conjurError.RequestID)
+	})
+
+	t.Run("Can be disabled", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token, WithoutRequestIDs())
+		require.NoError(t, err)
+
+		requestIDs = nil
+		conjur.RetrieveSecret("db/password")
+		assert.Equal(t, []string{""}, requestIDs)
+	})
+}
+
+func TestClient_IdempotencyKeys(t *testing.T) {
+	var keys []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      mockConjurServer.URL,
+		CredentialStorage: "none",
+		RetryPolicy:       RetryPolicy{AllMethods: true, BackoffBase: time.Millisecond},
+	}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Keeps the context's key across retries", func(t *testing.T) {
+		keys = nil
+		err := conjur.AddSecretWithContext(WithIdempotencyKey(context.Background(), "rotation-42"), "db/password", "secret")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"rotation-42", "rotation-42"}, keys)
+	})
+
+	t.Run("Sends no key by default", func(t *testing.T) {
+		keys = nil
+		err := conjur.AddSecret("db/password", "secret")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", ""}, keys)
+	})
+}
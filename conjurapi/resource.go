@@ -1,41 +1,110 @@
 package conjurapi
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 )
 
+// ResourceFilter limits the resources listed by Resources, ListResources,
+// ResourceIDs and ResourcesCount.
 type ResourceFilter struct {
 	Kind   string
 	Search string
 	Limit  int
 	Offset int
-	Role   string
+	// ActingAs lists the resources visible to another role rather than to the
+	// authenticated one, such as "host:myapp" or "myorg:host:myapp", to preview
+	// what the role can see. The authenticated role must be a member of it.
+	ActingAs string
+	// Deprecated: Use ActingAs, which takes precedence.
+	Role string
+}
+
+// Resource is a Conjur resource, such as a variable, host or policy.
+type Resource struct {
+	ID           string               `json:"id"`
+	Owner        string               `json:"owner"`
+	Policy       string               `json:"policy,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	Permissions  []ResourcePermission `json:"permissions"`
+	Annotations  []ResourceAnnotation `json:"annotations"`
+	RestrictedTo []string             `json:"restricted_to,omitempty"`
+	// Secrets lists the stored secret versions of a variable.
+	Secrets []SecretVersion `json:"secrets,omitempty"`
+	// PolicyVersions lists the loaded versions of a policy.
+	PolicyVersions []PolicyVersion `json:"policy_versions,omitempty"`
+}
+
+// ResourcePermission is a privilege on a resource granted to a role.
+type ResourcePermission struct {
+	Privilege string `json:"privilege"`
+	Role      string `json:"role"`
+	Policy    string `json:"policy,omitempty"`
+}
+
+// ResourceAnnotation is a name and value attached to a resource.
+type ResourceAnnotation struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Policy string `json:"policy,omitempty"`
+}
+
+// Annotation returns the value of the named annotation, if present.
+func (r *Resource) Annotation(name string) (string, bool) {
+	for _, annotation := range r.Annotations {
+		if annotation.Name == name {
+			return annotation.Value, true
+		}
+	}
+	return "", false
+}
+
+// SecretVersionCount returns the number of secret versions Conjur stores for
+// a variable, which is 0 for other kinds of resources.
+func (r *Resource) SecretVersionCount() int {
+	return len(r.Secrets)
 }
 
 // CheckPermission determines whether the authenticated user has a specified privilege
 // on a resource.
 func (c *Client) CheckPermission(resourceID string, privilege string) (bool, error) {
+	return c.CheckPermissionWithContext(context.Background(), resourceID, privilege)
+}
+
+// CheckPermissionWithContext is like CheckPermission but uses the provided
+// context for the underlying request.
+func (c *Client) CheckPermissionWithContext(ctx context.Context, resourceID string, privilege string) (bool, error) {
 	req, err := c.CheckPermissionRequest(resourceID, privilege)
 	if err != nil {
 		return false, err
 	}
 
-	return c.processPermissionCheck(req)
+	return c.processPermissionCheck(req.WithContext(ctx))
 }
 
 // CheckPermissionForRole determines whether the provided role has a specific
 // privilege on a resource.
 func (c *Client) CheckPermissionForRole(resourceID string, roleID string, privilege string) (bool, error) {
+	return c.CheckPermissionForRoleWithContext(context.Background(), resourceID, roleID, privilege)
+}
+
+// CheckPermissionForRoleWithContext is like CheckPermissionForRole but uses the
+// provided context for the underlying request.
+func (c *Client) CheckPermissionForRoleWithContext(ctx context.Context, resourceID string, roleID string, privilege string) (bool, error) {
 	req, err := c.CheckPermissionForRoleRequest(resourceID, roleID, privilege)
 	if err != nil {
 		return false, err
 	}
 
-	return c.processPermissionCheck(req)
+	return c.processPermissionCheck(req.WithContext(ctx))
 }
 
 func (c *Client) processPermissionCheck(req *http.Request) (bool, error) {
@@ -45,43 +114,88 @@ func (c *Client) processPermissionCheck(req *http.Request) (bool, error) {
 	}
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		resp.Body.Close()
 		return true, nil
 	} else if resp.StatusCode == 404 || resp.StatusCode == 403 {
+		resp.Body.Close()
 		return false, nil
 	} else {
-		return false, fmt.Errorf("Permission check failed with HTTP status %d", resp.StatusCode)
+		// Preserve the status and Conjur error details, e.g. for errors.Is
+		return false, fmt.Errorf("permission check failed with HTTP status %d: %w", resp.StatusCode, response.NewConjurError(resp))
 	}
 }
 
 // ResourceExists checks whether or not a resource exists
 func (c *Client) ResourceExists(resourceID string) (bool, error) {
+	return c.ResourceExistsWithContext(context.Background(), resourceID)
+}
+
+// ResourceExistsWithContext is like ResourceExists but uses the provided
+// context for the underlying request.
+func (c *Client) ResourceExistsWithContext(ctx context.Context, resourceID string) (bool, error) {
 	req, err := c.ResourceRequest(resourceID)
 	if err != nil {
 		return false, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return false, err
 	}
 
 	if (resp.StatusCode >= 200 && resp.StatusCode < 300) || resp.StatusCode == 403 {
+		resp.Body.Close()
 		return true, nil
 	} else if resp.StatusCode == 404 {
+		resp.Body.Close()
 		return false, nil
 	} else {
-		return false, fmt.Errorf("Resource exists check failed with HTTP status %d", resp.StatusCode)
+		// Preserve the status and Conjur error details, e.g. for errors.Is
+		return false, fmt.Errorf("resource exists check failed with HTTP status %d: %w", resp.StatusCode, response.NewConjurError(resp))
 	}
 }
 
+// ResourceMetadata fetches a single user-visible resource by id, like Resource
+// but returning a typed Resource. It returns ErrNotFound if the resource
+// doesn't exist or isn't visible to the authenticated user.
+func (c *Client) ResourceMetadata(resourceID string) (*Resource, error) {
+	return c.ResourceMetadataWithContext(context.Background(), resourceID)
+}
+
+// ResourceMetadataWithContext is like ResourceMetadata but uses the provided
+// context for the underlying request.
+func (c *Client) ResourceMetadataWithContext(ctx context.Context, resourceID string) (*Resource, error) {
+	req, err := c.ResourceRequest(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resource := Resource{}
+	if err := response.JSONResponse(resp, &resource); err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
 // Resource fetches a single user-visible resource by id.
 func (c *Client) Resource(resourceID string) (resource map[string]interface{}, err error) {
+	return c.ResourceWithContext(context.Background(), resourceID)
+}
+
+// ResourceWithContext is like Resource but uses the provided context for the
+// underlying request.
+func (c *Client) ResourceWithContext(ctx context.Context, resourceID string) (resource map[string]interface{}, err error) {
 	req, err := c.ResourceRequest(resourceID)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return
 	}
@@ -100,12 +214,18 @@ func (c *Client) Resource(resourceID string) (resource map[string]interface{}, e
 // be limited by the given ResourceFilter. If filter is non-nil, only
 // non-zero-valued members of the filter will be applied.
 func (c *Client) Resources(filter *ResourceFilter) (resources []map[string]interface{}, err error) {
+	return c.ResourcesWithContext(context.Background(), filter)
+}
+
+// ResourcesWithContext is like Resources but uses the provided context for the
+// underlying request.
+func (c *Client) ResourcesWithContext(ctx context.Context, filter *ResourceFilter) (resources []map[string]interface{}, err error) {
 	req, err := c.ResourcesRequest(filter)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return
 	}
@@ -120,8 +240,68 @@ func (c *Client) Resources(filter *ResourceFilter) (resources []map[string]inter
 	return
 }
 
+// ListResources is like Resources but returns typed Resource values.
+func (c *Client) ListResources(filter *ResourceFilter) ([]Resource, error) {
+	return c.ListResourcesWithContext(context.Background(), filter)
+}
+
+// ListResourcesWithContext is like ListResources but uses the provided context
+// for the underlying request.
+func (c *Client) ListResourcesWithContext(ctx context.Context, filter *ResourceFilter) ([]Resource, error) {
+	req, err := c.ResourcesRequest(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []Resource{}
+	if err := response.JSONResponse(resp, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// ResourcesCount returns the number of user-visible resources matching the
+// Kind, Search and ActingAs members of the given ResourceFilter. Limit and Offset
+// are ignored.
+func (c *Client) ResourcesCount(filter *ResourceFilter) (int, error) {
+	return c.ResourcesCountWithContext(context.Background(), filter)
+}
+
+// ResourcesCountWithContext is like ResourcesCount but uses the provided
+// context for the underlying request.
+func (c *Client) ResourcesCountWithContext(ctx context.Context, filter *ResourceFilter) (int, error) {
+	req, err := c.ResourcesCountRequest(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	count := struct {
+		Count int `json:"count"`
+	}{}
+	if err := response.JSONResponse(resp, &count); err != nil {
+		return 0, err
+	}
+	return count.Count, nil
+}
+
 func (c *Client) ResourceIDs(filter *ResourceFilter) ([]string, error) {
-	resources, err := c.Resources(filter)
+	return c.ResourceIDsWithContext(context.Background(), filter)
+}
+
+// ResourceIDsWithContext is like ResourceIDs but uses the provided context for
+// the underlying request.
+func (c *Client) ResourceIDsWithContext(ctx context.Context, filter *ResourceFilter) ([]string, error) {
+	resources, err := c.ResourcesWithContext(ctx, filter)
 
 	if err != nil {
 		return nil, err
@@ -136,14 +316,59 @@ func (c *Client) ResourceIDs(filter *ResourceFilter) ([]string, error) {
 	return resourceIDs, nil
 }
 
+// variablesPageSize is the number of variables fetched per request by
+// VariablesInPolicy.
+const variablesPageSize = 1000
+
+// VariablesInPolicy lists the IDs, without the account and kind, of the
+// variables under a policy branch such as "prod/payments", including those in
+// nested policies. An empty branch or "root" lists every visible variable.
+// The IDs are sorted.
+func (c *Client) VariablesInPolicy(policyBranch string) ([]string, error) {
+	return c.VariablesInPolicyWithContext(context.Background(), policyBranch)
+}
+
+// VariablesInPolicyWithContext is like VariablesInPolicy but uses the provided
+// context for the underlying requests.
+func (c *Client) VariablesInPolicyWithContext(ctx context.Context, policyBranch string) ([]string, error) {
+	prefix := strings.Trim(policyBranch, "/")
+	if prefix == "root" {
+		prefix = ""
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	variableIDs := []string{}
+	pager := c.ResourcesPagerWithContext(ctx, &ResourceFilter{Kind: "variable"}, PagerOptions{PageSize: variablesPageSize})
+	for pager.Next() {
+		_, _, id := c.unopinionatedParseID(pager.Value().ID)
+		if strings.HasPrefix(id, prefix) {
+			variableIDs = append(variableIDs, id)
+		}
+	}
+	if err := pager.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(variableIDs)
+	return variableIDs, nil
+}
+
 // PermittedRoles lists the roles which have the named permission on a resource
 func (c *Client) PermittedRoles(resourceID, privilege string) ([]string, error) {
+	return c.PermittedRolesWithContext(context.Background(), resourceID, privilege)
+}
+
+// PermittedRolesWithContext is like PermittedRoles but uses the provided
+// context for the underlying request.
+func (c *Client) PermittedRolesWithContext(ctx context.Context, resourceID, privilege string) ([]string, error) {
 	req, err := c.PermittedRolesRequest(resourceID, privilege)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +379,166 @@ func (c *Client) PermittedRoles(resourceID, privilege string) ([]string, error)
 	}
 
 	roles := make([]string, 0)
-	err = json.Unmarshal(data, &roles)
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, err
+	}
 	return roles, nil
 }
+
+// ResourceAnnotations fetches the annotations of a resource as a map of names
+// to values.
+//
+// The authenticated user must have read privilege on the resource.
+func (c *Client) ResourceAnnotations(resourceID string) (map[string]string, error) {
+	return c.ResourceAnnotationsWithContext(context.Background(), resourceID)
+}
+
+// ResourceAnnotationsWithContext is like ResourceAnnotations but uses the
+// provided context for the underlying request.
+func (c *Client) ResourceAnnotationsWithContext(ctx context.Context, resourceID string) (map[string]string, error) {
+	req, err := c.ResourceRequest(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	resource := struct {
+		Annotations []ResourceAnnotation `json:"annotations"`
+	}{}
+	if err := response.JSONResponse(resp, &resource); err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string, len(resource.Annotations))
+	for _, annotation := range resource.Annotations {
+		annotations[annotation.Name] = annotation.Value
+	}
+	return annotations, nil
+}
+
+// SetAnnotation sets an annotation on a resource. Conjur only changes
+// annotations through policy, so this looks up the policy which owns the
+// resource and updates it with a policy document which re-declares the
+// resource with the annotation.
+//
+// The authenticated user must have read privilege on the resource and update
+// privilege on the policy which owns it.
+func (c *Client) SetAnnotation(resourceID string, name string, value string) error {
+	return c.SetAnnotationsWithContext(context.Background(), resourceID, map[string]string{name: value})
+}
+
+// SetAnnotationWithContext is like SetAnnotation but uses the provided context
+// for the underlying requests.
+func (c *Client) SetAnnotationWithContext(ctx context.Context, resourceID string, name string, value string) error {
+	return c.SetAnnotationsWithContext(ctx, resourceID, map[string]string{name: value})
+}
+
+// SetAnnotations sets several annotations on a resource with a single policy
+// update. See SetAnnotation.
+func (c *Client) SetAnnotations(resourceID string, annotations map[string]string) error {
+	return c.SetAnnotationsWithContext(context.Background(), resourceID, annotations)
+}
+
+// SetAnnotationsWithContext is like SetAnnotations but uses the provided
+// context for the underlying requests.
+func (c *Client) SetAnnotationsWithContext(ctx context.Context, resourceID string, annotations map[string]string) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	policyID, kind, id, err := c.declaringPolicy(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+
+	policy, err := annotationsPolicy(kind, id, annotations)
+	if err != nil {
+		return err
+	}
+	_, err = c.LoadPolicyWithContext(ctx, PolicyModePatch, policyID, bytes.NewReader(policy))
+	return err
+}
+
+// declaringPolicy looks up the policy which owns a resource, returning its
+// fully-qualified ID along with the resource's kind and the ID by which the
+// policy declares it, for updating the resource with a policy patch.
+func (c *Client) declaringPolicy(ctx context.Context, resourceID string) (policyID, kind, id string, err error) {
+	account, kind, id, err := c.parseID(resourceID)
+	if err != nil {
+		return "", "", "", err
+	}
+	fullID := strings.Join([]string{account, kind, id}, ":")
+
+	req, err := c.ResourceRequest(fullID)
+	if err != nil {
+		return "", "", "", err
+	}
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return "", "", "", err
+	}
+	resource := struct {
+		Policy string `json:"policy"`
+	}{}
+	if err = response.JSONResponse(resp, &resource); err != nil {
+		return "", "", "", err
+	}
+	if resource.Policy == "" {
+		return "", "", "", fmt.Errorf("resource '%s' is not owned by a policy", fullID)
+	}
+
+	_, _, policyPath, err := c.parseID(resource.Policy)
+	if err != nil {
+		return "", "", "", err
+	}
+	return resource.Policy, kind, policyRelativeID(kind, id, policyPath), nil
+}
+
+// policyRelativeID returns the ID by which a resource is declared in the
+// policy which owns it. Conjur prefixes the IDs of records in a policy other
+// than root with the policy path, except for users, which get an
+// "@<policy-path-with-dashes>" suffix instead.
+func policyRelativeID(kind, id, policyID string) string {
+	if policyID == "root" {
+		return id
+	}
+	if kind == "user" {
+		return strings.TrimSuffix(id, "@"+strings.ReplaceAll(policyID, "/", "-"))
+	}
+	return strings.TrimPrefix(id, policyID+"/")
+}
+
+// annotationsPolicy returns a policy document which declares a resource with
+// the given annotations.
+func annotationsPolicy(kind, id string, annotations map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(annotations))
+	for name := range annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// JSON strings are valid YAML scalars, which takes care of quoting
+	quotedID, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy bytes.Buffer
+	fmt.Fprintf(&policy, "- !%s\n  id: %s\n  annotations:\n", strings.ReplaceAll(kind, "_", "-"), quotedID)
+	for _, name := range names {
+		quotedName, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		quotedValue, err := json.Marshal(annotations[name])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&policy, "    %s: %s\n", quotedName, quotedValue)
+	}
+	return policy.Bytes(), nil
+}
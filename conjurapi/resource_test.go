@@ -1,6 +1,13 @@
 package conjurapi
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -189,3 +196,400 @@ func TestClient_PermittedRoles(t *testing.T) {
 
 	t.Run("Lists permitted roles on a variable", listPermittedRoles(conjur, "cucumber:variable:db-password", 2))
 }
+
+func TestClient_PermittedRolesResponses(t *testing.T) {
+	var lastQuery string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/db-password":
+			w.Write([]byte(`["cucumber:host:app","cucumber:user:admin"]`))
+		case "/resources/cucumber/variable/garbled":
+			w.Write([]byte(`{"not":"a list"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Lists the permitted roles", func(t *testing.T) {
+		roles, err := conjur.PermittedRoles("cucumber:variable:db-password", "execute")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cucumber:host:app", "cucumber:user:admin"}, roles)
+		assert.Equal(t, "permitted_roles=true&privilege=execute", lastQuery)
+	})
+
+	t.Run("Reports an unexpected response", func(t *testing.T) {
+		_, err := conjur.PermittedRoles("cucumber:variable:garbled", "execute")
+		assert.Error(t, err)
+	})
+
+	t.Run("Returns ErrNotFound for an unknown resource", func(t *testing.T) {
+		_, err := conjur.PermittedRoles("cucumber:variable:unknown", "execute")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_ListResources(t *testing.T) {
+	var lastQuery string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimSuffix(r.URL.Path, "/") != "/resources/cucumber" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lastQuery = r.URL.RawQuery
+		if r.URL.Query().Get("count") == "true" {
+			w.Write([]byte(`{"count":42}`))
+			return
+		}
+		w.Write([]byte(`[{
+  "id": "cucumber:variable:db/password",
+  "owner": "cucumber:policy:db",
+  "policy": "cucumber:policy:root",
+  "created_at": "2023-06-14T15:39:13.199+00:00",
+  "permissions": [{"privilege": "execute", "role": "cucumber:host:app", "policy": "cucumber:policy:root"}],
+  "annotations": [{"name": "description", "value": "Database password", "policy": "cucumber:policy:root"}],
+  "secrets": [{"version": 1}, {"version": 2}]
+}]`))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns typed resources", func(t *testing.T) {
+		resources, err := conjur.ListResources(&ResourceFilter{Kind: "variable", Search: "db", Limit: 10, Offset: 20, Role: "cucumber:host:app"})
+		assert.NoError(t, err)
+		assert.Equal(t, "acting_as=cucumber%3Ahost%3Aapp&kind=variable&limit=10&offset=20&search=db", lastQuery)
+
+		assert.Len(t, resources, 1)
+		resource := resources[0]
+		assert.Equal(t, "cucumber:variable:db/password", resource.ID)
+		assert.Equal(t, "cucumber:policy:db", resource.Owner)
+		assert.Equal(t, 2023, resource.CreatedAt.Year())
+		assert.Equal(t, []ResourcePermission{{Privilege: "execute", Role: "cucumber:host:app", Policy: "cucumber:policy:root"}}, resource.Permissions)
+		assert.Len(t, resource.Secrets, 2)
+
+		description, ok := resource.Annotation("description")
+		assert.True(t, ok)
+		assert.Equal(t, "Database password", description)
+		_, ok = resource.Annotation("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("Counts resources ignoring limit and offset", func(t *testing.T) {
+		count, err := conjur.ResourcesCount(&ResourceFilter{Kind: "variable", Limit: 10, Offset: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.Equal(t, "count=true&kind=variable", lastQuery)
+	})
+
+	t.Run("Counts all resources without a filter", func(t *testing.T) {
+		count, err := conjur.ResourcesCount(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.Equal(t, "count=true", lastQuery)
+	})
+
+	t.Run("Acts as partially-qualified roles", func(t *testing.T) {
+		_, err := conjur.ResourceIDs(&ResourceFilter{ActingAs: "host:app", Role: "cucumber:user:alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "acting_as=cucumber%3Ahost%3Aapp", lastQuery)
+
+		count, err := conjur.ResourcesCount(&ResourceFilter{ActingAs: "other:host:app"})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.Equal(t, "acting_as=other%3Ahost%3Aapp&count=true", lastQuery)
+	})
+
+	t.Run("Rejects malformed roles to act as", func(t *testing.T) {
+		_, err := conjur.ListResources(&ResourceFilter{ActingAs: "app"})
+		assert.ErrorContains(t, err, "malformed ID 'app'")
+	})
+}
+
+func TestClient_CheckPermissionStatuses(t *testing.T) {
+	var lastQuery string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/allowed":
+			w.WriteHeader(http.StatusNoContent)
+		case "/resources/cucumber/variable/denied":
+			w.WriteHeader(http.StatusForbidden)
+		case "/resources/cucumber/variable/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns true for a 204 response", func(t *testing.T) {
+		allowed, err := conjur.CheckPermission("cucumber:variable:allowed", "execute")
+		assertSuccess(t, allowed, err)
+		assert.Equal(t, "check=true&privilege=execute", lastQuery)
+	})
+
+	t.Run("Returns false for 403 and 404 responses", func(t *testing.T) {
+		allowed, err := conjur.CheckPermission("cucumber:variable:denied", "execute")
+		assertFailure(t, allowed, err)
+
+		allowed, err = conjur.CheckPermissionForRole("cucumber:variable:missing", "cucumber:host:app", "read")
+		assertFailure(t, allowed, err)
+		assert.Equal(t, "check=true&privilege=read&role=cucumber%3Ahost%3Aapp", lastQuery)
+	})
+
+	t.Run("Returns a typed error for other responses", func(t *testing.T) {
+		allowed, err := conjur.CheckPermission("cucumber:variable:broken", "execute")
+		assert.False(t, allowed)
+		assert.ErrorContains(t, err, "permission check failed with HTTP status 500")
+		assert.ErrorIs(t, err, ErrServerError)
+	})
+}
+
+func TestClient_ResourceExistsStatuses(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/visible":
+			w.Write([]byte(`{"id":"cucumber:variable:visible"}`))
+		case "/resources/cucumber/variable/hidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/resources/cucumber/variable/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns true for 200 and 403 responses", func(t *testing.T) {
+		exists, err := conjur.ResourceExists("cucumber:variable:visible")
+		assertSuccess(t, exists, err)
+
+		exists, err = conjur.ResourceExists("cucumber:variable:hidden")
+		assertSuccess(t, exists, err)
+	})
+
+	t.Run("Returns false for a 404 response", func(t *testing.T) {
+		exists, err := conjur.ResourceExists("cucumber:variable:missing")
+		assertFailure(t, exists, err)
+	})
+
+	t.Run("Returns a typed error for other responses", func(t *testing.T) {
+		exists, err := conjur.ResourceExists("cucumber:variable:broken")
+		assert.False(t, exists)
+		assert.ErrorContains(t, err, "resource exists check failed with HTTP status 500")
+		assert.ErrorIs(t, err, ErrServerError)
+	})
+}
+
+func TestClient_ResourceMetadata(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/db-password":
+			w.Write([]byte(`{
+				"id": "cucumber:variable:db-password",
+				"owner": "cucumber:policy:db",
+				"policy": "cucumber:policy:db",
+				"created_at": "2024-01-02T03:04:05.000Z",
+				"annotations": [{"name": "description", "value": "Database password", "policy": "cucumber:policy:db"}],
+				"permissions": [{"privilege": "execute", "role": "cucumber:host:app"}],
+				"secrets": [{"version": 1}, {"version": 2}]
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns the typed resource", func(t *testing.T) {
+		resource, err := conjur.ResourceMetadata("cucumber:variable:db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "cucumber:policy:db", resource.Owner)
+		assert.Equal(t, "cucumber:policy:db", resource.Policy)
+		description, _ := resource.Annotation("description")
+		assert.Equal(t, "Database password", description)
+		assert.Equal(t, []ResourcePermission{{Privilege: "execute", Role: "cucumber:host:app"}}, resource.Permissions)
+		assert.Equal(t, 2, resource.SecretVersionCount())
+	})
+
+	t.Run("Returns ErrNotFound for an unknown resource", func(t *testing.T) {
+		_, err := conjur.ResourceMetadata("cucumber:variable:unknown")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Rejects malformed IDs", func(t *testing.T) {
+		_, err := conjur.ResourceMetadata("malformed")
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_ResourceAnnotations(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/resources/cucumber/variable/db%2Fpassword":
+			w.Write([]byte(`{
+				"id": "cucumber:variable:db/password",
+				"annotations": [
+					{"name": "conjur/mime_type", "value": "text/plain", "policy": "cucumber:policy:root"},
+					{"name": "rotation/ttl", "value": "P1D", "policy": "cucumber:policy:root"}
+				]
+			}`))
+		case "/resources/cucumber/variable/plain":
+			w.Write([]byte(`{"id": "cucumber:variable:plain", "annotations": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns the annotations by name", func(t *testing.T) {
+		annotations, err := conjur.ResourceAnnotations("variable:db/password")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"conjur/mime_type": "text/plain", "rotation/ttl": "P1D"}, annotations)
+	})
+
+	t.Run("Returns an empty map for resources without annotations", func(t *testing.T) {
+		annotations, err := conjur.ResourceAnnotations("cucumber:variable:plain")
+		assert.NoError(t, err)
+		assert.Empty(t, annotations)
+		assert.NotNil(t, annotations)
+	})
+
+	t.Run("Returns an error for missing resources", func(t *testing.T) {
+		_, err := conjur.ResourceAnnotations("variable:missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_SetAnnotations(t *testing.T) {
+	var policyPath, policyMethod, policyBody string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/resources/cucumber/variable/apps%2Fdb%2Fpassword":
+			w.Write([]byte(`{"id": "cucumber:variable:apps/db/password", "policy": "cucumber:policy:apps"}`))
+		case "/resources/cucumber/user/alice%40apps-dev":
+			w.Write([]byte(`{"id": "cucumber:user:alice@apps-dev", "policy": "cucumber:policy:apps/dev"}`))
+		case "/resources/cucumber/variable/shared":
+			w.Write([]byte(`{"id": "cucumber:variable:shared", "policy": "cucumber:policy:root"}`))
+		case "/policies/cucumber/policy/apps", "/policies/cucumber/policy/apps%2Fdev", "/policies/cucumber/policy/root":
+			policyPath = r.URL.EscapedPath()
+			policyMethod = r.Method
+			body, _ := io.ReadAll(r.Body)
+			policyBody = string(body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"created_roles": {}, "version": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Updates the owning policy", func(t *testing.T) {
+		err := conjur.SetAnnotation("variable:apps/db/password", "description", `Say "hi"`)
+		assert.NoError(t, err)
+		assert.Equal(t, "PATCH", policyMethod)
+		assert.Equal(t, "/policies/cucumber/policy/apps", policyPath)
+		assert.Equal(t, "- !variable\n  id: \"db/password\"\n  annotations:\n    \"description\": \"Say \\\"hi\\\"\"\n", policyBody)
+	})
+
+	t.Run("Strips the policy suffix from users", func(t *testing.T) {
+		err := conjur.SetAnnotations("cucumber:user:alice@apps-dev", map[string]string{"b": "2", "a": "1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "/policies/cucumber/policy/apps%2Fdev", policyPath)
+		assert.Equal(t, "- !user\n  id: \"alice\"\n  annotations:\n    \"a\": \"1\"\n    \"b\": \"2\"\n", policyBody)
+	})
+
+	t.Run("Keeps IDs in the root policy", func(t *testing.T) {
+		err := conjur.SetAnnotation("variable:shared", "owner", "team")
+		assert.NoError(t, err)
+		assert.Equal(t, "/policies/cucumber/policy/root", policyPath)
+		assert.Contains(t, policyBody, "id: \"shared\"")
+	})
+
+	t.Run("Returns an error for missing resources", func(t *testing.T) {
+		err := conjur.SetAnnotation("variable:missing", "owner", "team")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Requires a qualified ID", func(t *testing.T) {
+		err := conjur.SetAnnotation("missing", "owner", "team")
+		assert.ErrorContains(t, err, "malformed ID 'missing'")
+	})
+}
+
+func TestClient_VariablesInPolicy(t *testing.T) {
+	ids := []string{"prod/payments/db/password", "prod/payments-legacy/key", "shared"}
+	// Enough variables to need more than one page
+	for i := 0; i < variablesPageSize; i++ {
+		ids = append(ids, fmt.Sprintf("prod/other/%04d", i))
+	}
+	ids = append(ids, "prod/payments/api-key")
+
+	var queries []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		resources := []Resource{}
+		for i := offset; i < len(ids) && i < offset+limit; i++ {
+			resources = append(resources, Resource{ID: "cucumber:variable:" + ids[i]})
+		}
+		json.NewEncoder(w).Encode(resources)
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Lists variables under the branch across pages", func(t *testing.T) {
+		queries = nil
+		variableIDs, err := conjur.VariablesInPolicy("/prod/payments/")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"prod/payments/api-key", "prod/payments/db/password"}, variableIDs)
+		assert.Equal(t, []string{
+			"kind=variable&limit=1000",
+			"kind=variable&limit=1000&offset=1000",
+		}, queries)
+	})
+
+	t.Run("Lists all variables for the root policy", func(t *testing.T) {
+		variableIDs, err := conjur.VariablesInPolicy("root")
+		assert.NoError(t, err)
+		assert.Len(t, variableIDs, len(ids))
+	})
+
+	t.Run("Returns an empty list when nothing matches", func(t *testing.T) {
+		variableIDs, err := conjur.VariablesInPolicy("staging")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, variableIDs)
+	})
+}
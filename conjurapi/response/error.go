@@ -2,17 +2,45 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
 )
 
+// Sentinel errors matched by a ConjurError with the corresponding HTTP status,
+// so callers can use errors.Is(err, response.ErrNotFound).
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrServerError         = errors.New("server error")
+)
+
+var sentinelStatuses = map[error]int{
+	ErrBadRequest:          http.StatusBadRequest,
+	ErrUnauthorized:        http.StatusUnauthorized,
+	ErrForbidden:           http.StatusForbidden,
+	ErrNotFound:            http.StatusNotFound,
+	ErrConflict:            http.StatusConflict,
+	ErrUnprocessableEntity: http.StatusUnprocessableEntity,
+}
+
+// ConjurError is returned for failed Conjur API responses. Code is the HTTP
+// status of the response, and Details holds the error code and message
+// reported by Conjur, when present.
 type ConjurError struct {
 	Code    int
 	Message string
 	Details *ConjurErrorDetails `json:"error"`
+	// RequestID identifies the request in Conjur's logs, as returned by
+	// Conjur or else as sent by the client.
+	RequestID string `json:"-"`
 }
 
 type ConjurErrorDetails struct {
@@ -31,6 +59,10 @@ func NewConjurError(resp *http.Response) error {
 
 	cerr := ConjurError{}
 	cerr.Code = resp.StatusCode
+	cerr.RequestID = resp.Header.Get("X-Request-Id")
+	if cerr.RequestID == "" && resp.Request != nil {
+		cerr.RequestID = resp.Request.Header.Get("X-Request-Id")
+	}
 	err = json.Unmarshal(body, &cerr)
 	if err != nil {
 		cerr.Message = strings.TrimSpace(string(body))
@@ -57,5 +89,27 @@ func (self *ConjurError) Error() string {
 		b.WriteString(self.Details.Message + ".")
 	}
 
+	if self.RequestID != "" {
+		return strings.TrimSuffix(b.String(), " ") + " (request ID " + self.RequestID + ")"
+	}
 	return b.String()
 }
+
+// Is reports whether the error matches one of the sentinel errors of this
+// package, based on the HTTP status. ErrServerError matches any 5xx status.
+func (self *ConjurError) Is(target error) bool {
+	if target == ErrServerError {
+		return self.Code >= 500
+	}
+	status, ok := sentinelStatuses[target]
+	return ok && self.Code == status
+}
+
+// ErrorCode returns the Conjur error code, such as "not_found", or an empty
+// string if the response didn't include one.
+func (self *ConjurError) ErrorCode() string {
+	if self.Details == nil {
+		return ""
+	}
+	return self.Details.Code
+}
@@ -5,7 +5,7 @@ import (
 	"io"
 	"net/http"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
 )
 
 func readBody(resp *http.Response) ([]byte, error) {
@@ -21,7 +21,7 @@ func readBody(resp *http.Response) ([]byte, error) {
 
 func logResponse(resp *http.Response) {
 	req := resp.Request
-	logging.ApiLog.Debugf("%d %s %s %+v", resp.StatusCode, req.Method, req.URL, req.Header)
+	logging.ApiLog.Debugf("%d %s %s", resp.StatusCode, req.Method, req.URL.Path)
 }
 
 // DataResponse checks the HTTP status of the response. If it's less than
@@ -0,0 +1,65 @@
+package conjurapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned when Conjur responds with a body larger
+// than Config.MaxResponseBodySize.
+var ErrResponseTooLarge = errors.New("response body exceeds the maximum size")
+
+// responseLimitTransport fails responses whose body is larger than the limit.
+// Compressed responses are limited by their decompressed size.
+type responseLimitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func newResponseLimitTransport(base http.RoundTripper, limit int64) *responseLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &responseLimitTransport{base: base, limit: limit}
+}
+
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes is over the limit of %d bytes", ErrResponseTooLarge, resp.ContentLength, t.limit)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit}
+	return resp, nil
+}
+
+// limitedBody reads a response body up to a limit, and fails with
+// ErrResponseTooLarge if there's more.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var extra [1]byte
+		n, err := b.ReadCloser.Read(extra[:])
+		if n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
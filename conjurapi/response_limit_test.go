@@ -0,0 +1,87 @@
+package conjurapi
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_MaxResponseBodySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("x", 100)
+		switch r.URL.Path {
+		case "/secrets/cucumber/variable/chunked":
+			// Flushing before writing the body hides its length
+			w.(http.Flusher).Flush()
+		case "/secrets/cucumber/variable/small":
+			body = "password"
+		}
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", MaxResponseBodySize: 50}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Reads bodies within the limit", func(t *testing.T) {
+		value, err := conjur.RetrieveSecret("small")
+		assert.NoError(t, err)
+		assert.Equal(t, "password", string(value))
+	})
+
+	t.Run("Fails bodies declared larger than the limit", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("large")
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+		assert.Contains(t, err.Error(), "100 bytes is over the limit of 50 bytes")
+	})
+
+	t.Run("Fails bodies which turn out larger than the limit", func(t *testing.T) {
+		_, err := conjur.RetrieveSecret("chunked")
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+
+	t.Run("Rejects negative limits", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, MaxResponseBodySize: -1}
+		assert.EqualError(t, config.Validate(), "MaxResponseBodySize must not be negative")
+	})
+}
+
+func TestClient_GzipResponses(t *testing.T) {
+	resources := "[" + strings.TrimSuffix(strings.Repeat(`{"id":"cucumber:variable:db/password"},`, 1000), ",") + "]"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(resources))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(resources))
+		gz.Close()
+	}))
+	defer ts.Close()
+
+	t.Run("Negotiates and decompresses gzip transparently", func(t *testing.T) {
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		require.NoError(t, err)
+
+		list, err := conjur.Resources(nil)
+		require.NoError(t, err)
+		assert.Len(t, list, 1000)
+	})
+
+	t.Run("Limits the decompressed size", func(t *testing.T) {
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", MaxResponseBodySize: 10000}
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		_, err = conjur.Resources(nil)
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+}
@@ -0,0 +1,225 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	RetryMaxAttemptsDefaultValue = 3
+	RetryBackoffBaseDefaultValue = 100 * time.Millisecond
+	RetryBackoffCapDefaultValue  = 5 * time.Second
+)
+
+// RetryStatusCodesDefaultValue are the HTTP statuses retried when
+// RetryPolicy.RetryableStatusCodes is not set.
+var RetryStatusCodesDefaultValue = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how the client retries requests that fail with a
+// transient error: a connection reset or refusal, or one of the retryable
+// HTTP statuses. The zero value retries with the defaults below.
+//
+// Only idempotent requests (GET, HEAD, OPTIONS, PUT and DELETE) are retried,
+// as long as their body can be replayed. A POST or PATCH may have been acted
+// on before it failed, and retrying it could then repeat the change, such as
+// adding another secret version or creating another host factory token.
+type RetryPolicy struct {
+	// Disabled turns off retries, for callers that implement their own.
+	Disabled bool
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first. Defaults to RetryMaxAttemptsDefaultValue.
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry, which doubles on each
+	// subsequent retry with random jitter. Defaults to
+	// RetryBackoffBaseDefaultValue.
+	BackoffBase time.Duration
+	// BackoffCap bounds the delay between attempts, including delays
+	// requested by a Retry-After header. Defaults to
+	// RetryBackoffCapDefaultValue.
+	BackoffCap time.Duration
+	// RetryableStatusCodes are the HTTP statuses that are retried. Defaults
+	// to RetryStatusCodesDefaultValue.
+	RetryableStatusCodes []int
+	// IgnoreRetryAfter disables honouring the Retry-After header of a
+	// retryable response.
+	IgnoreRetryAfter bool
+	// AllMethods retries POST and PATCH requests too, for callers which can
+	// tolerate a change being applied twice.
+	AllMethods bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = RetryMaxAttemptsDefaultValue
+	}
+	if p.BackoffBase <= 0 {
+		p.BackoffBase = RetryBackoffBaseDefaultValue
+	}
+	if p.BackoffCap <= 0 {
+		p.BackoffCap = RetryBackoffCapDefaultValue
+	}
+	if p.RetryableStatusCodes == nil {
+		p.RetryableStatusCodes = RetryStatusCodesDefaultValue
+	}
+	return p
+}
+
+// retryTransport is an http.RoundTripper that retries transient failures of
+// the wrapped transport according to a RetryPolicy.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+	logger Logger
+}
+
+func newRetryTransport(base http.RoundTripper, policy RetryPolicy) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:   base,
+		policy: policy.withDefaults(),
+		sleep:  sleepWithContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		if attempt >= t.policy.MaxAttempts || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if t.logger != nil {
+			fields := requestLogFields(req)
+			fields["attempt"] = attempt + 1
+			fields["delay"] = delay
+			if err != nil {
+				fields["error"] = err.Error()
+			} else {
+				fields["status"] = resp.StatusCode
+			}
+			t.logger.Log(LogLevelWarn, "Retrying Conjur request", fields)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if !t.policy.AllMethods && !isIdempotent(req.Method) {
+		return false
+	}
+
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	for _, code := range t.policy.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether sending a request of the given method twice
+// has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil && !t.policy.IgnoreRetryAfter {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return minDuration(delay, t.policy.BackoffCap)
+		}
+	}
+
+	delay := t.policy.BackoffCap
+	if shift := uint(attempt - 1); shift < 32 {
+		delay = minDuration(t.policy.BackoffBase<<shift, t.policy.BackoffCap)
+	}
+	// Equal jitter keeps at least half of the delay while spreading out
+	// clients that failed at the same time
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,301 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func stubResponse(code int, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: code, Header: header, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func newTestRetryTransport(policy RetryPolicy, base roundTripFunc) (*retryTransport, *[]time.Duration) {
+	delays := []time.Duration{}
+	transport := newRetryTransport(base, policy)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return transport, &delays
+}
+
+func TestRetryTransport_RoundTrip(t *testing.T) {
+	t.Run("Retries retryable statuses until success", func(t *testing.T) {
+		codes := []int{503, 502, 200}
+		attempts := 0
+		transport, delays := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return stubResponse(codes[attempts-1], nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 3, attempts)
+		assert.Len(t, *delays, 2)
+	})
+
+	t.Run("Returns the last response after MaxAttempts", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{MaxAttempts: 2}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return stubResponse(504, nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 504, resp.StatusCode)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Does not retry other statuses", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return stubResponse(500, nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Uses the configured retryable statuses", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{RetryableStatusCodes: []int{429}}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return stubResponse(429, nil), nil
+			}
+			return stubResponse(503, nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 503, resp.StatusCode)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Retries connection resets", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+			}
+			return stubResponse(200, nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Does not retry other errors", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return nil, fmt.Errorf("x509: certificate signed by unknown authority")
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		_, err := transport.RoundTrip(req)
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Replays the request body", func(t *testing.T) {
+		bodies := []string{}
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				return stubResponse(503, nil), nil
+			}
+			return stubResponse(200, nil), nil
+		})
+
+		req, _ := http.NewRequest("PUT", "http://conjur/policies", strings.NewReader("- !user alice"))
+		_, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"- !user alice", "- !user alice"}, bodies)
+	})
+
+	t.Run("Does not retry POST or PATCH by default", func(t *testing.T) {
+		for _, method := range []string{"POST", "PATCH"} {
+			attempts := 0
+			transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+				attempts++
+				return nil, fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+			})
+
+			req, _ := http.NewRequest(method, "http://conjur/secrets/cucumber/variable/db-password", strings.NewReader("secret"))
+			_, err := transport.RoundTrip(req)
+			assert.Error(t, err)
+			assert.Equal(t, 1, attempts, method)
+		}
+	})
+
+	t.Run("Retries every method with AllMethods", func(t *testing.T) {
+		bodies := []string{}
+		transport, _ := newTestRetryTransport(RetryPolicy{AllMethods: true}, func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				return stubResponse(503, nil), nil
+			}
+			return stubResponse(200, nil), nil
+		})
+
+		req, _ := http.NewRequest("POST", "http://conjur/policies", strings.NewReader("- !user alice"))
+		_, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"- !user alice", "- !user alice"}, bodies)
+	})
+
+	t.Run("Does not retry bodies that cannot be replayed", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return stubResponse(503, nil), nil
+		})
+
+		req, _ := http.NewRequest("PUT", "http://conjur/policies", io.NopCloser(strings.NewReader("- !user alice")))
+		_, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Honours Retry-After up to BackoffCap", func(t *testing.T) {
+		attempts := 0
+		transport, delays := newTestRetryTransport(RetryPolicy{BackoffCap: 3 * time.Second}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			switch attempts {
+			case 1:
+				return stubResponse(503, http.Header{"Retry-After": []string{"2"}}), nil
+			case 2:
+				return stubResponse(503, http.Header{"Retry-After": []string{"120"}}), nil
+			}
+			return stubResponse(200, nil), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		_, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{2 * time.Second, 3 * time.Second}, *delays)
+	})
+
+	t.Run("Backs off exponentially with jitter", func(t *testing.T) {
+		transport, delays := newTestRetryTransport(RetryPolicy{MaxAttempts: 5, BackoffBase: time.Second, BackoffCap: 4 * time.Second, IgnoreRetryAfter: true}, func(req *http.Request) (*http.Response, error) {
+			return stubResponse(503, http.Header{"Retry-After": []string{"1"}}), nil
+		})
+
+		req, _ := http.NewRequest("GET", "http://conjur/secrets", nil)
+		_, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Len(t, *delays, 4)
+		for i, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+			assert.GreaterOrEqual(t, (*delays)[i], max/2)
+			assert.LessOrEqual(t, (*delays)[i], max)
+		}
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		attempts := 0
+		transport, _ := newTestRetryTransport(RetryPolicy{}, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return stubResponse(503, nil), nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", "http://conjur/secrets", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 503, resp.StatusCode)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter("5")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, float64(time.Hour), float64(delay), float64(2*time.Second))
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	newServer := func(attempts *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*attempts++
+			if *attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("secret"))
+		}))
+	}
+
+	t.Run("Retries transient failures by default", func(t *testing.T) {
+		attempts := 0
+		ts := newServer(&attempts)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", RetryPolicy: RetryPolicy{BackoffBase: time.Millisecond}}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		value, err := conjur.RetrieveSecret("db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Can be disabled", func(t *testing.T) {
+		attempts := 0
+		ts := newServer(&attempts)
+		defer ts.Close()
+
+		config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none", RetryPolicy: RetryPolicy{Disabled: true}}
+		conjur, err := NewClientFromToken(config, sample_token)
+		assert.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db-password")
+		assert.ErrorIs(t, err, ErrServerError)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -1,20 +1,27 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 )
 
 // RoleExists checks whether or not a role exists
 func (c *Client) RoleExists(roleID string) (bool, error) {
+	return c.RoleExistsWithContext(context.Background(), roleID)
+}
+
+// RoleExistsWithContext is like RoleExists but uses the provided context for the
+// underlying request.
+func (c *Client) RoleExistsWithContext(ctx context.Context, roleID string) (bool, error) {
 	req, err := c.RoleRequest(roleID)
 	if err != nil {
 		return false, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return false, err
 	}
@@ -24,19 +31,25 @@ func (c *Client) RoleExists(roleID string) (bool, error) {
 	} else if resp.StatusCode == 404 {
 		return false, nil
 	} else {
-		return false, fmt.Errorf("Role exists check failed with HTTP status %d", resp.StatusCode)
+		return false, fmt.Errorf("role exists check failed with HTTP status %d", resp.StatusCode)
 	}
 }
 
 // Role fetches detailed information about a specific role, including
 // the role members
 func (c *Client) Role(roleID string) (role map[string]interface{}, err error) {
+	return c.RoleWithContext(context.Background(), roleID)
+}
+
+// RoleWithContext is like Role but uses the provided context for the
+// underlying request.
+func (c *Client) RoleWithContext(ctx context.Context, roleID string) (role map[string]interface{}, err error) {
 	req, err := c.RoleRequest(roleID)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return
 	}
@@ -53,12 +66,18 @@ func (c *Client) Role(roleID string) (role map[string]interface{}, err error) {
 
 // RoleMembers fetches members within a role
 func (c *Client) RoleMembers(roleID string) (members []map[string]interface{}, err error) {
+	return c.RoleMembersWithContext(context.Background(), roleID)
+}
+
+// RoleMembersWithContext is like RoleMembers but uses the provided context for the
+// underlying request.
+func (c *Client) RoleMembersWithContext(ctx context.Context, roleID string) (members []map[string]interface{}, err error) {
 	req, err := c.RoleMembersRequest(roleID)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return
 	}
@@ -76,12 +95,18 @@ func (c *Client) RoleMembers(roleID string) (members []map[string]interface{}, e
 // RoleMemberships fetches memberships of a role, including
 // a list of groups of which a specific host or user is a member
 func (c *Client) RoleMemberships(roleID string) (memberships []map[string]interface{}, err error) {
+	return c.RoleMembershipsWithContext(context.Background(), roleID)
+}
+
+// RoleMembershipsWithContext is like RoleMemberships but uses the provided context for the
+// underlying request.
+func (c *Client) RoleMembershipsWithContext(ctx context.Context, roleID string) (memberships []map[string]interface{}, err error) {
 	req, err := c.RoleMembershipsRequest(roleID)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return
 	}
@@ -0,0 +1,151 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// DefaultRoleGraphMaxDepth bounds RoleGraph traversals which don't set
+// RoleGraphOptions.MaxDepth.
+const DefaultRoleGraphMaxDepth = 32
+
+// RoleGraphDirection selects the grants RoleGraph follows.
+type RoleGraphDirection int
+
+const (
+	// RoleGraphMemberships follows the roles a role is a member of, to find
+	// everything it ultimately inherits, such as what a host can read.
+	RoleGraphMemberships RoleGraphDirection = iota
+	// RoleGraphMembers follows the members of a role, to find every role
+	// which ultimately inherits it.
+	RoleGraphMembers
+)
+
+// RoleGraphOptions configures a RoleGraph traversal.
+type RoleGraphOptions struct {
+	Direction RoleGraphDirection
+	// MaxDepth is how many grants away from the root roles are resolved,
+	// DefaultRoleGraphMaxDepth when zero.
+	MaxDepth int
+}
+
+// RoleGrant is a membership of Member in Role.
+type RoleGrant struct {
+	Role        string `json:"role"`
+	Member      string `json:"member"`
+	AdminOption bool   `json:"admin_option"`
+	Ownership   bool   `json:"ownership"`
+	Policy      string `json:"policy,omitempty"`
+}
+
+// RoleGraph holds the roles reached from a root role by following its
+// memberships or members transitively.
+type RoleGraph struct {
+	Root string
+	// Depths maps each role reached, including Root, to the number of grants
+	// between it and Root.
+	Depths map[string]int
+	// Grants lists the grants followed, in the order they were found.
+	Grants []RoleGrant
+	// Truncated reports whether MaxDepth stopped the traversal before every
+	// role was resolved.
+	Truncated bool
+}
+
+// Roles returns the roles reached from Root, ordered by ID.
+func (g *RoleGraph) Roles() []string {
+	roles := []string{}
+	for role := range g.Depths {
+		if role != g.Root {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RoleGraph resolves the transitive memberships or members of a role, level by
+// level, with one request per role. Each role is resolved once, so cycles in
+// the grants are safe.
+//
+// The authenticated user must be able to read the roles traversed.
+func (c *Client) RoleGraph(roleID string, options RoleGraphOptions) (*RoleGraph, error) {
+	return c.RoleGraphWithContext(context.Background(), roleID, options)
+}
+
+// RoleGraphWithContext is like RoleGraph but uses the provided context for the
+// underlying requests.
+func (c *Client) RoleGraphWithContext(ctx context.Context, roleID string, options RoleGraphOptions) (*RoleGraph, error) {
+	account, kind, id, err := c.parseID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	maxDepth := options.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultRoleGraphMaxDepth
+	}
+
+	root := strings.Join([]string{account, kind, id}, ":")
+	graph := &RoleGraph{Root: root, Depths: map[string]int{root: 0}, Grants: []RoleGrant{}}
+	level := []string{root}
+	for depth := 1; len(level) > 0; depth++ {
+		next := []string{}
+		for _, role := range level {
+			grants, err := c.roleGrants(ctx, role, options.Direction)
+			if err != nil {
+				return nil, err
+			}
+			for _, grant := range grants {
+				neighbour := grant.Role
+				if options.Direction == RoleGraphMembers {
+					neighbour = grant.Member
+				}
+				if _, seen := graph.Depths[neighbour]; seen {
+					graph.Grants = append(graph.Grants, grant)
+					continue
+				}
+				if depth > maxDepth {
+					graph.Truncated = true
+					continue
+				}
+				graph.Grants = append(graph.Grants, grant)
+				graph.Depths[neighbour] = depth
+				next = append(next, neighbour)
+			}
+		}
+		level = next
+	}
+	return graph, nil
+}
+
+// roleGrants fetches the direct memberships or members of a role.
+func (c *Client) roleGrants(ctx context.Context, roleID string, direction RoleGraphDirection) ([]RoleGrant, error) {
+	var req *http.Request
+	var err error
+	if direction == RoleGraphMembers {
+		req, err = c.RoleMembersRequest(roleID)
+	} else {
+		req, err = c.RoleMembershipsRequest(roleID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.roleGrantsFrom(ctx, req)
+}
+
+func (c *Client) roleGrantsFrom(ctx context.Context, req *http.Request) ([]RoleGrant, error) {
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	grants := []RoleGrant{}
+	if err := response.JSONResponse(resp, &grants); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
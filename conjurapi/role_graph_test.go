@@ -0,0 +1,75 @@
+package conjurapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRoleGraphServer serves the memberships and members of roles granted
+// as given, parent role to members.
+func newTestRoleGraphServer(t *testing.T, grants map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roleID := strings.Replace(strings.TrimPrefix(r.URL.Path, "/roles/"), "/", ":", 2)
+		result := []RoleGrant{}
+		for role, members := range grants {
+			for _, member := range members {
+				if (r.URL.RawQuery == "memberships" && member == roleID) || (r.URL.RawQuery == "members" && role == roleID) {
+					result = append(result, RoleGrant{Role: role, Member: member})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+}
+
+func TestClient_RoleGraph(t *testing.T) {
+	ts := newTestRoleGraphServer(t, map[string][]string{
+		"cucumber:layer:apps":   {"cucumber:host:app"},
+		"cucumber:group:ops":    {"cucumber:layer:apps", "cucumber:group:admins"},
+		"cucumber:group:admins": {"cucumber:group:ops"},
+	})
+	defer ts.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Resolves transitive memberships through cycles", func(t *testing.T) {
+		graph, err := conjur.RoleGraph("host:app", RoleGraphOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "cucumber:host:app", graph.Root)
+		assert.Equal(t, []string{"cucumber:group:admins", "cucumber:group:ops", "cucumber:layer:apps"}, graph.Roles())
+		assert.Equal(t, map[string]int{
+			"cucumber:host:app":     0,
+			"cucumber:layer:apps":   1,
+			"cucumber:group:ops":    2,
+			"cucumber:group:admins": 3,
+		}, graph.Depths)
+		assert.Len(t, graph.Grants, 4)
+		assert.False(t, graph.Truncated)
+	})
+
+	t.Run("Resolves transitive members", func(t *testing.T) {
+		graph, err := conjur.RoleGraph("cucumber:group:ops", RoleGraphOptions{Direction: RoleGraphMembers})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cucumber:group:admins", "cucumber:host:app", "cucumber:layer:apps"}, graph.Roles())
+	})
+
+	t.Run("Stops at the depth limit", func(t *testing.T) {
+		graph, err := conjur.RoleGraph("host:app", RoleGraphOptions{MaxDepth: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cucumber:layer:apps"}, graph.Roles())
+		assert.True(t, graph.Truncated)
+	})
+
+	t.Run("Rejects malformed IDs", func(t *testing.T) {
+		_, err := conjur.RoleGraph("app", RoleGraphOptions{})
+		assert.Error(t, err)
+	})
+}
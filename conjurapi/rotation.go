@@ -0,0 +1,203 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// Annotations with which Conjur configures secret rotation on a variable.
+const (
+	// RotatorAnnotation names the rotator which rotates the variable, such as
+	// "postgresql/password".
+	RotatorAnnotation = "rotation/rotator"
+	// RotationTTLAnnotation is how long each secret version lives before it's
+	// rotated, as an ISO 8601 duration such as "PT1H".
+	RotationTTLAnnotation = "rotation/ttl"
+)
+
+// SecretRotation describes the rotation of a variable.
+type SecretRotation struct {
+	// Rotator is empty when the variable isn't rotated.
+	Rotator string
+	TTL     time.Duration
+	// ExpiresAt is when the current secret expires and is rotated, nil when
+	// it doesn't expire.
+	ExpiresAt *time.Time
+}
+
+// SecretRotation fetches the rotation settings of a variable from its
+// annotations, and when its current secret expires.
+//
+// The authenticated user must have read privilege on the variable.
+func (c *Client) SecretRotation(variableID string) (*SecretRotation, error) {
+	return c.SecretRotationWithContext(context.Background(), variableID)
+}
+
+// SecretRotationWithContext is like SecretRotation but uses the provided
+// context for the underlying request.
+func (c *Client) SecretRotationWithContext(ctx context.Context, variableID string) (*SecretRotation, error) {
+	resource, err := c.ResourceMetadataWithContext(ctx, makeFullId(c.config.Account, "variable", variableID))
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &SecretRotation{}
+	rotation.Rotator, _ = resource.Annotation(RotatorAnnotation)
+	if ttl, ok := resource.Annotation(RotationTTLAnnotation); ok && ttl != "" {
+		rotation.TTL, err = ParseISO8601Duration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation on '%s': %w", RotationTTLAnnotation, resource.ID, err)
+		}
+	}
+	if len(resource.Secrets) > 0 {
+		rotation.ExpiresAt = resource.Secrets[len(resource.Secrets)-1].ExpiresAt
+	}
+	return rotation, nil
+}
+
+// SetSecretRotation configures a variable to be rotated by a rotator every
+// ttl, by setting its rotation annotations with SetAnnotations.
+//
+// The authenticated user must have read privilege on the variable and update
+// privilege on the policy which owns it.
+func (c *Client) SetSecretRotation(variableID string, rotator string, ttl time.Duration) error {
+	return c.SetSecretRotationWithContext(context.Background(), variableID, rotator, ttl)
+}
+
+// SetSecretRotationWithContext is like SetSecretRotation but uses the provided
+// context for the underlying requests.
+func (c *Client) SetSecretRotationWithContext(ctx context.Context, variableID string, rotator string, ttl time.Duration) error {
+	if rotator == "" {
+		return fmt.Errorf("must specify a rotator")
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("rotation TTL must be positive")
+	}
+	return c.SetAnnotationsWithContext(ctx, makeFullId(c.config.Account, "variable", variableID), map[string]string{
+		RotatorAnnotation:     rotator,
+		RotationTTLAnnotation: FormatISO8601Duration(ttl),
+	})
+}
+
+// ExpireSecret expires the current secret of a variable, so that its rotator
+// rotates it right away. Only appliances with secret rotation support it.
+//
+// The authenticated user must have update privilege on the variable.
+func (c *Client) ExpireSecret(variableID string) error {
+	return c.ExpireSecretWithContext(context.Background(), variableID)
+}
+
+// ExpireSecretWithContext is like ExpireSecret but uses the provided context
+// for the underlying request.
+func (c *Client) ExpireSecretWithContext(ctx context.Context, variableID string) error {
+	req, err := c.ExpireSecretRequest(variableID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return response.EmptyResponse(resp)
+}
+
+// ExpireSecretRequest crafts an HTTP request to expire the current secret of
+// a variable.
+func (c *Client) ExpireSecretRequest(variableID string) (*http.Request, error) {
+	variableURL, err := c.variableURL(makeFullId(c.config.Account, "variable", variableID))
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("POST", variableURL+"?expirations", nil)
+}
+
+// ParseISO8601Duration parses the ISO 8601 durations used by rotation/ttl,
+// such as "P1D", "PT1H30M" or "P1W". Years and months are rejected, since
+// their length varies.
+func ParseISO8601Duration(value string) (time.Duration, error) {
+	rest := strings.ToUpper(value)
+	if !strings.HasPrefix(rest, "P") || strings.HasSuffix(rest, "T") {
+		return 0, fmt.Errorf("malformed ISO 8601 duration '%s'", value)
+	}
+	rest = rest[1:]
+
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	timeUnits := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+
+	var duration time.Duration
+	inTime := false
+	found := false
+	for len(rest) > 0 {
+		if rest[0] == 'T' && !inTime {
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, fmt.Errorf("malformed ISO 8601 duration '%s'", value)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("malformed ISO 8601 duration '%s': %w", value, err)
+		}
+		unit, ok := units[rest[i]]
+		if inTime {
+			unit, ok = timeUnits[rest[i]]
+		}
+		if !ok {
+			return 0, fmt.Errorf("unsupported unit '%c' in ISO 8601 duration '%s'", rest[i], value)
+		}
+		duration += time.Duration(n) * unit
+		found = true
+		rest = rest[i+1:]
+	}
+	if !found {
+		return 0, fmt.Errorf("malformed ISO 8601 duration '%s'", value)
+	}
+	return duration, nil
+}
+
+// FormatISO8601Duration formats a duration, rounded down to whole seconds, as
+// an ISO 8601 duration such as "P1DT12H".
+func FormatISO8601Duration(duration time.Duration) string {
+	seconds := int64(duration / time.Second)
+	days := seconds / 86400
+	hours := seconds % 86400 / 3600
+	minutes := seconds % 3600 / 60
+	seconds %= 60
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	if b.Len() == 1 {
+		b.WriteString("T0S")
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SecretRotation(t *testing.T) {
+	var requestMethod, requestURI, policyBody string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestMethod = r.Method
+		requestURI = r.URL.RequestURI()
+		switch r.URL.EscapedPath() {
+		case "/resources/cucumber/variable/db%2Fpassword":
+			w.Write([]byte(`{
+  "id": "cucumber:variable:db/password",
+  "policy": "cucumber:policy:root",
+  "annotations": [
+    {"name": "rotation/rotator", "value": "postgresql/password"},
+    {"name": "rotation/ttl", "value": "P1DT12H"}
+  ],
+  "secrets": [{"version": 1}, {"version": 2, "expires_at": "2023-06-15T03:39:13Z"}]
+}`))
+		case "/resources/cucumber/variable/static":
+			w.Write([]byte(`{"id": "cucumber:variable:static", "secrets": [{"version": 1}]}`))
+		case "/resources/cucumber/variable/broken":
+			w.Write([]byte(`{"id": "cucumber:variable:broken", "annotations": [{"name": "rotation/ttl", "value": "1 day"}]}`))
+		case "/policies/cucumber/policy/root":
+			body, _ := io.ReadAll(r.Body)
+			policyBody = string(body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"created_roles": {}, "version": 2}`))
+		case "/secrets/cucumber/variable/db%2Fpassword":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Reads the rotation settings", func(t *testing.T) {
+		rotation, err := conjur.SecretRotation("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "postgresql/password", rotation.Rotator)
+		assert.Equal(t, 36*time.Hour, rotation.TTL)
+		require.NotNil(t, rotation.ExpiresAt)
+		assert.Equal(t, time.Date(2023, 6, 15, 3, 39, 13, 0, time.UTC), rotation.ExpiresAt.UTC())
+	})
+
+	t.Run("Reads variables which aren't rotated", func(t *testing.T) {
+		rotation, err := conjur.SecretRotation("static")
+		require.NoError(t, err)
+		assert.Equal(t, &SecretRotation{}, rotation)
+	})
+
+	t.Run("Reports invalid TTLs", func(t *testing.T) {
+		_, err := conjur.SecretRotation("broken")
+		assert.ErrorContains(t, err, "invalid rotation/ttl annotation on 'cucumber:variable:broken'")
+	})
+
+	t.Run("Sets the rotation annotations", func(t *testing.T) {
+		err := conjur.SetSecretRotation("db/password", "postgresql/password", 90*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "- !variable\n  id: \"db/password\"\n  annotations:\n    \"rotation/rotator\": \"postgresql/password\"\n    \"rotation/ttl\": \"PT1H30M\"\n", policyBody)
+
+		assert.EqualError(t, conjur.SetSecretRotation("db/password", "", time.Hour), "must specify a rotator")
+		assert.EqualError(t, conjur.SetSecretRotation("db/password", "postgresql/password", 0), "rotation TTL must be positive")
+	})
+
+	t.Run("Expires secrets", func(t *testing.T) {
+		require.NoError(t, conjur.ExpireSecret("db/password"))
+		assert.Equal(t, "POST", requestMethod)
+		assert.Equal(t, "/secrets/cucumber/variable/db%2Fpassword?expirations", requestURI)
+	})
+}
+
+func TestISO8601Duration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"P1D":       24 * time.Hour,
+		"PT1H30M":   90 * time.Minute,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT12H":   36 * time.Hour,
+		"pt45s":     45 * time.Second,
+		"P2DT3M4S":  48*time.Hour + 3*time.Minute + 4*time.Second,
+		"PT0S":      0,
+		"PT36H":     36 * time.Hour,
+		"PT1H1M01S": time.Hour + time.Minute + time.Second,
+	} {
+		duration, err := ParseISO8601Duration(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, duration, value)
+	}
+
+	for _, value := range []string{"", "P", "PT", "P1DT", "1D", "P1Y", "P1M", "PT1D", "P1.5D", "PTH"} {
+		_, err := ParseISO8601Duration(value)
+		assert.Error(t, err, value)
+	}
+
+	assert.Equal(t, "PT1H30M", FormatISO8601Duration(90*time.Minute))
+	assert.Equal(t, "P1DT12H", FormatISO8601Duration(36*time.Hour))
+	assert.Equal(t, "P2DT3M4S", FormatISO8601Duration(48*time.Hour+3*time.Minute+4*time.Second+time.Millisecond))
+	assert.Equal(t, "PT0S", FormatISO8601Duration(0))
+}
@@ -0,0 +1,143 @@
+package conjurapi
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// SecretBytes holds a secret value which is wiped from memory when closed,
+// so that it doesn't linger in the heap until it's garbage collected. Its
+// String method is redacted, so the value isn't exposed by accidental
+// logging.
+//
+// Converting the value to a string makes a copy which can't be wiped, so
+// consume it as bytes where possible.
+type SecretBytes struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+// NewSecretBytes wraps a secret value, which SecretBytes takes ownership of
+// and wipes when closed.
+func NewSecretBytes(data []byte) *SecretBytes {
+	return &SecretBytes{data: data}
+}
+
+// Bytes returns the secret value, which is only valid until Close is called.
+// It returns nil once the value has been wiped.
+func (s *SecretBytes) Bytes() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.data
+}
+
+// Len returns the length of the secret value.
+func (s *SecretBytes) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.data)
+}
+
+// Close wipes the secret value. It may be called more than once.
+func (s *SecretBytes) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	WipeBytes(s.data)
+	s.data = nil
+	return nil
+}
+
+func (s *SecretBytes) String() string {
+	return "[REDACTED]"
+}
+
+func (s *SecretBytes) GoString() string {
+	return "[REDACTED]"
+}
+
+// WipeBytes overwrites data with zeroes, such as a secret value which is no
+// longer needed.
+func WipeBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+	// Keep the writes from being optimized away
+	runtime.KeepAlive(data)
+}
+
+// WithSecretWiping makes the client wipe each access token from memory once
+// it has been replaced by a new one.
+//
+// It's opt-in because the token bytes returned by custom authenticators are
+// wiped too, which breaks authenticators that keep using them.
+func WithSecretWiping() ClientOption {
+	return func(c *Client) {
+		c.wipeTokens = true
+	}
+}
+
+// RetrieveSecretBytes fetches a secret from a variable into a SecretBytes,
+// which the caller should close once the value is no longer needed. Unlike
+// RetrieveSecret, the response isn't read into intermediate buffers which
+// would leave copies of the value in memory.
+//
+// The authenticated user must have execute privilege on the variable.
+func (c *Client) RetrieveSecretBytes(variableID string) (*SecretBytes, error) {
+	return c.RetrieveSecretBytesWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretBytesWithContext is like RetrieveSecretBytes but uses the
+// provided context for the underlying request.
+func (c *Client) RetrieveSecretBytesWithContext(ctx context.Context, variableID string) (*SecretBytes, error) {
+	resp, err := c.retrieveSecret(ctx, variableID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := response.SecretDataResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := readAllWiping(body, resp.ContentLength)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecretBytes(data), nil
+}
+
+// readAllWiping reads r to the end like io.ReadAll, but wipes each buffer it
+// outgrows instead of leaving it to the garbage collector. The expected
+// size, if known, avoids growing the buffer at all.
+func readAllWiping(r io.Reader, size int64) ([]byte, error) {
+	capacity := 512
+	if size >= 0 && size < 1<<30 {
+		// One extra byte to detect the end of the body without growing
+		capacity = int(size) + 1
+	}
+
+	data := make([]byte, 0, capacity)
+	for {
+		if len(data) == cap(data) {
+			grown := make([]byte, len(data), 2*cap(data))
+			copy(grown, data)
+			WipeBytes(data)
+			data = grown
+		}
+
+		n, err := r.Read(data[len(data):cap(data)])
+		data = data[:len(data)+n]
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			WipeBytes(data)
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package conjurapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretBytes(t *testing.T) {
+	t.Run("Wipes the value when closed", func(t *testing.T) {
+		data := []byte("password")
+		secret := NewSecretBytes(data)
+		assert.Equal(t, []byte("password"), secret.Bytes())
+		assert.Equal(t, 8, secret.Len())
+
+		assert.NoError(t, secret.Close())
+		assert.Equal(t, make([]byte, 8), data)
+		assert.Nil(t, secret.Bytes())
+		assert.NoError(t, secret.Close())
+	})
+
+	t.Run("Redacts the value when formatted", func(t *testing.T) {
+		secret := NewSecretBytes([]byte("password"))
+		assert.Equal(t, "[REDACTED] [REDACTED] [REDACTED]", fmt.Sprintf("%v %s %#v", secret, secret, secret))
+	})
+}
+
+func TestReadAllWiping(t *testing.T) {
+	data := bytes.Repeat([]byte("secret"), 1000)
+
+	for _, size := range []int64{int64(len(data)), -1, 10} {
+		t.Run(fmt.Sprintf("Reads the whole body with expected size %d", size), func(t *testing.T) {
+			read, err := readAllWiping(iotest.HalfReader(bytes.NewReader(data)), size)
+			assert.NoError(t, err)
+			assert.Equal(t, data, read)
+		})
+	}
+
+	t.Run("Returns read errors", func(t *testing.T) {
+		_, err := readAllWiping(iotest.TimeoutReader(bytes.NewReader(data)), -1)
+		assert.ErrorIs(t, err, iotest.ErrTimeout)
+	})
+}
+
+func TestClient_RetrieveSecretBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/secrets/cucumber/variable/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("password"))
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	require.NoError(t, err)
+
+	secret, err := conjur.RetrieveSecretBytes("db/password")
+	require.NoError(t, err)
+	defer secret.Close()
+	assert.Equal(t, []byte("password"), secret.Bytes())
+
+	_, err = conjur.RetrieveSecretBytes("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestWithSecretWiping(t *testing.T) {
+	config := Config{Account: "cucumber", ApplianceURL: "https://conjur", CredentialStorage: "none"}
+
+	t.Run("Wipes replaced access tokens", func(t *testing.T) {
+		conjur, err := NewClientFromAuthenticator(config, &authn.TokenAuthenticator{Token: sample_token}, WithSecretWiping())
+		require.NoError(t, err)
+
+		require.NoError(t, conjur.ForceRefreshToken())
+		previous := conjur.getAuthToken().Raw()
+		require.NoError(t, conjur.ForceRefreshToken())
+
+		assert.Equal(t, make([]byte, len(sample_token)), previous)
+		assert.Equal(t, sample_token, string(conjur.getAuthToken().Raw()))
+	})
+
+	t.Run("Leaves tokens alone by default", func(t *testing.T) {
+		conjur, err := NewClientFromAuthenticator(config, &authn.TokenAuthenticator{Token: sample_token})
+		require.NoError(t, err)
+
+		require.NoError(t, conjur.ForceRefreshToken())
+		previous := conjur.getAuthToken().Raw()
+		require.NoError(t, conjur.ForceRefreshToken())
+
+		assert.Equal(t, sample_token, string(previous))
+	})
+}
@@ -0,0 +1,121 @@
+package conjurapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RetrieveSecretInto fetches a secret holding a JSON or YAML document and
+// decodes it into out, which must be a pointer. JSON values are decoded with
+// encoding/json, so according to json struct tags, and anything else as YAML,
+// according to yaml struct tags.
+//
+// The authenticated user must have execute privilege on the variable.
+func (c *Client) RetrieveSecretInto(variableID string, out interface{}) error {
+	return c.RetrieveSecretIntoWithContext(context.Background(), variableID, out)
+}
+
+// RetrieveSecretIntoWithContext is like RetrieveSecretInto but uses the
+// provided context for the underlying request.
+func (c *Client) RetrieveSecretIntoWithContext(ctx context.Context, variableID string, out interface{}) error {
+	value, err := c.RetrieveSecretWithContext(ctx, variableID)
+	if err != nil {
+		return err
+	}
+	defer WipeBytes(value)
+
+	if json.Valid(value) {
+		if err := json.Unmarshal(value, out); err != nil {
+			return fmt.Errorf("the value of variable '%s' can't be decoded as JSON: %w", variableID, err)
+		}
+		return nil
+	}
+	// YAML errors quote the value, so they aren't included
+	if err := yaml.Unmarshal(value, out); err != nil {
+		return fmt.Errorf("the value of variable '%s' can't be decoded as YAML into %T", variableID, out)
+	}
+	return nil
+}
+
+// RetrieveSecretString fetches a secret as a string.
+//
+// The authenticated user must have execute privilege on the variable.
+func (c *Client) RetrieveSecretString(variableID string) (string, error) {
+	return c.RetrieveSecretStringWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretStringWithContext is like RetrieveSecretString but uses the
+// provided context for the underlying request.
+func (c *Client) RetrieveSecretStringWithContext(ctx context.Context, variableID string) (string, error) {
+	value, err := c.RetrieveSecretWithContext(ctx, variableID)
+	if err != nil {
+		return "", err
+	}
+	defer WipeBytes(value)
+
+	return string(value), nil
+}
+
+// RetrieveSecretInt fetches a secret holding a decimal integer, ignoring
+// surrounding whitespace such as a trailing newline.
+//
+// The authenticated user must have execute privilege on the variable.
+func (c *Client) RetrieveSecretInt(variableID string) (int, error) {
+	return c.RetrieveSecretIntWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretIntWithContext is like RetrieveSecretInt but uses the provided
+// context for the underlying request.
+func (c *Client) RetrieveSecretIntWithContext(ctx context.Context, variableID string) (int, error) {
+	value, err := c.RetrieveSecretWithContext(ctx, variableID)
+	if err != nil {
+		return 0, err
+	}
+	defer WipeBytes(value)
+
+	number, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err != nil {
+		return 0, fmt.Errorf("the value of variable '%s' is not an integer: %w", variableID, numError(err))
+	}
+	return number, nil
+}
+
+// RetrieveSecretBool fetches a secret holding a boolean, in any of the forms
+// accepted by strconv.ParseBool such as "true" or "0", ignoring surrounding
+// whitespace.
+//
+// The authenticated user must have execute privilege on the variable.
+func (c *Client) RetrieveSecretBool(variableID string) (bool, error) {
+	return c.RetrieveSecretBoolWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretBoolWithContext is like RetrieveSecretBool but uses the
+// provided context for the underlying request.
+func (c *Client) RetrieveSecretBoolWithContext(ctx context.Context, variableID string) (bool, error) {
+	value, err := c.RetrieveSecretWithContext(ctx, variableID)
+	if err != nil {
+		return false, err
+	}
+	defer WipeBytes(value)
+
+	flag, err := strconv.ParseBool(strings.TrimSpace(string(value)))
+	if err != nil {
+		return false, fmt.Errorf("the value of variable '%s' is not a boolean: %w", variableID, numError(err))
+	}
+	return flag, nil
+}
+
+// numError strips the value from a strconv error, which quotes it.
+func numError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+	return err
+}
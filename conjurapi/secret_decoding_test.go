@@ -0,0 +1,85 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RetrieveSecretTyped(t *testing.T) {
+	secrets := map[string]string{
+		"json":    `{"host": "db.example.com", "port": 5432}`,
+		"yaml":    "host: db.example.com\nport: 5432\n",
+		"number":  "42\n",
+		"flag":    " true ",
+		"invalid": "hunter2",
+	}
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := secrets[strings.TrimPrefix(r.URL.Path, "/secrets/cucumber/variable/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(value))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	type database struct {
+		Host string `json:"host" yaml:"host"`
+		Port int    `json:"port" yaml:"port"`
+	}
+
+	t.Run("Decodes JSON and YAML values", func(t *testing.T) {
+		for _, id := range []string{"json", "yaml"} {
+			db := database{}
+			require.NoError(t, conjur.RetrieveSecretInto(id, &db), id)
+			assert.Equal(t, database{Host: "db.example.com", Port: 5432}, db, id)
+		}
+	})
+
+	t.Run("Reports values which can't be decoded", func(t *testing.T) {
+		var port int
+		err := conjur.RetrieveSecretInto("json", &port)
+		assert.ErrorContains(t, err, "the value of variable 'json' can't be decoded as JSON")
+
+		err = conjur.RetrieveSecretInto("invalid", &port)
+		assert.EqualError(t, err, "the value of variable 'invalid' can't be decoded as YAML into *int")
+	})
+
+	t.Run("Retrieves strings, integers and booleans", func(t *testing.T) {
+		value, err := conjur.RetrieveSecretString("invalid")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", value)
+
+		number, err := conjur.RetrieveSecretInt("number")
+		require.NoError(t, err)
+		assert.Equal(t, 42, number)
+
+		flag, err := conjur.RetrieveSecretBool("flag")
+		require.NoError(t, err)
+		assert.True(t, flag)
+	})
+
+	t.Run("Doesn't expose invalid values in errors", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretInt("invalid")
+		assert.EqualError(t, err, "the value of variable 'invalid' is not an integer: invalid syntax")
+		assert.ErrorIs(t, err, strconv.ErrSyntax)
+
+		_, err = conjur.RetrieveSecretBool("invalid")
+		assert.EqualError(t, err, "the value of variable 'invalid' is not a boolean: invalid syntax")
+	})
+
+	t.Run("Returns ErrNotFound for missing variables", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretInt("missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,14 @@
+package conjurapi
+
+// SecretsProvider is the subset of the client used by code that only reads
+// secrets. Depending on it rather than on *Client lets such code be tested
+// with an in-memory implementation, like the one in the conjurtest package.
+type SecretsProvider interface {
+	RetrieveSecret(variableID string) ([]byte, error)
+	RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error)
+}
+
+var (
+	_ SecretsProvider = (*Client)(nil)
+	_ SecretsProvider = (*CachingClient)(nil)
+)
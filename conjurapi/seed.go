@@ -0,0 +1,43 @@
+package conjurapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// FollowerSeed fetches a follower seed from a Conjur Enterprise leader's seed
+// service, for bootstrapping a follower, such as one in Kubernetes
+// authenticated with authn-k8s. It returns the seed file, a tar archive, as a
+// data stream, which the caller must close. The seed holds the leader's keys,
+// so store it only where the follower's secrets may be stored.
+//
+// The authenticated host must be permitted to generate follower seeds, with
+// execute privilege on the conjur/seed-generation webservice.
+func (c *Client) FollowerSeed() (io.ReadCloser, error) {
+	return c.FollowerSeedWithContext(context.Background())
+}
+
+// FollowerSeedWithContext is like FollowerSeed but uses the provided context
+// for the underlying request. The context must remain valid until the
+// returned stream has been read and closed.
+func (c *Client) FollowerSeedWithContext(ctx context.Context) (io.ReadCloser, error) {
+	req, err := c.FollowerSeedRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return response.SecretDataResponse(resp)
+}
+
+// FollowerSeedRequest crafts an HTTP request to generate a follower seed.
+func (c *Client) FollowerSeedRequest() (*http.Request, error) {
+	return http.NewRequest("POST", makeRouterURL(c.config.ApplianceURL, "configuration", c.config.Account, "seed", "follower").String(), nil)
+}
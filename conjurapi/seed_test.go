@@ -0,0 +1,53 @@
+package conjurapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+func TestClient_FollowerSeed(t *testing.T) {
+	authorized := true
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/configuration/cucumber/seed/follower" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !authorized {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("seed archive"))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Streams the seed", func(t *testing.T) {
+		seed, err := conjur.FollowerSeed()
+		require.NoError(t, err)
+		defer seed.Close()
+
+		data, err := io.ReadAll(seed)
+		require.NoError(t, err)
+		assert.Equal(t, "seed archive", string(data))
+	})
+
+	t.Run("Returns an error when the host isn't permitted", func(t *testing.T) {
+		authorized = false
+		defer func() { authorized = true }()
+
+		_, err := conjur.FollowerSeed()
+		assert.True(t, errors.Is(err, response.ErrForbidden))
+	})
+}
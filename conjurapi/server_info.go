@@ -0,0 +1,106 @@
+package conjurapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// Authenticators lists the authenticators known to a Conjur server. Entries
+// are authenticator names such as "authn" or "authn-jwt", optionally followed
+// by "/<service-id>".
+type Authenticators struct {
+	Installed  []string `json:"installed"`
+	Configured []string `json:"configured"`
+	Enabled    []string `json:"enabled"`
+}
+
+// IsEnabled reports whether the authenticator of the given type, such as
+// "authn" or "jwt", is enabled. When serviceID is non-empty, the authenticator
+// must be enabled for that service ID.
+func (a *Authenticators) IsEnabled(authnType, serviceID string) bool {
+	name := authenticatorName(authnType)
+	if serviceID != "" {
+		name = name + "/" + serviceID
+	}
+
+	for _, enabled := range a.Enabled {
+		if enabled == name || (serviceID == "" && strings.HasPrefix(enabled, name+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerInfo describes a Conjur server, as returned by its /info endpoint.
+type ServerInfo struct {
+	Release        string         `json:"release"`
+	Version        string         `json:"version"`
+	Role           string         `json:"role"`
+	Container      string         `json:"container"`
+	Authenticators Authenticators `json:"authenticators"`
+	Configuration  struct {
+		Conjur struct {
+			Account string `json:"account"`
+		} `json:"conjur"`
+	} `json:"configuration"`
+}
+
+// Account returns the account configured on the server.
+func (i *ServerInfo) Account() string {
+	return i.Configuration.Conjur.Account
+}
+
+// ServerInfo fetches the version, role and configuration of the Conjur
+// server. The /info endpoint is only available on Conjur Enterprise; Conjur
+// Open Source responds with ErrNotFound.
+func (c *Client) ServerInfo() (*ServerInfo, error) {
+	return c.ServerInfoWithContext(context.Background())
+}
+
+// ServerInfoWithContext is like ServerInfo but uses the provided context for
+// the underlying request.
+func (c *Client) ServerInfoWithContext(ctx context.Context) (*ServerInfo, error) {
+	req, err := c.ServerInfoRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationDefault, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	info := ServerInfo{}
+	if err := response.JSONResponse(res, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Authenticators fetches the authenticators installed, configured and enabled
+// on the Conjur server.
+func (c *Client) Authenticators() (*Authenticators, error) {
+	return c.AuthenticatorsWithContext(context.Background())
+}
+
+// AuthenticatorsWithContext is like Authenticators but uses the provided
+// context for the underlying request.
+func (c *Client) AuthenticatorsWithContext(ctx context.Context) (*Authenticators, error) {
+	req, err := c.AuthenticatorsRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.send(operationDefault, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	authenticators := Authenticators{}
+	if err := response.JSONResponse(res, &authenticators); err != nil {
+		return nil, err
+	}
+	return &authenticators, nil
+}
@@ -0,0 +1,84 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticators_IsEnabled(t *testing.T) {
+	authenticators := Authenticators{
+		Enabled: []string{"authn", "authn-jwt/github", "authn-k8s/prod"},
+	}
+
+	assert.True(t, authenticators.IsEnabled("authn", ""))
+	assert.True(t, authenticators.IsEnabled("jwt", ""))
+	assert.True(t, authenticators.IsEnabled("jwt", "github"))
+	assert.True(t, authenticators.IsEnabled("authn-jwt", "github"))
+	assert.False(t, authenticators.IsEnabled("jwt", "gitlab"))
+	assert.False(t, authenticators.IsEnabled("oidc", ""))
+	assert.False(t, authenticators.IsEnabled("k8s", "pro"))
+}
+
+func TestClient_ServerInfo(t *testing.T) {
+	t.Run("Parses the info response", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/info", r.URL.Path)
+			w.Write([]byte(`{
+				"release": "13.1.0",
+				"version": "5.19.0",
+				"role": "master",
+				"container": "conjur-leader",
+				"configuration": {"conjur": {"account": "cucumber"}},
+				"authenticators": {"installed": ["authn", "authn-jwt"], "configured": ["authn", "authn-jwt/github"], "enabled": ["authn", "authn-jwt/github"]}
+			}`))
+		}))
+		defer ts.Close()
+
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		assert.NoError(t, err)
+
+		info, err := conjur.ServerInfo()
+		assert.NoError(t, err)
+		assert.Equal(t, "13.1.0", info.Release)
+		assert.Equal(t, "5.19.0", info.Version)
+		assert.Equal(t, "master", info.Role)
+		assert.Equal(t, "cucumber", info.Account())
+		assert.True(t, info.Authenticators.IsEnabled("jwt", "github"))
+	})
+
+	t.Run("Returns ErrNotFound on Conjur Open Source", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer ts.Close()
+
+		conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+		assert.NoError(t, err)
+
+		_, err = conjur.ServerInfo()
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_Authenticators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/authenticators", r.URL.Path)
+		w.Write([]byte(`{"installed":["authn","authn-jwt","authn-oidc"],"configured":["authn","authn-jwt/github"],"enabled":["authn"]}`))
+	}))
+	defer ts.Close()
+
+	conjur, err := NewClientFromToken(Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}, sample_token)
+	assert.NoError(t, err)
+
+	authenticators, err := conjur.Authenticators()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"authn", "authn-jwt", "authn-oidc"}, authenticators.Installed)
+	assert.Equal(t, []string{"authn", "authn-jwt/github"}, authenticators.Configured)
+	assert.Equal(t, []string{"authn"}, authenticators.Enabled)
+	assert.False(t, authenticators.IsEnabled("jwt", "github"))
+}
@@ -0,0 +1,119 @@
+package conjurapi
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
+)
+
+// showPrivileges are the privileges for which ShowResource always lists the
+// permitted roles, besides those granted on the resource.
+var showPrivileges = []string{"read", "execute", "update"}
+
+// ResourceDetails aggregates what the Conjur CLI shows about a resource: its
+// metadata, annotations and permissions, the roles permitted each privilege,
+// directly or through their memberships, and for resources which are also
+// roles, such as hosts, the role's members and memberships.
+type ResourceDetails struct {
+	Resource
+	// PermittedRoles maps privileges to the roles which hold them.
+	PermittedRoles map[string][]string `json:"permitted_roles"`
+	// Role is set for resources which are also roles.
+	Role *RoleDetails `json:"role,omitempty"`
+}
+
+// RoleDetails aggregates what the Conjur CLI shows about a role: where it's
+// defined, its direct members and the roles it's directly a member of.
+type RoleDetails struct {
+	ID          string      `json:"id"`
+	Policy      string      `json:"policy,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Members     []RoleGrant `json:"members"`
+	Memberships []RoleGrant `json:"memberships"`
+}
+
+// ShowResource fetches a resource along with the roles permitted each of its
+// privileges, and its members and memberships if it's also a role, with one
+// request for each. See ResourceDetails.
+//
+// The authenticated user must have read privilege on the resource.
+func (c *Client) ShowResource(resourceID string) (*ResourceDetails, error) {
+	return c.ShowResourceWithContext(context.Background(), resourceID)
+}
+
+// ShowResourceWithContext is like ShowResource but uses the provided context
+// for the underlying requests.
+func (c *Client) ShowResourceWithContext(ctx context.Context, resourceID string) (*ResourceDetails, error) {
+	resource, err := c.ResourceMetadataWithContext(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &ResourceDetails{Resource: *resource, PermittedRoles: map[string][]string{}}
+	privileges := append([]string{}, showPrivileges...)
+	for _, permission := range resource.Permissions {
+		if !contains(privileges, permission.Privilege) {
+			privileges = append(privileges, permission.Privilege)
+		}
+	}
+	for _, privilege := range privileges {
+		roles, err := c.PermittedRolesWithContext(ctx, resource.ID, privilege)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(roles)
+		details.PermittedRoles[privilege] = roles
+	}
+
+	if _, kind, _ := c.unopinionatedParseID(resource.ID); contains(roleKinds, kind) {
+		details.Role, err = c.ShowRoleWithContext(ctx, resource.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
+
+// ShowRole fetches a role along with its direct members and memberships. See
+// RoleDetails.
+//
+// The authenticated user must be able to read the role.
+func (c *Client) ShowRole(roleID string) (*RoleDetails, error) {
+	return c.ShowRoleWithContext(context.Background(), roleID)
+}
+
+// ShowRoleWithContext is like ShowRole but uses the provided context for the
+// underlying requests.
+func (c *Client) ShowRoleWithContext(ctx context.Context, roleID string) (*RoleDetails, error) {
+	account, kind, id, err := c.parseID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	fullID := makeFullId(account, kind, id)
+
+	req, err := c.RoleRequest(fullID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	details := &RoleDetails{ID: fullID}
+	if err := response.JSONResponse(resp, details); err != nil {
+		return nil, err
+	}
+	if details.Members == nil {
+		details.Members = []RoleGrant{}
+	}
+
+	details.Memberships, err = c.roleGrants(ctx, fullID, RoleGraphMemberships)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
@@ -0,0 +1,90 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ShowResource(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.EscapedPath() {
+		case "/resources/cucumber/variable/db%2Fpassword", "/resources/cucumber/host/app":
+			if query.Has("permitted_roles") {
+				switch query.Get("privilege") {
+				case "execute":
+					w.Write([]byte(`["cucumber:user:admin", "cucumber:host:app"]`))
+				case "rotate":
+					w.Write([]byte(`["cucumber:host:rotator"]`))
+				default:
+					w.Write([]byte(`["cucumber:user:admin"]`))
+				}
+				return
+			}
+			if r.URL.EscapedPath() == "/resources/cucumber/host/app" {
+				w.Write([]byte(`{"id": "cucumber:host:app", "owner": "cucumber:policy:root", "permissions": []}`))
+				return
+			}
+			w.Write([]byte(`{
+  "id": "cucumber:variable:db/password",
+  "owner": "cucumber:policy:db",
+  "permissions": [{"privilege": "execute", "role": "cucumber:host:app"}, {"privilege": "rotate", "role": "cucumber:host:rotator"}],
+  "annotations": [{"name": "description", "value": "Database password"}]
+}`))
+		case "/roles/cucumber/host/app":
+			switch {
+			case query.Has("memberships"):
+				w.Write([]byte(`[{"role": "cucumber:layer:apps", "member": "cucumber:host:app"}]`))
+			default:
+				w.Write([]byte(`{"id": "cucumber:host:app", "policy": "cucumber:policy:root", "created_at": "2023-06-14T15:39:13Z", "members": [{"role": "cucumber:host:app", "member": "cucumber:policy:root", "admin_option": true, "ownership": true}]}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Shows a variable", func(t *testing.T) {
+		details, err := conjur.ShowResource("variable:db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "cucumber:policy:db", details.Owner)
+		description, _ := details.Annotation("description")
+		assert.Equal(t, "Database password", description)
+		assert.Equal(t, map[string][]string{
+			"read":    {"cucumber:user:admin"},
+			"execute": {"cucumber:host:app", "cucumber:user:admin"},
+			"update":  {"cucumber:user:admin"},
+			"rotate":  {"cucumber:host:rotator"},
+		}, details.PermittedRoles)
+		assert.Nil(t, details.Role)
+	})
+
+	t.Run("Shows a host with its role", func(t *testing.T) {
+		details, err := conjur.ShowResource("host:app")
+		require.NoError(t, err)
+		require.NotNil(t, details.Role)
+		assert.Equal(t, "cucumber:policy:root", details.Role.Policy)
+		assert.Equal(t, []RoleGrant{{Role: "cucumber:host:app", Member: "cucumber:policy:root", AdminOption: true, Ownership: true}}, details.Role.Members)
+		assert.Equal(t, []RoleGrant{{Role: "cucumber:layer:apps", Member: "cucumber:host:app"}}, details.Role.Memberships)
+	})
+
+	t.Run("Shows a role", func(t *testing.T) {
+		details, err := conjur.ShowRole("host:app")
+		require.NoError(t, err)
+		assert.Equal(t, "cucumber:host:app", details.ID)
+		assert.Equal(t, 2023, details.CreatedAt.Year())
+	})
+
+	t.Run("Returns ErrNotFound for missing resources", func(t *testing.T) {
+		_, err := conjur.ShowResource("variable:missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,128 @@
+// Package sqlcredentials opens database/sql connections with credentials
+// fetched from Conjur whenever a connection is opened, so that connection
+// pools pick up rotated credentials without restarting:
+//
+//	db := sql.OpenDB(sqlcredentials.NewConnector(
+//		&pq.Driver{},
+//		sqlcredentials.FromVariables(conjur, "prod/db/username", "prod/db/password"),
+//		func(username, password string) string {
+//			return fmt.Sprintf("postgres://%s:%s@db.example.com/app", url.PathEscape(username), url.PathEscape(password))
+//		},
+//	))
+//
+// Connections opened before a rotation keep working until the database
+// drops them, as each is authenticated once; setting sql.DB's
+// SetConnMaxLifetime below the rotation's grace period replaces them in time.
+package sqlcredentials
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+)
+
+// CredentialsFunc returns the credentials with which to open a connection.
+type CredentialsFunc func(ctx context.Context) (username string, password string, err error)
+
+// DSNFunc builds the data source name passed to the driver from the
+// credentials, escaping them as the driver requires.
+type DSNFunc func(username, password string) string
+
+// FromVariables returns a CredentialsFunc which fetches the username and
+// password from the given variables with a single batch request, so that a
+// rotation can't pair the username with a password of another version. The
+// request is bound to the connection's context when the provider supports
+// it, as *conjurapi.Client does. Variable IDs may be qualified with the
+// account.
+func FromVariables(provider conjurapi.SecretsProvider, usernameID, passwordID string) CredentialsFunc {
+	variableIDs := []string{usernameID}
+	if passwordID != usernameID {
+		variableIDs = append(variableIDs, passwordID)
+	}
+
+	return func(ctx context.Context) (string, string, error) {
+		var secrets map[string][]byte
+		var err error
+		if contextual, ok := provider.(contextProvider); ok {
+			secrets, err = contextual.RetrieveBatchSecretsWithContext(ctx, variableIDs)
+		} else {
+			secrets, err = provider.RetrieveBatchSecrets(variableIDs)
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		username, ok := lookup(secrets, usernameID)
+		if !ok {
+			return "", "", fmt.Errorf("no value was returned for variable '%s'", usernameID)
+		}
+		password, ok := lookup(secrets, passwordID)
+		if !ok {
+			return "", "", fmt.Errorf("no value was returned for variable '%s'", passwordID)
+		}
+		return string(username), string(password), nil
+	}
+}
+
+// contextProvider is implemented by secrets providers which can bind their
+// requests to a context.
+type contextProvider interface {
+	RetrieveBatchSecretsWithContext(ctx context.Context, variableIDs []string) (map[string][]byte, error)
+}
+
+// lookup finds a variable's value in a batch result, which is keyed by fully
+// qualified ID.
+func lookup(secrets map[string][]byte, variableID string) ([]byte, bool) {
+	if value, ok := secrets[variableID]; ok {
+		return value, true
+	}
+	for id, value := range secrets {
+		parts := strings.SplitN(id, ":", 3)
+		if len(parts) == 3 && (parts[2] == variableID || parts[1]+":"+parts[2] == variableID) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// Connector is a driver.Connector which fetches credentials for every
+// connection it opens. Pass it to sql.OpenDB.
+type Connector struct {
+	driver      driver.Driver
+	credentials CredentialsFunc
+	dsn         DSNFunc
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector creates a Connector which opens connections with the given
+// driver and the data source name built from the credentials.
+func NewConnector(d driver.Driver, credentials CredentialsFunc, dsn DSNFunc) *Connector {
+	return &Connector{driver: d, credentials: credentials, dsn: dsn}
+}
+
+// Connect fetches the credentials and opens a connection with them.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := c.dsn(username, password)
+	if driverContext, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := driverContext.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(dsn)
+}
+
+// Driver returns the underlying driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,129 @@
+package sqlcredentials
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/conjurtest"
+)
+
+// recordingDriver opens connections which do nothing, recording the data
+// source names they were opened with.
+type recordingDriver struct {
+	dsns []string
+}
+
+func (d *recordingDriver) Open(dsn string) (driver.Conn, error) {
+	d.dsns = append(d.dsns, dsn)
+	return nopConn{}, nil
+}
+
+type nopConn struct{}
+
+func (nopConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (nopConn) Close() error                              { return nil }
+func (nopConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+// batchProvider records how the credentials are fetched.
+type batchProvider struct {
+	*conjurtest.SecretsProvider
+	batches  [][]string
+	contexts []context.Context
+}
+
+func (p *batchProvider) RetrieveSecret(variableID string) ([]byte, error) {
+	return nil, errors.New("unexpected single secret request")
+}
+
+func (p *batchProvider) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
+	p.batches = append(p.batches, variableIDs)
+	return p.SecretsProvider.RetrieveBatchSecrets(variableIDs)
+}
+
+// contextBatchProvider also binds batch requests to a context.
+type contextBatchProvider struct {
+	batchProvider
+}
+
+func (p *contextBatchProvider) RetrieveBatchSecretsWithContext(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	p.contexts = append(p.contexts, ctx)
+	return p.RetrieveBatchSecrets(variableIDs)
+}
+
+type contextKey struct{}
+
+func TestFromVariables(t *testing.T) {
+	secrets := map[string]string{
+		"prod/db/username": "app",
+		"prod/db/password": "secret",
+	}
+
+	t.Run("Fetches both credentials with a single batch request", func(t *testing.T) {
+		provider := &batchProvider{SecretsProvider: conjurtest.NewSecretsProvider("cucumber", secrets)}
+
+		username, password, err := FromVariables(provider, "prod/db/username", "cucumber:variable:prod/db/password")(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "app", username)
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, [][]string{{"prod/db/username", "cucumber:variable:prod/db/password"}}, provider.batches)
+	})
+
+	t.Run("Uses the connection's context when the provider supports it", func(t *testing.T) {
+		provider := &contextBatchProvider{batchProvider{SecretsProvider: conjurtest.NewSecretsProvider("cucumber", secrets)}}
+		ctx := context.WithValue(context.Background(), contextKey{}, "connect")
+
+		_, _, err := FromVariables(provider, "prod/db/username", "prod/db/password")(ctx)
+		require.NoError(t, err)
+		require.Len(t, provider.contexts, 1)
+		assert.Equal(t, "connect", provider.contexts[0].Value(contextKey{}))
+		assert.Len(t, provider.batches, 1)
+	})
+
+	t.Run("Fetches a shared variable once", func(t *testing.T) {
+		provider := &batchProvider{SecretsProvider: conjurtest.NewSecretsProvider("cucumber", secrets)}
+
+		username, password, err := FromVariables(provider, "prod/db/password", "prod/db/password")(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "secret", username)
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, [][]string{{"prod/db/password"}}, provider.batches)
+	})
+}
+
+func TestConnector(t *testing.T) {
+	provider := conjurtest.NewSecretsProvider("cucumber", map[string]string{
+		"prod/db/username": "app",
+		"prod/db/password": "first",
+	})
+	recorder := &recordingDriver{}
+	connector := NewConnector(recorder, FromVariables(provider, "prod/db/username", "prod/db/password"), func(username, password string) string {
+		return username + ":" + password + "@db"
+	})
+
+	t.Run("Opens connections with the current credentials", func(t *testing.T) {
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		require.NoError(t, db.PingContext(context.Background()))
+
+		provider.SetSecret("prod/db/password", "rotated")
+		_, err := connector.Connect(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"app:first@db", "app:rotated@db"}, recorder.dsns)
+		assert.Same(t, recorder, connector.Driver())
+	})
+
+	t.Run("Fails when the credentials can't be fetched", func(t *testing.T) {
+		provider.SetError("prod/db/password", errors.New("forbidden"))
+		defer provider.SetError("prod/db/password", nil)
+
+		_, err := connector.Connect(context.Background())
+		assert.EqualError(t, err, "forbidden")
+	})
+}
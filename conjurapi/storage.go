@@ -3,14 +3,22 @@ package conjurapi
 import (
 	"fmt"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
-	"github.com/cyberark/conjur-api-go/conjurapi/storage"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/storage"
 )
 
+// Values of Config.CredentialStorage. When it's not set, the keyring is used
+// if it's available, and the .netrc file otherwise.
 const (
-	CredentialStorageFile    = "file"
+	// CredentialStorageFile stores credentials in plain text in the .netrc
+	// file at Config.NetRCPath.
+	CredentialStorageFile = "file"
+	// CredentialStorageKeyring stores credentials in the OS keychain: the
+	// macOS Keychain, Windows Credential Manager or the Secret Service on
+	// Linux.
 	CredentialStorageKeyring = "keyring"
-	CredentialStorageNone    = "none"
+	// CredentialStorageNone doesn't store credentials.
+	CredentialStorageNone = "none"
 )
 
 func createStorageProvider(config Config) (CredentialStorageProvider, error) {
@@ -27,7 +35,7 @@ func createStorageProvider(config Config) (CredentialStorageProvider, error) {
 		), nil
 	case CredentialStorageKeyring:
 		if !storage.IsKeyringAvailable() {
-			return nil, fmt.Errorf("Keyring is not available")
+			return nil, fmt.Errorf("keyring is not available")
 		}
 
 		return storage.NewKeyringStorageProvider(
@@ -38,7 +46,7 @@ func createStorageProvider(config Config) (CredentialStorageProvider, error) {
 		logging.ApiLog.Debugf("Not storing credentials")
 		return nil, nil
 	default:
-		return nil, fmt.Errorf("Unknown credential storage type")
+		return nil, fmt.Errorf("unknown credential storage type")
 	}
 }
 
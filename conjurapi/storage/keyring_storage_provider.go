@@ -3,10 +3,12 @@ package storage
 import (
 	"errors"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
 	"github.com/zalando/go-keyring"
 )
 
+// KeyringStorageProvider stores credentials in the OS keychain, under a
+// service named after the machine, e.g. "https://conjur.example.com/authn".
 type KeyringStorageProvider struct {
 	machineName string
 }
@@ -6,7 +6,7 @@ import (
 	"os"
 	"testing"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
 	"github.com/sirupsen/logrus"
 	"github.com/zalando/go-keyring"
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/bgentry/go-netrc/netrc"
 )
@@ -47,7 +48,7 @@ func (s *NetrcStorageProvider) StoreCredentials(login string, password string) e
 
 	data = ensureEndsWithNewline(data)
 
-	return os.WriteFile(s.netRCPath, data, 0600)
+	return writeFileAtomic(s.netRCPath, data)
 }
 
 func (s *NetrcStorageProvider) ReadCredentials() (string, string, error) {
@@ -58,7 +59,7 @@ func (s *NetrcStorageProvider) ReadCredentials() (string, string, error) {
 
 	m := nrc.FindMachine(s.machineName)
 	if m == nil {
-		return "", "", fmt.Errorf("No credentials found in NetRCPath")
+		return "", "", fmt.Errorf("no credentials found in NetRCPath")
 	}
 
 	return m.Login, m.Password, nil
@@ -103,7 +104,7 @@ func (s *NetrcStorageProvider) PurgeCredentials() error {
 		return err
 	}
 
-	return os.WriteFile(s.netRCPath, data, 0600)
+	return writeFileAtomic(s.netRCPath, data)
 }
 
 func (s *NetrcStorageProvider) ensureNetrcFileExists() error {
@@ -121,8 +122,44 @@ func (s *NetrcStorageProvider) ensureNetrcFileExists() error {
 	return nil
 }
 
+// writeFileAtomic replaces the contents of a file, readable only by its owner,
+// so that readers never see a partially written file. If path is a symlink,
+// its target is replaced.
+func writeFileAtomic(path string, data []byte) (err error) {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// CreateTemp already uses 0600, but umask-independent permissions are
+	// explicit here since the file holds credentials
+	if err = tmp.Chmod(0600); err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func ensureEndsWithNewline(data []byte) []byte {
-	if data[len(data)-1] != byte('\n') {
+	if len(data) > 0 && data[len(data)-1] != byte('\n') {
 		data = append(data, byte('\n'))
 	}
 	return data
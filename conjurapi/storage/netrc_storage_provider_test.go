@@ -68,6 +68,55 @@ machine http://conjur/authn
 	})
 }
 
+func TestNetrcStorageProvider_AtomicWrites(t *testing.T) {
+	config := setupNetrcConfig(t)
+
+	t.Run("Restricts permissions of an existing file", func(t *testing.T) {
+		err := os.WriteFile(config.NetRCPath, []byte("machine other\n\tlogin a\n\tpassword b\n"), 0644)
+		assert.NoError(t, err)
+
+		storage := setupNetrcStorage(config)
+		assert.NoError(t, storage.StoreCredentials("login", "apiKey"))
+
+		info, err := os.Stat(config.NetRCPath)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		contents, err := os.ReadFile(config.NetRCPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "machine other")
+		assert.Contains(t, string(contents), "apiKey")
+	})
+
+	t.Run("Replaces the target of a symlink", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "netrc")
+		assert.NoError(t, os.WriteFile(target, []byte{}, 0600))
+		os.Remove(config.NetRCPath)
+		assert.NoError(t, os.Symlink(target, config.NetRCPath))
+
+		storage := setupNetrcStorage(config)
+		assert.NoError(t, storage.StoreCredentials("login", "apiKey"))
+
+		info, err := os.Lstat(config.NetRCPath)
+		assert.NoError(t, err)
+		assert.True(t, info.Mode()&os.ModeSymlink != 0)
+		contents, err := os.ReadFile(target)
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "apiKey")
+	})
+
+	t.Run("Leaves no temporary files behind", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := NewNetrcStorageProvider(filepath.Join(dir, ".netrc"), "http://conjur/authn")
+		assert.NoError(t, storage.StoreCredentials("login", "apiKey"))
+		assert.NoError(t, storage.PurgeCredentials())
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
+
 func TestNetrcStorageProvider_ReadCredentials(t *testing.T) {
 	config := setupNetrcConfig(t)
 
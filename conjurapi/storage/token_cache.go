@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/internal/fips"
+)
+
+const (
+	tokenCacheVersion = 1
+	tokenCacheSaltLen = 16
+	// tokenCacheIterations is the PBKDF2 work factor for passphrases
+	tokenCacheIterations = 100000
+)
+
+// TokenCache stores access tokens in a file encrypted with AES-256-GCM, so
+// that short-lived processes such as CLI invocations can reuse a token until
+// it expires instead of authenticating on every run. The file holds one token
+// per key, typically one per appliance and identity.
+//
+// The encryption key is derived from a passphrase when one is given. Otherwise
+// it's derived from a random machine key, created next to the cache file and
+// readable only by its owner.
+type TokenCache struct {
+	path       string
+	keyPath    string
+	passphrase []byte
+	mutex      sync.Mutex
+}
+
+type cachedToken struct {
+	Token     []byte    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewTokenCache(path string, passphrase []byte) *TokenCache {
+	return &TokenCache{
+		path:       path,
+		keyPath:    path + ".key",
+		passphrase: passphrase,
+	}
+}
+
+// Read returns the token cached under key, or nil if there is none or it has
+// expired.
+func (t *TokenCache) Read(key string) ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	tokens, err := t.load(false)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok := tokens[key]
+	if !ok || !time.Now().Before(cached.ExpiresAt) {
+		return nil, nil
+	}
+	return cached.Token, nil
+}
+
+// Store caches a token under key until expiresAt. Expired tokens are dropped
+// from the cache, as is a cache which can't be decrypted, such as one written
+// with another passphrase.
+func (t *TokenCache) Store(key string, token []byte, expiresAt time.Time) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	tokens, err := t.load(true)
+	if err != nil {
+		tokens = map[string]cachedToken{}
+	}
+	tokens[key] = cachedToken{Token: token, ExpiresAt: expiresAt}
+	return t.save(tokens)
+}
+
+// Purge removes the token cached under key.
+func (t *TokenCache) Purge(key string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	tokens, err := t.load(false)
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+	delete(tokens, key)
+	return t.save(tokens)
+}
+
+// load decrypts the cache, without its expired tokens. A missing cache is
+// empty.
+func (t *TokenCache) load(createKey bool) (map[string]cachedToken, error) {
+	tokens := map[string]cachedToken{}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 1+tokenCacheSaltLen || data[0] != tokenCacheVersion {
+		return nil, fmt.Errorf("token cache %s is not in a supported format", t.path)
+	}
+	salt := data[1 : 1+tokenCacheSaltLen]
+	aead, err := t.cipher(salt, createKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed := data[1+tokenCacheSaltLen:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("token cache %s is truncated", t.path)
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], data[:1])
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt token cache %s: %w", t.path, err)
+	}
+	defer wipe(plaintext)
+
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("unable to parse token cache %s: %w", t.path, err)
+	}
+	for key, cached := range tokens {
+		if !time.Now().Before(cached.ExpiresAt) {
+			delete(tokens, key)
+		}
+	}
+	return tokens, nil
+}
+
+func (t *TokenCache) save(tokens map[string]cachedToken) error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	defer wipe(plaintext)
+
+	salt := make([]byte, tokenCacheSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	aead, err := t.cipher(salt, true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	data := append([]byte{tokenCacheVersion}, salt...)
+	data = append(data, nonce...)
+	data = aead.Seal(data, nonce, plaintext, data[:1])
+	return writeFileAtomic(t.path, data)
+}
+
+// cipher derives the cache's AES-GCM cipher for a salt, creating the machine
+// key if asked to and no passphrase is set.
+func (t *TokenCache) cipher(salt []byte, createKey bool) (cipher.AEAD, error) {
+	var key []byte
+	if len(t.passphrase) > 0 {
+		if fips.Enabled() && len(t.passphrase) < fips.MinHMACKeySize {
+			return nil, fmt.Errorf("token cache passphrase must be at least %d bytes in FIPS mode", fips.MinHMACKeySize)
+		}
+		key = pbkdf2SHA256(t.passphrase, salt, tokenCacheIterations)
+	} else {
+		machineKey, err := t.machineKey(createKey)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha256.New, machineKey)
+		mac.Write(salt)
+		key = mac.Sum(nil)
+		wipe(machineKey)
+	}
+	defer wipe(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return fips.NewGCM(block)
+}
+
+func (t *TokenCache) machineKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(t.keyPath)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key = make([]byte, sha256.Size)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(t.keyPath, key); err != nil {
+			return nil, fmt.Errorf("unable to create token cache key: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token cache key: %w", err)
+	}
+	return key, nil
+}
+
+// pbkdf2SHA256 derives a 32 byte key from a password with PBKDF2-HMAC-SHA256,
+// as specified by RFC 8018.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+
+	key := make([]byte, len(u))
+	copy(key, u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
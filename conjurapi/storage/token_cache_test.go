@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/internal/fips"
+)
+
+func TestTokenCache(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("Reads stored tokens back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "conjur", "tokens")
+		cache := NewTokenCache(path, nil)
+
+		token, err := cache.Read("alice")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+
+		require.NoError(t, cache.Store("alice", []byte("alice-token"), expiresAt))
+		require.NoError(t, cache.Store("bob", []byte("bob-token"), expiresAt))
+
+		token, err = NewTokenCache(path, nil).Read("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice-token", string(token))
+	})
+
+	t.Run("Encrypts the cache", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens")
+		cache := NewTokenCache(path, nil)
+		require.NoError(t, cache.Store("alice", []byte("alice-token"), expiresAt))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(data, []byte("alice")))
+
+		for _, file := range []string{path, path + ".key"} {
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+	})
+
+	t.Run("Skips expired tokens", func(t *testing.T) {
+		cache := NewTokenCache(filepath.Join(t.TempDir(), "tokens"), nil)
+		require.NoError(t, cache.Store("alice", []byte("alice-token"), time.Now().Add(-time.Second)))
+
+		token, err := cache.Read("alice")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	t.Run("Requires the passphrase", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tokens")
+		require.NoError(t, NewTokenCache(path, []byte("correct passphrase")).Store("alice", []byte("alice-token"), expiresAt))
+
+		_, err := NewTokenCache(path, []byte("wrong passphrase")).Read("alice")
+		assert.ErrorContains(t, err, "unable to decrypt token cache")
+
+		token, err := NewTokenCache(path, []byte("correct passphrase")).Read("alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice-token", string(token))
+
+		_, err = os.Stat(path + ".key")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Rejects short passphrases in FIPS mode", func(t *testing.T) {
+		if !fips.Enabled() {
+			t.Skip("FIPS mode is not enabled")
+		}
+		err := NewTokenCache(filepath.Join(t.TempDir(), "tokens"), []byte("short")).Store("alice", []byte("alice-token"), expiresAt)
+		assert.EqualError(t, err, "token cache passphrase must be at least 14 bytes in FIPS mode")
+	})
+
+	t.Run("Purges tokens", func(t *testing.T) {
+		cache := NewTokenCache(filepath.Join(t.TempDir(), "tokens"), nil)
+		require.NoError(t, cache.Store("alice", []byte("alice-token"), expiresAt))
+		require.NoError(t, cache.Store("bob", []byte("bob-token"), expiresAt))
+
+		require.NoError(t, cache.Purge("alice"))
+
+		token, err := cache.Read("alice")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+		token, err = cache.Read("bob")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob-token", string(token))
+	})
+}
+
+func TestPbkdf2SHA256(t *testing.T) {
+	if fips.Enabled() {
+		t.Skip("the test vector's password is too short for FIPS mode")
+	}
+	// Test vector from RFC 7914 section 11
+	key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc", hex.EncodeToString(key))
+}
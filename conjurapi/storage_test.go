@@ -3,7 +3,7 @@ package conjurapi
 import (
 	"testing"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/storage"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/zalando/go-keyring"
 )
@@ -30,6 +30,15 @@ func TestGetMachineName(t *testing.T) {
 			},
 			expected: "https://conjur/authn-oidc/test-service",
 		},
+		{
+			name: "authn-ldap",
+			config: Config{
+				ApplianceURL: "https://conjur",
+				AuthnType:    "ldap",
+				ServiceID:    "test-ldap",
+			},
+			expected: "https://conjur/authn-ldap/test-ldap",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -66,7 +75,7 @@ func TestCreateStorageProvider(t *testing.T) {
 				CredentialStorage: "keyring",
 			},
 			assert: func(t *testing.T, storageProvider CredentialStorageProvider, err error) {
-				assert.ErrorContains(t, err, "Keyring is not available")
+				assert.ErrorContains(t, err, "keyring is not available")
 			},
 		},
 		{
@@ -130,7 +139,7 @@ func TestCreateStorageProvider(t *testing.T) {
 				CredentialStorage: "invalid",
 			},
 			assert: func(t *testing.T, storageProvider CredentialStorageProvider, err error) {
-				assert.ErrorContains(t, err, "Unknown credential storage type")
+				assert.ErrorContains(t, err, "unknown credential storage type")
 			},
 		},
 	}
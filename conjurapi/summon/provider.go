@@ -0,0 +1,61 @@
+// Package summon implements the Summon provider contract with the Conjur API
+// client, so that a program built on this module can also serve as Summon's
+// Conjur provider. Summon runs its provider with a variable ID as the only
+// argument, and expects the secret on stdout, or an error on stderr and a
+// non-zero exit code.
+package summon
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi"
+)
+
+const usage = "usage: summon-conjur <variable-id>"
+
+// Provide writes the value of the variable named by args, the provider's
+// command line arguments without the program name, to stdout, and errors to
+// stderr. It returns the exit code for the provider's process: 0 once the
+// secret has been written, and 1 otherwise.
+func Provide(provider conjurapi.SecretsProvider, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		fmt.Fprintln(stderr, usage)
+		return 1
+	}
+
+	value, err := provider.RetrieveSecret(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer conjurapi.WipeBytes(value)
+
+	if _, err := stdout.Write(value); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// Main is like Provide with a client configured by conjurapi.LoadConfig and
+// authenticated by conjurapi.NewClientFromEnvironment, as summon-conjur is.
+func Main(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, usage)
+		return 1
+	}
+
+	config, err := conjurapi.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	client, err := conjurapi.NewClientFromEnvironment(config)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return Provide(client, args, stdout, stderr)
+}
@@ -0,0 +1,39 @@
+package summon
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/conjurtest"
+)
+
+func TestProvide(t *testing.T) {
+	provider := conjurtest.NewSecretsProvider("cucumber", map[string]string{"db/password": "s3cret"})
+
+	t.Run("Writes the secret to stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := Provide(provider, []string{"db/password"}, &stdout, &stderr)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "s3cret", stdout.String())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("Writes errors to stderr", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := Provide(provider, []string{"db/missing"}, &stdout, &stderr)
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, stderr.String(), "cucumber:variable:db/missing")
+	})
+
+	t.Run("Requires one variable ID", func(t *testing.T) {
+		for _, args := range [][]string{{}, {""}, {"db/password", "db/user"}} {
+			var stdout, stderr bytes.Buffer
+			code := Provide(provider, args, &stdout, &stderr)
+			assert.Equal(t, 1, code, args)
+			assert.Equal(t, "usage: summon-conjur <variable-id>\n", stderr.String(), args)
+		}
+	})
+}
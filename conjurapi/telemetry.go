@@ -0,0 +1,112 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Telemetry receives instrumentation events from the client, so that tracing
+// and metrics libraries such as OpenTelemetry can be plugged in without this
+// package depending on them.
+type Telemetry interface {
+	// StartRequest is called before each API call, before any retries. The
+	// returned context is used for the request, and the returned function is
+	// called with its outcome.
+	StartRequest(ctx context.Context, request TelemetryRequest) (context.Context, func(TelemetryResult))
+	// TokenRefreshed is called after each attempt to obtain an access token.
+	TokenRefreshed(duration time.Duration, err error)
+}
+
+// TelemetryRequest describes an API call about to be made.
+type TelemetryRequest struct {
+	Method string
+	// Endpoint is a low-cardinality name for the API, such as "secrets" or
+	// "authn-jwt/authenticate", suitable as a span name or metric attribute.
+	Endpoint string
+	// Header collects headers to add to the request, such as trace context.
+	// It does not contain the request's existing headers.
+	Header http.Header
+}
+
+// TelemetryResult describes the outcome of an API call. StatusCode is zero
+// when no response was received.
+type TelemetryResult struct {
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// WithTelemetry makes the client report API calls and token refreshes to the
+// given Telemetry implementation.
+func WithTelemetry(telemetry Telemetry) ClientOption {
+	return func(c *Client) {
+		c.telemetry = telemetry
+	}
+}
+
+// telemetryTransport is an http.RoundTripper that reports each request made
+// through the wrapped transport to a Telemetry implementation.
+type telemetryTransport struct {
+	base      http.RoundTripper
+	telemetry Telemetry
+	basePath  string
+}
+
+func (t *telemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := TelemetryRequest{
+		Method:   req.Method,
+		Endpoint: telemetryEndpoint(strings.TrimPrefix(req.URL.Path, t.basePath)),
+		Header:   http.Header{},
+	}
+	ctx, finish := t.telemetry.StartRequest(req.Context(), request)
+
+	req = req.Clone(ctx)
+	for name, values := range request.Header {
+		req.Header[name] = values
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	result := TelemetryResult{Duration: time.Since(start), Err: err}
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	if finish != nil {
+		finish(result)
+	}
+	return resp, err
+}
+
+// telemetryEndpoint names the API a path belongs to by its first segment,
+// adding the operation for authenticator APIs, which share their first
+// segment across operations.
+func telemetryEndpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	endpoint := segments[0]
+	if strings.HasPrefix(endpoint, "authn") && len(segments) > 1 {
+		endpoint += "/" + segments[len(segments)-1]
+	}
+	return endpoint
+}
+
+// withTelemetry returns a copy of an HTTP client which reports its requests
+// to the telemetry implementation.
+func withTelemetry(client *http.Client, telemetry Telemetry, applianceURL string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	basePath := ""
+	if parsed, err := url.Parse(applianceURL); err == nil {
+		basePath = strings.TrimSuffix(parsed.Path, "/")
+	}
+
+	httpClient := *client
+	httpClient.Transport = &telemetryTransport{base: base, telemetry: telemetry, basePath: basePath}
+	return &httpClient
+}
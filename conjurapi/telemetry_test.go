@@ -0,0 +1,117 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTelemetry struct {
+	mutex     sync.Mutex
+	requests  []TelemetryRequest
+	results   []TelemetryResult
+	refreshes []error
+}
+
+func (r *recordingTelemetry) StartRequest(ctx context.Context, request TelemetryRequest) (context.Context, func(TelemetryResult)) {
+	request.Header.Set("Traceparent", "00-trace-span-01")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests = append(r.requests, request)
+	return ctx, func(result TelemetryResult) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.results = append(r.results, result)
+	}
+}
+
+func (r *recordingTelemetry) TokenRefreshed(duration time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.refreshes = append(r.refreshes, err)
+}
+
+func TestTelemetryEndpoint(t *testing.T) {
+	testCases := map[string]string{
+		"/secrets/cucumber/variable/db-password":       "secrets",
+		"/secrets":                                     "secrets",
+		"/authn/cucumber/alice/authenticate":           "authn/authenticate",
+		"/authn-jwt/github/cucumber/host/authenticate": "authn-jwt/authenticate",
+		"/authn/cucumber/login":                        "authn/login",
+		"/whoami":                                      "whoami",
+		"/":                                            "",
+	}
+	for path, expected := range testCases {
+		assert.Equal(t, expected, telemetryEndpoint(path), path)
+	}
+}
+
+func TestWithTelemetry(t *testing.T) {
+	var mutex sync.Mutex
+	traceparents := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/api/authn/cucumber/alice/authenticate":
+			w.Write([]byte(sample_token))
+		case "/api/secrets/cucumber/variable/db-password":
+			w.Write([]byte("secret"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	telemetry := &recordingTelemetry{}
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL + "/api", CredentialStorage: "none"}
+	conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "api-key"}, WithTelemetry(telemetry))
+	assert.NoError(t, err)
+
+	_, err = conjur.RetrieveSecret("db-password")
+	assert.NoError(t, err)
+	_, err = conjur.RetrieveSecret("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Len(t, telemetry.requests, 3)
+	assert.Equal(t, "authn/authenticate", telemetry.requests[0].Endpoint)
+	assert.Equal(t, "POST", telemetry.requests[0].Method)
+	assert.Equal(t, "secrets", telemetry.requests[1].Endpoint)
+	assert.Equal(t, "GET", telemetry.requests[1].Method)
+
+	assert.Len(t, telemetry.results, 3)
+	assert.Equal(t, 200, telemetry.results[0].StatusCode)
+	assert.Equal(t, 200, telemetry.results[1].StatusCode)
+	assert.Equal(t, 404, telemetry.results[2].StatusCode)
+	assert.NoError(t, telemetry.results[2].Err)
+
+	assert.Equal(t, []string{"00-trace-span-01", "00-trace-span-01", "00-trace-span-01"}, traceparents)
+	assert.Equal(t, []error{nil}, telemetry.refreshes)
+}
+
+func TestWithTelemetry_FailedRefresh(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	telemetry := &recordingTelemetry{}
+	config := Config{Account: "cucumber", ApplianceURL: ts.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "wrong"}, WithTelemetry(telemetry))
+	assert.NoError(t, err)
+
+	_, err = conjur.RetrieveSecret("db-password")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Len(t, telemetry.refreshes, 1)
+	assert.ErrorIs(t, telemetry.refreshes[0], ErrUnauthorized)
+	assert.Equal(t, 401, telemetry.results[0].StatusCode)
+}
@@ -0,0 +1,69 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// templatePlaceholder matches a {{ conjur "variable-id" }} placeholder. The
+// ID is a Go double-quoted string, so quotes and backslashes in it must be
+// escaped.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*conjur\s+("(?:[^"\\]|\\.)*")\s*\}\}`)
+
+// ExpandTemplate replaces the {{ conjur "variable-id" }} placeholders of a
+// template, such as a configuration file, with the values of the variables
+// they name, which are fetched together with a single batch request. Variable
+// IDs may be qualified with the account, as with RetrieveSecret. Everything
+// else in the template, including other {{ }} actions, is left as is.
+//
+// The authenticated user must have execute privilege on all the variables.
+func (c *Client) ExpandTemplate(template []byte) ([]byte, error) {
+	return c.ExpandTemplateWithContext(context.Background(), template)
+}
+
+// ExpandTemplateWithContext is like ExpandTemplate but uses the provided
+// context for the underlying request.
+func (c *Client) ExpandTemplateWithContext(ctx context.Context, template []byte) ([]byte, error) {
+	matches := templatePlaceholder.FindAllSubmatchIndex(template, -1)
+	if len(matches) == 0 {
+		return template, nil
+	}
+
+	variableIDs := make([]string, len(matches))
+	uniqueIDs := []string{}
+	for i, match := range matches {
+		variableID, err := strconv.Unquote(string(template[match[2]:match[3]]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid variable ID in template placeholder %s", template[match[0]:match[1]])
+		}
+		variableIDs[i] = makeFullId(c.config.Account, "variable", variableID)
+		if !contains(uniqueIDs, variableIDs[i]) {
+			uniqueIDs = append(uniqueIDs, variableIDs[i])
+		}
+	}
+
+	secrets, err := c.RetrieveBatchSecretsWithContext(ctx, uniqueIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, value := range secrets {
+			WipeBytes(value)
+		}
+	}()
+
+	expanded := make([]byte, 0, len(template))
+	last := 0
+	for i, match := range matches {
+		value, ok := secrets[variableIDs[i]]
+		if !ok {
+			return nil, fmt.Errorf("no value was returned for variable '%s'", variableIDs[i])
+		}
+		expanded = append(expanded, template[last:match[0]]...)
+		expanded = append(expanded, value...)
+		last = match[1]
+	}
+	return append(expanded, template[last:]...), nil
+}
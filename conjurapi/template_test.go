@@ -0,0 +1,65 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ExpandTemplate(t *testing.T) {
+	var requests int
+	var variableIDs string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		variableIDs = r.URL.Query().Get("variable_ids")
+		if strings.TrimSuffix(r.URL.Path, "/") != "/secrets" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"cucumber:variable:prod/db/password": "s3cr\"t", "cucumber:variable:prod/db/user": "app"}`))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Replaces placeholders with one batch request", func(t *testing.T) {
+		requests = 0
+		expanded, err := conjur.ExpandTemplate([]byte(`user: {{ conjur "prod/db/user" }}
+password: {{conjur "cucumber:variable:prod/db/password"}}
+again: {{ conjur "prod/db/password" }}
+other: {{ .Values.other }}
+`))
+		require.NoError(t, err)
+		assert.Equal(t, `user: app
+password: s3cr"t
+again: s3cr"t
+other: {{ .Values.other }}
+`, string(expanded))
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "cucumber:variable:prod/db/user,cucumber:variable:prod/db/password", variableIDs)
+	})
+
+	t.Run("Leaves templates without placeholders alone", func(t *testing.T) {
+		requests = 0
+		expanded, err := conjur.ExpandTemplate([]byte("port: 5432\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "port: 5432\n", string(expanded))
+		assert.Equal(t, 0, requests)
+	})
+
+	t.Run("Reports variables without a value", func(t *testing.T) {
+		_, err := conjur.ExpandTemplate([]byte(`{{ conjur "prod/db/host" }}`))
+		assert.EqualError(t, err, "no value was returned for variable 'cucumber:variable:prod/db/host'")
+	})
+
+	t.Run("Reports invalid placeholders", func(t *testing.T) {
+		_, err := conjur.ExpandTemplate([]byte(`{{ conjur "prod/\q" }}`))
+		assert.EqualError(t, err, `invalid variable ID in template placeholder {{ conjur "prod/\q" }}`)
+	})
+}
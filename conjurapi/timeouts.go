@@ -0,0 +1,66 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// operation is a category of requests which can be given its own timeout, so
+// that a policy load can take minutes while a secret read fails in seconds.
+type operation int
+
+const (
+	operationDefault operation = iota
+	operationAuthn
+	operationSecretRead
+	operationPolicyLoad
+)
+
+type operationContextKey struct{}
+
+// withOperation marks the requests sent with the returned context as being of
+// the given category, which selects their timeout.
+func withOperation(ctx context.Context, op operation) context.Context {
+	return context.WithValue(ctx, operationContextKey{}, op)
+}
+
+func operationOf(ctx context.Context) operation {
+	op, _ := ctx.Value(operationContextKey{}).(operation)
+	return op
+}
+
+// operationTimeout returns the timeout configured for a category of requests,
+// if any. A negative timeout disables it.
+func (c *Config) operationTimeout(op operation) (time.Duration, bool) {
+	var timeout time.Duration
+	switch op {
+	case operationAuthn:
+		timeout = c.AuthnTimeout
+	case operationSecretRead:
+		timeout = c.SecretReadTimeout
+	case operationPolicyLoad:
+		timeout = c.PolicyLoadTimeout
+	}
+	switch {
+	case timeout < 0:
+		return 0, true
+	case timeout == 0:
+		return 0, false
+	default:
+		return timeout, true
+	}
+}
+
+// httpClientFor returns the HTTP client for a category of requests: the
+// client's own, or a copy of it sharing its transport with the category's
+// timeout in place of Config.HttpTimeout.
+func (c *Client) httpClientFor(op operation) *http.Client {
+	timeout, ok := c.config.operationTimeout(op)
+	if !ok || c.httpClient == nil {
+		return c.httpClient
+	}
+	httpClient := *c.httpClient
+	httpClient.Timeout = timeout
+	return &httpClient
+}
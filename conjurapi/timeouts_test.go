@@ -0,0 +1,68 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+)
+
+func TestClient_OperationTimeouts(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/authenticate"):
+			w.Write([]byte(sample_token))
+		case strings.HasPrefix(r.URL.Path, "/secrets/"):
+			w.Write([]byte("secret"))
+		default:
+			w.Write([]byte(`{"id": "cucumber:variable:db/password"}`))
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      mockConjurServer.URL,
+		CredentialStorage: "none",
+		SecretReadTimeout: 10 * time.Millisecond,
+		PolicyLoadTimeout: -1,
+	}
+
+	t.Run("Applies the timeout of the request's category", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db/password")
+		assert.ErrorContains(t, err, "Client.Timeout exceeded")
+
+		_, err = conjur.ResourceMetadata("variable:db/password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Applies the authentication timeout", func(t *testing.T) {
+		config := config
+		config.AuthnTimeout = 10 * time.Millisecond
+		conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "admin", APIKey: "key"})
+		require.NoError(t, err)
+
+		_, err = conjur.ResourceMetadata("variable:db/password")
+		assert.ErrorContains(t, err, "Client.Timeout exceeded")
+	})
+
+	t.Run("Copies the HTTP client for categories with a timeout", func(t *testing.T) {
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		assert.Same(t, conjur.GetHttpClient(), conjur.httpClientFor(operationAuthn))
+		assert.Equal(t, 10*time.Millisecond, conjur.httpClientFor(operationSecretRead).Timeout)
+		assert.Equal(t, time.Duration(0), conjur.httpClientFor(operationPolicyLoad).Timeout)
+		assert.Equal(t, conjur.GetHttpClient().Transport, conjur.httpClientFor(operationPolicyLoad).Transport)
+	})
+}
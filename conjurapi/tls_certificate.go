@@ -0,0 +1,173 @@
+package conjurapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const CertificateReloaderDefaultInterval = 5 * time.Minute
+
+// RetrieveTLSCertificate fetches a PEM-encoded certificate chain and private
+// key, held by two variables, with a single batch request and returns them as
+// a tls.Certificate, with its Leaf parsed.
+//
+// The authenticated user must have execute privilege on both variables.
+func (c *Client) RetrieveTLSCertificate(certificateID, keyID string) (*tls.Certificate, error) {
+	return c.RetrieveTLSCertificateWithContext(context.Background(), certificateID, keyID)
+}
+
+// RetrieveTLSCertificateWithContext is like RetrieveTLSCertificate but uses
+// the provided context for the underlying request.
+func (c *Client) RetrieveTLSCertificateWithContext(ctx context.Context, certificateID, keyID string) (*tls.Certificate, error) {
+	certificateID = makeFullId(c.config.Account, "variable", certificateID)
+	keyID = makeFullId(c.config.Account, "variable", keyID)
+
+	secrets, err := c.RetrieveBatchSecretsWithContext(ctx, []string{certificateID, keyID})
+	if err != nil {
+		return nil, err
+	}
+	defer WipeBytes(secrets[keyID])
+
+	cert, err := tls.X509KeyPair(secrets[certificateID], secrets[keyID])
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate in variables '%s' and '%s': %w", certificateID, keyID, err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate in variable '%s': %w", certificateID, err)
+	}
+	return &cert, nil
+}
+
+// CertificateReloaderOptions configures a CertificateReloader.
+type CertificateReloaderOptions struct {
+	// Interval is how often the certificate is fetched again. Defaults to
+	// CertificateReloaderDefaultInterval.
+	Interval time.Duration
+	// OnError is called with the error of every failed reload. The previous
+	// certificate stays in use.
+	OnError func(error)
+}
+
+// CertificateReloader keeps a TLS certificate held by Conjur variables up to
+// date, for servers or clients whose certificate Conjur rotates. Its
+// GetCertificate and GetClientCertificate methods plug into a tls.Config.
+type CertificateReloader struct {
+	client        *Client
+	certificateID string
+	keyID         string
+	options       CertificateReloaderOptions
+
+	certMutex sync.RWMutex
+	cert      *tls.Certificate
+
+	mutex sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewCertificateReloader fetches the certificate held by the given variables,
+// as RetrieveTLSCertificate does, and creates a CertificateReloader serving
+// it. Call Start to begin reloading it in the background and Stop to end it.
+func (c *Client) NewCertificateReloader(certificateID, keyID string, options CertificateReloaderOptions) (*CertificateReloader, error) {
+	if options.Interval <= 0 {
+		options.Interval = CertificateReloaderDefaultInterval
+	}
+
+	r := &CertificateReloader{
+		client:        c,
+		certificateID: certificateID,
+		keyID:         keyID,
+		options:       options,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload fetches the certificate again. On failure, the previous certificate
+// stays in use.
+func (r *CertificateReloader) Reload() error {
+	cert, err := r.client.RetrieveTLSCertificate(r.certificateID, r.keyID)
+	if err != nil {
+		return err
+	}
+
+	r.certMutex.Lock()
+	defer r.certMutex.Unlock()
+	r.cert = cert
+	return nil
+}
+
+// Certificate returns the current certificate.
+func (r *CertificateReloader) Certificate() *tls.Certificate {
+	r.certMutex.RLock()
+	defer r.certMutex.RUnlock()
+	return r.cert
+}
+
+// GetCertificate returns the current certificate, for tls.Config's
+// GetCertificate.
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate returns the current certificate, for tls.Config's
+// GetClientCertificate.
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Start launches the background goroutine. Calling Start on a running
+// reloader has no effect.
+func (r *CertificateReloader) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+}
+
+// Stop ends the background goroutine and waits for it to exit. Calling Stop
+// on a reloader which isn't running has no effect.
+func (r *CertificateReloader) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop = nil
+	r.done = nil
+}
+
+func (r *CertificateReloader) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Reload(); err != nil && r.options.OnError != nil {
+			r.options.OnError(err)
+		}
+	}
+}
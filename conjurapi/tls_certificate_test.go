@@ -0,0 +1,78 @@
+package conjurapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RetrieveTLSCertificate(t *testing.T) {
+	firstCert, firstKey := generateTestClientCert(t, "first")
+	var mutex sync.Mutex
+	secrets := map[string]string{
+		"cucumber:variable:tls/cert": firstCert,
+		"cucumber:variable:tls/key":  firstKey,
+	}
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		json.NewEncoder(w).Encode(secrets)
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	t.Run("Returns the certificate", func(t *testing.T) {
+		cert, err := conjur.RetrieveTLSCertificate("tls/cert", "tls/key")
+		require.NoError(t, err)
+		require.NotNil(t, cert.Leaf)
+		assert.Equal(t, "first", cert.Leaf.Subject.CommonName)
+	})
+
+	t.Run("Reports invalid certificates", func(t *testing.T) {
+		_, err := conjur.RetrieveTLSCertificate("tls/cert", "tls/cert")
+		assert.ErrorContains(t, err, "invalid TLS certificate in variables 'cucumber:variable:tls/cert' and 'cucumber:variable:tls/cert'")
+	})
+
+	t.Run("Reloads the certificate", func(t *testing.T) {
+		reloader, err := conjur.NewCertificateReloader("tls/cert", "tls/key", CertificateReloaderOptions{Interval: 10 * time.Millisecond})
+		require.NoError(t, err)
+		cert, err := reloader.GetCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "first", cert.Leaf.Subject.CommonName)
+
+		reloader.Start()
+		defer reloader.Stop()
+
+		secondCert, secondKey := generateTestClientCert(t, "second")
+		mutex.Lock()
+		secrets["cucumber:variable:tls/cert"] = secondCert
+		secrets["cucumber:variable:tls/key"] = secondKey
+		mutex.Unlock()
+
+		assert.Eventually(t, func() bool {
+			cert, _ := reloader.GetClientCertificate(nil)
+			return cert.Leaf.Subject.CommonName == "second"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Keeps the certificate when reloading fails", func(t *testing.T) {
+		reloader, err := conjur.NewCertificateReloader("tls/cert", "tls/key", CertificateReloaderOptions{})
+		require.NoError(t, err)
+
+		mutex.Lock()
+		secrets["cucumber:variable:tls/key"] = "not a key"
+		mutex.Unlock()
+
+		assert.Error(t, reloader.Reload())
+		assert.NotNil(t, reloader.Certificate())
+	})
+}
@@ -0,0 +1,124 @@
+package conjurapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/storage"
+)
+
+// WithTokenCachePassphrase encrypts the token cache at Config.TokenCachePath
+// with a key derived from the passphrase, rather than with the machine key
+// stored next to it.
+func WithTokenCachePassphrase(passphrase []byte) ClientOption {
+	return func(c *Client) {
+		c.tokenCachePassphrase = passphrase
+	}
+}
+
+// WithoutTokenCache disables the token cache, like Config.NoTokenCache, so
+// that the client neither reads nor stores cached tokens. It's the equivalent
+// of a --no-cache flag.
+func WithoutTokenCache() ClientOption {
+	return func(c *Client) {
+		c.config.NoTokenCache = true
+	}
+}
+
+func (c *Client) setupTokenCache() {
+	if c.config.TokenCachePath == "" || c.config.NoTokenCache {
+		return
+	}
+	c.tokenCache = storage.NewTokenCache(c.config.TokenCachePath, c.tokenCachePassphrase)
+}
+
+// tokenCacheKey identifies the tokens of the client's identity in the token
+// cache. No key is returned when the identity isn't known before
+// authenticating, so that clients logged in as different roles can't share a
+// cached token.
+func (c *Client) tokenCacheKey() (string, bool) {
+	identity := authenticatorIdentity(c.GetAuthenticator())
+	if identity == "" {
+		return "", false
+	}
+	return strings.Join([]string{
+		getMachineName(c.config),
+		c.config.Account,
+		c.config.AuthnType,
+		c.config.ServiceID,
+		identity,
+	}, " "), true
+}
+
+// authenticatorIdentity returns what identifies the Conjur role an
+// authenticator logs in as, where it's known before authenticating.
+func authenticatorIdentity(authenticator Authenticator) string {
+	switch a := authenticator.(type) {
+	case *authn.APIKeyAuthenticator:
+		return a.Login
+	case *authn.JWTAuthenticator:
+		switch {
+		case a.HostID != "":
+			return a.HostID
+		case a.JWTFilePath != "":
+			return "jwt-file:" + a.JWTFilePath
+		case a.JWT != "":
+			hash := sha256.Sum256([]byte(a.JWT))
+			return "jwt:" + hex.EncodeToString(hash[:])
+		}
+	case *authn.TokenFileAuthenticator:
+		return "token-file:" + a.TokenFile
+	case *authn.K8sAuthenticator:
+		return a.Login
+	case *authn.IAMAuthenticator:
+		return a.Login
+	case *authn.AzureAuthenticator:
+		return a.Login
+	case *authn.GCPAuthenticator:
+		return a.Login
+	case *authn.LocalAuthenticator:
+		return a.Login
+	case *authn.IdentityAuthenticator:
+		return a.ClientID
+	case authn.TokenCacheAuthenticator:
+		return a.TokenCacheIdentity()
+	}
+	return ""
+}
+
+// readTokenCache returns the cached access token of the client's identity, if
+// it isn't yet due for refresh.
+func (c *Client) readTokenCache() *authn.AuthnToken {
+	key, ok := c.tokenCacheKey()
+	if !ok {
+		return nil
+	}
+	tokenBytes, err := c.tokenCache.Read(key)
+	if err != nil {
+		logging.ApiLog.Debugf("Unable to read the token cache: %s", err)
+		return nil
+	}
+	if tokenBytes == nil {
+		return nil
+	}
+
+	token, err := authn.NewToken(tokenBytes)
+	if err != nil || c.tokenDueForRefresh(token) {
+		return nil
+	}
+	return token
+}
+
+func (c *Client) storeTokenCache(token *authn.AuthnToken) {
+	key, ok := c.tokenCacheKey()
+	if !ok {
+		return
+	}
+	raw := append([]byte(nil), token.Raw()...)
+	if err := c.tokenCache.Store(key, raw, token.ExpiresAt()); err != nil {
+		logging.ApiLog.Debugf("Unable to store the access token in the token cache: %s", err)
+	}
+}
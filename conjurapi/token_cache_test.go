@@ -0,0 +1,137 @@
+package conjurapi
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// identifiedAuthenticator is a custom authenticator naming its identity for
+// the token cache.
+type identifiedAuthenticator struct {
+	countingAuthenticator
+	identity string
+}
+
+func (a *identifiedAuthenticator) TokenCacheIdentity() string {
+	return a.identity
+}
+
+func TestClient_TokenCache(t *testing.T) {
+	config := Config{
+		Account:           "cucumber",
+		ApplianceURL:      "https://conjur.example.com",
+		CredentialStorage: "none",
+		TokenCachePath:    filepath.Join(t.TempDir(), "tokens"),
+	}
+
+	t.Run("Reuses tokens across clients", func(t *testing.T) {
+		first := &identifiedAuthenticator{identity: "app"}
+		client, err := NewClientFromAuthenticator(config, first)
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+		assert.Equal(t, int32(1), first.calls)
+
+		second := &identifiedAuthenticator{identity: "app"}
+		client, err = NewClientFromAuthenticator(config, second)
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+		assert.Equal(t, int32(0), second.calls)
+		assert.Equal(t, sample_token, string(client.getAuthToken().Raw()))
+	})
+
+	t.Run("Is disabled with WithoutTokenCache", func(t *testing.T) {
+		authenticator := &countingAuthenticator{}
+		client, err := NewClientFromAuthenticator(config, authenticator, WithoutTokenCache())
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+		assert.Equal(t, int32(1), authenticator.calls)
+	})
+
+	t.Run("Keeps identities apart", func(t *testing.T) {
+		config := config
+		config.TokenCachePath = filepath.Join(t.TempDir(), "tokens")
+
+		alice, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "alice-key"})
+		require.NoError(t, err)
+		require.NoError(t, alice.tokenCache.Store(mustTokenCacheKey(t, alice), []byte(sample_token), time.Now().Add(time.Hour)))
+		assert.NotNil(t, alice.readTokenCache())
+
+		bob, err := NewClientFromKey(config, authn.LoginPair{Login: "bob", APIKey: "bob-key"})
+		require.NoError(t, err)
+		assert.Nil(t, bob.readTokenCache())
+	})
+
+	t.Run("Skips authenticators without a known identity", func(t *testing.T) {
+		config := config
+		config.TokenCachePath = filepath.Join(t.TempDir(), "tokens")
+
+		first := &countingAuthenticator{}
+		client, err := NewClientFromAuthenticator(config, first)
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+
+		second := &countingAuthenticator{}
+		client, err = NewClientFromAuthenticator(config, second)
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+		assert.Equal(t, int32(1), second.calls)
+	})
+
+	t.Run("Keeps JWT identities apart", func(t *testing.T) {
+		config := config
+		config.TokenCachePath = filepath.Join(t.TempDir(), "tokens")
+		config.AuthnType = "jwt"
+		config.ServiceID = "k8s-cluster"
+
+		newJWTClient := func(config Config) *Client {
+			client, err := NewClientFromJWTAuthenticator(config)
+			require.NoError(t, err)
+			return client
+		}
+
+		first := config
+		first.JWTContent = "first.jwt.token"
+		client := newJWTClient(first)
+		require.NoError(t, client.tokenCache.Store(mustTokenCacheKey(t, client), []byte(sample_token), time.Now().Add(time.Hour)))
+		assert.NotNil(t, client.readTokenCache())
+		assert.NotNil(t, newJWTClient(first).readTokenCache())
+
+		second := config
+		second.JWTContent = "second.jwt.token"
+		assert.Nil(t, newJWTClient(second).readTokenCache())
+
+		otherService := first
+		otherService.ServiceID = "other-cluster"
+		assert.Nil(t, newJWTClient(otherService).readTokenCache())
+
+		firstFile := config
+		firstFile.JWTFilePath = filepath.Join(t.TempDir(), "first-jwt")
+		client = newJWTClient(firstFile)
+		require.NoError(t, client.tokenCache.Store(mustTokenCacheKey(t, client), []byte(sample_token), time.Now().Add(time.Hour)))
+		assert.NotNil(t, newJWTClient(firstFile).readTokenCache())
+
+		secondFile := config
+		secondFile.JWTFilePath = filepath.Join(t.TempDir(), "second-jwt")
+		assert.Nil(t, newJWTClient(secondFile).readTokenCache())
+	})
+
+	t.Run("Purges the cached token", func(t *testing.T) {
+		client, err := NewClientFromAuthenticator(config, &identifiedAuthenticator{identity: "app"})
+		require.NoError(t, err)
+		require.NoError(t, client.RefreshToken())
+		require.NoError(t, client.PurgeCredentials())
+
+		assert.Nil(t, client.readTokenCache())
+	})
+}
+
+func mustTokenCacheKey(t *testing.T, client *Client) string {
+	key, ok := client.tokenCacheKey()
+	require.True(t, ok)
+	return key
+}
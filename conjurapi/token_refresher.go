@@ -0,0 +1,183 @@
+package conjurapi
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const TokenRefresherDefaultCheckInterval = 5 * time.Second
+
+const (
+	// asyncRefreshMinBackoff and asyncRefreshMaxBackoff bound the delay
+	// before retrying a failed background renewal.
+	asyncRefreshMinBackoff = time.Second
+	asyncRefreshMaxBackoff = time.Minute
+)
+
+// TokenRefresherOptions configures a TokenRefresher.
+type TokenRefresherOptions struct {
+	// CheckInterval is how often the access token is checked for renewal.
+	// Defaults to TokenRefresherDefaultCheckInterval.
+	CheckInterval time.Duration
+	// Jitter is the maximum random delay added before renewing the token. It
+	// spreads renewals over time when many replicas obtained their tokens at
+	// the same moment.
+	Jitter time.Duration
+	// OnError is called with the error of every failed renewal.
+	OnError func(error)
+}
+
+// TokenRefresher renews a client's access token in the background as soon as
+// the token should be refreshed, instead of on the next request.
+type TokenRefresher struct {
+	client  *Client
+	options TokenRefresherOptions
+	random  *rand.Rand
+
+	mutex sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewTokenRefresher creates a TokenRefresher for the client. Call Start to
+// begin renewing the token and Stop to end it.
+func (c *Client) NewTokenRefresher(options TokenRefresherOptions) *TokenRefresher {
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = TokenRefresherDefaultCheckInterval
+	}
+
+	return &TokenRefresher{
+		client:  c,
+		options: options,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Start launches the background goroutine. Calling Start on a running
+// refresher has no effect.
+func (r *TokenRefresher) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+}
+
+// Stop ends the background goroutine and waits for it to exit. Calling Stop
+// on a refresher which isn't running has no effect.
+func (r *TokenRefresher) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop = nil
+	r.done = nil
+}
+
+func (r *TokenRefresher) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if r.client.NeedsTokenRefresh() {
+			select {
+			case <-stop:
+				return
+			case <-time.After(r.jitter()):
+			}
+
+			// RefreshToken checks again, in case a request renewed the
+			// token during the jitter delay
+			if err := r.client.RefreshToken(); err != nil && r.options.OnError != nil {
+				r.options.OnError(err)
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *TokenRefresher) jitter() time.Duration {
+	if r.options.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(r.random.Int63n(int64(r.options.Jitter)))
+}
+
+// asyncTokenRefresh is the state of background renewals started by requests.
+type asyncTokenRefresh struct {
+	onError func(error)
+	running bool
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// WithAsyncTokenRefresh makes the client renew its access token in the
+// background as soon as a request finds that the token should be refreshed,
+// while that request and the ones after it keep using the still-valid token.
+// Requests only wait for authentication when the client has no token yet or
+// it has expired. Unlike a TokenRefresher, no goroutine runs while the client
+// is idle.
+//
+// Failed renewals are passed to onError, which may be nil, and retried with
+// exponential backoff starting at one second, up to one minute.
+func WithAsyncTokenRefresh(onError func(error)) ClientOption {
+	return func(c *Client) {
+		c.asyncRefresh = &asyncTokenRefresh{onError: onError}
+	}
+}
+
+// refreshTokenInBackground starts renewing the access token, unless a renewal
+// is already running or backing off after a failure.
+func (c *Client) refreshTokenInBackground() {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+
+	async := c.asyncRefresh
+	if async.running || c.refreshing != nil || time.Now().Before(async.retryAt) {
+		return
+	}
+	async.running = true
+
+	go func() {
+		err := c.refreshTokenOnce(context.Background(), false)
+
+		c.refreshMutex.Lock()
+		async.running = false
+		if err != nil {
+			async.backoff *= 2
+			if async.backoff < asyncRefreshMinBackoff {
+				async.backoff = asyncRefreshMinBackoff
+			}
+			if async.backoff > asyncRefreshMaxBackoff {
+				async.backoff = asyncRefreshMaxBackoff
+			}
+			async.retryAt = time.Now().Add(async.backoff)
+		} else {
+			async.backoff = 0
+			async.retryAt = time.Time{}
+		}
+		c.refreshMutex.Unlock()
+
+		if err != nil && async.onError != nil {
+			async.onError(err)
+		}
+	}()
+}
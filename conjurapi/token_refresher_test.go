@@ -0,0 +1,193 @@
+package conjurapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingAuthenticator struct {
+	calls int32
+	err   error
+}
+
+func (a *countingAuthenticator) RefreshToken() ([]byte, error) {
+	atomic.AddInt32(&a.calls, 1)
+	if a.err != nil {
+		return nil, a.err
+	}
+	return []byte(sample_token), nil
+}
+
+func (a *countingAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestTokenRefresher(t *testing.T) {
+	config := Config{Account: "account", ApplianceURL: "appliance-url"}
+
+	t.Run("Renews the token in the background", func(t *testing.T) {
+		authenticator := &countingAuthenticator{}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		refresher := client.NewTokenRefresher(TokenRefresherOptions{CheckInterval: 10 * time.Millisecond, Jitter: 10 * time.Millisecond})
+		refresher.Start()
+		defer refresher.Stop()
+
+		assert.Eventually(t, func() bool {
+			return client.getAuthToken() != nil
+		}, time.Second, 5*time.Millisecond)
+
+		// The token doesn't need renewing again until it nears expiry
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&authenticator.calls))
+	})
+
+	t.Run("Reports renewal errors", func(t *testing.T) {
+		authenticator := &countingAuthenticator{err: fmt.Errorf("authentication failed")}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		errors := make(chan error, 10)
+		refresher := client.NewTokenRefresher(TokenRefresherOptions{
+			CheckInterval: 10 * time.Millisecond,
+			OnError: func(err error) {
+				select {
+				case errors <- err:
+				default:
+				}
+			},
+		})
+		refresher.Start()
+		defer refresher.Stop()
+
+		select {
+		case err := <-errors:
+			assert.EqualError(t, err, "authentication failed")
+		case <-time.After(time.Second):
+			t.Fatal("expected a renewal error")
+		}
+	})
+
+	t.Run("Stops renewing after Stop", func(t *testing.T) {
+		authenticator := &countingAuthenticator{err: fmt.Errorf("authentication failed")}
+		client, err := NewClientFromAuthenticator(config, authenticator)
+		assert.NoError(t, err)
+
+		refresher := client.NewTokenRefresher(TokenRefresherOptions{CheckInterval: 5 * time.Millisecond})
+		refresher.Start()
+		refresher.Start()
+		time.Sleep(20 * time.Millisecond)
+		refresher.Stop()
+		refresher.Stop()
+
+		calls := atomic.LoadInt32(&authenticator.calls)
+		assert.Greater(t, calls, int32(0))
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, calls, atomic.LoadInt32(&authenticator.calls))
+	})
+}
+
+// tokenExpiringIn returns an access token which was issued a minute ago and
+// expires after the given duration.
+func tokenExpiringIn(expiresIn time.Duration) []byte {
+	now := time.Now()
+	payload := fmt.Sprintf(`{"sub":"admin","iat":%d,"exp":%d}`, now.Add(-time.Minute).Unix(), now.Add(expiresIn).Unix())
+	return []byte(fmt.Sprintf(`{"protected":"e30=","payload":"%s","signature":"c2ln"}`, base64.StdEncoding.EncodeToString([]byte(payload))))
+}
+
+// gatedAuthenticator returns its first token right away, and each later one
+// once released.
+type gatedAuthenticator struct {
+	calls   int32
+	first   []byte
+	release chan struct{}
+	err     error
+}
+
+func (a *gatedAuthenticator) RefreshToken() ([]byte, error) {
+	if atomic.AddInt32(&a.calls, 1) == 1 {
+		return a.first, nil
+	}
+	<-a.release
+	if a.err != nil {
+		return nil, a.err
+	}
+	return []byte(sample_token), nil
+}
+
+func (a *gatedAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestWithAsyncTokenRefresh(t *testing.T) {
+	config := Config{Account: "account", ApplianceURL: "appliance-url"}
+
+	t.Run("Renews a token due for refresh without blocking requests", func(t *testing.T) {
+		authenticator := &gatedAuthenticator{first: tokenExpiringIn(5 * time.Second), release: make(chan struct{})}
+		client, err := NewClientFromAuthenticator(config, authenticator, WithAsyncTokenRefresh(nil))
+		assert.NoError(t, err)
+
+		// Without a token, the first request waits for authentication
+		assert.NoError(t, client.RefreshToken())
+		first := client.getAuthToken()
+		assert.True(t, first.ShouldRefresh())
+
+		// Later requests keep using the current token during the renewal
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, client.RefreshToken())
+			assert.Same(t, first, client.getAuthToken())
+		}
+
+		close(authenticator.release)
+		assert.Eventually(t, func() bool {
+			return string(client.getAuthToken().Raw()) == sample_token
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+	})
+
+	t.Run("Waits for authentication once the token has expired", func(t *testing.T) {
+		authenticator := &gatedAuthenticator{first: tokenExpiringIn(-time.Second), release: make(chan struct{})}
+		client, err := NewClientFromAuthenticator(config, authenticator, WithAsyncTokenRefresh(nil))
+		assert.NoError(t, err)
+		assert.NoError(t, client.RefreshToken())
+
+		close(authenticator.release)
+		assert.NoError(t, client.RefreshToken())
+		assert.Equal(t, sample_token, string(client.getAuthToken().Raw()))
+	})
+
+	t.Run("Reports failures and backs off", func(t *testing.T) {
+		release := make(chan struct{})
+		close(release)
+		authenticator := &gatedAuthenticator{first: tokenExpiringIn(5 * time.Second), release: release, err: fmt.Errorf("authentication failed")}
+
+		errors := make(chan error, 10)
+		client, err := NewClientFromAuthenticator(config, authenticator, WithAsyncTokenRefresh(func(err error) {
+			errors <- err
+		}))
+		assert.NoError(t, err)
+		assert.NoError(t, client.RefreshToken())
+		assert.NoError(t, client.RefreshToken())
+
+		select {
+		case err := <-errors:
+			assert.EqualError(t, err, "authentication failed")
+		case <-time.After(time.Second):
+			t.Fatal("expected a renewal error")
+		}
+
+		// Requests during the backoff neither fail nor retry
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, client.RefreshToken())
+		}
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+		assert.Empty(t, errors)
+	})
+}
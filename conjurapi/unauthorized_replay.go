@@ -0,0 +1,80 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+)
+
+// WithoutUnauthorizedReplay stops the client from re-authenticating and
+// replaying requests which Conjur rejects with 401 Unauthorized, for callers
+// which handle expired tokens themselves.
+func WithoutUnauthorizedReplay() ClientOption {
+	return func(c *Client) {
+		c.noUnauthorizedReplay = true
+	}
+}
+
+// WithUnauthorizedReplayOfAllMethods makes the client replay POST and PATCH
+// requests rejected with 401 Unauthorized too, and not only idempotent ones.
+// Conjur rejects a request with an expired token before acting on it, so a
+// replay can't apply a change twice, but a proxy in front of Conjur might not.
+func WithUnauthorizedReplayOfAllMethods() ClientOption {
+	return func(c *Client) {
+		c.replayAllUnauthorized = true
+	}
+}
+
+// replayUnauthorized re-authenticates and sends the request once more after
+// Conjur rejected it with 401 Unauthorized, which happens when the access
+// token expires earlier than the client expected, for example because of
+// clock skew. The token is only renewed if no other request renewed it since
+// this one was sent.
+func (c *Client) replayUnauthorized(req *http.Request, resp *http.Response) (*http.Response, error) {
+	replay := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		replay.Body = body
+	}
+	// Keep the ID of the rejected request, so that both attempts show up in
+	// Conjur's logs as the same request
+	if resp.Request != nil {
+		if requestID := resp.Request.Header.Get(RequestIDHeader); requestID != "" {
+			replay.Header.Set(RequestIDHeader, requestID)
+		}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if c.logger != nil {
+		c.logger.Log(LogLevelInfo, "Re-authenticating after Conjur rejected the access token", requestLogFields(req))
+	}
+	if req.Header.Get("Authorization") == c.authorizationHeader() {
+		if err := c.ForceRefreshTokenWithContext(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	replay.Header.Set("Authorization", c.authorizationHeader())
+
+	return c.submitRequestWithCustomAuth(replay)
+}
+
+func (c *Client) canReplayUnauthorized(req *http.Request) bool {
+	authenticator := c.GetAuthenticator()
+	if c.noUnauthorizedReplay || authenticator == nil {
+		return false
+	}
+	// A pre-fetched token can't be renewed
+	if _, ok := authenticator.(*authn.TokenAuthenticator); ok {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	return isIdempotent(req.Method) || c.replayAllUnauthorized
+}
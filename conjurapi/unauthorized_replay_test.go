@@ -0,0 +1,115 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UnauthorizedReplay(t *testing.T) {
+	var requests int32
+	var bodies []string
+	var requestIDs []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		// Every other request is rejected, as if its token had expired
+		if atomic.AddInt32(&requests, 1)%2 == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("secret"))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+
+	t.Run("Re-authenticates and replays idempotent requests", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		authenticator := &countingAuthenticator{}
+		conjur, err := NewClientFromAuthenticator(config, authenticator)
+		require.NoError(t, err)
+
+		value, err := conjur.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(value))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&authenticator.calls))
+	})
+
+	t.Run("Keeps the request ID of the rejected request", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		requestIDs = nil
+		conjur, err := NewClientFromAuthenticator(config, &countingAuthenticator{})
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		require.Len(t, requestIDs, 2)
+		assert.NotEmpty(t, requestIDs[0])
+		assert.Equal(t, requestIDs[0], requestIDs[1])
+	})
+
+	t.Run("Doesn't replay POST requests by default", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		conjur, err := NewClientFromAuthenticator(config, &countingAuthenticator{})
+		require.NoError(t, err)
+
+		err = conjur.AddSecret("db/password", "new")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Replays POST requests with their body when enabled", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		bodies = nil
+		conjur, err := NewClientFromAuthenticator(config, &countingAuthenticator{}, WithUnauthorizedReplayOfAllMethods())
+		require.NoError(t, err)
+
+		require.NoError(t, conjur.AddSecret("db/password", "new"))
+		assert.Equal(t, []string{"new", "new"}, bodies)
+	})
+
+	t.Run("Replays once", func(t *testing.T) {
+		rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer rejecting.Close()
+
+		atomic.StoreInt32(&requests, 0)
+		conjur, err := NewClientFromAuthenticator(Config{Account: "cucumber", ApplianceURL: rejecting.URL, CredentialStorage: "none"}, &countingAuthenticator{})
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db/password")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Can be disabled", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		conjur, err := NewClientFromAuthenticator(config, &countingAuthenticator{}, WithoutUnauthorizedReplay())
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db/password")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Doesn't replay with a pre-fetched token", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		conjur, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+
+		_, err = conjur.RetrieveSecret("db/password")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+}
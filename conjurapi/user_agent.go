@@ -0,0 +1,45 @@
+package conjurapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Version is the version of this library, as sent in the User-Agent header.
+const Version = "2.0.0"
+
+// userAgent is the User-Agent header sent by clients without an application.
+const userAgent = "conjur-api-go/" + Version
+
+// WithApplication appends the name and version of the application using the
+// client to its User-Agent header, such as "conjur-api-go/2.0.0
+// billing-service/1.4.2", so that Conjur's logs tell apart the services
+// which use this library. The version may be empty.
+func WithApplication(name, version string) ClientOption {
+	return func(c *Client) {
+		product := strings.Join(strings.Fields(name), "-")
+		if version = strings.Join(strings.Fields(version), "-"); version != "" {
+			product += "/" + version
+		}
+		c.userAgent = userAgent
+		if product != "" {
+			c.userAgent += " " + product
+		}
+	}
+}
+
+// withUserAgent sets the User-Agent header of a request, unless it has one
+// already. It returns a copy of the request if it changes it.
+func (c *Client) withUserAgent(req *http.Request) *http.Request {
+	if req.Header.Get("User-Agent") != "" {
+		return req
+	}
+
+	agent := c.userAgent
+	if agent == "" {
+		agent = userAgent
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", agent)
+	return req
+}
@@ -0,0 +1,91 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	version, err := os.ReadFile("../VERSION")
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(version)), Version)
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	var userAgents []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		w.Write([]byte("secret"))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+
+	testCases := []struct {
+		name     string
+		options  []ClientOption
+		expected string
+	}{
+		{
+			name:     "Identifies the library",
+			expected: "conjur-api-go/" + Version,
+		},
+		{
+			name:     "Appends the application",
+			options:  []ClientOption{WithApplication("billing-service", "1.4.2")},
+			expected: "conjur-api-go/" + Version + " billing-service/1.4.2",
+		},
+		{
+			name:     "Omits an empty application version",
+			options:  []ClientOption{WithApplication("billing-service", "")},
+			expected: "conjur-api-go/" + Version + " billing-service",
+		},
+		{
+			name:     "Replaces spaces in the application",
+			options:  []ClientOption{WithApplication(" billing service ", "1.4.2 beta")},
+			expected: "conjur-api-go/" + Version + " billing-service/1.4.2-beta",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userAgents = nil
+			conjur, err := NewClientFromToken(config, sample_token, tc.options...)
+			require.NoError(t, err)
+
+			_, err = conjur.RetrieveSecret("db/password")
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.expected}, userAgents)
+		})
+	}
+
+	t.Run("Keeps the User-Agent of the request", func(t *testing.T) {
+		userAgents = nil
+		conjur, err := NewClientFromToken(config, sample_token, WithApplication("billing-service", "1.4.2"))
+		require.NoError(t, err)
+
+		req, err := conjur.NewRequest(http.MethodGet, "/secrets/cucumber/variable/db%2Fpassword", nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", "custom/1.0")
+		resp, err := conjur.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, []string{"custom/1.0"}, userAgents)
+	})
+
+	t.Run("Is kept by clones", func(t *testing.T) {
+		userAgents = nil
+		conjur, err := NewClientFromToken(config, sample_token, WithApplication("billing-service", "1.4.2"))
+		require.NoError(t, err)
+
+		_, err = conjur.clone(conjur.authenticator).RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"conjur-api-go/" + Version + " billing-service/1.4.2"}, userAgents)
+	})
+}
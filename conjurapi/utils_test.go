@@ -3,7 +3,7 @@ package conjurapi
 import (
 	"os"
 	"strings"
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
 )
 
 var defaultTestPolicy = `
@@ -0,0 +1,79 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+)
+
+// Conjur Enterprise v4 serves its API under /api, without accounts. In V4
+// mode, the client sends authentication and secret retrieval requests to the
+// v4 endpoints instead; other requests aren't supported.
+
+func (c *Client) v4URL(components ...string) routerURL {
+	return makeRouterURL(withAPIPath(c.config.ApplianceURL), components...)
+}
+
+// v4VariableID returns the v4 ID of a variable, which may be given fully- or
+// partially-qualified like with v5.
+func (c *Client) v4VariableID(variableID string) (string, error) {
+	_, _, id, err := c.parseIDandEnforceKind(variableID, "variable")
+	return id, err
+}
+
+func (c *Client) v4LoginRequest(login string, password string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", c.v4URL("authn", "users", "login").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(login, password)
+	req.Header.Set("Content-Type", "text/plain")
+
+	return req, nil
+}
+
+func (c *Client) v4AuthenticateRequest(loginPair authn.LoginPair) (*http.Request, error) {
+	authenticateURL := c.v4URL("authn", "users", url.QueryEscape(loginPair.Login), "authenticate").String()
+
+	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(loginPair.APIKey))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	return req, nil
+}
+
+func (c *Client) v4RetrieveSecretRequest(variableID string, version int) (*http.Request, error) {
+	id, err := c.v4VariableID(variableID)
+	if err != nil {
+		return nil, err
+	}
+
+	variableURL := c.v4URL("variables", url.QueryEscape(id), "value")
+	if version > 0 {
+		variableURL = variableURL.withFormattedQuery("version=%d", version)
+	}
+	return http.NewRequest("GET", variableURL.String(), nil)
+}
+
+func (c *Client) v4RetrieveBatchSecretsRequest(variableIDs []string, base64Flag bool) (*http.Request, error) {
+	if base64Flag {
+		return nil, fmt.Errorf("batch secrets can't be base64-encoded by Conjur v4")
+	}
+
+	ids := []string{}
+	for _, variableID := range variableIDs {
+		id, err := c.v4VariableID(variableID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	batchURL := c.v4URL("variables", "values").withFormattedQuery("vars=%s", url.QueryEscape(strings.Join(ids, ",")))
+	return http.NewRequest("GET", batchURL.String(), nil)
+}
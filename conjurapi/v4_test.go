@@ -0,0 +1,99 @@
+package conjurapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func v4Token() []byte {
+	token, _ := json.Marshal(map[string]string{
+		"data":      "alice",
+		"timestamp": time.Now().UTC().Format(authn.TimeFormatToken4),
+		"signature": "c2ln",
+		"key":       "a2V5",
+	})
+	return token
+}
+
+func newTestV4Server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/authn/users/alice/authenticate":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "api-key", string(body))
+			w.Write(v4Token())
+		case "/api/authn/users/login":
+			login, password, _ := r.BasicAuth()
+			assert.Equal(t, "alice", login)
+			assert.Equal(t, "password", password)
+			w.Write([]byte("api-key"))
+		case "/api/variables/db%2Fpassword/value":
+			assert.Contains(t, r.Header.Get("Authorization"), "Token token=")
+			w.Write([]byte("secret" + r.URL.Query().Get("version")))
+		case "/api/variables/values":
+			assert.Equal(t, "db/password,db/user", r.URL.Query().Get("vars"))
+			w.Write([]byte(`{"db/password":"secret","db/user":"admin"}`))
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient_V4(t *testing.T) {
+	ts := newTestV4Server(t)
+	defer ts.Close()
+
+	config := Config{ApplianceURL: ts.URL, V4: true, CredentialStorage: "none"}
+	conjur, err := NewClientFromKey(config, authn.LoginPair{Login: "alice", APIKey: "api-key"})
+	require.NoError(t, err)
+
+	t.Run("Login", func(t *testing.T) {
+		apiKey, err := conjur.Login("alice", "password")
+		require.NoError(t, err)
+		assert.Equal(t, "api-key", apiKey)
+	})
+
+	t.Run("RetrieveSecret", func(t *testing.T) {
+		for _, variableID := range []string{"db/password", "variable:db/password", "legacy:variable:db/password"} {
+			value, err := conjur.RetrieveSecret(variableID)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", string(value))
+		}
+	})
+
+	t.Run("RetrieveSecretWithVersion", func(t *testing.T) {
+		value, err := conjur.RetrieveSecretWithVersion("db/password", 2)
+		require.NoError(t, err)
+		assert.Equal(t, "secret2", string(value))
+	})
+
+	t.Run("RetrieveBatchSecrets", func(t *testing.T) {
+		values, err := conjur.RetrieveBatchSecrets([]string{"db/password", "db/user"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"db/password": []byte("secret"), "db/user": []byte("admin")}, values)
+	})
+
+	t.Run("RetrieveBatchSecretsSafe", func(t *testing.T) {
+		_, err := conjur.RetrieveBatchSecretsSafe([]string{"db/password"})
+		assert.EqualError(t, err, "batch secrets can't be base64-encoded by Conjur v4")
+	})
+}
+
+func TestConfig_Validate_V4(t *testing.T) {
+	config := Config{ApplianceURL: "https://conjur.example.com", V4: true}
+	assert.NoError(t, config.Validate())
+
+	config.AuthnType = "jwt"
+	config.JWTContent = "jwt"
+	config.ServiceID = "jwt-service"
+	assert.EqualError(t, config.Validate(), "AuthnType jwt is not supported in V4 mode")
+}
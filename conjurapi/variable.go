@@ -1,21 +1,46 @@
 package conjurapi
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 )
 
 // RetrieveBatchSecrets fetches values for all variables in a slice using a
 // single API call
 //
+// If any of the values can't be represented in a JSON response, such as binary
+// values, the request is retried with server-side Base64 encoding as with
+// RetrieveBatchSecretsSafe.
+//
 // The authenticated user must have execute privilege on all variables.
 func (c *Client) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
-	jsonResponse, err := c.retrieveBatchSecrets(variableIDs, false)
+	return c.RetrieveBatchSecretsWithContext(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsWithContext is like RetrieveBatchSecrets but uses the
+// provided context for the underlying request.
+func (c *Client) RetrieveBatchSecretsWithContext(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	if len(variableIDs) == 0 {
+		return map[string][]byte{}, nil
+	}
+
+	jsonResponse := map[string]string{}
+	err := c.retrieveBatchSecrets(ctx, variableIDs, false, &jsonResponse)
+
+	// Conjur responds with 406 Not Acceptable when a value isn't valid UTF-8
+	var conjurError *response.ConjurError
+	if errors.As(err, &conjurError) && conjurError.Code == http.StatusNotAcceptable {
+		return c.RetrieveBatchSecretsSafeWithContext(ctx, variableIDs)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -35,19 +60,21 @@ func (c *Client) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte,
 //
 // The authenticated user must have execute privilege on all variables.
 func (c *Client) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
-	jsonResponse, err := c.retrieveBatchSecrets(variableIDs, true)
-	if err != nil {
-		return nil, err
+	return c.RetrieveBatchSecretsSafeWithContext(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsSafeWithContext is like RetrieveBatchSecretsSafe but uses
+// the provided context for the underlying request.
+func (c *Client) RetrieveBatchSecretsSafeWithContext(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	if len(variableIDs) == 0 {
+		return map[string][]byte{}, nil
 	}
 
+	// JSON decodes base64 strings straight into byte slices, so the values
+	// aren't copied into strings which can't be wiped
 	resolvedVariables := map[string][]byte{}
-	var decodedValue []byte
-	for id, value := range jsonResponse {
-		decodedValue, err = base64.StdEncoding.DecodeString(value)
-		if err != nil {
-			return nil, err
-		}
-		resolvedVariables[id] = decodedValue
+	if err := c.retrieveBatchSecrets(ctx, variableIDs, true, &resolvedVariables); err != nil {
+		return nil, err
 	}
 
 	return resolvedVariables, nil
@@ -57,7 +84,13 @@ func (c *Client) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]by
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecret(variableID string) ([]byte, error) {
-	resp, err := c.retrieveSecret(variableID)
+	return c.RetrieveSecretWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretWithContext is like RetrieveSecret but uses the provided
+// context for the underlying request.
+func (c *Client) RetrieveSecretWithContext(ctx context.Context, variableID string) ([]byte, error) {
+	resp, err := c.retrieveSecret(ctx, variableID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,12 +98,51 @@ func (c *Client) RetrieveSecret(variableID string) ([]byte, error) {
 	return response.DataResponse(resp)
 }
 
+// RetrieveSecretWithFallback fetches a secret from the first of the given
+// variables which exists and has a value, trying them in order. This suits
+// secrets being moved, which may live at either their old or new ID. Errors
+// other than ErrNotFound are returned without trying the remaining variables.
+//
+// The authenticated user must have execute privilege on the variables.
+func (c *Client) RetrieveSecretWithFallback(variableIDs ...string) ([]byte, error) {
+	return c.RetrieveSecretWithFallbackWithContext(context.Background(), variableIDs...)
+}
+
+// RetrieveSecretWithFallbackWithContext is like RetrieveSecretWithFallback but
+// uses the provided context for the underlying requests.
+func (c *Client) RetrieveSecretWithFallbackWithContext(ctx context.Context, variableIDs ...string) ([]byte, error) {
+	if len(variableIDs) == 0 {
+		return nil, fmt.Errorf("must specify at least one variable ID")
+	}
+
+	var err error
+	for _, variableID := range variableIDs {
+		var value []byte
+		value, err = c.RetrieveSecretWithContext(ctx, variableID)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("none of the variables %v has a value: %w", variableIDs, err)
+}
+
 // RetrieveSecretReader fetches a secret from a variable and returns it as a
-// data stream.
+// data stream. The value is not buffered in memory, which suits large secrets
+// such as certificate bundles; the caller must close the stream.
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretReader(variableID string) (io.ReadCloser, error) {
-	resp, err := c.retrieveSecret(variableID)
+	return c.RetrieveSecretReaderWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretReaderWithContext is like RetrieveSecretReader but uses the
+// provided context for the underlying request. The context must remain valid
+// until the returned stream has been read and closed.
+func (c *Client) RetrieveSecretReaderWithContext(ctx context.Context, variableID string) (io.ReadCloser, error) {
+	resp, err := c.retrieveSecret(ctx, variableID)
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +155,13 @@ func (c *Client) RetrieveSecretReader(variableID string) (io.ReadCloser, error)
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
-	resp, err := c.retrieveSecretWithVersion(variableID, version)
+	return c.RetrieveSecretWithVersionWithContext(context.Background(), variableID, version)
+}
+
+// RetrieveSecretWithVersionWithContext is like RetrieveSecretWithVersion but
+// uses the provided context for the underlying request.
+func (c *Client) RetrieveSecretWithVersionWithContext(ctx context.Context, variableID string, version int) ([]byte, error) {
+	resp, err := c.retrieveSecretWithVersion(ctx, variableID, version)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +174,15 @@ func (c *Client) RetrieveSecretWithVersion(variableID string, version int) ([]by
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretWithVersionReader(variableID string, version int) (io.ReadCloser, error) {
-	resp, err := c.retrieveSecretWithVersion(variableID, version)
+	return c.RetrieveSecretWithVersionReaderWithContext(context.Background(), variableID, version)
+}
+
+// RetrieveSecretWithVersionReaderWithContext is like
+// RetrieveSecretWithVersionReader but uses the provided context for the
+// underlying request. The context must remain valid until the returned stream
+// has been read and closed.
+func (c *Client) RetrieveSecretWithVersionReaderWithContext(ctx context.Context, variableID string, version int) (io.ReadCloser, error) {
+	resp, err := c.retrieveSecretWithVersion(ctx, variableID, version)
 	if err != nil {
 		return nil, err
 	}
@@ -104,66 +190,235 @@ func (c *Client) RetrieveSecretWithVersionReader(variableID string, version int)
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) retrieveBatchSecrets(variableIDs []string, base64Flag bool) (map[string]string, error) {
-	req, err := c.RetrieveBatchSecretsRequest(variableIDs, base64Flag)
+// SecretVersion describes a version of a variable's secret stored by Conjur.
+type SecretVersion struct {
+	Version   int        `json:"version"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// RetrieveSecretVersions lists the stored versions of a variable's secret,
+// oldest first, using the variable's resource metadata. Each version can be
+// fetched with RetrieveSecretWithVersion. Conjur only retains the most recent
+// versions of a secret (20 by default).
+//
+// The authenticated user must have read privilege on the variable.
+func (c *Client) RetrieveSecretVersions(variableID string) ([]SecretVersion, error) {
+	return c.RetrieveSecretVersionsWithContext(context.Background(), variableID)
+}
+
+// RetrieveSecretVersionsWithContext is like RetrieveSecretVersions but uses
+// the provided context for the underlying request.
+func (c *Client) RetrieveSecretVersionsWithContext(ctx context.Context, variableID string) ([]SecretVersion, error) {
+	req, err := c.ResourceRequest(makeFullId(c.config.Account, "variable", variableID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := response.DataResponse(resp)
-	if err != nil {
+	resource := struct {
+		Secrets []SecretVersion `json:"secrets"`
+	}{}
+	if err := response.JSONResponse(resp, &resource); err != nil {
 		return nil, err
 	}
 
-	if base64Flag && resp.Header.Get("Content-Encoding") != "base64" {
-		return nil, errors.New(
-			"Conjur response is not Base64-encoded. " +
-			"The Conjur version may not be compatible with this function - " +
-			"try using RetrieveBatchSecrets instead." )
+	versions := resource.Secrets
+	if versions == nil {
+		versions = []SecretVersion{}
 	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
 
-	jsonResponse := map[string]string{}
-	err = json.Unmarshal(data, &jsonResponse)
+	return versions, nil
+}
+
+// RetrieveSecretIfChanged fetches the latest version of a variable's secret
+// unless it's lastVersion, the version returned by an earlier call, in which
+// case the value isn't transferred and nil is returned instead. The versions
+// are compared using the variable's metadata, which saves bandwidth for large
+// secrets polled frequently. Pass a lastVersion of 0 to always fetch the
+// value.
+//
+// The latest version is returned along with the value. Variables without a
+// value fail with an error matching ErrNotFound, like RetrieveSecret.
+//
+// The authenticated user must have read and execute privileges on the
+// variable.
+func (c *Client) RetrieveSecretIfChanged(variableID string, lastVersion int) ([]byte, int, error) {
+	return c.RetrieveSecretIfChangedWithContext(context.Background(), variableID, lastVersion)
+}
+
+// RetrieveSecretIfChangedWithContext is like RetrieveSecretIfChanged but uses
+// the provided context for the underlying requests.
+func (c *Client) RetrieveSecretIfChangedWithContext(ctx context.Context, variableID string, lastVersion int) ([]byte, int, error) {
+	latest, err := c.latestSecretVersion(ctx, variableID)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	if latest == 0 {
+		return nil, 0, fmt.Errorf("variable '%s' has no value: %w", variableID, ErrNotFound)
+	}
+	if latest == lastVersion {
+		return nil, latest, nil
+	}
+
+	value, err := c.RetrieveSecretWithVersionWithContext(ctx, variableID, latest)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, latest, nil
+}
+
+// latestSecretVersion returns the latest version of a variable's secret, or 0
+// if it has no value.
+func (c *Client) latestSecretVersion(ctx context.Context, variableID string) (int, error) {
+	versions, err := c.RetrieveSecretVersionsWithContext(ctx, variableID)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[len(versions)-1].Version, nil
+}
+
+// retrieveBatchSecrets fetches a batch of secrets and decodes the response
+// into values, wiping the response from memory afterwards.
+func (c *Client) retrieveBatchSecrets(ctx context.Context, variableIDs []string, base64Flag bool, values interface{}) error {
+	req, err := c.RetrieveBatchSecretsRequest(variableIDs, base64Flag)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.SubmitRequest(req.WithContext(withOperation(ctx, operationSecretRead)))
+	if err != nil {
+		return err
+	}
+
+	body, err := response.SecretDataResponse(resp)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	data, err := readAllWiping(body, resp.ContentLength)
+	if err != nil {
+		return err
+	}
+	defer WipeBytes(data)
+
+	if base64Flag && resp.Header.Get("Content-Encoding") != "base64" {
+		return errors.New(
+			"Conjur response is not Base64-encoded: " +
+				"the Conjur version may not be compatible with this function, " +
+				"try using RetrieveBatchSecrets instead")
 	}
 
-	return jsonResponse, nil
+	return json.Unmarshal(data, values)
 }
 
-func (c *Client) retrieveSecret(variableID string) (*http.Response, error) {
+func (c *Client) retrieveSecret(ctx context.Context, variableID string) (*http.Response, error) {
 	req, err := c.RetrieveSecretRequest(variableID)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.SubmitRequest(req)
+	return c.SubmitRequest(req.WithContext(withOperation(ctx, operationSecretRead)))
 }
 
-func (c *Client) retrieveSecretWithVersion(variableID string, version int) (*http.Response, error) {
+func (c *Client) retrieveSecretWithVersion(ctx context.Context, variableID string, version int) (*http.Response, error) {
 	req, err := c.RetrieveSecretWithVersionRequest(variableID, version)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.SubmitRequest(req)
+	return c.SubmitRequest(req.WithContext(withOperation(ctx, operationSecretRead)))
+}
+
+// Annotations recorded by AddSecretWithOptions.
+const (
+	MimeTypeAnnotation = "conjur/mime_type"
+	TTLAnnotation      = "ttl"
+)
+
+// SecretOptions holds the metadata recorded by AddSecretWithOptions.
+type SecretOptions struct {
+	// MimeType is recorded in the conjur/mime_type annotation if set.
+	MimeType string
+	// TTL is recorded in the ttl annotation, as an ISO 8601 duration such as
+	// "PT1H30M", if set.
+	TTL time.Duration
+}
+
+// AddSecretWithOptions adds a secret value to a variable, first recording the
+// given metadata as annotations on the variable with SetAnnotations. If
+// adding the value fails, the annotations have already been updated.
+//
+// The authenticated user must have update privilege on the variable, and on
+// the policy which owns it if any metadata is set.
+func (c *Client) AddSecretWithOptions(variableID string, secretValue string, options SecretOptions) error {
+	return c.AddSecretWithOptionsWithContext(context.Background(), variableID, secretValue, options)
+}
+
+// AddSecretWithOptionsWithContext is like AddSecretWithOptions but uses the
+// provided context for the underlying requests.
+func (c *Client) AddSecretWithOptionsWithContext(ctx context.Context, variableID string, secretValue string, options SecretOptions) error {
+	if options.TTL < 0 {
+		return fmt.Errorf("secret TTL must not be negative, got %s", options.TTL)
+	}
+
+	annotations := map[string]string{}
+	if options.MimeType != "" {
+		annotations[MimeTypeAnnotation] = options.MimeType
+	}
+	if options.TTL > 0 {
+		annotations[TTLAnnotation] = isoDuration(options.TTL)
+	}
+
+	if err := c.SetAnnotationsWithContext(ctx, makeFullId(c.config.Account, "variable", variableID), annotations); err != nil {
+		return err
+	}
+	return c.AddSecretWithContext(ctx, variableID, secretValue)
+}
+
+// isoDuration formats a duration in ISO 8601 form, e.g. "PT1H30M", rounded
+// down to whole seconds.
+func isoDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	hours, minutes, seconds := seconds/3600, seconds/60%60, seconds%60
+
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if seconds > 0 || b.Len() == 2 {
+		fmt.Fprintf(&b, "%dS", seconds)
+	}
+	return b.String()
 }
 
 // AddSecret adds a secret value to a variable.
 //
 // The authenticated user must have update privilege on the variable.
 func (c *Client) AddSecret(variableID string, secretValue string) error {
+	return c.AddSecretWithContext(context.Background(), variableID, secretValue)
+}
+
+// AddSecretWithContext is like AddSecret but uses the provided context for the
+// underlying request.
+func (c *Client) AddSecretWithContext(ctx context.Context, variableID string, secretValue string) error {
 	req, err := c.AddSecretRequest(variableID, secretValue)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.SubmitRequest(req)
+	resp, err := c.SubmitRequest(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
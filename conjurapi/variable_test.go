@@ -1,14 +1,20 @@
 package conjurapi
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/cyberark/conjur-api-go/conjurapi/authn"
-	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/v2/conjurapi/response"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -279,3 +285,336 @@ func TestClient_RetrieveSecret(t *testing.T) {
 		})
 	})
 }
+
+func TestClient_RetrieveSecretWithContext(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/secrets/cucumber/variable/slow-variable") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		if strings.HasSuffix(r.URL.Path, "/secrets/cucumber/variable/db-password") {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("secret"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns the secret value", func(t *testing.T) {
+		secretValue, err := conjur.RetrieveSecretWithContext(context.Background(), "db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secretValue))
+	})
+
+	t.Run("Returns error when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := conjur.RetrieveSecretWithContext(ctx, "db-password")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Returns error when context deadline is exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := conjur.RetrieveSecretWithContext(ctx, "slow-variable")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestClient_RetrieveSecretReaderStreams(t *testing.T) {
+	release := make(chan struct{})
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/secrets/cucumber/variable/keystore", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("first-chunk"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("-second-chunk"))
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	// The reader is returned while the server is still sending the body
+	secretResponse, err := conjur.RetrieveSecretReader("keystore")
+	assert.NoError(t, err)
+	defer secretResponse.Close()
+
+	first := make([]byte, len("first-chunk"))
+	_, err = io.ReadFull(secretResponse, first)
+	assert.NoError(t, err)
+	assert.Equal(t, "first-chunk", string(first))
+
+	close(release)
+	rest, err := io.ReadAll(secretResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, "-second-chunk", string(rest))
+}
+
+func TestClient_RetrieveBatchSecretsWithContext(t *testing.T) {
+	binaryValue := []byte{0xff, 0xfe, 0x00, 0x01}
+	requests := 0
+
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if strings.TrimSuffix(r.URL.Path, "/") != "/secrets" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		ids := r.URL.Query().Get("variable_ids")
+		binary := strings.Contains(ids, "binary")
+		base64Requested := r.Header.Get("Accept-Encoding") == "base64"
+
+		switch {
+		case binary && !base64Requested:
+			w.WriteHeader(http.StatusNotAcceptable)
+			w.Write([]byte(`{"error":{"code":"not_acceptable","message":"Issue encoding secret into JSON format"}}`))
+		case base64Requested:
+			w.Header().Set("Content-Encoding", "base64")
+			fmt.Fprintf(w, `{"cucumber:variable:binary":%q,"cucumber:variable:text":%q}`,
+				base64.StdEncoding.EncodeToString(binaryValue), base64.StdEncoding.EncodeToString([]byte("text")))
+		default:
+			w.Write([]byte(`{"cucumber:variable:text":"text"}`))
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns text values", func(t *testing.T) {
+		requests = 0
+		secrets, err := conjur.RetrieveBatchSecrets([]string{"text"})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("text"), secrets["cucumber:variable:text"])
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("Retries with Base64 encoding when Conjur responds 406", func(t *testing.T) {
+		requests = 0
+		secrets, err := conjur.RetrieveBatchSecrets([]string{"binary", "text"})
+		assert.NoError(t, err)
+		assert.Equal(t, binaryValue, secrets["cucumber:variable:binary"])
+		assert.Equal(t, []byte("text"), secrets["cucumber:variable:text"])
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("Returns an empty map without a request for no variables", func(t *testing.T) {
+		requests = 0
+		secrets, err := conjur.RetrieveBatchSecrets([]string{})
+		assert.NoError(t, err)
+		assert.Empty(t, secrets)
+
+		secrets, err = conjur.RetrieveBatchSecretsSafe(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, secrets)
+		assert.Equal(t, 0, requests)
+	})
+}
+
+func TestClient_RetrieveSecretVersions(t *testing.T) {
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/db-password":
+			w.Write([]byte(`{"id":"cucumber:variable:db-password","secrets":[{"version":2},{"version":1,"expires_at":"2024-01-02T03:04:05.000Z"},{"version":3}]}`))
+		case "/resources/cucumber/variable/empty":
+			w.Write([]byte(`{"id":"cucumber:variable:empty","secrets":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Lists versions oldest first", func(t *testing.T) {
+		versions, err := conjur.RetrieveSecretVersions("db-password")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 3)
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), versions[0].ExpiresAt.UTC())
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Nil(t, versions[1].ExpiresAt)
+		assert.Equal(t, 3, versions[2].Version)
+	})
+
+	t.Run("Returns an empty list for a variable without secrets", func(t *testing.T) {
+		versions, err := conjur.RetrieveSecretVersions("cucumber:variable:empty")
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("Returns ErrNotFound for an unknown variable", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretVersions("unknown")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_RetrieveSecretIfChanged(t *testing.T) {
+	var secretReads int
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/db-password":
+			w.Write([]byte(`{"id":"cucumber:variable:db-password","secrets":[{"version":1},{"version":2}]}`))
+		case "/resources/cucumber/variable/empty":
+			w.Write([]byte(`{"id":"cucumber:variable:empty","secrets":[]}`))
+		case "/secrets/cucumber/variable/db-password":
+			secretReads++
+			w.Write([]byte("value-" + r.URL.Query().Get("version")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Fetches a new version", func(t *testing.T) {
+		value, version, err := conjur.RetrieveSecretIfChanged("db-password", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "value-2", string(value))
+		assert.Equal(t, 2, version)
+		assert.Equal(t, 1, secretReads)
+	})
+
+	t.Run("Skips the value when the version is unchanged", func(t *testing.T) {
+		value, version, err := conjur.RetrieveSecretIfChanged("db-password", 2)
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+		assert.Equal(t, 2, version)
+		assert.Equal(t, 1, secretReads)
+	})
+
+	t.Run("Returns ErrNotFound for a variable without secrets", func(t *testing.T) {
+		_, _, err := conjur.RetrieveSecretIfChanged("empty", 0)
+		assert.EqualError(t, err, "variable 'empty' has no value: "+ErrNotFound.Error())
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestClient_RetrieveSecretWithFallback(t *testing.T) {
+	var requested []string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/secrets/cucumber/variable/new/db-password":
+			w.Write([]byte("new-secret"))
+		case "/secrets/cucumber/variable/old/db-password":
+			w.Write([]byte("old-secret"))
+		case "/secrets/cucumber/variable/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Returns the first variable with a value", func(t *testing.T) {
+		requested = nil
+		value, err := conjur.RetrieveSecretWithFallback("renamed/db-password", "new/db-password", "old/db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "new-secret", string(value))
+		assert.Equal(t, []string{"/secrets/cucumber/variable/renamed/db-password", "/secrets/cucumber/variable/new/db-password"}, requested)
+	})
+
+	t.Run("Stops at other errors", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretWithFallback("forbidden", "old/db-password")
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Returns ErrNotFound when no variable has a value", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretWithFallback("missing", "also-missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Contains(t, err.Error(), "none of the variables [missing also-missing] has a value")
+	})
+
+	t.Run("Requires a variable ID", func(t *testing.T) {
+		_, err := conjur.RetrieveSecretWithFallback()
+		assert.EqualError(t, err, "must specify at least one variable ID")
+	})
+}
+
+func TestClient_AddSecretWithOptions(t *testing.T) {
+	var requests []string
+	var policyBody, secretValue string
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/resources/cucumber/variable/db-password":
+			w.Write([]byte(`{"id": "cucumber:variable:db-password", "policy": "cucumber:policy:root"}`))
+		case "/policies/cucumber/policy/root":
+			policyBody = string(body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"created_roles": {}, "version": 2}`))
+		case "/secrets/cucumber/variable/db-password":
+			secretValue = string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	assert.NoError(t, err)
+
+	t.Run("Records annotations before adding the value", func(t *testing.T) {
+		requests = nil
+		err := conjur.AddSecretWithOptions("db-password", "secret", SecretOptions{
+			MimeType: "application/json",
+			TTL:      90*time.Minute + 5*time.Second,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"GET /resources/cucumber/variable/db-password",
+			"PATCH /policies/cucumber/policy/root",
+			"POST /secrets/cucumber/variable/db-password",
+		}, requests)
+		assert.Contains(t, policyBody, `"conjur/mime_type": "application/json"`)
+		assert.Contains(t, policyBody, `"ttl": "PT1H30M5S"`)
+		assert.Equal(t, "secret", secretValue)
+	})
+
+	t.Run("Only adds the value without options", func(t *testing.T) {
+		requests = nil
+		err := conjur.AddSecretWithOptions("db-password", "other", SecretOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"POST /secrets/cucumber/variable/db-password"}, requests)
+	})
+
+	t.Run("Rejects a negative TTL", func(t *testing.T) {
+		requests = nil
+		err := conjur.AddSecretWithOptions("db-password", "other", SecretOptions{TTL: -time.Second})
+		assert.EqualError(t, err, "secret TTL must not be negative, got -1s")
+		assert.Empty(t, requests)
+	})
+}
+
+func TestIsoDuration(t *testing.T) {
+	assert.Equal(t, "PT0S", isoDuration(0))
+	assert.Equal(t, "PT45S", isoDuration(45*time.Second))
+	assert.Equal(t, "PT2M", isoDuration(2*time.Minute+500*time.Millisecond))
+	assert.Equal(t, "PT48H", isoDuration(48*time.Hour))
+}
@@ -0,0 +1,106 @@
+package conjurapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const WatchSecretDefaultInterval = 30 * time.Second
+
+// SecretUpdate is sent by WatchSecret when a variable's secret changes, or
+// when checking it fails.
+type SecretUpdate struct {
+	// Version is the version of the secret.
+	Version int
+	// Value is the value of that version.
+	Value []byte
+	// Err is set, and the other fields aren't, when checking the secret
+	// failed. Watching continues regardless.
+	Err error
+}
+
+// WatchSecret polls a variable's secret versions every interval and sends the
+// value of each new version on the returned channel, starting with the one
+// current when watching starts. Polling only fetches the variable's metadata,
+// so the value itself is only fetched when it changes. Variables without a
+// value yet aren't reported until one is added.
+//
+// Polling pauses until each update is received. Call the returned function to
+// stop watching, after which the channel is closed. An interval of zero or
+// less polls every WatchSecretDefaultInterval.
+//
+// The authenticated user must have read and execute privileges on the
+// variable.
+func (c *Client) WatchSecret(variableID string, interval time.Duration) (<-chan SecretUpdate, func()) {
+	return c.WatchSecretWithContext(context.Background(), variableID, interval)
+}
+
+// WatchSecretWithContext is like WatchSecret but uses the provided context
+// for the underlying requests, and stops watching once the context is done.
+func (c *Client) WatchSecretWithContext(ctx context.Context, variableID string, interval time.Duration) (<-chan SecretUpdate, func()) {
+	if interval <= 0 {
+		interval = WatchSecretDefaultInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	updates := make(chan SecretUpdate)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(updates)
+		c.watchSecret(ctx, variableID, interval, updates)
+	}()
+
+	var once sync.Once
+	return updates, func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
+func (c *Client) watchSecret(ctx context.Context, variableID string, interval time.Duration, updates chan<- SecretUpdate) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	current := 0
+	for {
+		if update, ok := c.checkSecret(ctx, variableID, current); ok {
+			select {
+			case updates <- update:
+				if update.Err == nil {
+					current = update.Version
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkSecret fetches a variable's secret if its latest version isn't the
+// current one.
+func (c *Client) checkSecret(ctx context.Context, variableID string, current int) (SecretUpdate, bool) {
+	latest, err := c.latestSecretVersion(ctx, variableID)
+	if err != nil {
+		return SecretUpdate{Err: err}, ctx.Err() == nil
+	}
+	if latest == 0 || latest == current {
+		return SecretUpdate{}, false
+	}
+
+	value, err := c.RetrieveSecretWithVersionWithContext(ctx, variableID, latest)
+	if err != nil {
+		return SecretUpdate{Err: err}, ctx.Err() == nil
+	}
+	return SecretUpdate{Version: latest, Value: value}, true
+}
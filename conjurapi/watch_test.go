@@ -0,0 +1,103 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WatchSecret(t *testing.T) {
+	var mutex sync.Mutex
+	version, metadataReads, secretReads := 0, 0, 0
+	failing := false
+	mockConjurServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case failing:
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.HasPrefix(r.URL.Path, "/resources/"):
+			metadataReads++
+			secrets := []string{}
+			for v := 1; v <= version; v++ {
+				secrets = append(secrets, fmt.Sprintf(`{"version": %d}`, v))
+			}
+			fmt.Fprintf(w, `{"id": "cucumber:variable:db/password", "secrets": [%s]}`, strings.Join(secrets, ","))
+		case strings.HasPrefix(r.URL.Path, "/secrets/"):
+			secretReads++
+			fmt.Fprintf(w, "value-%s", r.URL.Query().Get("version"))
+		}
+	}))
+	defer mockConjurServer.Close()
+
+	config := Config{Account: "cucumber", ApplianceURL: mockConjurServer.URL, CredentialStorage: "none"}
+	conjur, err := NewClientFromToken(config, sample_token)
+	require.NoError(t, err)
+
+	setVersion := func(v int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		version = v
+	}
+	receive := func(updates <-chan SecretUpdate) SecretUpdate {
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("no update received")
+			return SecretUpdate{}
+		}
+	}
+
+	setVersion(1)
+	updates, stop := conjur.WatchSecret("db/password", 5*time.Millisecond)
+
+	update := receive(updates)
+	require.NoError(t, update.Err)
+	assert.Equal(t, 1, update.Version)
+	assert.Equal(t, "value-1", string(update.Value))
+
+	t.Run("Only reads the secret when its version changes", func(t *testing.T) {
+		time.Sleep(50 * time.Millisecond)
+		setVersion(2)
+		update := receive(updates)
+		assert.Equal(t, 2, update.Version)
+		assert.Equal(t, "value-2", string(update.Value))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, 2, secretReads)
+		assert.Greater(t, metadataReads, 2)
+	})
+
+	t.Run("Reports errors and keeps watching", func(t *testing.T) {
+		mutex.Lock()
+		failing = true
+		mutex.Unlock()
+		assert.Error(t, receive(updates).Err)
+
+		mutex.Lock()
+		failing = false
+		version = 3
+		mutex.Unlock()
+		update := receive(updates)
+		for update.Err != nil {
+			update = receive(updates)
+		}
+		assert.Equal(t, 3, update.Version)
+	})
+
+	t.Run("Closes the channel when stopped", func(t *testing.T) {
+		stop()
+		stop()
+		for range updates {
+		}
+	})
+}
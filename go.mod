@@ -1,4 +1,4 @@
-module github.com/cyberark/conjur-api-go
+module github.com/cyberark/conjur-api-go/v2
 
 go 1.18
 
@@ -8,6 +8,7 @@ require (
 	github.com/stretchr/testify v1.7.2
 	github.com/zalando/go-keyring v0.2.3-0.20230503081219-17db2e5354bd
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -20,7 +21,6 @@ require (
 	github.com/stretchr/objx v0.3.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c => gopkg.in/yaml.v3 v3.0.1